@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Server is the part of *http.Server the App needs to drain requests on shutdown
+type Server interface {
+	Shutdown(ctx context.Context) error
+}
+
+// App holds the long-lived dependencies of cart-service
+// Shutdown releases them in a fixed order so in-flight requests never see a closed Redis client
+type App struct {
+	server         Server
+	redisClient    interface{ Close() error }
+	shutdownTracer func(context.Context) error
+	logger         *zap.Logger
+}
+
+// waitForShutdown blocks until a signal arrives on quit and returns it
+// markDraining runs as soon as the signal is received, before App.Shutdown stops the server,
+// so the readiness probe fails while the pod is still serving requests
+func waitForShutdown(quit <-chan os.Signal, markDraining func(), logger *zap.Logger) os.Signal {
+	sig := <-quit
+	markDraining()
+	logger.Info("Shutdown signal received, readiness now failing", zap.String("signal", sig.String()))
+	return sig
+}
+
+// waitDrainDelay keeps the server serving for delay after readiness started failing
+// kube-proxy and load balancers take a moment to drop the pod from their endpoints, and
+// requests routed from a stale list in the meantime must still be answered; 0 skips the wait
+func waitDrainDelay(delay time.Duration, logger *zap.Logger) {
+	if delay <= 0 {
+		return
+	}
+	logger.Info("Waiting for endpoints to drop the pod before shutting down", zap.Duration("drain_delay", delay))
+	time.Sleep(delay)
+}
+
+// Shutdown stops the service in dependency order:
+// 1. Stop accepting new requests and wait for in-flight requests to finish
+// 2. Close the Redis client once nothing can use it anymore
+// 3. Flush remaining spans, including those recorded while draining
+// Every step runs even if an earlier one fails; the errors are joined
+func (a *App) Shutdown(ctx context.Context) error {
+	var errs []error
+
+	a.logger.Info("Draining HTTP server")
+	if err := a.server.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("failed to drain HTTP server: %w", err))
+	}
+
+	a.logger.Info("Closing Redis connection")
+	if err := a.redisClient.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("failed to close Redis connection: %w", err))
+	}
+
+	a.logger.Info("Flushing traces")
+	if err := a.shutdownTracer(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("failed to shut down tracer: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"cart-service/handlers"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// shutdownRecorder records the order in which shutdown steps happen
+type shutdownRecorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *shutdownRecorder) record(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *shutdownRecorder) snapshot() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.events...)
+}
+
+// recordingRedis stands in for the Redis client and records when it is closed
+type recordingRedis struct {
+	recorder *shutdownRecorder
+	err      error
+}
+
+func (r *recordingRedis) Close() error {
+	r.recorder.record("redis closed")
+	return r.err
+}
+
+// healthyRedis is a Redis stand-in whose Ping always succeeds
+type healthyRedis struct{}
+
+func (healthyRedis) Ping(ctx context.Context) error { return nil }
+
+func TestWaitForShutdown(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("should fail readiness as soon as the signal arrives", func(t *testing.T) {
+		healthHandler := handlers.NewHealthHandler(healthyRedis{}, zap.NewNop(), "test-pod", "test-node", 0)
+		router := gin.New()
+		router.GET("/ready", healthHandler.Ready)
+		router.GET("/live", healthHandler.Live)
+
+		probe := func(path string) int {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", path, nil)
+			router.ServeHTTP(w, req)
+			return w.Code
+		}
+
+		require.Equal(t, http.StatusOK, probe("/ready"))
+
+		quit := make(chan os.Signal, 1)
+		received := make(chan os.Signal, 1)
+		go func() {
+			received <- waitForShutdown(quit, healthHandler.StartDraining, zap.NewNop())
+		}()
+
+		quit <- syscall.SIGTERM
+
+		select {
+		case sig := <-received:
+			assert.Equal(t, syscall.SIGTERM, sig)
+		case <-time.After(time.Second):
+			t.Fatal("waitForShutdown did not return after the signal")
+		}
+
+		// The server hasn't been shut down yet, but load balancers must already see the pod as not ready
+		assert.Equal(t, http.StatusServiceUnavailable, probe("/ready"))
+		assert.Equal(t, http.StatusOK, probe("/live"))
+	})
+}
+
+func TestWaitDrainDelay(t *testing.T) {
+	t.Run("should keep serving for the drain delay", func(t *testing.T) {
+		start := time.Now()
+		waitDrainDelay(50*time.Millisecond, zap.NewNop())
+		assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	})
+
+	t.Run("should return immediately when disabled", func(t *testing.T) {
+		start := time.Now()
+		waitDrainDelay(0, zap.NewNop())
+		assert.Less(t, time.Since(start), 10*time.Millisecond)
+	})
+}
+
+func TestAppShutdown(t *testing.T) {
+	t.Run("should close Redis only after in-flight requests drain", func(t *testing.T) {
+		recorder := &shutdownRecorder{}
+		started := make(chan struct{})
+		release := make(chan struct{})
+
+		// Handler simulates a request still using Redis when shutdown begins
+		ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-release
+			recorder.record("request finished")
+			w.WriteHeader(http.StatusOK)
+		}))
+		ts.Start()
+		defer ts.Close()
+
+		app := &App{
+			server:      ts.Config,
+			redisClient: &recordingRedis{recorder: recorder},
+			shutdownTracer: func(ctx context.Context) error {
+				recorder.record("tracer flushed")
+				return nil
+			},
+			logger: zap.NewNop(),
+		}
+
+		requestDone := make(chan error, 1)
+		go func() {
+			resp, err := http.Get(ts.URL)
+			if err == nil {
+				resp.Body.Close()
+			}
+			requestDone <- err
+		}()
+		<-started
+
+		shutdownDone := make(chan error, 1)
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			shutdownDone <- app.Shutdown(ctx)
+		}()
+
+		// Redis must stay open while the request is still running
+		time.Sleep(50 * time.Millisecond)
+		assert.Empty(t, recorder.snapshot())
+
+		close(release)
+
+		require.NoError(t, <-shutdownDone)
+		require.NoError(t, <-requestDone)
+		assert.Equal(t, []string{"request finished", "redis closed", "tracer flushed"}, recorder.snapshot())
+	})
+
+	t.Run("should run every step and join errors", func(t *testing.T) {
+		recorder := &shutdownRecorder{}
+		ts := httptest.NewServer(http.NotFoundHandler())
+		defer ts.Close()
+
+		app := &App{
+			server:      ts.Config,
+			redisClient: &recordingRedis{recorder: recorder, err: errors.New("redis close failed")},
+			shutdownTracer: func(ctx context.Context) error {
+				recorder.record("tracer flushed")
+				return errors.New("exporter unavailable")
+			},
+			logger: zap.NewNop(),
+		}
+
+		err := app.Shutdown(context.Background())
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "redis close failed")
+		assert.Contains(t, err.Error(), "exporter unavailable")
+		assert.Equal(t, []string{"redis closed", "tracer flushed"}, recorder.snapshot())
+	})
+}
@@ -0,0 +1,396 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"cart-service/internal/apierror"
+	"cart-service/redis"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+)
+
+// maxLargestCartsLimit caps the ?limit= accepted by GET /admin/carts/largest
+const maxLargestCartsLimit = 100
+
+// maxListCartsCount caps the ?count= accepted by GET /v1/carts
+const maxListCartsCount = 1000
+
+// AdminStore defines the keyspace-wide cart operations used by admin handlers
+// These operations walk many keys and are only exposed when admin endpoints are enabled
+type AdminStore interface {
+	LargestCarts(ctx context.Context, limit, maxKeys int) (*redis.LargestCartsResult, error)
+	NormalizeCart(ctx context.Context, userID string, opts redis.NormalizeOptions) ([]redis.CartMerge, error)
+	EstimateCartMemory(ctx context.Context, sampleRate float64, maxKeys int) (*redis.MemoryEstimate, error)
+	ListCartUserIDs(ctx context.Context, cursor uint64, count int64) ([]string, uint64, error)
+	RawCart(ctx context.Context, userID string) (map[string]string, error)
+	RepairCart(ctx context.Context, userID string) (int, error)
+}
+
+// AdminHandlerConfig holds the settings for admin handlers
+type AdminHandlerConfig struct {
+	// ScanMaxKeys bounds how many cart keys a single keyspace scan may inspect
+	ScanMaxKeys int
+	// Normalize selects how product IDs are normalized by POST /admin/carts/:user_id/normalize
+	Normalize redis.NormalizeOptions
+	// MemorySampleRate is the fraction of cart keys measured with MEMORY USAGE by GET /admin/carts/memory
+	MemorySampleRate float64
+	// MemoryCapBytes is the total cart memory budget; 0 disables the cap check
+	MemoryCapBytes int64
+}
+
+// AdminHandler holds dependencies for admin handlers
+type AdminHandler struct {
+	store  AdminStore
+	logger *zap.Logger
+	config AdminHandlerConfig
+}
+
+// CartSizeResponse represents a single cart in the largest carts report
+type CartSizeResponse struct {
+	UserID    string `json:"user_id"`
+	ItemCount int64  `json:"item_count"`
+}
+
+// LargestCartsResponse represents the response for GET /admin/carts/largest
+type LargestCartsResponse struct {
+	Carts       []CartSizeResponse `json:"carts"`
+	ScannedKeys int                `json:"scanned_keys"`
+	Truncated   bool               `json:"truncated"`
+}
+
+// ListCartsResponse represents one page of GET /v1/carts
+// NextCursor is a string because SCAN cursors are unsigned 64-bit and can overflow JSON numbers
+type ListCartsResponse struct {
+	UserIDs    []string `json:"user_ids"`
+	NextCursor string   `json:"next_cursor"`
+}
+
+// RawCartResponse represents the response for GET /v1/cart/:user_id/raw
+// Fields is the cart hash as stored; InvalidFields lists the product IDs whose quantity is not
+// an integer, which GetCart skips
+type RawCartResponse struct {
+	UserID        string            `json:"user_id"`
+	Fields        map[string]string `json:"fields"`
+	InvalidFields []string          `json:"invalid_fields"`
+}
+
+// RepairCartResponse represents the response for POST /v1/cart/:user_id/repair
+type RepairCartResponse struct {
+	UserID  string `json:"user_id"`
+	Removed int    `json:"removed"`
+}
+
+// CartMergeResponse describes product lines merged into one normalized ID
+type CartMergeResponse struct {
+	ProductID string   `json:"product_id"`
+	SourceIDs []string `json:"source_ids"`
+	Quantity  int      `json:"quantity"`
+}
+
+// NormalizeCartResponse represents the response for POST /admin/carts/:user_id/normalize
+type NormalizeCartResponse struct {
+	UserID string              `json:"user_id"`
+	Merges []CartMergeResponse `json:"merges"`
+}
+
+// CartMemoryResponse represents the response for GET /admin/carts/memory
+type CartMemoryResponse struct {
+	EstimatedBytes int64   `json:"estimated_bytes"`
+	TotalKeys      int     `json:"total_keys"`
+	SampledKeys    int     `json:"sampled_keys"`
+	SampleRate     float64 `json:"sample_rate"`
+	Truncated      bool    `json:"truncated"`
+	CapBytes       int64   `json:"cap_bytes,omitempty"`
+	OverCap        bool    `json:"over_cap"`
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(store AdminStore, logger *zap.Logger, config AdminHandlerConfig) *AdminHandler {
+	return &AdminHandler{
+		store:  store,
+		logger: logger,
+		config: config,
+	}
+}
+
+// LargestCarts handles GET /admin/carts/largest
+// Returns the carts with the most distinct items to help spot abuse
+// Query parameters:
+// - limit: Number of carts to return (default: 10, max: 100)
+func (h *AdminHandler) LargestCarts(c *gin.Context) {
+	ctx := c.Request.Context()
+	tracer := otel.Tracer("cart-service")
+	ctx, span := tracer.Start(ctx, "handler.LargestCarts")
+	defer span.End()
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > maxLargestCartsLimit {
+		span.SetStatus(codes.Error, "Invalid limit")
+		apierror.RespondError(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "limit must be between 1 and "+strconv.Itoa(maxLargestCartsLimit))
+		return
+	}
+
+	span.SetAttributes(attribute.Int("limit", limit))
+
+	result, err := h.store.LargestCarts(ctx, limit, h.config.ScanMaxKeys)
+	if err != nil {
+		span.SetStatus(codes.Error, "Failed to scan carts")
+		span.RecordError(err)
+		h.logger.Error("Failed to find largest carts", zap.Error(err))
+		apierror.RespondError(c, http.StatusInternalServerError, CodeRedisUnavailable, "Failed to scan carts")
+		return
+	}
+
+	carts := make([]CartSizeResponse, len(result.Carts))
+	for i, cart := range result.Carts {
+		carts[i] = CartSizeResponse{
+			UserID:    cart.UserID,
+			ItemCount: cart.ItemCount,
+		}
+	}
+
+	span.SetAttributes(
+		attribute.Int("scanned_keys", result.ScannedKeys),
+		attribute.Bool("truncated", result.Truncated),
+	)
+	span.SetStatus(codes.Ok, "Largest carts retrieved")
+
+	c.JSON(http.StatusOK, LargestCartsResponse{
+		Carts:       carts,
+		ScannedKeys: result.ScannedKeys,
+		Truncated:   result.Truncated,
+	})
+}
+
+// NormalizeCart handles POST /admin/carts/:user_id/normalize
+// Rewrites inconsistently stored product IDs (e.g. "001" and "1") to their normalized form
+// and sums the quantities of lines that collapse onto the same ID
+func (h *AdminHandler) NormalizeCart(c *gin.Context) {
+	ctx := c.Request.Context()
+	tracer := otel.Tracer("cart-service")
+	ctx, span := tracer.Start(ctx, "handler.NormalizeCart")
+	defer span.End()
+
+	userID := c.Param("user_id")
+	if !validateID(userID) {
+		span.SetStatus(codes.Error, "Invalid user_id")
+		apierror.RespondError(c, http.StatusBadRequest, CodeInvalidUserID, "user_id "+idRuleMessage)
+		return
+	}
+
+	span.SetAttributes(attribute.String("user_id", userID))
+
+	merges, err := h.store.NormalizeCart(ctx, userID, h.config.Normalize)
+	if err != nil {
+		span.SetStatus(codes.Error, "Failed to normalize cart")
+		span.RecordError(err)
+		h.logger.Error("Failed to normalize cart",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		apierror.RespondError(c, http.StatusInternalServerError, CodeRedisUnavailable, "Failed to normalize cart")
+		return
+	}
+
+	response := NormalizeCartResponse{
+		UserID: userID,
+		Merges: make([]CartMergeResponse, len(merges)),
+	}
+	for i, merge := range merges {
+		response.Merges[i] = CartMergeResponse{
+			ProductID: merge.ProductID,
+			SourceIDs: merge.SourceIDs,
+			Quantity:  merge.Quantity,
+		}
+	}
+
+	span.SetAttributes(attribute.Int("merge_count", len(merges)))
+	span.SetStatus(codes.Ok, "Cart normalized")
+
+	c.JSON(http.StatusOK, response)
+}
+
+// CartMemory handles GET /admin/carts/memory
+// Estimates the memory used by all carts from a sample of keys and checks it against the configured cap
+func (h *AdminHandler) CartMemory(c *gin.Context) {
+	ctx := c.Request.Context()
+	tracer := otel.Tracer("cart-service")
+	ctx, span := tracer.Start(ctx, "handler.CartMemory")
+	defer span.End()
+
+	estimate, err := h.store.EstimateCartMemory(ctx, h.config.MemorySampleRate, h.config.ScanMaxKeys)
+	if err != nil {
+		span.SetStatus(codes.Error, "Failed to estimate cart memory")
+		span.RecordError(err)
+		h.logger.Error("Failed to estimate cart memory", zap.Error(err))
+		apierror.RespondError(c, http.StatusInternalServerError, CodeRedisUnavailable, "Failed to estimate cart memory")
+		return
+	}
+
+	overCap := h.config.MemoryCapBytes > 0 && estimate.EstimatedBytes > h.config.MemoryCapBytes
+	if overCap {
+		h.logger.Warn("Cart memory over cap",
+			zap.Int64("estimated_bytes", estimate.EstimatedBytes),
+			zap.Int64("cap_bytes", h.config.MemoryCapBytes),
+		)
+	}
+
+	span.SetAttributes(
+		attribute.Int64("estimated_bytes", estimate.EstimatedBytes),
+		attribute.Bool("over_cap", overCap),
+	)
+	span.SetStatus(codes.Ok, "Cart memory estimated")
+
+	c.JSON(http.StatusOK, CartMemoryResponse{
+		EstimatedBytes: estimate.EstimatedBytes,
+		TotalKeys:      estimate.TotalKeys,
+		SampledKeys:    estimate.SampledKeys,
+		SampleRate:     estimate.SampleRate,
+		Truncated:      estimate.Truncated,
+		CapBytes:       h.config.MemoryCapBytes,
+		OverCap:        overCap,
+	})
+}
+
+// ListCarts handles GET /v1/carts
+// Pages through the user IDs that have a cart, e.g. for debugging; it is an admin endpoint
+// Query parameters:
+// - cursor: Cursor returned by the previous page (default: 0, the first page)
+// - count: SCAN batch size hint (default: 100, max: 1000)
+// next_cursor is "0" on the last page
+func (h *AdminHandler) ListCarts(c *gin.Context) {
+	ctx := c.Request.Context()
+	tracer := otel.Tracer("cart-service")
+	ctx, span := tracer.Start(ctx, "handler.ListCarts")
+	defer span.End()
+
+	cursor, err := strconv.ParseUint(c.DefaultQuery("cursor", "0"), 10, 64)
+	if err != nil {
+		span.SetStatus(codes.Error, "Invalid cursor")
+		apierror.RespondError(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "cursor must be a cursor returned by a previous page")
+		return
+	}
+
+	count, err := strconv.ParseInt(c.DefaultQuery("count", "100"), 10, 64)
+	if err != nil || count < 1 || count > maxListCartsCount {
+		span.SetStatus(codes.Error, "Invalid count")
+		apierror.RespondError(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "count must be between 1 and "+strconv.Itoa(maxListCartsCount))
+		return
+	}
+
+	userIDs, next, err := h.store.ListCartUserIDs(ctx, cursor, count)
+	if err != nil {
+		span.SetStatus(codes.Error, "Failed to list carts")
+		span.RecordError(err)
+		h.logger.Error("Failed to list carts", zap.Error(err))
+		apierror.RespondError(c, http.StatusInternalServerError, CodeRedisUnavailable, "Failed to list carts")
+		return
+	}
+
+	span.SetAttributes(
+		attribute.Int("user_ids", len(userIDs)),
+		attribute.Bool("last_page", next == 0),
+	)
+	span.SetStatus(codes.Ok, "Carts listed")
+
+	c.JSON(http.StatusOK, ListCartsResponse{
+		UserIDs:    userIDs,
+		NextCursor: strconv.FormatUint(next, 10),
+	})
+}
+
+// RawCart handles GET /v1/cart/:user_id/raw
+// Dumps the unparsed cart hash, including entries GetCart silently skips, for incident debugging
+func (h *AdminHandler) RawCart(c *gin.Context) {
+	ctx := c.Request.Context()
+	tracer := otel.Tracer("cart-service")
+	ctx, span := tracer.Start(ctx, "handler.RawCart")
+	defer span.End()
+
+	userID := c.Param("user_id")
+	if !validateID(userID) {
+		span.SetStatus(codes.Error, "Invalid user_id")
+		apierror.RespondError(c, http.StatusBadRequest, CodeInvalidUserID, "user_id "+idRuleMessage)
+		return
+	}
+
+	span.SetAttributes(attribute.String("user_id", userID))
+
+	fields, err := h.store.RawCart(ctx, userID)
+	if err != nil {
+		span.SetStatus(codes.Error, "Failed to get raw cart")
+		span.RecordError(err)
+		h.logger.Error("Failed to get raw cart",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		apierror.RespondError(c, http.StatusInternalServerError, CodeRedisUnavailable, "Failed to retrieve cart")
+		return
+	}
+
+	// Same rule as GetCart, so the list is exactly what GetCart hides
+	invalid := []string{}
+	for productID, quantity := range fields {
+		if _, err := strconv.Atoi(quantity); err != nil {
+			invalid = append(invalid, productID)
+		}
+	}
+	sort.Strings(invalid)
+
+	span.SetAttributes(
+		attribute.Int("field_count", len(fields)),
+		attribute.Int("invalid_count", len(invalid)),
+	)
+	span.SetStatus(codes.Ok, "Raw cart retrieved")
+
+	c.JSON(http.StatusOK, RawCartResponse{
+		UserID:        userID,
+		Fields:        fields,
+		InvalidFields: invalid,
+	})
+}
+
+// RepairCart handles POST /v1/cart/:user_id/repair
+// Deletes the cart entries whose quantity is not an integer, i.e. those listed by RawCart as invalid
+func (h *AdminHandler) RepairCart(c *gin.Context) {
+	ctx := c.Request.Context()
+	tracer := otel.Tracer("cart-service")
+	ctx, span := tracer.Start(ctx, "handler.RepairCart")
+	defer span.End()
+
+	userID := c.Param("user_id")
+	if !validateID(userID) {
+		span.SetStatus(codes.Error, "Invalid user_id")
+		apierror.RespondError(c, http.StatusBadRequest, CodeInvalidUserID, "user_id "+idRuleMessage)
+		return
+	}
+
+	span.SetAttributes(attribute.String("user_id", userID))
+
+	removed, err := h.store.RepairCart(ctx, userID)
+	if err != nil {
+		span.SetStatus(codes.Error, "Failed to repair cart")
+		span.RecordError(err)
+		h.logger.Error("Failed to repair cart",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		apierror.RespondError(c, http.StatusInternalServerError, CodeRedisUnavailable, "Failed to repair cart")
+		return
+	}
+
+	span.SetAttributes(attribute.Int("removed_fields", removed))
+	span.SetStatus(codes.Ok, "Cart repaired")
+
+	c.JSON(http.StatusOK, RepairCartResponse{
+		UserID:  userID,
+		Removed: removed,
+	})
+}
@@ -0,0 +1,532 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"cart-service/redis"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	redisclient "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// setupAdminTest creates a miniredis instance and an admin handler backed by the real Redis client
+func setupAdminTest(t *testing.T, scanMaxKeys int) (*AdminHandler, *miniredis.Miniredis, func()) {
+	mr := miniredis.NewMiniRedis()
+	if err := mr.Start(); err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+
+	rdb := redisclient.NewClient(&redisclient.Options{
+		Addr: mr.Addr(),
+	})
+
+	logger := zap.NewNop()
+	handler := NewAdminHandler(redis.NewClient(rdb, logger), logger, AdminHandlerConfig{
+		ScanMaxKeys: scanMaxKeys,
+		Normalize: redis.NormalizeOptions{
+			TrimSpace:         true,
+			StripLeadingZeros: true,
+		},
+	})
+
+	cleanup := func() {
+		rdb.Close()
+		mr.Close()
+	}
+
+	return handler, mr, cleanup
+}
+
+// seedCart writes a cart hash with the given number of distinct items directly into miniredis
+func seedCart(mr *miniredis.Miniredis, userID string, itemCount int) {
+	for i := 0; i < itemCount; i++ {
+		mr.HSet("cart:"+userID, fmt.Sprintf("prod-%d", i), "1")
+	}
+}
+
+func TestLargestCarts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("should rank carts by distinct item count", func(t *testing.T) {
+		handler, mr, cleanup := setupAdminTest(t, 1000)
+		defer cleanup()
+
+		seedCart(mr, "small", 1)
+		seedCart(mr, "medium", 5)
+		seedCart(mr, "huge", 40)
+		seedCart(mr, "large", 12)
+		mr.Set("unrelated", "value")
+
+		router := gin.New()
+		router.GET("/admin/carts/largest", handler.LargestCarts)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/admin/carts/largest?limit=3", nil)
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response LargestCartsResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+		assert.Equal(t, []CartSizeResponse{
+			{UserID: "huge", ItemCount: 40},
+			{UserID: "large", ItemCount: 12},
+			{UserID: "medium", ItemCount: 5},
+		}, response.Carts)
+		assert.Equal(t, 4, response.ScannedKeys)
+		assert.False(t, response.Truncated)
+	})
+
+	t.Run("should stop scanning at the key budget", func(t *testing.T) {
+		handler, mr, cleanup := setupAdminTest(t, 2)
+		defer cleanup()
+
+		seedCart(mr, "a", 1)
+		seedCart(mr, "b", 2)
+		seedCart(mr, "c", 3)
+
+		router := gin.New()
+		router.GET("/admin/carts/largest", handler.LargestCarts)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/admin/carts/largest", nil)
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response LargestCartsResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+		assert.Len(t, response.Carts, 2)
+		assert.Equal(t, 2, response.ScannedKeys)
+		assert.True(t, response.Truncated)
+	})
+
+	t.Run("should reject an out of range limit", func(t *testing.T) {
+		handler, _, cleanup := setupAdminTest(t, 1000)
+		defer cleanup()
+
+		router := gin.New()
+		router.GET("/admin/carts/largest", handler.LargestCarts)
+
+		for _, limit := range []string{"0", "101", "abc"} {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/admin/carts/largest?limit="+limit, nil)
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, "limit=%s", limit)
+		}
+	})
+
+	t.Run("should fail rather than panic without a key budget", func(t *testing.T) {
+		handler, mr, cleanup := setupAdminTest(t, 0)
+		defer cleanup()
+
+		seedCart(mr, "a", 1)
+
+		router := gin.New()
+		router.GET("/admin/carts/largest", handler.LargestCarts)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/admin/carts/largest", nil)
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+func TestListCarts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("should page through every cart user ID", func(t *testing.T) {
+		handler, mr, cleanup := setupAdminTest(t, 1000)
+		defer cleanup()
+
+		var want []string
+		for i := 0; i < 25; i++ {
+			userID := fmt.Sprintf("user-%d", i)
+			seedCart(mr, userID, 1)
+			want = append(want, userID)
+		}
+		mr.Set("idem:user-1:key", "1")
+
+		router := gin.New()
+		router.GET("/v1/carts", handler.ListCarts)
+
+		var got []string
+		cursor := "0"
+		for pages := 0; ; pages++ {
+			require.Less(t, pages, 25, "paging did not terminate")
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/v1/carts?count=10&cursor="+cursor, nil)
+			router.ServeHTTP(w, req)
+			require.Equal(t, http.StatusOK, w.Code)
+
+			var response ListCartsResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			got = append(got, response.UserIDs...)
+
+			cursor = response.NextCursor
+			if cursor == "0" {
+				break
+			}
+		}
+
+		assert.ElementsMatch(t, want, got)
+	})
+
+	t.Run("should return an empty page when there are no carts", func(t *testing.T) {
+		handler, _, cleanup := setupAdminTest(t, 1000)
+		defer cleanup()
+
+		router := gin.New()
+		router.GET("/v1/carts", handler.ListCarts)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/v1/carts", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"user_ids": [], "next_cursor": "0"}`, w.Body.String())
+	})
+
+	t.Run("should reject an invalid cursor or count", func(t *testing.T) {
+		handler, _, cleanup := setupAdminTest(t, 1000)
+		defer cleanup()
+
+		router := gin.New()
+		router.GET("/v1/carts", handler.ListCarts)
+
+		for _, query := range []string{"cursor=-1", "cursor=abc", "count=0", "count=1001", "count=abc"} {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/v1/carts?"+query, nil)
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		}
+	})
+}
+
+func TestRawCart(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("should include corrupted entries that GetCart skips", func(t *testing.T) {
+		handler, mr, cleanup := setupAdminTest(t, 1000)
+		defer cleanup()
+
+		mr.HSet("cart:user-1", "prod-1", "2")
+		mr.HSet("cart:user-1", "prod-2", "two")
+
+		router := gin.New()
+		router.GET("/v1/cart/:user_id/raw", handler.RawCart)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/v1/cart/user-1/raw", nil)
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response RawCartResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, map[string]string{"prod-1": "2", "prod-2": "two"}, response.Fields)
+		assert.Equal(t, []string{"prod-2"}, response.InvalidFields)
+
+		// The normal cart view hides the corrupted entry
+		items, err := handler.store.(*redis.Client).GetCart(req.Context(), "user-1")
+		require.NoError(t, err)
+		assert.Equal(t, []redis.CartItem{{ProductID: "prod-1", Quantity: 2}}, items)
+	})
+
+	t.Run("should return an empty dump for a missing cart", func(t *testing.T) {
+		handler, _, cleanup := setupAdminTest(t, 1000)
+		defer cleanup()
+
+		router := gin.New()
+		router.GET("/v1/cart/:user_id/raw", handler.RawCart)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/v1/cart/nobody/raw", nil)
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response RawCartResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Empty(t, response.Fields)
+		assert.Empty(t, response.InvalidFields)
+	})
+
+	t.Run("should reject an invalid user_id", func(t *testing.T) {
+		handler, _, cleanup := setupAdminTest(t, 1000)
+		defer cleanup()
+
+		router := gin.New()
+		router.GET("/v1/cart/:user_id/raw", handler.RawCart)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/v1/cart/user%2A/raw", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"code":"`+CodeInvalidUserID+`"`)
+	})
+}
+
+func TestRepairCart(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("should remove corrupt fields and keep valid ones", func(t *testing.T) {
+		handler, mr, cleanup := setupAdminTest(t, 1000)
+		defer cleanup()
+
+		mr.HSet("cart:user-1", "prod-1", "2")
+		mr.HSet("cart:user-1", "prod-2", "two")
+		mr.HSet("cart:user-1", "prod-3", "")
+		mr.HSet("cart:user-1", "prod-4", "7")
+
+		router := gin.New()
+		router.POST("/v1/cart/:user_id/repair", handler.RepairCart)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/v1/cart/user-1/repair", nil)
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response RepairCartResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, RepairCartResponse{UserID: "user-1", Removed: 2}, response)
+
+		fields, err := mr.HKeys("cart:user-1")
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"prod-1", "prod-4"}, fields)
+		assert.Equal(t, "2", mr.HGet("cart:user-1", "prod-1"))
+	})
+
+	t.Run("should report nothing removed for a clean cart", func(t *testing.T) {
+		handler, mr, cleanup := setupAdminTest(t, 1000)
+		defer cleanup()
+
+		seedCart(mr, "user-1", 3)
+
+		router := gin.New()
+		router.POST("/v1/cart/:user_id/repair", handler.RepairCart)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/v1/cart/user-1/repair", nil)
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response RepairCartResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Zero(t, response.Removed)
+
+		fields, err := mr.HKeys("cart:user-1")
+		require.NoError(t, err)
+		assert.Len(t, fields, 3)
+	})
+
+	t.Run("should reject an invalid user_id", func(t *testing.T) {
+		handler, mr, cleanup := setupAdminTest(t, 1000)
+		defer cleanup()
+
+		mr.HSet("cart:user*", "prod-1", "two")
+
+		router := gin.New()
+		router.POST("/v1/cart/:user_id/repair", handler.RepairCart)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/v1/cart/user%2A/repair", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"code":"`+CodeInvalidUserID+`"`)
+		assert.Equal(t, "two", mr.HGet("cart:user*", "prod-1"))
+	})
+}
+
+func TestNormalizeCart(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("should merge lines that normalize to the same product", func(t *testing.T) {
+		handler, mr, cleanup := setupAdminTest(t, 1000)
+		defer cleanup()
+
+		mr.HSet("cart:user-1", "001", "2")
+		mr.HSet("cart:user-1", "1", "3")
+		mr.HSet("cart:user-1", " 2 ", "1")
+		mr.HSet("cart:user-1", "3", "4")
+		mr.HSet("cart:user-1", "prod-abc", "6")
+
+		router := gin.New()
+		router.POST("/admin/carts/:user_id/normalize", handler.NormalizeCart)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/admin/carts/user-1/normalize", nil)
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response NormalizeCartResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+		assert.Equal(t, "user-1", response.UserID)
+		assert.Equal(t, []CartMergeResponse{
+			{ProductID: "1", SourceIDs: []string{"001", "1"}, Quantity: 5},
+			{ProductID: "2", SourceIDs: []string{" 2 "}, Quantity: 1},
+		}, response.Merges)
+
+		// Verify the stored hash now only holds normalized IDs
+		fields, err := mr.HKeys("cart:user-1")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"1", "2", "3", "prod-abc"}, fields)
+		assert.Equal(t, "5", mr.HGet("cart:user-1", "1"))
+		assert.Equal(t, "1", mr.HGet("cart:user-1", "2"))
+		assert.Equal(t, "4", mr.HGet("cart:user-1", "3"))
+	})
+
+	t.Run("should report no merges for an already normalized cart", func(t *testing.T) {
+		handler, mr, cleanup := setupAdminTest(t, 1000)
+		defer cleanup()
+
+		mr.HSet("cart:user-1", "1", "3")
+
+		router := gin.New()
+		router.POST("/admin/carts/:user_id/normalize", handler.NormalizeCart)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/admin/carts/user-1/normalize", nil)
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response NormalizeCartResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Empty(t, response.Merges)
+		assert.Equal(t, "3", mr.HGet("cart:user-1", "1"))
+	})
+
+	t.Run("should reject an invalid user_id", func(t *testing.T) {
+		handler, _, cleanup := setupAdminTest(t, 1000)
+		defer cleanup()
+
+		router := gin.New()
+		router.POST("/admin/carts/:user_id/normalize", handler.NormalizeCart)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/admin/carts/user%20one/normalize", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"code":"`+CodeInvalidUserID+`"`)
+	})
+}
+
+func TestNormalizeOptions(t *testing.T) {
+	tests := []struct {
+		opts     redis.NormalizeOptions
+		input    string
+		expected string
+	}{
+		{redis.NormalizeOptions{TrimSpace: true, StripLeadingZeros: true}, " 007 ", "7"},
+		{redis.NormalizeOptions{TrimSpace: true, StripLeadingZeros: true}, "000", "0"},
+		{redis.NormalizeOptions{TrimSpace: true, StripLeadingZeros: true}, "0prod", "0prod"},
+		{redis.NormalizeOptions{TrimSpace: true}, " 007 ", "007"},
+		{redis.NormalizeOptions{StripLeadingZeros: true}, " 007", " 007"},
+		{redis.NormalizeOptions{}, " 007 ", " 007 "},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%+v %q", tt.opts, tt.input), func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.opts.Normalize(tt.input))
+		})
+	}
+}
+
+func TestCartMemory(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("should extrapolate memory from a sample of carts", func(t *testing.T) {
+		handler, mr, cleanup := setupAdminTest(t, 1000)
+		defer cleanup()
+		handler.config.MemorySampleRate = 0.25
+
+		for i := 0; i < 20; i++ {
+			seedCart(mr, fmt.Sprintf("user-%d", i), 3)
+		}
+
+		router := gin.New()
+		router.GET("/admin/carts/memory", handler.CartMemory)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/admin/carts/memory", nil)
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response CartMemoryResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+		assert.Equal(t, 20, response.TotalKeys)
+		assert.Equal(t, 5, response.SampledKeys)
+		assert.Greater(t, response.EstimatedBytes, int64(0))
+		assert.False(t, response.OverCap)
+	})
+
+	t.Run("should fail rather than panic without a key budget", func(t *testing.T) {
+		handler, mr, cleanup := setupAdminTest(t, -1)
+		defer cleanup()
+
+		seedCart(mr, "user-1", 3)
+
+		router := gin.New()
+		router.GET("/admin/carts/memory", handler.CartMemory)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/admin/carts/memory", nil)
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+
+	t.Run("should flag carts over the memory cap", func(t *testing.T) {
+		handler, mr, cleanup := setupAdminTest(t, 1000)
+		defer cleanup()
+		handler.config.MemorySampleRate = 1
+		handler.config.MemoryCapBytes = 1
+
+		seedCart(mr, "user-1", 3)
+
+		router := gin.New()
+		router.GET("/admin/carts/memory", handler.CartMemory)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/admin/carts/memory", nil)
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response CartMemoryResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+		assert.Equal(t, int64(1), response.CapBytes)
+		assert.True(t, response.OverCap)
+	})
+}
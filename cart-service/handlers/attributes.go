@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"cart-service/redis"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Business dimensions attached to handler spans
+// These describe the cart, never the user, so they stay low-cardinality and free of PII
+const (
+	cartSizeAttribute          = attribute.Key("cart.size")
+	cartTotalQuantityAttribute = attribute.Key("cart.total_quantity")
+)
+
+// setCartAttributes records the cart's business dimensions on the span when enabled
+// Uses the items the handler already loaded, so it costs no extra Redis calls
+func (h *CartHandler) setCartAttributes(span trace.Span, items []redis.CartItem) {
+	if !h.config.BusinessSpanAttributes {
+		return
+	}
+
+	totalQuantity := 0
+	for _, item := range items {
+		totalQuantity += item.Quantity
+	}
+
+	span.SetAttributes(
+		cartSizeAttribute.Int(len(items)),
+		cartTotalQuantityAttribute.Int(totalQuantity),
+	)
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans installs a global tracer provider that keeps finished spans in memory
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+	})
+
+	return recorder
+}
+
+// spanAttributes returns the attributes of the first finished span with the given name
+func spanAttributes(t *testing.T, recorder *tracetest.SpanRecorder, name string) map[attribute.Key]attribute.Value {
+	for _, span := range recorder.Ended() {
+		if span.Name() == name {
+			attrs := make(map[attribute.Key]attribute.Value)
+			for _, kv := range span.Attributes() {
+				attrs[kv.Key] = kv.Value
+			}
+			return attrs
+		}
+	}
+	t.Fatalf("span %q was not recorded", name)
+	return nil
+}
+
+func TestCartBusinessAttributes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("should add cart dimensions to the handler span", func(t *testing.T) {
+		recorder := recordSpans(t)
+
+		handler, mr, cleanup := setupTest(t)
+		defer cleanup()
+		handler.config.BusinessSpanAttributes = true
+
+		mr.HSet("cart:user-123", "prod-1", "2")
+		mr.HSet("cart:user-123", "prod-2", "5")
+		mr.HSet("cart:user-123", "prod-3", "1")
+
+		router := gin.New()
+		router.GET("/v1/cart/:user_id", handler.GetCart)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/v1/cart/user-123", nil)
+
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+
+		attrs := spanAttributes(t, recorder, "handler.GetCart")
+		assert.Equal(t, int64(3), attrs["cart.size"].AsInt64())
+		assert.Equal(t, int64(8), attrs["cart.total_quantity"].AsInt64())
+	})
+
+	t.Run("should leave the span alone when disabled", func(t *testing.T) {
+		recorder := recordSpans(t)
+
+		handler, mr, cleanup := setupTest(t)
+		defer cleanup()
+
+		mr.HSet("cart:user-123", "prod-1", "2")
+
+		router := gin.New()
+		router.GET("/v1/cart/:user_id", handler.GetCart)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/v1/cart/user-123", nil)
+
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+
+		attrs := spanAttributes(t, recorder, "handler.GetCart")
+		assert.NotContains(t, attrs, attribute.Key("cart.size"))
+		assert.NotContains(t, attrs, attribute.Key("cart.total_quantity"))
+	})
+}
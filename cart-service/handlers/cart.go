@@ -1,20 +1,31 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"cart-service/internal/apierror"
+	"cart-service/products"
 	"cart-service/redis"
+	"cart-service/telemetry"
 
 	"github.com/gin-gonic/gin"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
 // AddItemRequest represents the request body for adding an item to cart
 type AddItemRequest struct {
-	ProductID string `json:"product_id" binding:"required"`
+	ProductID string `json:"product_id" binding:"required,safeid"`
 	Quantity  int    `json:"quantity" binding:"required,min=1"`
 }
 
@@ -29,24 +40,114 @@ type CartResponse struct {
 	UserID     string     `json:"user_id"`
 	Items      []CartItem `json:"items"`
 	TotalItems int        `json:"total_items"`
+	// Meta is only populated by GetCart and AddItem, and omitted when the cart has none
+	Meta *CartMetaResponse `json:"meta,omitempty"`
+	// Degraded is set when Redis was unavailable and the cart was served from memory
+	Degraded bool `json:"degraded,omitempty"`
+}
+
+// SetItemRequest represents a single line in a bulk quantity update
+// A quantity of 0 removes the product from the cart; a missing quantity is rejected rather than read as 0
+type SetItemRequest struct {
+	ProductID string `json:"product_id" binding:"required,safeid"`
+	Quantity  *int   `json:"quantity" binding:"required,min=0"`
+}
+
+// MergeCartRequest represents the request body for merging another cart into the user's cart
+type MergeCartRequest struct {
+	FromUserID string `json:"from_user_id" binding:"required,safeid"`
+}
+
+// SetQuantityRequest represents the request body for overwriting one item's quantity
+// A quantity of 0 removes the product from the cart
+type SetQuantityRequest struct {
+	Quantity *int `json:"quantity" binding:"required,min=0"`
+}
+
+// TransferItemRequest represents the request body for moving a product quantity to another cart
+type TransferItemRequest struct {
+	ToUserID  string `json:"to_user_id" binding:"required,safeid"`
+	ProductID string `json:"product_id" binding:"required,safeid"`
+	Quantity  int    `json:"quantity" binding:"required,min=1"`
+}
+
+// CartStore defines the cart operations the handlers depend on
+// This interface enables easy mocking for testing
+type CartStore interface {
+	AddItem(ctx context.Context, userID, productID string, quantity int) error
+	AddItemWithLimit(ctx context.Context, userID, productID string, quantity, maxItems, maxQuantity int) error
+	GetCart(ctx context.Context, userID string) ([]redis.CartItem, error)
+	SetItems(ctx context.Context, userID string, items []redis.CartItem, maxItems, maxQuantity int) error
+	ClearCart(ctx context.Context, userID string) error
+	GetCartMeta(ctx context.Context, userID string) (redis.CartMeta, error)
+	SetCartCurrency(ctx context.Context, userID, currency string) error
+	MergeCart(ctx context.Context, fromUserID, toUserID string, maxItems, maxQuantity int) error
+	TransferItem(ctx context.Context, fromUserID, toUserID, productID string, quantity, maxItems, maxQuantity int) error
+	SetItemQuantityIfMatch(ctx context.Context, userID, productID string, expected, newQty, maxItems, maxQuantity int) (bool, error)
+	ClaimIdempotencyKey(ctx context.Context, userID, requestKey string, ttl time.Duration) (bool, error)
+	ReleaseIdempotencyKey(ctx context.Context, userID, requestKey string) error
+}
+
+// IdempotencyKeyHeader lets clients retry AddItem without double-counting quantity
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// idempotentReplayedHeader is set on responses to requests whose key was already processed
+const idempotentReplayedHeader = "Idempotent-Replayed"
+
+// IfMatchHeader carries the quantity a client last read for an item; SetItemQuantity only
+// applies the update if the item still has that quantity
+const IfMatchHeader = "If-Match"
+
+// maxIdempotencyKeyLength bounds the client-supplied key stored in Redis
+const maxIdempotencyKeyLength = 255
+
+// defaultIdempotencyTTL is used when CartHandlerConfig.IdempotencyTTL is not set
+const defaultIdempotencyTTL = 10 * time.Minute
+
+// CartHandlerConfig holds optional behaviour toggles for the cart handlers
+// The zero value keeps the default, most permissive behaviour
+type CartHandlerConfig struct {
+	// ProductIDNumeric requires product IDs to be positive integers (e.g. "42")
+	// matching the integer IDs used by product-service
+	ProductIDNumeric bool
+	// BusinessSpanAttributes adds cart.size and cart.total_quantity to handler spans
+	// so traces can be sliced by cart shape
+	BusinessSpanAttributes bool
+	// IdempotencyTTL is how long a processed Idempotency-Key is remembered (default: 10 minutes)
+	IdempotencyTTL time.Duration
+	// StockChecker, when set, is asked for the product before AddItem writes to Redis
+	// so unknown products (404) and quantities above stock (409) are rejected; nil skips the check
+	StockChecker ProductCatalog
+	// MaxCartItems caps the number of distinct products in a cart; adding a new product to a full
+	// cart is rejected with 409 while existing products can still be incremented (0 = unlimited)
+	MaxCartItems int
+	// MaxItemQuantity caps the quantity of a single product, both per request and for the total
+	// reached by repeated adds; larger quantities are rejected with 400 (0 = unlimited)
+	MaxItemQuantity int
 }
 
 // CartHandler holds dependencies for cart handlers
 type CartHandler struct {
-	redisClient *redis.Client
+	redisClient CartStore
 	logger      *zap.Logger
+	config      CartHandlerConfig
+	// metrics is nil for handlers built without NewCartHandler, which skips recording
+	metrics *cartMetrics
 }
 
 // NewCartHandler creates a new cart handler
-func NewCartHandler(redisClient *redis.Client, logger *zap.Logger) *CartHandler {
+func NewCartHandler(redisClient CartStore, logger *zap.Logger, config CartHandlerConfig) *CartHandler {
 	return &CartHandler{
 		redisClient: redisClient,
 		logger:      logger,
+		config:      config,
+		metrics:     newCartMetrics(logger),
 	}
 }
 
 // AddItem handles POST /v1/cart/:user_id
 // Adds an item to the user's cart or increments quantity if it already exists
+// With an Idempotency-Key header, a repeated request returns the current cart without incrementing again
 func (h *CartHandler) AddItem(c *gin.Context) {
 	// Extract trace context for creating child spans
 	ctx := c.Request.Context()
@@ -56,25 +157,35 @@ func (h *CartHandler) AddItem(c *gin.Context) {
 
 	// Extract user_id from path parameter
 	userID := c.Param("user_id")
-	if userID == "" {
-		span.SetStatus(codes.Error, "Missing user_id")
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "user_id is required",
-		})
+	if !validateID(userID) {
+		span.SetStatus(codes.Error, "Invalid user_id")
+		apierror.RespondError(c, http.StatusBadRequest, CodeInvalidUserID, "user_id "+idRuleMessage)
 		return
 	}
 
 	span.SetAttributes(attribute.String("user_id", userID))
 
+	// Carry user_id to product-service (stock check) in the baggage header
+	ctx = telemetry.ContextWithUserID(ctx, userID)
+
 	// Parse request body
 	var req AddItemRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		span.SetStatus(codes.Error, "Invalid request body")
 		span.RecordError(err)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request body",
-			"details": err.Error(),
-		})
+		respondInvalidBody(c, bindingErrors(err))
+		return
+	}
+
+	if h.invalidProductID(req.ProductID) {
+		span.SetStatus(codes.Error, "Invalid product_id")
+		respondInvalidBody(c, []FieldError{{Field: "product_id", Message: numericProductIDMessage}})
+		return
+	}
+
+	if h.exceedsMaxQuantity(req.Quantity) {
+		span.SetStatus(codes.Error, "Quantity above limit")
+		respondInvalidBody(c, []FieldError{{Field: "quantity", Message: h.maxQuantityMessage()}})
 		return
 	}
 
@@ -83,8 +194,55 @@ func (h *CartHandler) AddItem(c *gin.Context) {
 		attribute.Int("quantity", req.Quantity),
 	)
 
+	requestKey := c.GetHeader(IdempotencyKeyHeader)
+	if len(requestKey) > maxIdempotencyKeyLength {
+		span.SetStatus(codes.Error, "Invalid Idempotency-Key")
+		apierror.RespondError(c, http.StatusBadRequest, CodeInvalidIdempotencyKey, fmt.Sprintf("%s must be at most %d characters", IdempotencyKeyHeader, maxIdempotencyKeyLength))
+		return
+	}
+
+	if requestKey != "" {
+		claimed, err := h.redisClient.ClaimIdempotencyKey(ctx, userID, requestKey, h.idempotencyTTL())
+		if err != nil {
+			span.SetStatus(codes.Error, "Failed to check idempotency key")
+			span.RecordError(err)
+			apierror.RespondError(c, http.StatusInternalServerError, CodeRedisUnavailable, "Failed to add item to cart")
+			return
+		}
+
+		span.SetAttributes(attribute.Bool("idempotent_replay", !claimed))
+		if !claimed {
+			// Already processed: answer with the current cart instead of incrementing again
+			h.logger.Info("Duplicate add item request ignored",
+				zap.String("user_id", userID),
+				zap.String("product_id", req.ProductID),
+			)
+			c.Header(idempotentReplayedHeader, "true")
+			h.respondWithCart(ctx, c, span, userID)
+			return
+		}
+	}
+
+	if status, apiErr := h.checkStock(ctx, span, userID, req); status != 0 {
+		// Nothing was written, so let the client retry with the same key
+		if requestKey != "" {
+			h.redisClient.ReleaseIdempotencyKey(ctx, userID, requestKey)
+		}
+		apierror.Respond(c, status, apiErr)
+		return
+	}
+
 	// Add item to cart via Redis
-	if err := h.redisClient.AddItem(ctx, userID, req.ProductID, req.Quantity); err != nil {
+	if err := h.addItem(ctx, userID, req); err != nil {
+		// Nothing was written, so let the client retry with the same key
+		if requestKey != "" {
+			h.redisClient.ReleaseIdempotencyKey(ctx, userID, requestKey)
+		}
+
+		if h.respondLimitExceeded(c, span, err, req.ProductID) {
+			return
+		}
+
 		span.SetStatus(codes.Error, "Failed to add item")
 		span.RecordError(err)
 		h.logger.Error("Failed to add item to cart",
@@ -93,12 +251,115 @@ func (h *CartHandler) AddItem(c *gin.Context) {
 			zap.Int("quantity", req.Quantity),
 			zap.Error(err),
 		)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to add item to cart",
-		})
+		apierror.RespondError(c, http.StatusInternalServerError, CodeRedisUnavailable, "Failed to add item to cart")
 		return
 	}
 
+	h.respondWithCart(ctx, c, span, userID)
+}
+
+// respondLimitExceeded answers a write rejected by MaxCartItems (409 CART_FULL) or
+// MaxItemQuantity (400 CART_INVALID_QUANTITY) and reports whether err was one of those
+// productID names the product in the quantity message; leave it empty when several were written
+func (h *CartHandler) respondLimitExceeded(c *gin.Context, span trace.Span, err error, productID string) bool {
+	switch {
+	case errors.Is(err, redis.ErrCartFull):
+		span.SetStatus(codes.Error, "Cart is full")
+		apierror.RespondErrorDetails(c, http.StatusConflict, CodeCartFull, "Cart has reached the maximum number of items", gin.H{
+			"max_items": h.config.MaxCartItems,
+		})
+		return true
+	case errors.Is(err, redis.ErrQuantityLimit):
+		message := fmt.Sprintf("Item quantity would exceed the maximum of %d per item", h.config.MaxItemQuantity)
+		if productID != "" {
+			message = fmt.Sprintf("Quantity of product %s would exceed the maximum of %d per item", productID, h.config.MaxItemQuantity)
+		}
+		span.SetStatus(codes.Error, "Item quantity limit reached")
+		apierror.RespondErrorDetails(c, http.StatusBadRequest, CodeInvalidQuantity, message,
+			gin.H{"max_quantity": h.config.MaxItemQuantity})
+		return true
+	}
+	return false
+}
+
+// addItem writes the item through AddItemWithLimit when MaxCartItems or MaxItemQuantity is set,
+// AddItem otherwise
+func (h *CartHandler) addItem(ctx context.Context, userID string, req AddItemRequest) error {
+	if h.config.MaxCartItems > 0 || h.config.MaxItemQuantity > 0 {
+		return h.redisClient.AddItemWithLimit(ctx, userID, req.ProductID, req.Quantity, h.config.MaxCartItems, h.config.MaxItemQuantity)
+	}
+	return h.redisClient.AddItem(ctx, userID, req.ProductID, req.Quantity)
+}
+
+// checkStock confirms with product-service that the product exists and has enough stock for the
+// quantity already in the user's cart plus the quantity being added
+// Returns a zero status when the item may be added, otherwise the error response to send
+func (h *CartHandler) checkStock(ctx context.Context, span trace.Span, userID string, req AddItemRequest) (int, apierror.APIError) {
+	if h.config.StockChecker == nil {
+		return 0, apierror.APIError{}
+	}
+
+	product, err := h.config.StockChecker.GetProduct(ctx, req.ProductID)
+	if err != nil {
+		span.RecordError(err)
+		if errors.Is(err, products.ErrProductNotFound) {
+			span.SetStatus(codes.Error, "Product not found")
+			return http.StatusNotFound, apierror.APIError{
+				Code:    CodeProductNotFound,
+				Message: "Product not found",
+				Details: gin.H{"product_id": req.ProductID},
+			}
+		}
+		span.SetStatus(codes.Error, "Stock check failed")
+		h.logger.Error("Failed to check product stock",
+			zap.String("product_id", req.ProductID),
+			zap.Error(err),
+		)
+		return http.StatusBadGateway, apierror.APIError{
+			Code:    CodeProductServiceUnavailable,
+			Message: "Product service unavailable",
+		}
+	}
+
+	// The add increments the line, so the stock has to cover what the cart will hold afterwards
+	items, err := h.redisClient.GetCart(ctx, userID)
+	if err != nil {
+		span.SetStatus(codes.Error, "Failed to get cart")
+		span.RecordError(err)
+		h.logger.Error("Failed to read cart for stock check",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		return http.StatusInternalServerError, apierror.APIError{
+			Code:    CodeRedisUnavailable,
+			Message: "Failed to add item to cart",
+		}
+	}
+	inCart := 0
+	for _, item := range items {
+		if item.ProductID == req.ProductID {
+			inCart = item.Quantity
+			break
+		}
+	}
+
+	span.SetAttributes(
+		attribute.Int("product.stock", product.Stock),
+		attribute.Int("product.in_cart", inCart),
+	)
+	if inCart+req.Quantity > product.Stock {
+		span.SetStatus(codes.Error, "Insufficient stock")
+		return http.StatusConflict, apierror.APIError{
+			Code:    CodeInsufficientStock,
+			Message: "Insufficient stock",
+			Details: gin.H{"product_id": req.ProductID, "available": product.Stock, "in_cart": inCart},
+		}
+	}
+	return 0, apierror.APIError{}
+}
+
+// respondWithCart writes the user's current cart as the AddItem response
+func (h *CartHandler) respondWithCart(ctx context.Context, c *gin.Context, span trace.Span, userID string) {
 	// Get updated cart to return in response
 	items, err := h.redisClient.GetCart(ctx, userID)
 	if err != nil {
@@ -114,26 +375,25 @@ func (h *CartHandler) AddItem(c *gin.Context) {
 	}
 
 	// Convert to response format
-	responseItems := make([]CartItem, len(items))
-	for i, item := range items {
-		responseItems[i] = CartItem{
-			ProductID: item.ProductID,
-			Quantity:  item.Quantity,
-		}
-	}
-
-	response := CartResponse{
-		UserID:     userID,
-		Items:      responseItems,
-		TotalItems: len(responseItems),
-	}
+	response := newCartResponse(ctx, userID, items)
+	response.Meta = h.cartMeta(ctx, userID)
 
 	span.SetStatus(codes.Ok, "Item added successfully")
-	span.SetAttributes(attribute.Int("total_items", len(responseItems)))
+	span.SetAttributes(attribute.Int("total_items", response.TotalItems))
+	h.setCartAttributes(span, items)
+	h.recordCartSize(ctx, items)
 
 	c.JSON(http.StatusOK, response)
 }
 
+// idempotencyTTL returns the configured Idempotency-Key lifetime or the default
+func (h *CartHandler) idempotencyTTL() time.Duration {
+	if h.config.IdempotencyTTL > 0 {
+		return h.config.IdempotencyTTL
+	}
+	return defaultIdempotencyTTL
+}
+
 // GetCart handles GET /v1/cart/:user_id
 // Returns all items in the user's cart
 func (h *CartHandler) GetCart(c *gin.Context) {
@@ -143,11 +403,9 @@ func (h *CartHandler) GetCart(c *gin.Context) {
 	defer span.End()
 
 	userID := c.Param("user_id")
-	if userID == "" {
-		span.SetStatus(codes.Error, "Missing user_id")
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "user_id is required",
-		})
+	if !validateID(userID) {
+		span.SetStatus(codes.Error, "Invalid user_id")
+		apierror.RespondError(c, http.StatusBadRequest, CodeInvalidUserID, "user_id "+idRuleMessage)
 		return
 	}
 
@@ -162,29 +420,410 @@ func (h *CartHandler) GetCart(c *gin.Context) {
 			zap.String("user_id", userID),
 			zap.Error(err),
 		)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to retrieve cart",
-		})
+		apierror.RespondError(c, http.StatusInternalServerError, CodeRedisUnavailable, "Failed to retrieve cart")
 		return
 	}
 
 	// Convert to response format
-	responseItems := make([]CartItem, len(items))
-	for i, item := range items {
-		responseItems[i] = CartItem{
-			ProductID: item.ProductID,
-			Quantity:  item.Quantity,
+	response := newCartResponse(ctx, userID, items)
+	response.Meta = h.cartMeta(ctx, userID)
+
+	span.SetStatus(codes.Ok, "Cart retrieved successfully")
+	span.SetAttributes(attribute.Int("total_items", response.TotalItems))
+	h.setCartAttributes(span, items)
+	h.recordCartSize(ctx, items)
+
+	c.JSON(http.StatusOK, response)
+}
+
+// SetItems handles PUT /v1/cart/:user_id/items
+// Overwrites the quantities of several items in one call; a quantity of 0 removes the item
+func (h *CartHandler) SetItems(c *gin.Context) {
+	ctx := c.Request.Context()
+	tracer := otel.Tracer("cart-service")
+	ctx, span := tracer.Start(ctx, "handler.SetItems")
+	defer span.End()
+
+	userID := c.Param("user_id")
+	if !validateID(userID) {
+		span.SetStatus(codes.Error, "Invalid user_id")
+		apierror.RespondError(c, http.StatusBadRequest, CodeInvalidUserID, "user_id "+idRuleMessage)
+		return
+	}
+
+	span.SetAttributes(attribute.String("user_id", userID))
+
+	// Parse and validate every line up front so nothing is written on a bad request
+	// Lines are validated individually so errors can point at the offending index
+	var req []SetItemRequest
+	if err := json.NewDecoder(c.Request.Body).Decode(&req); err != nil {
+		span.SetStatus(codes.Error, "Invalid request body")
+		span.RecordError(err)
+		respondInvalidBody(c, bindingErrors(err))
+		return
+	}
+
+	fieldErrs := validateEach(req)
+	for i, line := range req {
+		// Lines failing the safeid rule were already reported by validateEach
+		if validateID(line.ProductID) && h.invalidProductID(line.ProductID) {
+			fieldErrs = append(fieldErrs, FieldError{
+				Field:   fmt.Sprintf("[%d].product_id", i),
+				Message: numericProductIDMessage,
+			})
+		}
+		// Missing quantities were already reported by validateEach
+		if line.Quantity != nil && h.exceedsMaxQuantity(*line.Quantity) {
+			fieldErrs = append(fieldErrs, FieldError{
+				Field:   fmt.Sprintf("[%d].quantity", i),
+				Message: h.maxQuantityMessage(),
+			})
+		}
+	}
+	if len(fieldErrs) > 0 {
+		span.SetStatus(codes.Error, "Invalid request body")
+		respondInvalidBody(c, fieldErrs)
+		return
+	}
+
+	if len(req) == 0 {
+		span.SetStatus(codes.Error, "Empty request body")
+		apierror.RespondError(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "At least one item is required")
+		return
+	}
+
+	span.SetAttributes(attribute.Int("line_count", len(req)))
+
+	lines := make([]redis.CartItem, len(req))
+	for i, line := range req {
+		lines[i] = redis.CartItem{
+			ProductID: line.ProductID,
+			Quantity:  *line.Quantity,
 		}
 	}
 
-	response := CartResponse{
-		UserID:     userID,
-		Items:      responseItems,
-		TotalItems: len(responseItems),
+	// Apply all lines atomically via Redis
+	if err := h.redisClient.SetItems(ctx, userID, lines, h.config.MaxCartItems, h.config.MaxItemQuantity); err != nil {
+		if h.respondLimitExceeded(c, span, err, "") {
+			return
+		}
+		span.SetStatus(codes.Error, "Failed to set items")
+		span.RecordError(err)
+		h.logger.Error("Failed to set cart items",
+			zap.String("user_id", userID),
+			zap.Int("line_count", len(lines)),
+			zap.Error(err),
+		)
+		apierror.RespondError(c, http.StatusInternalServerError, CodeRedisUnavailable, "Failed to update cart")
+		return
 	}
 
-	span.SetStatus(codes.Ok, "Cart retrieved successfully")
-	span.SetAttributes(attribute.Int("total_items", len(responseItems)))
+	// Get updated cart to return in response
+	items, err := h.redisClient.GetCart(ctx, userID)
+	if err != nil {
+		span.SetStatus(codes.Error, "Failed to retrieve cart")
+		span.RecordError(err)
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Cart updated successfully",
+			"warning": "Failed to retrieve updated cart",
+		})
+		return
+	}
+
+	response := newCartResponse(ctx, userID, items)
+
+	span.SetStatus(codes.Ok, "Items set successfully")
+	span.SetAttributes(attribute.Int("total_items", response.TotalItems))
+	h.setCartAttributes(span, items)
+
+	c.JSON(http.StatusOK, response)
+}
+
+// SetItemQuantity handles PUT /v1/cart/:user_id/items/:product_id
+// Overwrites the quantity of a single item; a quantity of 0 removes it
+// With an If-Match header holding the quantity the client last read (0 for an item not in the
+// cart), the update is a compare-and-set and returns 412 if the item changed in the meantime
+func (h *CartHandler) SetItemQuantity(c *gin.Context) {
+	ctx := c.Request.Context()
+	tracer := otel.Tracer("cart-service")
+	ctx, span := tracer.Start(ctx, "handler.SetItemQuantity")
+	defer span.End()
+
+	userID := c.Param("user_id")
+	productID := c.Param("product_id")
+	if !validateID(userID) {
+		span.SetStatus(codes.Error, "Invalid user_id")
+		apierror.RespondError(c, http.StatusBadRequest, CodeInvalidUserID, "user_id "+idRuleMessage)
+		return
+	}
+	if !validateID(productID) {
+		span.SetStatus(codes.Error, "Invalid product_id")
+		respondInvalidBody(c, []FieldError{{Field: "product_id", Message: idRuleMessage}})
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("user_id", userID),
+		attribute.String("product_id", productID),
+	)
+
+	if h.invalidProductID(productID) {
+		span.SetStatus(codes.Error, "Invalid product_id")
+		respondInvalidBody(c, []FieldError{{Field: "product_id", Message: numericProductIDMessage}})
+		return
+	}
+
+	var req SetQuantityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		span.SetStatus(codes.Error, "Invalid request body")
+		span.RecordError(err)
+		respondInvalidBody(c, bindingErrors(err))
+		return
+	}
+	quantity := *req.Quantity
+	if h.exceedsMaxQuantity(quantity) {
+		span.SetStatus(codes.Error, "Quantity above limit")
+		respondInvalidBody(c, []FieldError{{Field: "quantity", Message: h.maxQuantityMessage()}})
+		return
+	}
+
+	span.SetAttributes(attribute.Int("quantity", quantity))
+
+	var err error
+	if ifMatch := c.GetHeader(IfMatchHeader); ifMatch != "" {
+		expected, parseErr := parseIfMatch(ifMatch)
+		if parseErr != nil {
+			span.SetStatus(codes.Error, "Invalid If-Match")
+			apierror.RespondError(c, http.StatusBadRequest, CodeInvalidQuantity, "If-Match must be a non-negative quantity")
+			return
+		}
+		span.SetAttributes(attribute.Int("expected_quantity", expected))
+
+		var matched bool
+		matched, err = h.redisClient.SetItemQuantityIfMatch(ctx, userID, productID, expected, quantity, h.config.MaxCartItems, h.config.MaxItemQuantity)
+		if err == nil && !matched {
+			span.SetStatus(codes.Error, "Quantity precondition failed")
+			apierror.RespondError(c, http.StatusPreconditionFailed, CodeQuantityChanged, "Item quantity has changed")
+			return
+		}
+	} else {
+		err = h.redisClient.SetItems(ctx, userID, []redis.CartItem{{ProductID: productID, Quantity: quantity}}, h.config.MaxCartItems, h.config.MaxItemQuantity)
+	}
+	if err != nil {
+		if h.respondLimitExceeded(c, span, err, productID) {
+			return
+		}
+		span.SetStatus(codes.Error, "Failed to set item quantity")
+		span.RecordError(err)
+		h.logger.Error("Failed to set item quantity",
+			zap.String("user_id", userID),
+			zap.String("product_id", productID),
+			zap.Error(err),
+		)
+		apierror.RespondError(c, http.StatusInternalServerError, CodeRedisUnavailable, "Failed to update cart")
+		return
+	}
+
+	// Get updated cart to return in response
+	items, err := h.redisClient.GetCart(ctx, userID)
+	if err != nil {
+		span.SetStatus(codes.Error, "Failed to retrieve cart")
+		span.RecordError(err)
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Cart updated successfully",
+			"warning": "Failed to retrieve updated cart",
+		})
+		return
+	}
+
+	response := newCartResponse(ctx, userID, items)
+
+	span.SetStatus(codes.Ok, "Item quantity set successfully")
+	span.SetAttributes(attribute.Int("total_items", response.TotalItems))
+	h.setCartAttributes(span, items)
+
+	c.JSON(http.StatusOK, response)
+}
+
+// parseIfMatch reads the expected quantity from an If-Match header
+// Both a bare number and an ETag-style quoted value ("3" or W/"3") are accepted
+func parseIfMatch(value string) (int, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "W/")
+	expected, err := strconv.Atoi(strings.Trim(value, `"`))
+	if err != nil {
+		return 0, err
+	}
+	if expected < 0 {
+		return 0, fmt.Errorf("negative quantity %d", expected)
+	}
+	return expected, nil
+}
+
+// MergeCart handles POST /v1/cart/:user_id/merge
+// Moves every item of from_user_id's cart into the user's cart, adding quantities of products
+// in both, and deletes the source cart; used when a guest shopper logs in
+// Merging an empty or missing cart is a no-op. Returns the merged cart
+func (h *CartHandler) MergeCart(c *gin.Context) {
+	ctx := c.Request.Context()
+	tracer := otel.Tracer("cart-service")
+	ctx, span := tracer.Start(ctx, "handler.MergeCart")
+	defer span.End()
+
+	userID := c.Param("user_id")
+	if !validateID(userID) {
+		span.SetStatus(codes.Error, "Invalid user_id")
+		apierror.RespondError(c, http.StatusBadRequest, CodeInvalidUserID, "user_id "+idRuleMessage)
+		return
+	}
+
+	span.SetAttributes(attribute.String("user_id", userID))
+
+	var req MergeCartRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		span.SetStatus(codes.Error, "Invalid request body")
+		span.RecordError(err)
+		respondInvalidBody(c, bindingErrors(err))
+		return
+	}
+
+	// Merging a cart into itself would delete it
+	if req.FromUserID == userID {
+		span.SetStatus(codes.Error, "Merge into same cart")
+		apierror.RespondError(c, http.StatusBadRequest, CodeSameUser, "from_user_id must differ from user_id")
+		return
+	}
+
+	span.SetAttributes(attribute.String("from_user_id", req.FromUserID))
+
+	if err := h.redisClient.MergeCart(ctx, req.FromUserID, userID, h.config.MaxCartItems, h.config.MaxItemQuantity); err != nil {
+		if h.respondLimitExceeded(c, span, err, "") {
+			return
+		}
+		if errors.Is(err, redis.ErrCrossSlot) {
+			span.SetStatus(codes.Error, "Carts in different cluster slots")
+			apierror.RespondError(c, http.StatusNotImplemented, CodeCrossSlot, "Merging these carts is not supported in Redis Cluster mode")
+			return
+		}
+		span.SetStatus(codes.Error, "Failed to merge cart")
+		span.RecordError(err)
+		h.logger.Error("Failed to merge cart",
+			zap.String("user_id", userID),
+			zap.String("from_user_id", req.FromUserID),
+			zap.Error(err),
+		)
+		apierror.RespondError(c, http.StatusInternalServerError, CodeRedisUnavailable, "Failed to merge cart")
+		return
+	}
+
+	// Get merged cart to return in response
+	items, err := h.redisClient.GetCart(ctx, userID)
+	if err != nil {
+		span.SetStatus(codes.Error, "Failed to retrieve cart")
+		span.RecordError(err)
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Cart merged successfully",
+			"warning": "Failed to retrieve updated cart",
+		})
+		return
+	}
+
+	response := newCartResponse(ctx, userID, items)
+
+	span.SetStatus(codes.Ok, "Cart merged successfully")
+	span.SetAttributes(attribute.Int("total_items", response.TotalItems))
+	h.setCartAttributes(span, items)
+
+	c.JSON(http.StatusOK, response)
+}
+
+// TransferItem handles POST /v1/cart/:user_id/transfer
+// Moves quantity units of a product from the user's cart to to_user_id's cart, e.g. to a
+// "saved for later" cart. Nothing is moved when the cart holds fewer units than requested
+// Returns the user's (source) cart
+func (h *CartHandler) TransferItem(c *gin.Context) {
+	ctx := c.Request.Context()
+	tracer := otel.Tracer("cart-service")
+	ctx, span := tracer.Start(ctx, "handler.TransferItem")
+	defer span.End()
+
+	userID := c.Param("user_id")
+	if !validateID(userID) {
+		span.SetStatus(codes.Error, "Invalid user_id")
+		apierror.RespondError(c, http.StatusBadRequest, CodeInvalidUserID, "user_id "+idRuleMessage)
+		return
+	}
+
+	span.SetAttributes(attribute.String("user_id", userID))
+
+	var req TransferItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		span.SetStatus(codes.Error, "Invalid request body")
+		span.RecordError(err)
+		respondInvalidBody(c, bindingErrors(err))
+		return
+	}
+
+	if h.invalidProductID(req.ProductID) {
+		span.SetStatus(codes.Error, "Invalid product_id")
+		respondInvalidBody(c, []FieldError{{Field: "product_id", Message: numericProductIDMessage}})
+		return
+	}
+
+	if req.ToUserID == userID {
+		span.SetStatus(codes.Error, "Transfer into same cart")
+		apierror.RespondError(c, http.StatusBadRequest, CodeSameUser, "to_user_id must differ from user_id")
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("to_user_id", req.ToUserID),
+		attribute.String("product_id", req.ProductID),
+		attribute.Int("quantity", req.Quantity),
+	)
+
+	if err := h.redisClient.TransferItem(ctx, userID, req.ToUserID, req.ProductID, req.Quantity, h.config.MaxCartItems, h.config.MaxItemQuantity); err != nil {
+		if errors.Is(err, redis.ErrInsufficientQuantity) {
+			span.SetStatus(codes.Error, "Insufficient quantity")
+			apierror.RespondError(c, http.StatusConflict, CodeInsufficientQuantity, "Insufficient quantity in cart")
+			return
+		}
+		if h.respondLimitExceeded(c, span, err, req.ProductID) {
+			return
+		}
+		if errors.Is(err, redis.ErrCrossSlot) {
+			span.SetStatus(codes.Error, "Carts in different cluster slots")
+			apierror.RespondError(c, http.StatusNotImplemented, CodeCrossSlot, "Transferring between these carts is not supported in Redis Cluster mode")
+			return
+		}
+		span.SetStatus(codes.Error, "Failed to transfer item")
+		span.RecordError(err)
+		h.logger.Error("Failed to transfer item",
+			zap.String("user_id", userID),
+			zap.String("to_user_id", req.ToUserID),
+			zap.String("product_id", req.ProductID),
+			zap.Error(err),
+		)
+		apierror.RespondError(c, http.StatusInternalServerError, CodeRedisUnavailable, "Failed to transfer item")
+		return
+	}
+
+	// Get updated cart to return in response
+	items, err := h.redisClient.GetCart(ctx, userID)
+	if err != nil {
+		span.SetStatus(codes.Error, "Failed to retrieve cart")
+		span.RecordError(err)
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Item transferred successfully",
+			"warning": "Failed to retrieve updated cart",
+		})
+		return
+	}
+
+	response := newCartResponse(ctx, userID, items)
+
+	span.SetStatus(codes.Ok, "Item transferred successfully")
+	span.SetAttributes(attribute.Int("total_items", response.TotalItems))
+	h.setCartAttributes(span, items)
 
 	c.JSON(http.StatusOK, response)
 }
@@ -198,11 +837,9 @@ func (h *CartHandler) DeleteCart(c *gin.Context) {
 	defer span.End()
 
 	userID := c.Param("user_id")
-	if userID == "" {
-		span.SetStatus(codes.Error, "Missing user_id")
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "user_id is required",
-		})
+	if !validateID(userID) {
+		span.SetStatus(codes.Error, "Invalid user_id")
+		apierror.RespondError(c, http.StatusBadRequest, CodeInvalidUserID, "user_id "+idRuleMessage)
 		return
 	}
 
@@ -216,16 +853,37 @@ func (h *CartHandler) DeleteCart(c *gin.Context) {
 			zap.String("user_id", userID),
 			zap.Error(err),
 		)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to clear cart",
-		})
+		apierror.RespondError(c, http.StatusInternalServerError, CodeRedisUnavailable, "Failed to clear cart")
 		return
 	}
 
 	span.SetStatus(codes.Ok, "Cart cleared successfully")
 
-	c.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"message": "Cart cleared successfully",
 		"user_id": userID,
-	})
+	}
+	if isDegraded(ctx) {
+		response["degraded"] = true
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// newCartResponse converts Redis cart items into the API response format
+// The response is flagged as degraded if the request was served by the fallback store
+func newCartResponse(ctx context.Context, userID string, items []redis.CartItem) CartResponse {
+	responseItems := make([]CartItem, len(items))
+	for i, item := range items {
+		responseItems[i] = CartItem{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+		}
+	}
+
+	return CartResponse{
+		UserID:     userID,
+		Items:      responseItems,
+		TotalItems: len(responseItems),
+		Degraded:   isDegraded(ctx),
+	}
 }
@@ -7,15 +7,23 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"cart-service/internal/apierror"
+	"cart-service/products"
 	"cart-service/redis"
+	"cart-service/telemetry"
 
 	"github.com/alicebob/miniredis/v2"
 	"github.com/gin-gonic/gin"
 	redisclient "github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/baggage"
 	"go.uber.org/zap"
 )
 
@@ -36,11 +44,9 @@ func setupTest(t *testing.T) (*CartHandler, *miniredis.Miniredis, func()) {
 	// Create logger (use nop logger for tests to avoid output clutter)
 	logger := zap.NewNop()
 
-	// Create redis.Client wrapper
-	redisClient := &redis.Client{}
-	// Use reflection or direct assignment if Client struct is exported
-	// For simplicity, we'll create a new InitRedis that accepts a client
-	// But since we can't modify redis package in tests, we'll create wrapper
+	// Create redis.Client wrapper around the miniredis connection
+	// Operations not overridden by testRedisClient fall through to the real implementation
+	redisClient := redis.NewClient(rdb, logger)
 
 	// Quick workaround: manually create what we need
 	ctx := context.Background()
@@ -49,6 +55,7 @@ func setupTest(t *testing.T) (*CartHandler, *miniredis.Miniredis, func()) {
 
 	// Create a test client wrapper
 	testClient := &testRedisClient{
+		Client: redisClient,
 		rdb:    rdb,
 		logger: logger,
 	}
@@ -69,6 +76,7 @@ func setupTest(t *testing.T) (*CartHandler, *miniredis.Miniredis, func()) {
 
 // testRedisClient wraps the Redis client for testing
 type testRedisClient struct {
+	*redis.Client
 	rdb    *redisclient.Client
 	logger *zap.Logger
 }
@@ -213,6 +221,317 @@ func TestAddItem(t *testing.T) {
 
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 	})
+
+	t.Run("should reject a new product once the cart is full", func(t *testing.T) {
+		handler, mr, cleanup := setupTest(t)
+		defer cleanup()
+		handler.config.MaxCartItems = 2
+
+		router := gin.New()
+		router.POST("/v1/cart/:user_id", handler.AddItem)
+
+		add := func(productID string) *httptest.ResponseRecorder {
+			body, _ := json.Marshal(AddItemRequest{ProductID: productID, Quantity: 1})
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/v1/cart/user-1", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+			return w
+		}
+
+		require.Equal(t, http.StatusOK, add("prod-1").Code)
+		require.Equal(t, http.StatusOK, add("prod-2").Code)
+
+		w := add("prod-3")
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		var apiErr apierror.APIError
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &apiErr))
+		assert.Equal(t, CodeCartFull, apiErr.Code)
+		assert.Equal(t, map[string]any{"max_items": float64(2)}, apiErr.Details)
+		assert.Empty(t, mr.HGet("cart:user-1", "prod-3"), "The rejected product must not be written")
+
+		// Products already in the full cart can still be incremented
+		assert.Equal(t, http.StatusOK, add("prod-1").Code)
+		assert.Equal(t, "2", mr.HGet("cart:user-1", "prod-1"))
+	})
+}
+
+func TestAddItemIdempotency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// addItem sends an AddItem request with an optional Idempotency-Key header
+	addItem := func(router *gin.Engine, userID, requestKey string, quantity int) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(AddItemRequest{ProductID: "prod-123", Quantity: quantity})
+		req, _ := http.NewRequest("POST", "/v1/cart/"+userID, bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		if requestKey != "" {
+			req.Header.Set(IdempotencyKeyHeader, requestKey)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should increment on the first call", func(t *testing.T) {
+		handler, mr, cleanup := setupTest(t)
+		defer cleanup()
+
+		router := gin.New()
+		router.POST("/v1/cart/:user_id", handler.AddItem)
+
+		w := addItem(router, "user-1", "req-abc", 2)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Idempotent-Replayed"))
+		assert.Equal(t, "2", mr.HGet("cart:user-1", "prod-123"))
+
+		// The key is remembered with a TTL
+		assert.True(t, mr.Exists("idem:user-1:req-abc"))
+		assert.Equal(t, defaultIdempotencyTTL, mr.TTL("idem:user-1:req-abc"))
+	})
+
+	t.Run("should not increment again for a duplicate call", func(t *testing.T) {
+		handler, mr, cleanup := setupTest(t)
+		defer cleanup()
+
+		router := gin.New()
+		router.POST("/v1/cart/:user_id", handler.AddItem)
+
+		first := addItem(router, "user-1", "req-abc", 2)
+		assert.Equal(t, http.StatusOK, first.Code)
+
+		retry := addItem(router, "user-1", "req-abc", 2)
+
+		assert.Equal(t, http.StatusOK, retry.Code)
+		assert.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+		assert.Equal(t, "2", mr.HGet("cart:user-1", "prod-123"))
+
+		// The replay still returns the current cart
+		var response CartResponse
+		require.NoError(t, json.Unmarshal(retry.Body.Bytes(), &response))
+		require.Len(t, response.Items, 1)
+		assert.Equal(t, 2, response.Items[0].Quantity)
+	})
+
+	t.Run("should treat different keys and users independently", func(t *testing.T) {
+		handler, mr, cleanup := setupTest(t)
+		defer cleanup()
+
+		router := gin.New()
+		router.POST("/v1/cart/:user_id", handler.AddItem)
+
+		addItem(router, "user-1", "req-abc", 2)
+		addItem(router, "user-1", "req-def", 3)
+		addItem(router, "user-2", "req-abc", 1)
+
+		assert.Equal(t, "5", mr.HGet("cart:user-1", "prod-123"))
+		assert.Equal(t, "1", mr.HGet("cart:user-2", "prod-123"))
+	})
+
+	t.Run("should increment every call without a key", func(t *testing.T) {
+		handler, mr, cleanup := setupTest(t)
+		defer cleanup()
+
+		router := gin.New()
+		router.POST("/v1/cart/:user_id", handler.AddItem)
+
+		addItem(router, "user-1", "", 2)
+		addItem(router, "user-1", "", 2)
+
+		assert.Equal(t, "4", mr.HGet("cart:user-1", "prod-123"))
+	})
+
+	t.Run("should reject an oversized key", func(t *testing.T) {
+		handler, mr, cleanup := setupTest(t)
+		defer cleanup()
+
+		router := gin.New()
+		router.POST("/v1/cart/:user_id", handler.AddItem)
+
+		w := addItem(router, "user-1", strings.Repeat("k", maxIdempotencyKeyLength+1), 2)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.False(t, mr.Exists("cart:user-1"))
+	})
+}
+
+func TestAddItemStockCheck(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Serves GET /products/{id} like product-service; "broken" simulates an outage
+	productService := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stock := map[string]int{"1": 5, "2": 0}
+		productID := strings.TrimPrefix(r.URL.Path, "/products/")
+		if productID == "broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		available, ok := stock[productID]
+		if r.Method != http.MethodGet || !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(products.Product{Name: "Product " + productID, Stock: available})
+	}))
+	defer productService.Close()
+
+	tests := []struct {
+		name           string
+		productID      string
+		quantity       int
+		expectedStatus int
+		expectedCode   string
+	}{
+		{"should add when stock covers the quantity", "1", 5, http.StatusOK, ""},
+		{"should return 409 when stock is insufficient", "1", 6, http.StatusConflict, CodeInsufficientStock},
+		{"should return 409 when out of stock", "2", 1, http.StatusConflict, CodeInsufficientStock},
+		{"should return 404 for an unknown product", "999", 1, http.StatusNotFound, CodeProductNotFound},
+		{"should return 502 when product-service fails", "broken", 1, http.StatusBadGateway, CodeProductServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mr, cleanup := setupTest(t)
+			defer cleanup()
+			handler.config.StockChecker = products.NewClient(productService.URL, time.Second, zap.NewNop())
+
+			router := gin.New()
+			router.POST("/v1/cart/:user_id", handler.AddItem)
+
+			body, _ := json.Marshal(AddItemRequest{ProductID: tt.productID, Quantity: tt.quantity})
+			req, _ := http.NewRequest("POST", "/v1/cart/user-1", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set(IdempotencyKeyHeader, "req-abc")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, fmt.Sprint(tt.quantity), mr.HGet("cart:user-1", tt.productID))
+				return
+			}
+
+			var apiErr apierror.APIError
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &apiErr))
+			assert.Equal(t, tt.expectedCode, apiErr.Code)
+
+			// Rejected adds write nothing and leave the key free for a retry
+			assert.False(t, mr.Exists("cart:user-1"))
+			assert.False(t, mr.Exists("idem:user-1:req-abc"))
+		})
+	}
+
+	t.Run("should count the quantity already in the cart", func(t *testing.T) {
+		handler, mr, cleanup := setupTest(t)
+		defer cleanup()
+		handler.config.StockChecker = products.NewClient(productService.URL, time.Second, zap.NewNop())
+
+		// 5 in stock and 3 already in the cart
+		mr.HSet("cart:user-1", "1", "3")
+
+		router := gin.New()
+		router.POST("/v1/cart/:user_id", handler.AddItem)
+
+		add := func(quantity int) *httptest.ResponseRecorder {
+			body, _ := json.Marshal(AddItemRequest{ProductID: "1", Quantity: quantity})
+			req, _ := http.NewRequest("POST", "/v1/cart/user-1", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			return w
+		}
+
+		w := add(3)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		var apiErr apierror.APIError
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &apiErr))
+		assert.Equal(t, CodeInsufficientStock, apiErr.Code)
+		assert.Equal(t, map[string]any{"product_id": "1", "available": float64(5), "in_cart": float64(3)}, apiErr.Details)
+		assert.Equal(t, "3", mr.HGet("cart:user-1", "1"), "a rejected add should write nothing")
+
+		assert.Equal(t, http.StatusOK, add(2).Code)
+		assert.Equal(t, "5", mr.HGet("cart:user-1", "1"))
+	})
+
+	t.Run("should skip the check when disabled", func(t *testing.T) {
+		handler, mr, cleanup := setupTest(t)
+		defer cleanup()
+
+		router := gin.New()
+		router.POST("/v1/cart/:user_id", handler.AddItem)
+
+		body, _ := json.Marshal(AddItemRequest{ProductID: "999", Quantity: 1})
+		req, _ := http.NewRequest("POST", "/v1/cart/user-1", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "1", mr.HGet("cart:user-1", "999"))
+	})
+}
+
+// baggageCatalog is a ProductCatalog that records the user_id baggage of each lookup
+type baggageCatalog struct {
+	userIDs []string
+}
+
+func (c *baggageCatalog) GetProduct(ctx context.Context, productID string) (*products.Product, error) {
+	c.userIDs = append(c.userIDs, baggage.FromContext(ctx).Member(telemetry.UserIDBaggageKey).Value())
+	return &products.Product{Name: "Product " + productID, Stock: 10}, nil
+}
+
+func TestAddItemBaggage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("should put user_id in the baggage of downstream calls", func(t *testing.T) {
+		handler, _, cleanup := setupTest(t)
+		defer cleanup()
+		catalog := &baggageCatalog{}
+		handler.config.StockChecker = catalog
+
+		router := gin.New()
+		router.POST("/v1/cart/:user_id", handler.AddItem)
+
+		body, _ := json.Marshal(AddItemRequest{ProductID: "1", Quantity: 1})
+		req, _ := http.NewRequest("POST", "/v1/cart/user-1", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, []string{"user-1"}, catalog.userIDs)
+	})
+}
+
+func TestRedisUnauthorized(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("should return 500 when Redis denies permission", func(t *testing.T) {
+		handler, mr, cleanup := setupTest(t)
+		defer cleanup()
+
+		// setupTest has already connected; revoke permissions as an ACL change would
+		mr.SetError("NOPERM User cart has no permissions to run the 'hincrby' command")
+
+		router := gin.New()
+		router.POST("/v1/cart/:user_id", handler.AddItem)
+
+		body, _ := json.Marshal(AddItemRequest{ProductID: "prod-123", Quantity: 1})
+		req, _ := http.NewRequest("POST", "/v1/cart/user-1", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		// Credentials problems are not leaked to clients
+		assert.NotContains(t, w.Body.String(), "NOPERM")
+	})
 }
 
 func TestGetCart(t *testing.T) {
@@ -267,6 +586,66 @@ func TestGetCart(t *testing.T) {
 	})
 }
 
+func TestSetCartCurrency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("should store the currency and return it in the cart", func(t *testing.T) {
+		handler, mr, cleanup := setupTest(t)
+		defer cleanup()
+
+		router := gin.New()
+		router.PUT("/v1/cart/:user_id/currency", handler.SetCartCurrency)
+		router.GET("/v1/cart/:user_id", handler.GetCart)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PUT", "/v1/cart/user-1/currency", strings.NewReader(`{"currency":"EUR"}`))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "eur", mr.HGet("cartmeta:user-1", "currency"))
+
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/v1/cart/user-1", nil)
+		router.ServeHTTP(w, req)
+
+		var response CartResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.NotNil(t, response.Meta)
+		assert.Equal(t, "eur", response.Meta.Currency)
+		assert.Nil(t, response.Meta.CreatedAt)
+	})
+
+	t.Run("should reject a currency that is not a three-letter code", func(t *testing.T) {
+		handler, _, cleanup := setupTest(t)
+		defer cleanup()
+
+		router := gin.New()
+		router.PUT("/v1/cart/:user_id/currency", handler.SetCartCurrency)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PUT", "/v1/cart/user-1/currency", strings.NewReader(`{"currency":"euro"}`))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("should omit meta for a cart without metadata", func(t *testing.T) {
+		handler, _, cleanup := setupTest(t)
+		defer cleanup()
+
+		router := gin.New()
+		router.GET("/v1/cart/:user_id", handler.GetCart)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/v1/cart/user-1", nil)
+		router.ServeHTTP(w, req)
+
+		assert.NotContains(t, w.Body.String(), `"meta"`)
+	})
+}
+
 func TestDeleteCart(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -293,3 +672,940 @@ func TestDeleteCart(t *testing.T) {
 		assert.Empty(t, items)
 	})
 }
+
+func TestSetItems(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("should apply sets, zero-deletes and new products together", func(t *testing.T) {
+		handler, _, cleanup := setupTest(t)
+		defer cleanup()
+
+		// Seed existing items
+		ctx := context.Background()
+		handler.redisClient.AddItem(ctx, "user-1", "prod-1", 2)
+		handler.redisClient.AddItem(ctx, "user-1", "prod-2", 3)
+
+		router := gin.New()
+		router.PUT("/v1/cart/:user_id/items", handler.SetItems)
+
+		// Overwrite prod-1, remove prod-2 and add prod-3
+		body := []byte(`[{"product_id":"prod-1","quantity":5},{"product_id":"prod-2","quantity":0},{"product_id":"prod-3","quantity":1}]`)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PUT", "/v1/cart/user-1/items", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response CartResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		quantities := make(map[string]int)
+		for _, item := range response.Items {
+			quantities[item.ProductID] = item.Quantity
+		}
+
+		assert.Equal(t, 2, response.TotalItems)
+		assert.Equal(t, map[string]int{"prod-1": 5, "prod-3": 1}, quantities)
+	})
+
+	t.Run("should reject the whole batch when any quantity is invalid", func(t *testing.T) {
+		handler, _, cleanup := setupTest(t)
+		defer cleanup()
+
+		ctx := context.Background()
+		handler.redisClient.AddItem(ctx, "user-1", "prod-1", 2)
+
+		router := gin.New()
+		router.PUT("/v1/cart/:user_id/items", handler.SetItems)
+
+		body := []byte(`[{"product_id":"prod-1","quantity":7},{"product_id":"prod-2","quantity":-1}]`)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PUT", "/v1/cart/user-1/items", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		// Verify nothing was written
+		items, _ := handler.redisClient.GetCart(ctx, "user-1")
+		require.Len(t, items, 1)
+		assert.Equal(t, 2, items[0].Quantity)
+	})
+
+	t.Run("should reject a line without a quantity instead of removing it", func(t *testing.T) {
+		handler, _, cleanup := setupTest(t)
+		defer cleanup()
+
+		ctx := context.Background()
+		handler.redisClient.AddItem(ctx, "user-1", "prod-1", 2)
+
+		router := gin.New()
+		router.PUT("/v1/cart/:user_id/items", handler.SetItems)
+
+		body := []byte(`[{"product_id":"prod-1"}]`)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PUT", "/v1/cart/user-1/items", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"field":"[0].quantity"`)
+
+		items, _ := handler.redisClient.GetCart(ctx, "user-1")
+		require.Len(t, items, 1)
+		assert.Equal(t, 2, items[0].Quantity)
+	})
+
+	t.Run("should reject an empty batch", func(t *testing.T) {
+		handler, _, cleanup := setupTest(t)
+		defer cleanup()
+
+		router := gin.New()
+		router.PUT("/v1/cart/:user_id/items", handler.SetItems)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PUT", "/v1/cart/user-1/items", bytes.NewBufferString(`[]`))
+		req.Header.Set("Content-Type", "application/json")
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestSetItemQuantity(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// setQuantity sends a SetItemQuantity request with an optional If-Match header
+	setQuantity := func(router *gin.Engine, body, ifMatch string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PUT", "/v1/cart/user-1/items/prod-1", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		if ifMatch != "" {
+			req.Header.Set(IfMatchHeader, ifMatch)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should overwrite unconditionally without If-Match", func(t *testing.T) {
+		handler, mr, cleanup := setupTest(t)
+		defer cleanup()
+
+		mr.HSet("cart:user-1", "prod-1", "2")
+
+		router := gin.New()
+		router.PUT("/v1/cart/:user_id/items/:product_id", handler.SetItemQuantity)
+
+		w := setQuantity(router, `{"quantity":7}`, "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "7", mr.HGet("cart:user-1", "prod-1"))
+	})
+
+	t.Run("should apply the update only when If-Match equals the current quantity", func(t *testing.T) {
+		handler, mr, cleanup := setupTest(t)
+		defer cleanup()
+
+		mr.HSet("cart:user-1", "prod-1", "2")
+
+		router := gin.New()
+		router.PUT("/v1/cart/:user_id/items/:product_id", handler.SetItemQuantity)
+
+		w := setQuantity(router, `{"quantity":5}`, "3")
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+		assert.Contains(t, w.Body.String(), `"code":"`+CodeQuantityChanged+`"`)
+		assert.Equal(t, "2", mr.HGet("cart:user-1", "prod-1"))
+
+		w = setQuantity(router, `{"quantity":5}`, `"2"`)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "5", mr.HGet("cart:user-1", "prod-1"))
+
+		w = setQuantity(router, `{"quantity":0}`, `W/"5"`)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.False(t, mr.Exists("cart:user-1"), "quantity 0 should remove the item")
+	})
+
+	t.Run("should reject invalid requests", func(t *testing.T) {
+		handler, _, cleanup := setupTest(t)
+		defer cleanup()
+
+		router := gin.New()
+		router.PUT("/v1/cart/:user_id/items/:product_id", handler.SetItemQuantity)
+
+		assert.Equal(t, http.StatusBadRequest, setQuantity(router, `{}`, "").Code)
+		assert.Equal(t, http.StatusBadRequest, setQuantity(router, `{"quantity":-1}`, "").Code)
+		assert.Equal(t, http.StatusBadRequest, setQuantity(router, `{"quantity":1}`, "abc").Code)
+		assert.Equal(t, http.StatusBadRequest, setQuantity(router, `{"quantity":1}`, "-2").Code)
+	})
+
+	t.Run("should let only one of two racing updates win", func(t *testing.T) {
+		handler, mr, cleanup := setupTest(t)
+		defer cleanup()
+
+		mr.HSet("cart:user-1", "prod-1", "2")
+
+		ctx := context.Background()
+		start := make(chan struct{})
+		results := make(chan bool, 2)
+		var wg sync.WaitGroup
+		for _, newQty := range []int{3, 4} {
+			wg.Add(1)
+			go func(newQty int) {
+				defer wg.Done()
+				<-start
+				matched, err := handler.redisClient.SetItemQuantityIfMatch(ctx, "user-1", "prod-1", 2, newQty, 0, 0)
+				assert.NoError(t, err)
+				results <- matched
+			}(newQty)
+		}
+		close(start)
+		wg.Wait()
+		close(results)
+
+		won := 0
+		for matched := range results {
+			if matched {
+				won++
+			}
+		}
+		assert.Equal(t, 1, won, "exactly one read-modify-write should succeed")
+		assert.Contains(t, []string{"3", "4"}, mr.HGet("cart:user-1", "prod-1"))
+	})
+}
+
+func TestCartEvents(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// setupEventsTest returns a router over a client publishing cart events, and a miniredis
+	// subscriber to the events channel
+	setupEventsTest := func(t *testing.T) (*gin.Engine, *miniredis.Miniredis, *miniredis.Subscriber) {
+		mr := miniredis.RunT(t)
+		client, err := redis.InitRedis(context.Background(), redis.Config{
+			Addr:          mr.Addr(),
+			EventsChannel: "cart-events",
+		}, zap.NewNop())
+		require.NoError(t, err)
+		t.Cleanup(func() { client.Close() })
+
+		subscriber := mr.NewSubscriber()
+		t.Cleanup(subscriber.Close)
+		subscriber.Subscribe("cart-events")
+
+		handler := NewCartHandler(client, zap.NewNop(), CartHandlerConfig{})
+		router := gin.New()
+		router.PUT("/v1/cart/:user_id/items", handler.SetItems)
+		router.PUT("/v1/cart/:user_id/items/:product_id", handler.SetItemQuantity)
+		return router, mr, subscriber
+	}
+
+	// nextEvent decodes the next published event, failing the test if none arrives in time
+	nextEvent := func(t *testing.T, subscriber *miniredis.Subscriber) redis.CartEvent {
+		select {
+		case message := <-subscriber.Messages():
+			var event redis.CartEvent
+			require.NoError(t, json.Unmarshal([]byte(message.Message), &event))
+			event.Timestamp = time.Time{}
+			return event
+		case <-time.After(time.Second):
+			t.Fatal("no cart event published")
+			return redis.CartEvent{}
+		}
+	}
+
+	put := func(router *gin.Engine, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PUT", path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should publish a remove for each line PUT /items sets to 0", func(t *testing.T) {
+		router, mr, subscriber := setupEventsTest(t)
+		mr.HSet("cart:user-1", "prod-1", "2")
+		mr.HSet("cart:user-1", "prod-2", "5")
+
+		w := put(router, "/v1/cart/user-1/items",
+			`[{"product_id":"prod-1","quantity":0},{"product_id":"prod-2","quantity":3},{"product_id":"prod-3","quantity":0}]`)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, redis.CartEvent{UserID: "user-1", ProductID: "prod-1", Action: redis.EventActionRemove, Quantity: 2},
+			nextEvent(t, subscriber))
+		// prod-3 was not in the cart, so nothing else was removed
+		select {
+		case message := <-subscriber.Messages():
+			t.Fatalf("unexpected cart event %s", message.Message)
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+
+	t.Run("should publish a remove when PUT /items/:product_id sets 0 without If-Match", func(t *testing.T) {
+		router, mr, subscriber := setupEventsTest(t)
+		mr.HSet("cart:user-1", "prod-1", "4")
+
+		w := put(router, "/v1/cart/user-1/items/prod-1", `{"quantity":0}`)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, redis.CartEvent{UserID: "user-1", ProductID: "prod-1", Action: redis.EventActionRemove, Quantity: 4},
+			nextEvent(t, subscriber))
+	})
+}
+
+func TestMergeCart(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("should sum quantities into the destination and delete the source", func(t *testing.T) {
+		handler, mr, cleanup := setupTest(t)
+		defer cleanup()
+
+		mr.HSet("cart:guest-1", "prod-1", "2", "prod-2", "1")
+		mr.HSet("cart:user-1", "prod-1", "3")
+
+		router := gin.New()
+		router.POST("/v1/cart/:user_id/merge", handler.MergeCart)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/v1/cart/user-1/merge", bytes.NewBufferString(`{"from_user_id":"guest-1"}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response CartResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+
+		quantities := make(map[string]int)
+		for _, item := range response.Items {
+			quantities[item.ProductID] = item.Quantity
+		}
+
+		assert.Equal(t, "user-1", response.UserID)
+		assert.Equal(t, map[string]int{"prod-1": 5, "prod-2": 1}, quantities)
+		assert.False(t, mr.Exists("cart:guest-1"), "source cart should be deleted")
+	})
+
+	t.Run("should be a no-op when the source cart is empty", func(t *testing.T) {
+		handler, mr, cleanup := setupTest(t)
+		defer cleanup()
+
+		mr.HSet("cart:user-1", "prod-1", "3")
+
+		router := gin.New()
+		router.POST("/v1/cart/:user_id/merge", handler.MergeCart)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/v1/cart/user-1/merge", bytes.NewBufferString(`{"from_user_id":"guest-1"}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response CartResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+		require.Len(t, response.Items, 1)
+		assert.Equal(t, 3, response.Items[0].Quantity)
+	})
+
+	t.Run("should reject invalid requests", func(t *testing.T) {
+		handler, _, cleanup := setupTest(t)
+		defer cleanup()
+
+		router := gin.New()
+		router.POST("/v1/cart/:user_id/merge", handler.MergeCart)
+
+		for _, body := range []string{`{}`, `{"from_user_id":"user-1"}`} {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/v1/cart/user-1/merge", bytes.NewBufferString(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, body)
+		}
+	})
+}
+
+func TestTransferItem(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("should move the quantity and delete emptied lines", func(t *testing.T) {
+		handler, mr, cleanup := setupTest(t)
+		defer cleanup()
+
+		mr.HSet("cart:user-1", "prod-1", "3", "prod-2", "1")
+		mr.HSet("cart:saved-1", "prod-1", "1")
+
+		router := gin.New()
+		router.POST("/v1/cart/:user_id/transfer", handler.TransferItem)
+
+		for _, body := range []string{
+			`{"to_user_id":"saved-1","product_id":"prod-1","quantity":2}`,
+			`{"to_user_id":"saved-1","product_id":"prod-2","quantity":1}`,
+		} {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/v1/cart/user-1/transfer", bytes.NewBufferString(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code, body)
+		}
+
+		assert.Equal(t, "1", mr.HGet("cart:user-1", "prod-1"))
+		assert.Empty(t, mr.HGet("cart:user-1", "prod-2"), "emptied line should be removed")
+		assert.Equal(t, "3", mr.HGet("cart:saved-1", "prod-1"))
+		assert.Equal(t, "1", mr.HGet("cart:saved-1", "prod-2"))
+	})
+
+	t.Run("should reject a transfer larger than the source quantity", func(t *testing.T) {
+		handler, mr, cleanup := setupTest(t)
+		defer cleanup()
+
+		mr.HSet("cart:user-1", "prod-1", "2")
+
+		router := gin.New()
+		router.POST("/v1/cart/:user_id/transfer", handler.TransferItem)
+
+		for _, body := range []string{
+			`{"to_user_id":"saved-1","product_id":"prod-1","quantity":3}`,
+			`{"to_user_id":"saved-1","product_id":"prod-9","quantity":1}`,
+		} {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/v1/cart/user-1/transfer", bytes.NewBufferString(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusConflict, w.Code, body)
+		}
+
+		// Verify nothing was moved
+		assert.Equal(t, "2", mr.HGet("cart:user-1", "prod-1"))
+		assert.False(t, mr.Exists("cart:saved-1"))
+	})
+
+	t.Run("should reject invalid requests", func(t *testing.T) {
+		handler, _, cleanup := setupTest(t)
+		defer cleanup()
+
+		router := gin.New()
+		router.POST("/v1/cart/:user_id/transfer", handler.TransferItem)
+
+		for _, body := range []string{
+			`{"product_id":"prod-1","quantity":1}`,
+			`{"to_user_id":"saved-1","product_id":"prod-1","quantity":0}`,
+			`{"to_user_id":"user-1","product_id":"prod-1","quantity":1}`,
+		} {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/v1/cart/user-1/transfer", bytes.NewBufferString(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, body)
+		}
+	})
+}
+
+func TestValidationErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	type errorResponse struct {
+		Code    string       `json:"code"`
+		Message string       `json:"message"`
+		Details []FieldError `json:"details"`
+	}
+
+	t.Run("should report field-level errors for AddItem", func(t *testing.T) {
+		handler, _, cleanup := setupTest(t)
+		defer cleanup()
+
+		router := gin.New()
+		router.POST("/v1/cart/:user_id", handler.AddItem)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/v1/cart/user-1", bytes.NewBufferString(`{"quantity":-1}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.NotContains(t, w.Body.String(), "AddItemRequest")
+
+		var response errorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, apierror.CodeInvalidRequest, response.Code)
+		assert.Equal(t, "Invalid request body", response.Message)
+		assert.ElementsMatch(t, []FieldError{
+			{Field: "product_id", Message: "is required"},
+			{Field: "quantity", Message: "must be at least 1"},
+		}, response.Details)
+	})
+
+	t.Run("should report type errors for AddItem", func(t *testing.T) {
+		handler, _, cleanup := setupTest(t)
+		defer cleanup()
+
+		router := gin.New()
+		router.POST("/v1/cart/:user_id", handler.AddItem)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/v1/cart/user-1", bytes.NewBufferString(`{"product_id":"prod-1","quantity":"two"}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var response errorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, CodeInvalidQuantity, response.Code, "Only the quantity is wrong")
+		assert.Equal(t, []FieldError{{Field: "quantity", Message: "must be of type int"}}, response.Details)
+	})
+
+	t.Run("should report indexed errors for SetItems", func(t *testing.T) {
+		handler, _, cleanup := setupTest(t)
+		defer cleanup()
+
+		router := gin.New()
+		router.PUT("/v1/cart/:user_id/items", handler.SetItems)
+
+		body := `[{"product_id":"prod-1","quantity":1},{"quantity":-2}]`
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PUT", "/v1/cart/user-1/items", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var response errorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.ElementsMatch(t, []FieldError{
+			{Field: "[1].product_id", Message: "is required"},
+			{Field: "[1].quantity", Message: "must be at least 0"},
+		}, response.Details)
+	})
+
+	t.Run("should report malformed JSON", func(t *testing.T) {
+		handler, _, cleanup := setupTest(t)
+		defer cleanup()
+
+		router := gin.New()
+		router.POST("/v1/cart/:user_id", handler.AddItem)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/v1/cart/user-1", bytes.NewBufferString(`{"product_id":`))
+		req.Header.Set("Content-Type", "application/json")
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var response errorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, apierror.CodeInvalidRequest, response.Code)
+		assert.Equal(t, []FieldError{{Field: "body", Message: "must be valid JSON"}}, response.Details)
+	})
+}
+
+func TestProductIDNumericValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		numeric        bool
+		productID      string
+		expectedStatus int
+	}{
+		{"numeric id allowed when disabled", false, "42", http.StatusOK},
+		{"opaque id allowed when disabled", false, "prod-123", http.StatusOK},
+		{"numeric id allowed when enabled", true, "42", http.StatusOK},
+		{"opaque id rejected when enabled", true, "prod-123", http.StatusBadRequest},
+		{"zero rejected when enabled", true, "0", http.StatusBadRequest},
+		{"negative rejected when enabled", true, "-7", http.StatusBadRequest},
+		{"leading zeros rejected when enabled", true, "042", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run("AddItem "+tt.name, func(t *testing.T) {
+			handler, _, cleanup := setupTest(t)
+			defer cleanup()
+			handler.config.ProductIDNumeric = tt.numeric
+
+			router := gin.New()
+			router.POST("/v1/cart/:user_id", handler.AddItem)
+
+			body, _ := json.Marshal(AddItemRequest{ProductID: tt.productID, Quantity: 1})
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/v1/cart/user-1", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusBadRequest {
+				assert.Contains(t, w.Body.String(), `"field":"product_id"`)
+			}
+		})
+
+		t.Run("SetItems "+tt.name, func(t *testing.T) {
+			handler, _, cleanup := setupTest(t)
+			defer cleanup()
+			handler.config.ProductIDNumeric = tt.numeric
+
+			router := gin.New()
+			router.PUT("/v1/cart/:user_id/items", handler.SetItems)
+
+			body, _ := json.Marshal([]map[string]any{{"product_id": tt.productID, "quantity": 1}})
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("PUT", "/v1/cart/user-1/items", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusBadRequest {
+				assert.Contains(t, w.Body.String(), `"field":"[0].product_id"`)
+			}
+		})
+
+		t.Run("TransferItem "+tt.name, func(t *testing.T) {
+			handler, mr, cleanup := setupTest(t)
+			defer cleanup()
+			handler.config.ProductIDNumeric = tt.numeric
+
+			mr.HSet("cart:user-1", tt.productID, "1")
+
+			router := gin.New()
+			router.POST("/v1/cart/:user_id/transfer", handler.TransferItem)
+
+			body, _ := json.Marshal(map[string]any{"to_user_id": "user-2", "product_id": tt.productID, "quantity": 1})
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/v1/cart/user-1/transfer", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusBadRequest {
+				assert.Contains(t, w.Body.String(), `"field":"product_id"`)
+				assert.Equal(t, "1", mr.HGet("cart:user-1", tt.productID), "nothing should be moved")
+			}
+		})
+	}
+}
+
+func TestIDValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tooLong := strings.Repeat("a", maxIDLength+1)
+
+	tests := []struct {
+		name           string
+		id             string
+		expectedStatus int
+	}{
+		{"letters digits underscore and dash", "User_1-a", http.StatusOK},
+		{"max length", strings.Repeat("a", maxIDLength), http.StatusOK},
+		{"too long", tooLong, http.StatusBadRequest},
+		{"colon", "user:1", http.StatusBadRequest},
+		{"space", "user 1", http.StatusBadRequest},
+		{"asterisk", "user*", http.StatusBadRequest},
+		{"non-ascii", "usér", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run("user_id "+tt.name, func(t *testing.T) {
+			handler, _, cleanup := setupTest(t)
+			defer cleanup()
+
+			router := gin.New()
+			router.GET("/v1/cart/:user_id", handler.GetCart)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/v1/cart/"+url.PathEscape(tt.id), nil)
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusBadRequest {
+				assert.Contains(t, w.Body.String(), CodeInvalidUserID)
+			}
+		})
+
+		t.Run("product_id body "+tt.name, func(t *testing.T) {
+			handler, _, cleanup := setupTest(t)
+			defer cleanup()
+
+			router := gin.New()
+			router.POST("/v1/cart/:user_id", handler.AddItem)
+
+			body, _ := json.Marshal(AddItemRequest{ProductID: tt.id, Quantity: 1})
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/v1/cart/user-1", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusBadRequest {
+				assert.Contains(t, w.Body.String(), `"field":"product_id"`)
+				assert.Contains(t, w.Body.String(), "letters, digits")
+			}
+		})
+
+		t.Run("product_id path "+tt.name, func(t *testing.T) {
+			handler, _, cleanup := setupTest(t)
+			defer cleanup()
+
+			router := gin.New()
+			router.PUT("/v1/cart/:user_id/items/:product_id", handler.SetItemQuantity)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("PUT", "/v1/cart/user-1/items/"+url.PathEscape(tt.id), bytes.NewBufferString(`{"quantity":1}`))
+			req.Header.Set("Content-Type", "application/json")
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+
+	t.Run("merge from_user_id", func(t *testing.T) {
+		handler, _, cleanup := setupTest(t)
+		defer cleanup()
+
+		router := gin.New()
+		router.POST("/v1/cart/:user_id/merge", handler.MergeCart)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/v1/cart/user-1/merge", bytes.NewBufferString(`{"from_user_id":"guest:1"}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"field":"from_user_id"`)
+	})
+}
+
+func TestMaxItemQuantity(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	addItem := func(router *gin.Engine, quantity int) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(AddItemRequest{ProductID: "prod-1", Quantity: quantity})
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/v1/cart/user-1", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should reject a single request above the cap", func(t *testing.T) {
+		handler, mr, cleanup := setupTest(t)
+		defer cleanup()
+		handler.config.MaxItemQuantity = 10
+
+		router := gin.New()
+		router.POST("/v1/cart/:user_id", handler.AddItem)
+
+		w := addItem(router, 11)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), CodeInvalidQuantity)
+		assert.Contains(t, w.Body.String(), "must be at most 10")
+		assert.False(t, mr.Exists("cart:user-1"))
+
+		assert.Equal(t, http.StatusOK, addItem(router, 10).Code)
+	})
+
+	t.Run("should reject repeated adds past the cap", func(t *testing.T) {
+		handler, mr, cleanup := setupTest(t)
+		defer cleanup()
+		handler.config.MaxItemQuantity = 10
+
+		router := gin.New()
+		router.POST("/v1/cart/:user_id", handler.AddItem)
+
+		require.Equal(t, http.StatusOK, addItem(router, 6).Code)
+		require.Equal(t, http.StatusOK, addItem(router, 4).Code)
+
+		w := addItem(router, 1)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		var body apierror.APIError
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, CodeInvalidQuantity, body.Code)
+		assert.Contains(t, body.Message, "maximum of 10")
+		assert.Equal(t, map[string]interface{}{"max_quantity": float64(10)}, body.Details)
+		assert.Equal(t, "10", mr.HGet("cart:user-1", "prod-1"))
+	})
+
+	t.Run("should reject set quantities above the cap", func(t *testing.T) {
+		handler, _, cleanup := setupTest(t)
+		defer cleanup()
+		handler.config.MaxItemQuantity = 10
+
+		router := gin.New()
+		router.PUT("/v1/cart/:user_id/items/:product_id", handler.SetItemQuantity)
+		router.PUT("/v1/cart/:user_id/items", handler.SetItems)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PUT", "/v1/cart/user-1/items/prod-1", bytes.NewBufferString(`{"quantity":11}`))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"field":"quantity"`)
+
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("PUT", "/v1/cart/user-1/items", bytes.NewBufferString(`[{"product_id":"prod-1","quantity":2},{"product_id":"prod-2","quantity":11}]`))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"field":"[1].quantity"`)
+	})
+}
+
+func TestCartLimitsOnSetMergeTransfer(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	setup := func(t *testing.T) (*gin.Engine, *miniredis.Miniredis) {
+		handler, mr, cleanup := setupTest(t)
+		t.Cleanup(cleanup)
+		handler.config.MaxCartItems = 2
+		handler.config.MaxItemQuantity = 10
+
+		router := gin.New()
+		router.PUT("/v1/cart/:user_id/items", handler.SetItems)
+		router.PUT("/v1/cart/:user_id/items/:product_id", handler.SetItemQuantity)
+		router.POST("/v1/cart/:user_id/merge", handler.MergeCart)
+		router.POST("/v1/cart/:user_id/transfer", handler.TransferItem)
+
+		mr.HSet("cart:user-1", "prod-1", "5", "prod-2", "1")
+		return router, mr
+	}
+
+	send := func(router *gin.Engine, method, path, body, ifMatch string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		if ifMatch != "" {
+			req.Header.Set(IfMatchHeader, ifMatch)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assertCode := func(t *testing.T, w *httptest.ResponseRecorder, status int, code string) {
+		t.Helper()
+		require.Equal(t, status, w.Code, w.Body.String())
+		var apiErr apierror.APIError
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &apiErr))
+		assert.Equal(t, code, apiErr.Code)
+	}
+
+	t.Run("should reject PUT /items adding a product to a full cart", func(t *testing.T) {
+		router, mr := setup(t)
+
+		w := send(router, "PUT", "/v1/cart/user-1/items", `[{"product_id":"prod-3","quantity":1}]`, "")
+
+		assertCode(t, w, http.StatusConflict, CodeCartFull)
+		assert.Empty(t, mr.HGet("cart:user-1", "prod-3"), "The rejected product must not be written")
+
+		// Replacing one product with another keeps the cart at the limit
+		w = send(router, "PUT", "/v1/cart/user-1/items", `[{"product_id":"prod-2","quantity":0},{"product_id":"prod-3","quantity":1}]`, "")
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("should reject PUT /items/:product_id adding a product to a full cart", func(t *testing.T) {
+		router, mr := setup(t)
+
+		assertCode(t, send(router, "PUT", "/v1/cart/user-1/items/prod-3", `{"quantity":1}`, ""), http.StatusConflict, CodeCartFull)
+		assertCode(t, send(router, "PUT", "/v1/cart/user-1/items/prod-3", `{"quantity":1}`, "0"), http.StatusConflict, CodeCartFull)
+		assert.Empty(t, mr.HGet("cart:user-1", "prod-3"))
+
+		// Existing products can still be changed in a full cart
+		assert.Equal(t, http.StatusOK, send(router, "PUT", "/v1/cart/user-1/items/prod-1", `{"quantity":7}`, "5").Code)
+	})
+
+	t.Run("should reject merges that break either limit and keep the source cart", func(t *testing.T) {
+		router, mr := setup(t)
+		mr.HSet("cart:guest-1", "prod-1", "6")
+		mr.HSet("cart:guest-2", "prod-3", "1")
+
+		w := send(router, "POST", "/v1/cart/user-1/merge", `{"from_user_id":"guest-1"}`, "")
+		assertCode(t, w, http.StatusBadRequest, CodeInvalidQuantity)
+		assertCode(t, send(router, "POST", "/v1/cart/user-1/merge", `{"from_user_id":"guest-2"}`, ""), http.StatusConflict, CodeCartFull)
+
+		assert.Equal(t, "5", mr.HGet("cart:user-1", "prod-1"))
+		assert.Empty(t, mr.HGet("cart:user-1", "prod-3"))
+		assert.True(t, mr.Exists("cart:guest-1"))
+		assert.True(t, mr.Exists("cart:guest-2"))
+	})
+
+	t.Run("should reject transfers that break either limit of the destination", func(t *testing.T) {
+		router, mr := setup(t)
+		mr.HSet("cart:guest-1", "prod-1", "6", "prod-3", "1")
+
+		w := send(router, "POST", "/v1/cart/guest-1/transfer", `{"to_user_id":"user-1","product_id":"prod-1","quantity":6}`, "")
+		assertCode(t, w, http.StatusBadRequest, CodeInvalidQuantity)
+		assert.Contains(t, w.Body.String(), "prod-1")
+		w = send(router, "POST", "/v1/cart/guest-1/transfer", `{"to_user_id":"user-1","product_id":"prod-3","quantity":1}`, "")
+		assertCode(t, w, http.StatusConflict, CodeCartFull)
+
+		assert.Equal(t, "6", mr.HGet("cart:guest-1", "prod-1"))
+		assert.Equal(t, "1", mr.HGet("cart:guest-1", "prod-3"))
+		assert.Equal(t, "5", mr.HGet("cart:user-1", "prod-1"))
+
+		// Moving units within the limits still works
+		assert.Equal(t, http.StatusOK, send(router, "POST", "/v1/cart/guest-1/transfer", `{"to_user_id":"user-1","product_id":"prod-1","quantity":5}`, "").Code)
+		assert.Equal(t, "10", mr.HGet("cart:user-1", "prod-1"))
+	})
+}
+
+func TestCrossSlotCartMoves(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Against a cluster client, cart:user-1 and cart:guest-1 hash to different slots
+	mr := miniredis.RunT(t)
+	rdb := redisclient.NewClusterClient(&redisclient.ClusterOptions{Addrs: []string{mr.Addr()}})
+	defer rdb.Close()
+	handler := NewCartHandler(redis.NewClient(rdb, zap.NewNop()), zap.NewNop(), CartHandlerConfig{})
+
+	router := gin.New()
+	router.POST("/v1/cart/:user_id/merge", handler.MergeCart)
+	router.POST("/v1/cart/:user_id/transfer", handler.TransferItem)
+
+	mr.HSet("cart:guest-1", "prod-1", "2")
+
+	for path, body := range map[string]string{
+		"/v1/cart/user-1/merge":     `{"from_user_id":"guest-1"}`,
+		"/v1/cart/guest-1/transfer": `{"to_user_id":"user-1","product_id":"prod-1","quantity":1}`,
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotImplemented, w.Code, path)
+		assert.Contains(t, w.Body.String(), `"code":"`+CodeCrossSlot+`"`, path)
+	}
+
+	assert.Equal(t, "2", mr.HGet("cart:guest-1", "prod-1"), "nothing should be moved")
+	assert.False(t, mr.Exists("cart:user-1"))
+}
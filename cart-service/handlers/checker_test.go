@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthChecker(t *testing.T) {
+	ok := func(ctx context.Context) error { return nil }
+	failing := func(ctx context.Context) error { return errors.New("connection refused") }
+	// hangs ignores its context, like a dependency client without deadlines
+	hangs := func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}
+
+	t.Run("should report every check in registration order", func(t *testing.T) {
+		checker := NewHealthChecker(time.Second,
+			DependencyCheck{Name: "redis", Critical: true, Check: ok},
+			DependencyCheck{Name: "product-service", Check: failing},
+		)
+
+		results, healthy := checker.Run(context.Background())
+
+		assert.True(t, healthy, "A failing non-critical check should not fail the response")
+		require.Len(t, results, 2)
+		assert.Equal(t, CheckResult{Name: "redis", Status: "healthy", Critical: true, LatencyMs: results[0].LatencyMs}, results[0])
+		assert.Equal(t, "product-service", results[1].Name)
+		assert.Equal(t, "unhealthy", results[1].Status)
+		assert.Equal(t, "connection refused", results[1].Error)
+	})
+
+	t.Run("should be unhealthy when a critical check fails", func(t *testing.T) {
+		checker := NewHealthChecker(time.Second,
+			DependencyCheck{Name: "redis", Critical: true, Check: failing},
+		)
+
+		_, healthy := checker.Run(context.Background())
+		assert.False(t, healthy)
+	})
+
+	t.Run("should run checks concurrently and give up at the timeout", func(t *testing.T) {
+		checker := NewHealthChecker(50*time.Millisecond,
+			DependencyCheck{Name: "first", Critical: true, Check: hangs},
+			DependencyCheck{Name: "second", Critical: true, Check: hangs},
+		)
+
+		start := time.Now()
+		results, healthy := checker.Run(context.Background())
+
+		assert.Less(t, time.Since(start), 500*time.Millisecond)
+		assert.False(t, healthy)
+		for _, result := range results {
+			assert.Equal(t, "unhealthy", result.Status)
+			assert.Equal(t, context.DeadlineExceeded.Error(), result.Error)
+			assert.GreaterOrEqual(t, result.LatencyMs, float64(50))
+		}
+	})
+
+	t.Run("should report a slow check as degraded without failing", func(t *testing.T) {
+		slow := func(ctx context.Context) error {
+			time.Sleep(30 * time.Millisecond)
+			return nil
+		}
+		checker := NewHealthChecker(time.Second,
+			DependencyCheck{Name: "redis", Critical: true, Check: slow, MaxLatency: 10 * time.Millisecond},
+			DependencyCheck{Name: "fast", Critical: true, Check: ok, MaxLatency: 10 * time.Millisecond},
+		)
+
+		results, healthy := checker.Run(context.Background())
+
+		assert.True(t, healthy, "A degraded check must not fail the response")
+		assert.Equal(t, "degraded", results[0].Status)
+		assert.Contains(t, results[0].Warning, "above the 10ms threshold")
+		assert.Empty(t, results[0].Error)
+		assert.Equal(t, "healthy", results[1].Status)
+		assert.Empty(t, results[1].Warning)
+	})
+
+	t.Run("should run registered checks after the initial ones", func(t *testing.T) {
+		checker := NewHealthChecker(time.Second, DependencyCheck{Name: "redis", Critical: true, Check: ok})
+		checker.Register(DependencyCheck{Name: "product-service", Check: failing})
+
+		results, healthy := checker.Run(context.Background())
+
+		assert.True(t, healthy)
+		require.Len(t, results, 2)
+		assert.Equal(t, "product-service", results[1].Name)
+		assert.False(t, results[1].Critical)
+	})
+
+	t.Run("should give up on a check at its own timeout", func(t *testing.T) {
+		checker := NewHealthChecker(time.Second,
+			DependencyCheck{Name: "redis", Critical: true, Check: ok},
+			DependencyCheck{Name: "product-service", Check: hangs, Timeout: 20 * time.Millisecond},
+		)
+
+		start := time.Now()
+		results, healthy := checker.Run(context.Background())
+
+		assert.Less(t, time.Since(start), 500*time.Millisecond, "The check timeout should end the run before the checker timeout")
+		assert.True(t, healthy)
+		assert.Equal(t, "healthy", results[0].Status)
+		assert.Equal(t, "unhealthy", results[1].Status)
+		assert.Equal(t, context.DeadlineExceeded.Error(), results[1].Error)
+	})
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"cart-service/internal/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Error codes returned by the cart handlers in apierror.APIError.Code
+// Clients branch on these, so an existing code must never be renamed
+const (
+	CodeInvalidUserID             = "CART_INVALID_USER_ID"
+	CodeInvalidQuantity           = "CART_INVALID_QUANTITY"
+	CodeInvalidIdempotencyKey     = "CART_INVALID_IDEMPOTENCY_KEY"
+	CodeCartEmpty                 = "CART_EMPTY"
+	CodeSameUser                  = "CART_SAME_USER"
+	CodeQuantityChanged           = "CART_QUANTITY_CHANGED"
+	CodeInsufficientQuantity      = "CART_INSUFFICIENT_QUANTITY"
+	CodeCartFull                  = "CART_FULL"
+	CodeCrossSlot                 = "CART_CROSS_SLOT"
+	CodeDuplicateReservation      = "CART_DUPLICATE_RESERVATION"
+	CodeUnsupportedFormat         = "CART_UNSUPPORTED_FORMAT"
+	CodeUnsupportedProvider       = "CART_UNSUPPORTED_PROVIDER"
+	CodeProductNotFound           = "PRODUCT_NOT_FOUND"
+	CodeInsufficientStock         = "PRODUCT_INSUFFICIENT_STOCK"
+	CodeProductServiceUnavailable = "PRODUCT_SERVICE_UNAVAILABLE"
+	CodeRedisUnavailable          = "REDIS_UNAVAILABLE"
+)
+
+// respondInvalidBody writes a 400 listing the invalid fields in Details
+// The code is CART_INVALID_QUANTITY when only quantities are wrong, so clients can prompt for a new one
+func respondInvalidBody(c *gin.Context, fieldErrs []FieldError) {
+	code := CodeInvalidQuantity
+	for _, fe := range fieldErrs {
+		if fe.Field != "quantity" && !strings.HasSuffix(fe.Field, "].quantity") {
+			code = apierror.CodeInvalidRequest
+			break
+		}
+	}
+	apierror.RespondErrorDetails(c, http.StatusBadRequest, code, "Invalid request body", fieldErrs)
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"cart-service/internal/apierror"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+)
+
+// exportFormats is the whitelist of supported ?format= values
+var exportFormats = []string{"json", "csv"}
+
+// ExportCart handles GET /v1/cart/:user_id/export
+// Returns the cart as a downloadable file, e.g. for support staff looking into a customer's cart
+// Query parameters:
+// - format: Export format (default: json; supported: json, csv)
+// The CSV export has a product_id,quantity header row followed by one row per item
+func (h *CartHandler) ExportCart(c *gin.Context) {
+	ctx := c.Request.Context()
+	tracer := otel.Tracer("cart-service")
+	ctx, span := tracer.Start(ctx, "handler.ExportCart")
+	defer span.End()
+
+	userID := c.Param("user_id")
+	if !validateID(userID) {
+		span.SetStatus(codes.Error, "Invalid user_id")
+		apierror.RespondError(c, http.StatusBadRequest, CodeInvalidUserID, "user_id "+idRuleMessage)
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		span.SetStatus(codes.Error, "Unsupported format")
+		apierror.RespondErrorDetails(c, http.StatusBadRequest, CodeUnsupportedFormat, "Unsupported format", gin.H{
+			"supported": exportFormats,
+		})
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("user_id", userID),
+		attribute.String("format", format),
+	)
+
+	items, err := h.redisClient.GetCart(ctx, userID)
+	if err != nil {
+		span.SetStatus(codes.Error, "Failed to get cart")
+		span.RecordError(err)
+		h.logger.Error("Failed to get cart",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		apierror.RespondError(c, http.StatusInternalServerError, CodeRedisUnavailable, "Failed to retrieve cart")
+		return
+	}
+
+	// Stable order so repeated exports of the same cart are identical
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].ProductID < items[j].ProductID
+	})
+
+	span.SetAttributes(attribute.Int("total_items", len(items)))
+	h.setCartAttributes(span, items)
+
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+		"filename": "cart-" + userID + "." + format,
+	}))
+
+	if format == "json" {
+		span.SetStatus(codes.Ok, "Cart exported successfully")
+		c.JSON(http.StatusOK, newCartResponse(ctx, userID, items))
+		return
+	}
+
+	// Stream rows straight to the response instead of building the file in memory
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	_ = w.Write([]string{"product_id", "quantity"})
+	for _, item := range items {
+		_ = w.Write([]string{item.ProductID, strconv.Itoa(item.Quantity)})
+	}
+	w.Flush()
+
+	// Headers are already sent, so a write failure can only be recorded
+	if err := w.Error(); err != nil {
+		span.SetStatus(codes.Error, "Failed to write CSV")
+		span.RecordError(err)
+		h.logger.Warn("Failed to write cart export",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		return
+	}
+
+	span.SetStatus(codes.Ok, "Cart exported successfully")
+}
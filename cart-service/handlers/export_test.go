@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportCart(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("should export CSV with a header row and one line per item", func(t *testing.T) {
+		handler, mr, cleanup := setupTest(t)
+		defer cleanup()
+
+		mr.HSet("cart:user-1", "prod-2", "1", "prod-1", "3")
+
+		router := gin.New()
+		router.GET("/v1/cart/:user_id/export", handler.ExportCart)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/v1/cart/user-1/export?format=csv", nil)
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename=cart-user-1.csv`, w.Header().Get("Content-Disposition"))
+
+		records, err := csv.NewReader(w.Body).ReadAll()
+		require.NoError(t, err)
+		assert.Equal(t, [][]string{
+			{"product_id", "quantity"},
+			{"prod-1", "3"},
+			{"prod-2", "1"},
+		}, records)
+	})
+
+	t.Run("should default to JSON", func(t *testing.T) {
+		handler, mr, cleanup := setupTest(t)
+		defer cleanup()
+
+		mr.HSet("cart:user-1", "prod-1", "3")
+
+		router := gin.New()
+		router.GET("/v1/cart/:user_id/export", handler.ExportCart)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/v1/cart/user-1/export", nil)
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+
+		var response CartResponse
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		require.NoError(t, err)
+		assert.Equal(t, []CartItem{{ProductID: "prod-1", Quantity: 3}}, response.Items)
+	})
+
+	t.Run("should reject unsupported formats", func(t *testing.T) {
+		handler, _, cleanup := setupTest(t)
+		defer cleanup()
+
+		router := gin.New()
+		router.GET("/v1/cart/:user_id/export", handler.ExportCart)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/v1/cart/user-1/export?format=xml", nil)
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"cart-service/redis"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// Defaults for FallbackConfig fields left at zero
+const (
+	defaultFallbackMaxCarts = 1000
+	defaultFallbackTTL      = 15 * time.Minute
+)
+
+// FallbackConfig bounds the in-memory store used while Redis is unavailable
+type FallbackConfig struct {
+	// MaxCarts caps the carts held in memory; the cart closest to expiry is evicted first (default: 1000)
+	MaxCarts int
+	// TTL is how long a cart survives in memory after its last write (default: 15 minutes)
+	TTL time.Duration
+}
+
+// FallbackStore serves cart operations from a local, bounded map when Redis errors out
+// Every call goes to Redis first, so once Redis recovers new reads and writes go back to it
+// Carts written to memory during an outage are per pod and are not copied back to Redis
+type FallbackStore struct {
+	primary CartStore
+	local   *memoryCartStore
+	logger  *zap.Logger
+}
+
+// NewFallbackStore wraps primary with an in-memory fallback
+func NewFallbackStore(primary CartStore, logger *zap.Logger, config FallbackConfig) *FallbackStore {
+	if config.MaxCarts <= 0 {
+		config.MaxCarts = defaultFallbackMaxCarts
+	}
+	if config.TTL <= 0 {
+		config.TTL = defaultFallbackTTL
+	}
+	return &FallbackStore{
+		primary: primary,
+		local:   newMemoryCartStore(config.MaxCarts, config.TTL),
+		logger:  logger,
+	}
+}
+
+// degradedKey is the context key of the per-request degraded flag
+type degradedKey struct{}
+
+// TrackDegraded installs a per-request flag that FallbackStore sets when it answers from memory
+// Cart responses built from that request carry "degraded": true
+func TrackDegraded() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.WithValue(c.Request.Context(), degradedKey{}, new(atomic.Bool))
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// markDegraded flags the request as served from the fallback store
+// It is a no-op for requests that did not pass through TrackDegraded
+func markDegraded(ctx context.Context) {
+	if flag, ok := ctx.Value(degradedKey{}).(*atomic.Bool); ok {
+		flag.Store(true)
+	}
+}
+
+// isDegraded reports whether any store call of the request was served from the fallback store
+func isDegraded(ctx context.Context) bool {
+	flag, ok := ctx.Value(degradedKey{}).(*atomic.Bool)
+	return ok && flag.Load()
+}
+
+// fallBack reports whether err means Redis is unavailable and the call should use memory
+// Only outages count (see redis.IsUnavailable): network failures, timeouts, and LOADING or
+// CLUSTERDOWN-style replies. Business errors (full cart, quantity limit, insufficient quantity),
+// invalid quantities, carts in different cluster slots and logical Redis errors such as WRONGTYPE
+// or a failing script are real answers and are returned as is, and a cancelled request is not
+// retried anywhere
+// Rejected credentials are not an outage either: serving from memory would hide the
+// misconfiguration behind carts that live on one pod and vanish, so the auth error is returned
+func (s *FallbackStore) fallBack(ctx context.Context, operation, userID string, err error) bool {
+	if err == nil || ctx.Err() != nil || !redis.IsUnavailable(err) {
+		return false
+	}
+
+	markDegraded(ctx)
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Bool("cart.degraded", true))
+	s.logger.Warn("Redis unavailable, serving cart from memory",
+		zap.String("operation", operation),
+		zap.String("user_id", userID),
+		zap.Error(err),
+	)
+	return true
+}
+
+// AddItem adds to the Redis cart, or to the in-memory cart when Redis is unavailable
+func (s *FallbackStore) AddItem(ctx context.Context, userID, productID string, quantity int) error {
+	err := s.primary.AddItem(ctx, userID, productID, quantity)
+	if !s.fallBack(ctx, "AddItem", userID, err) {
+		return err
+	}
+	return s.local.AddItem(ctx, userID, productID, quantity)
+}
+
+// AddItemWithLimit adds to the Redis cart, or to the in-memory cart when Redis is unavailable
+func (s *FallbackStore) AddItemWithLimit(ctx context.Context, userID, productID string, quantity, maxItems, maxQuantity int) error {
+	err := s.primary.AddItemWithLimit(ctx, userID, productID, quantity, maxItems, maxQuantity)
+	if !s.fallBack(ctx, "AddItemWithLimit", userID, err) {
+		return err
+	}
+	return s.local.AddItemWithLimit(ctx, userID, productID, quantity, maxItems, maxQuantity)
+}
+
+// GetCart reads the Redis cart, or the in-memory cart when Redis is unavailable
+func (s *FallbackStore) GetCart(ctx context.Context, userID string) ([]redis.CartItem, error) {
+	items, err := s.primary.GetCart(ctx, userID)
+	if !s.fallBack(ctx, "GetCart", userID, err) {
+		return items, err
+	}
+	return s.local.GetCart(ctx, userID)
+}
+
+// SetItems writes to the Redis cart, or to the in-memory cart when Redis is unavailable
+func (s *FallbackStore) SetItems(ctx context.Context, userID string, items []redis.CartItem, maxItems, maxQuantity int) error {
+	err := s.primary.SetItems(ctx, userID, items, maxItems, maxQuantity)
+	if !s.fallBack(ctx, "SetItems", userID, err) {
+		return err
+	}
+	return s.local.SetItems(ctx, userID, items, maxItems, maxQuantity)
+}
+
+// ClearCart clears the in-memory cart and, when reachable, the Redis cart
+// The local copy is always dropped so a cart cleared during an outage does not reappear
+func (s *FallbackStore) ClearCart(ctx context.Context, userID string) error {
+	_ = s.local.ClearCart(ctx, userID)
+	err := s.primary.ClearCart(ctx, userID)
+	if !s.fallBack(ctx, "ClearCart", userID, err) {
+		return err
+	}
+	return nil
+}
+
+// GetCartMeta reads the Redis metadata, or the in-memory metadata when Redis is unavailable
+func (s *FallbackStore) GetCartMeta(ctx context.Context, userID string) (redis.CartMeta, error) {
+	meta, err := s.primary.GetCartMeta(ctx, userID)
+	if !s.fallBack(ctx, "GetCartMeta", userID, err) {
+		return meta, err
+	}
+	return s.local.GetCartMeta(ctx, userID)
+}
+
+// SetCartCurrency writes to Redis, or to the in-memory cart when Redis is unavailable
+func (s *FallbackStore) SetCartCurrency(ctx context.Context, userID, currency string) error {
+	err := s.primary.SetCartCurrency(ctx, userID, currency)
+	if !s.fallBack(ctx, "SetCartCurrency", userID, err) {
+		return err
+	}
+	return s.local.SetCartCurrency(ctx, userID, currency)
+}
+
+// MergeCart merges in Redis, or in memory when Redis is unavailable
+func (s *FallbackStore) MergeCart(ctx context.Context, fromUserID, toUserID string, maxItems, maxQuantity int) error {
+	err := s.primary.MergeCart(ctx, fromUserID, toUserID, maxItems, maxQuantity)
+	if !s.fallBack(ctx, "MergeCart", toUserID, err) {
+		return err
+	}
+	return s.local.MergeCart(ctx, fromUserID, toUserID, maxItems, maxQuantity)
+}
+
+// TransferItem transfers in Redis, or in memory when Redis is unavailable
+func (s *FallbackStore) TransferItem(ctx context.Context, fromUserID, toUserID, productID string, quantity, maxItems, maxQuantity int) error {
+	err := s.primary.TransferItem(ctx, fromUserID, toUserID, productID, quantity, maxItems, maxQuantity)
+	if !s.fallBack(ctx, "TransferItem", toUserID, err) {
+		return err
+	}
+	return s.local.TransferItem(ctx, fromUserID, toUserID, productID, quantity, maxItems, maxQuantity)
+}
+
+// SetItemQuantityIfMatch runs the compare-and-set in Redis, or in memory when Redis is unavailable
+func (s *FallbackStore) SetItemQuantityIfMatch(ctx context.Context, userID, productID string, expected, newQty, maxItems, maxQuantity int) (bool, error) {
+	matched, err := s.primary.SetItemQuantityIfMatch(ctx, userID, productID, expected, newQty, maxItems, maxQuantity)
+	if !s.fallBack(ctx, "SetItemQuantityIfMatch", userID, err) {
+		return matched, err
+	}
+	return s.local.SetItemQuantityIfMatch(ctx, userID, productID, expected, newQty, maxItems, maxQuantity)
+}
+
+// ClaimIdempotencyKey claims the key in Redis, or in memory when Redis is unavailable
+func (s *FallbackStore) ClaimIdempotencyKey(ctx context.Context, userID, requestKey string, ttl time.Duration) (bool, error) {
+	claimed, err := s.primary.ClaimIdempotencyKey(ctx, userID, requestKey, ttl)
+	if !s.fallBack(ctx, "ClaimIdempotencyKey", userID, err) {
+		return claimed, err
+	}
+	return s.local.ClaimIdempotencyKey(ctx, userID, requestKey, ttl)
+}
+
+// ReleaseIdempotencyKey releases the key in memory and, when reachable, in Redis
+func (s *FallbackStore) ReleaseIdempotencyKey(ctx context.Context, userID, requestKey string) error {
+	_ = s.local.ReleaseIdempotencyKey(ctx, userID, requestKey)
+	err := s.primary.ReleaseIdempotencyKey(ctx, userID, requestKey)
+	if !s.fallBack(ctx, "ReleaseIdempotencyKey", userID, err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,351 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"cart-service/redis"
+)
+
+// memoryCart is one cart held by memoryCartStore
+type memoryCart struct {
+	items map[string]int
+	meta  redis.CartMeta
+	// idempotencyKeys maps claimed Idempotency-Key values to their expiry
+	idempotencyKeys map[string]time.Time
+	expiresAt       time.Time
+}
+
+// memoryCartStore is the CartStore FallbackStore uses while Redis is unavailable
+// Each cart expires ttl after its last write and at most maxCarts carts are kept
+type memoryCartStore struct {
+	mu       sync.Mutex
+	carts    map[string]*memoryCart
+	maxCarts int
+	ttl      time.Duration
+	// now is replaced in tests to control expiry
+	now func() time.Time
+}
+
+// newMemoryCartStore creates an empty in-memory cart store
+func newMemoryCartStore(maxCarts int, ttl time.Duration) *memoryCartStore {
+	return &memoryCartStore{
+		carts:    make(map[string]*memoryCart),
+		maxCarts: maxCarts,
+		ttl:      ttl,
+		now:      time.Now,
+	}
+}
+
+// lookup returns the user's live cart, or nil; expired carts are dropped on access
+// Callers must hold s.mu
+func (s *memoryCartStore) lookup(userID string) *memoryCart {
+	cart, ok := s.carts[userID]
+	if !ok {
+		return nil
+	}
+	if !s.now().Before(cart.expiresAt) {
+		delete(s.carts, userID)
+		return nil
+	}
+	return cart
+}
+
+// write returns the user's cart for modification, creating it if needed, and extends its expiry
+// When the store is full the expired carts are dropped, then the cart closest to expiry
+// Callers must hold s.mu
+func (s *memoryCartStore) write(userID string) *memoryCart {
+	now := s.now()
+	cart := s.lookup(userID)
+	if cart == nil {
+		if len(s.carts) >= s.maxCarts {
+			s.evict(now)
+		}
+		cart = &memoryCart{
+			items:           make(map[string]int),
+			meta:            redis.CartMeta{CreatedAt: now.UTC()},
+			idempotencyKeys: make(map[string]time.Time),
+		}
+		s.carts[userID] = cart
+	}
+	cart.expiresAt = now.Add(s.ttl)
+	return cart
+}
+
+// evict makes room for one more cart
+// Callers must hold s.mu
+func (s *memoryCartStore) evict(now time.Time) {
+	oldestID := ""
+	var oldest time.Time
+	for userID, cart := range s.carts {
+		if !now.Before(cart.expiresAt) {
+			delete(s.carts, userID)
+			continue
+		}
+		if oldestID == "" || cart.expiresAt.Before(oldest) {
+			oldestID, oldest = userID, cart.expiresAt
+		}
+	}
+	if len(s.carts) >= s.maxCarts {
+		delete(s.carts, oldestID)
+	}
+}
+
+// AddItem increments a product's quantity
+func (s *memoryCartStore) AddItem(ctx context.Context, userID, productID string, quantity int) error {
+	return s.AddItemWithLimit(ctx, userID, productID, quantity, 0, 0)
+}
+
+// AddItemWithLimit increments a product's quantity unless it would exceed maxItems distinct products
+// or take the product past maxQuantity units; a limit of 0 means unlimited
+func (s *memoryCartStore) AddItemWithLimit(ctx context.Context, userID, productID string, quantity, maxItems, maxQuantity int) error {
+	if quantity <= 0 {
+		return fmt.Errorf("quantity must be positive, got %d: %w", quantity, redis.ErrInvalidQuantity)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current := 0
+	if cart := s.lookup(userID); cart != nil {
+		var exists bool
+		current, exists = cart.items[productID]
+		if !exists && maxItems > 0 && len(cart.items) >= maxItems {
+			return fmt.Errorf("cannot add product %s to cart of user %s: %w", productID, userID, redis.ErrCartFull)
+		}
+	}
+	if maxQuantity > 0 && current+quantity > maxQuantity {
+		return fmt.Errorf("cannot add %d of product %s to cart of user %s: %w", quantity, productID, userID, redis.ErrQuantityLimit)
+	}
+	s.write(userID).items[productID] += quantity
+	return nil
+}
+
+// GetCart returns the cart's items sorted by product ID
+func (s *memoryCartStore) GetCart(ctx context.Context, userID string) ([]redis.CartItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := []redis.CartItem{}
+	cart := s.lookup(userID)
+	if cart == nil {
+		return items, nil
+	}
+	for productID, quantity := range cart.items {
+		items = append(items, redis.CartItem{ProductID: productID, Quantity: quantity})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ProductID < items[j].ProductID })
+	return items, nil
+}
+
+// SetItems overwrites quantities; a quantity of 0 removes the product
+// The update is rejected when a quantity exceeds maxQuantity or it adds products to a cart that
+// would then hold more than maxItems; a limit of 0 means unlimited
+func (s *memoryCartStore) SetItems(ctx context.Context, userID string, items []redis.CartItem, maxItems, maxQuantity int) error {
+	for _, item := range items {
+		if item.Quantity < 0 {
+			return fmt.Errorf("quantity must not be negative, got %d for product %s: %w", item.Quantity, item.ProductID, redis.ErrInvalidQuantity)
+		}
+		if maxQuantity > 0 && item.Quantity > maxQuantity {
+			return fmt.Errorf("cannot set %d of product %s in cart of user %s: %w", item.Quantity, item.ProductID, userID, redis.ErrQuantityLimit)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if maxItems > 0 {
+		present := make(map[string]bool)
+		if cart := s.lookup(userID); cart != nil {
+			for productID := range cart.items {
+				present[productID] = true
+			}
+		}
+		added := false
+		for _, item := range items {
+			if item.Quantity > 0 && !present[item.ProductID] {
+				added = true
+			}
+			present[item.ProductID] = item.Quantity > 0
+		}
+		size := 0
+		for _, ok := range present {
+			if ok {
+				size++
+			}
+		}
+		if added && size > maxItems {
+			return fmt.Errorf("cannot set %d lines in cart of user %s: %w", len(items), userID, redis.ErrCartFull)
+		}
+	}
+
+	cart := s.write(userID)
+	for _, item := range items {
+		if item.Quantity == 0 {
+			delete(cart.items, item.ProductID)
+		} else {
+			cart.items[item.ProductID] = item.Quantity
+		}
+	}
+	return nil
+}
+
+// ClearCart removes the cart and its metadata
+func (s *memoryCartStore) ClearCart(ctx context.Context, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.carts, userID)
+	return nil
+}
+
+// GetCartMeta returns the cart's metadata, or a zero CartMeta for an unknown cart
+func (s *memoryCartStore) GetCartMeta(ctx context.Context, userID string) (redis.CartMeta, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cart := s.lookup(userID); cart != nil {
+		return cart.meta, nil
+	}
+	return redis.CartMeta{}, nil
+}
+
+// SetCartCurrency records the cart's currency
+func (s *memoryCartStore) SetCartCurrency(ctx context.Context, userID, currency string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.write(userID).meta.Currency = currency
+	return nil
+}
+
+// MergeCart adds the source cart's quantities to the target cart and removes the source cart
+// Nothing is merged when the target would break maxItems or maxQuantity (0 = unlimited)
+func (s *memoryCartStore) MergeCart(ctx context.Context, fromUserID, toUserID string, maxItems, maxQuantity int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	from := s.lookup(fromUserID)
+	if from == nil || len(from.items) == 0 {
+		return nil
+	}
+	if err := s.checkAddLimits(toUserID, from.items, maxItems, maxQuantity); err != nil {
+		return fmt.Errorf("cannot merge cart of user %s into cart of user %s: %w", fromUserID, toUserID, err)
+	}
+	to := s.write(toUserID)
+	for productID, quantity := range from.items {
+		to.items[productID] += quantity
+	}
+	delete(s.carts, fromUserID)
+	return nil
+}
+
+// TransferItem moves quantity of a product from one cart to another
+// Nothing is moved when the target would break maxItems or maxQuantity (0 = unlimited)
+func (s *memoryCartStore) TransferItem(ctx context.Context, fromUserID, toUserID, productID string, quantity, maxItems, maxQuantity int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	from := s.lookup(fromUserID)
+	if from == nil || from.items[productID] < quantity {
+		return redis.ErrInsufficientQuantity
+	}
+	if err := s.checkAddLimits(toUserID, map[string]int{productID: quantity}, maxItems, maxQuantity); err != nil {
+		return fmt.Errorf("cannot transfer product %s to cart of user %s: %w", productID, toUserID, err)
+	}
+
+	from = s.write(fromUserID)
+	from.items[productID] -= quantity
+	if from.items[productID] == 0 {
+		delete(from.items, productID)
+	}
+	s.write(toUserID).items[productID] += quantity
+	return nil
+}
+
+// SetItemQuantityIfMatch sets the quantity only if the current quantity equals expected
+// An item not in the cart has quantity 0; maxItems and maxQuantity apply as in SetItems
+func (s *memoryCartStore) SetItemQuantityIfMatch(ctx context.Context, userID, productID string, expected, newQty, maxItems, maxQuantity int) (bool, error) {
+	if newQty < 0 {
+		return false, fmt.Errorf("quantity must not be negative, got %d for product %s: %w", newQty, productID, redis.ErrInvalidQuantity)
+	}
+	if maxQuantity > 0 && newQty > maxQuantity {
+		return false, fmt.Errorf("cannot set %d of product %s in cart of user %s: %w", newQty, productID, userID, redis.ErrQuantityLimit)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, exists, size := 0, false, 0
+	if cart := s.lookup(userID); cart != nil {
+		current, exists = cart.items[productID]
+		size = len(cart.items)
+	}
+	if current != expected {
+		return false, nil
+	}
+	if !exists && newQty > 0 && maxItems > 0 && size >= maxItems {
+		return false, fmt.Errorf("cannot add product %s to cart of user %s: %w", productID, userID, redis.ErrCartFull)
+	}
+
+	cart := s.write(userID)
+	if newQty == 0 {
+		delete(cart.items, productID)
+	} else {
+		cart.items[productID] = newQty
+	}
+	return true, nil
+}
+
+// checkAddLimits returns ErrCartFull or ErrQuantityLimit when incrementing userID's cart by
+// items would break maxItems or maxQuantity (0 = unlimited); the caller holds s.mu
+func (s *memoryCartStore) checkAddLimits(userID string, items map[string]int, maxItems, maxQuantity int) error {
+	current := map[string]int{}
+	if cart := s.lookup(userID); cart != nil {
+		current = cart.items
+	}
+
+	added := 0
+	for productID, quantity := range items {
+		existing, exists := current[productID]
+		if !exists {
+			added++
+		}
+		if maxQuantity > 0 && existing+quantity > maxQuantity {
+			return redis.ErrQuantityLimit
+		}
+	}
+	if added > 0 && maxItems > 0 && len(current)+added > maxItems {
+		return redis.ErrCartFull
+	}
+	return nil
+}
+
+// ClaimIdempotencyKey records the key for ttl and reports whether this call claimed it
+// Keys live with the cart, so they are bounded and evicted together with it
+func (s *memoryCartStore) ClaimIdempotencyKey(ctx context.Context, userID, requestKey string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	cart := s.write(userID)
+	if expiresAt, ok := cart.idempotencyKeys[requestKey]; ok && now.Before(expiresAt) {
+		return false, nil
+	}
+	cart.idempotencyKeys[requestKey] = now.Add(ttl)
+	return true, nil
+}
+
+// ReleaseIdempotencyKey forgets a claimed key so the request can be retried
+func (s *memoryCartStore) ReleaseIdempotencyKey(ctx context.Context, userID, requestKey string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cart := s.lookup(userID); cart != nil {
+		delete(cart.idempotencyKeys, requestKey)
+	}
+	return nil
+}
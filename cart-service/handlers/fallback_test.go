@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cart-service/redis"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	redisclient "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// setupFallbackTest returns a cart handler whose store falls back to memory, and a router
+// with TrackDegraded installed like main does
+func setupFallbackTest(t *testing.T) (*gin.Engine, *FallbackStore, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	// No retries so a stopped miniredis fails fast
+	rdb := redisclient.NewClient(&redisclient.Options{Addr: mr.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { rdb.Close() })
+
+	logger := zap.NewNop()
+	store := NewFallbackStore(redis.NewClient(rdb, logger), logger, FallbackConfig{})
+	handler := NewCartHandler(store, logger, CartHandlerConfig{MaxCartItems: 2})
+
+	router := gin.New()
+	v1 := router.Group("/v1", TrackDegraded())
+	v1.POST("/cart/:user_id", handler.AddItem)
+	v1.GET("/cart/:user_id", handler.GetCart)
+	return router, store, mr
+}
+
+// doFallbackRequest sends a request and decodes the cart response
+func doFallbackRequest(t *testing.T, router *gin.Engine, method, path, body string) (int, CartResponse) {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	var response CartResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &response)
+	return w.Code, response
+}
+
+func TestFallbackStore(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("should use Redis and not flag responses while Redis is up", func(t *testing.T) {
+		router, _, mr := setupFallbackTest(t)
+
+		code, response := doFallbackRequest(t, router, "POST", "/v1/cart/user-1", `{"product_id":"prod-1","quantity":2}`)
+
+		require.Equal(t, http.StatusOK, code)
+		assert.False(t, response.Degraded)
+		assert.Equal(t, "2", mr.HGet("cart:user-1", "prod-1"))
+	})
+
+	t.Run("should serve reads and writes from memory while Redis is down", func(t *testing.T) {
+		router, _, mr := setupFallbackTest(t)
+		mr.Close()
+
+		code, response := doFallbackRequest(t, router, "POST", "/v1/cart/user-1", `{"product_id":"prod-1","quantity":2}`)
+		require.Equal(t, http.StatusOK, code)
+		assert.True(t, response.Degraded)
+
+		code, response = doFallbackRequest(t, router, "GET", "/v1/cart/user-1", "")
+		require.Equal(t, http.StatusOK, code)
+		assert.True(t, response.Degraded)
+		assert.Equal(t, []CartItem{{ProductID: "prod-1", Quantity: 2}}, response.Items)
+	})
+
+	t.Run("should go back to Redis once it recovers", func(t *testing.T) {
+		router, _, mr := setupFallbackTest(t)
+		mr.Close()
+
+		code, _ := doFallbackRequest(t, router, "POST", "/v1/cart/user-1", `{"product_id":"prod-1","quantity":1}`)
+		require.Equal(t, http.StatusOK, code)
+
+		require.NoError(t, mr.Restart())
+
+		code, response := doFallbackRequest(t, router, "POST", "/v1/cart/user-1", `{"product_id":"prod-2","quantity":3}`)
+		require.Equal(t, http.StatusOK, code)
+		assert.False(t, response.Degraded)
+		assert.Equal(t, "3", mr.HGet("cart:user-1", "prod-2"))
+	})
+
+	t.Run("should return business errors from Redis without falling back", func(t *testing.T) {
+		router, store, _ := setupFallbackTest(t)
+
+		for i := 1; i <= 2; i++ {
+			code, _ := doFallbackRequest(t, router, "POST", "/v1/cart/user-1", fmt.Sprintf(`{"product_id":"prod-%d","quantity":1}`, i))
+			require.Equal(t, http.StatusOK, code)
+		}
+
+		code, _ := doFallbackRequest(t, router, "POST", "/v1/cart/user-1", `{"product_id":"prod-3","quantity":1}`)
+		assert.Equal(t, http.StatusConflict, code)
+
+		items, err := store.local.GetCart(context.Background(), "user-1")
+		require.NoError(t, err)
+		assert.Empty(t, items, "Nothing should have been written to memory")
+	})
+
+	t.Run("should return auth failures instead of masking them with memory", func(t *testing.T) {
+		router, store, mr := setupFallbackTest(t)
+		// Rejected credentials, as after a password rotation
+		mr.SetError("NOPERM User cart has no permissions to run the 'eval' command")
+
+		code, response := doFallbackRequest(t, router, "POST", "/v1/cart/user-1", `{"product_id":"prod-1","quantity":1}`)
+		assert.Equal(t, http.StatusInternalServerError, code)
+		assert.False(t, response.Degraded)
+
+		_, err := store.GetCart(context.Background(), "user-1")
+		assert.ErrorIs(t, err, redis.ErrRedisUnauthorized)
+
+		items, err := store.local.GetCart(context.Background(), "user-1")
+		require.NoError(t, err)
+		assert.Empty(t, items, "Nothing should have been written to memory")
+	})
+
+	t.Run("should return logical Redis errors without falling back", func(t *testing.T) {
+		router, store, mr := setupFallbackTest(t)
+		mr.SetError("WRONGTYPE Operation against a key holding the wrong kind of value")
+
+		code, response := doFallbackRequest(t, router, "POST", "/v1/cart/user-1", `{"product_id":"prod-1","quantity":1}`)
+		assert.Equal(t, http.StatusInternalServerError, code)
+		assert.False(t, response.Degraded)
+
+		items, err := store.local.GetCart(context.Background(), "user-1")
+		require.NoError(t, err)
+		assert.Empty(t, items, "Nothing should have been written to memory")
+	})
+
+	t.Run("should fall back while Redis is loading its dataset", func(t *testing.T) {
+		router, _, mr := setupFallbackTest(t)
+		mr.SetError("LOADING Redis is loading the dataset in memory")
+
+		code, response := doFallbackRequest(t, router, "POST", "/v1/cart/user-1", `{"product_id":"prod-1","quantity":1}`)
+		assert.Equal(t, http.StatusOK, code)
+		assert.True(t, response.Degraded)
+	})
+
+	t.Run("should return cross-slot rejections instead of treating them as an outage", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		rdb := redisclient.NewClusterClient(&redisclient.ClusterOptions{Addrs: []string{mr.Addr()}})
+		t.Cleanup(func() { rdb.Close() })
+		store := NewFallbackStore(redis.NewClient(rdb, zap.NewNop()), zap.NewNop(), FallbackConfig{})
+		ctx := context.Background()
+
+		mr.HSet("cart:guest-1", "prod-1", "2")
+
+		assert.ErrorIs(t, store.MergeCart(ctx, "guest-1", "user-1", 0, 0), redis.ErrCrossSlot)
+		assert.ErrorIs(t, store.TransferItem(ctx, "guest-1", "user-1", "prod-1", 1, 0, 0), redis.ErrCrossSlot)
+
+		items, err := store.local.GetCart(ctx, "user-1")
+		require.NoError(t, err)
+		assert.Empty(t, items, "Nothing should have been written to memory")
+	})
+
+	t.Run("should return invalid quantities without falling back", func(t *testing.T) {
+		_, store, _ := setupFallbackTest(t)
+		// Track degraded calls like TrackDegraded does for requests
+		ctx := context.WithValue(context.Background(), degradedKey{}, new(atomic.Bool))
+
+		assert.ErrorIs(t, store.AddItem(ctx, "user-1", "prod-1", 0), redis.ErrInvalidQuantity)
+		assert.ErrorIs(t, store.SetItems(ctx, "user-1", []redis.CartItem{{ProductID: "prod-1", Quantity: -1}}, 0, 0), redis.ErrInvalidQuantity)
+		assert.False(t, isDegraded(ctx))
+	})
+}
+
+func TestMemoryCartStore(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should expire carts after the TTL", func(t *testing.T) {
+		store := newMemoryCartStore(10, time.Minute)
+		now := time.Now()
+		store.now = func() time.Time { return now }
+
+		require.NoError(t, store.AddItem(ctx, "user-1", "prod-1", 1))
+
+		now = now.Add(2 * time.Minute)
+		items, err := store.GetCart(ctx, "user-1")
+		require.NoError(t, err)
+		assert.Empty(t, items)
+	})
+
+	t.Run("should evict the cart closest to expiry when full", func(t *testing.T) {
+		store := newMemoryCartStore(2, time.Minute)
+		now := time.Now()
+		store.now = func() time.Time { return now }
+
+		require.NoError(t, store.AddItem(ctx, "user-1", "prod-1", 1))
+		now = now.Add(time.Second)
+		require.NoError(t, store.AddItem(ctx, "user-2", "prod-1", 1))
+		now = now.Add(time.Second)
+		require.NoError(t, store.AddItem(ctx, "user-3", "prod-1", 1))
+
+		assert.Len(t, store.carts, 2)
+		assert.NotContains(t, store.carts, "user-1")
+	})
+
+	t.Run("should reject transfers above the available quantity", func(t *testing.T) {
+		store := newMemoryCartStore(10, time.Minute)
+
+		require.NoError(t, store.AddItem(ctx, "user-1", "prod-1", 2))
+		assert.ErrorIs(t, store.TransferItem(ctx, "user-1", "user-2", "prod-1", 3, 0, 0), redis.ErrInsufficientQuantity)
+
+		require.NoError(t, store.TransferItem(ctx, "user-1", "user-2", "prod-1", 2, 0, 0))
+		items, _ := store.GetCart(ctx, "user-2")
+		assert.Equal(t, []redis.CartItem{{ProductID: "prod-1", Quantity: 2}}, items)
+	})
+
+	t.Run("should enforce the cart limits on set, merge and transfer", func(t *testing.T) {
+		store := newMemoryCartStore(10, time.Minute)
+
+		require.NoError(t, store.SetItems(ctx, "user-1", []redis.CartItem{{ProductID: "prod-1", Quantity: 5}, {ProductID: "prod-2", Quantity: 1}}, 2, 10))
+		assert.ErrorIs(t, store.SetItems(ctx, "user-1", []redis.CartItem{{ProductID: "prod-3", Quantity: 1}}, 2, 10), redis.ErrCartFull)
+		assert.ErrorIs(t, store.SetItems(ctx, "user-1", []redis.CartItem{{ProductID: "prod-1", Quantity: 11}}, 2, 10), redis.ErrQuantityLimit)
+		// Swapping one product for another keeps the cart within the limit
+		require.NoError(t, store.SetItems(ctx, "user-1", []redis.CartItem{{ProductID: "prod-2", Quantity: 0}, {ProductID: "prod-3", Quantity: 1}}, 2, 10))
+
+		_, err := store.SetItemQuantityIfMatch(ctx, "user-1", "prod-4", 0, 1, 2, 10)
+		assert.ErrorIs(t, err, redis.ErrCartFull)
+
+		require.NoError(t, store.AddItem(ctx, "guest-1", "prod-1", 6))
+		assert.ErrorIs(t, store.MergeCart(ctx, "guest-1", "user-1", 2, 10), redis.ErrQuantityLimit)
+		assert.ErrorIs(t, store.TransferItem(ctx, "guest-1", "user-1", "prod-1", 6, 2, 10), redis.ErrQuantityLimit)
+
+		require.NoError(t, store.AddItem(ctx, "guest-2", "prod-5", 1))
+		assert.ErrorIs(t, store.MergeCart(ctx, "guest-2", "user-1", 2, 10), redis.ErrCartFull)
+		assert.ErrorIs(t, store.TransferItem(ctx, "guest-2", "user-1", "prod-5", 1, 2, 10), redis.ErrCartFull)
+
+		items, _ := store.GetCart(ctx, "user-1")
+		assert.Equal(t, []redis.CartItem{{ProductID: "prod-1", Quantity: 5}, {ProductID: "prod-3", Quantity: 1}}, items)
+		items, _ = store.GetCart(ctx, "guest-2")
+		assert.Len(t, items, 1, "A rejected merge must keep the source cart")
+	})
+}
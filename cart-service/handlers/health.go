@@ -3,20 +3,26 @@ package handlers
 import (
 	"context"
 	"net/http"
+	"sync/atomic"
 	"time"
 
-	"cart-service/redis"
-
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
+// RedisPinger is the subset of the Redis client used by health checks
+type RedisPinger interface {
+	Ping(ctx context.Context) error
+}
+
 // HealthHandler holds dependencies for health check handlers
 type HealthHandler struct {
-	redisClient *redis.Client
-	logger      *zap.Logger
-	podName     string
-	nodeName    string
+	checker  *HealthChecker
+	logger   *zap.Logger
+	podName  string
+	nodeName string
+	// draining is set once shutdown starts so /ready fails while connections still drain
+	draining atomic.Bool
 }
 
 // HealthResponse represents the response for health check endpoints
@@ -26,51 +32,128 @@ type HealthResponse struct {
 	PodName  string `json:"pod_name"`
 	NodeName string `json:"node_name"`
 	Redis    string `json:"redis,omitempty"`
+	// RedisLatencyMs is the measured Redis ping round-trip
+	RedisLatencyMs float64 `json:"redis_latency_ms,omitempty"`
+	// Warning explains a degraded status, e.g. a slow Redis
+	Warning string `json:"warning,omitempty"`
+	// Checks details every dependency check with its latency and, on failure, the error
+	Checks []CheckResult `json:"checks,omitempty"`
 }
 
+// healthCheckTimeout bounds how long a probe waits for all dependency checks
+const healthCheckTimeout = 2 * time.Second
+
 // NewHealthHandler creates a new health handler
-func NewHealthHandler(redisClient *redis.Client, logger *zap.Logger, podName, nodeName string) *HealthHandler {
+// A Redis ping slower than redisMaxLatency reports Redis as degraded (0 disables the threshold)
+func NewHealthHandler(redisClient RedisPinger, logger *zap.Logger, podName, nodeName string, redisMaxLatency time.Duration) *HealthHandler {
 	return &HealthHandler{
-		redisClient: redisClient,
-		logger:      logger,
-		podName:     podName,
-		nodeName:    nodeName,
+		checker: NewHealthChecker(healthCheckTimeout, DependencyCheck{
+			Name:       "redis",
+			Critical:   true,
+			Check:      redisClient.Ping,
+			MaxLatency: redisMaxLatency,
+		}),
+		logger:   logger,
+		podName:  podName,
+		nodeName: nodeName,
 	}
 }
 
 // Healthz handles GET /healthz
-// Kubernetes liveness/readiness probe that checks Redis connectivity
+// Combined health check kept for existing probes and docker-compose: checks Redis connectivity
 // Returns 200 OK if Redis is reachable, 503 Service Unavailable otherwise
+// Prefer /live and /ready for Kubernetes so a Redis blip doesn't restart the pod
 func (h *HealthHandler) Healthz(c *gin.Context) {
-	// Create a context with timeout for Redis ping
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
-	defer cancel()
-
-	// Check Redis connectivity
-	redisStatus := "healthy"
-	err := h.redisClient.Ping(ctx)
-	if err != nil {
-		redisStatus = "unhealthy"
-		h.logger.Error("Health check failed: Redis unreachable",
-			zap.Error(err),
-		)
+	h.respondWithChecks(c, "healthy", "unhealthy")
+}
 
+// RegisterCheck adds a dependency check to /healthz and /ready, next to the Redis ping
+func (h *HealthHandler) RegisterCheck(check DependencyCheck) {
+	h.checker.Register(check)
+}
+
+// StartDraining marks the service as shutting down
+// From then on /ready answers 503 so load balancers stop routing new traffic to the pod,
+// while /live keeps answering 200 so Kubernetes doesn't kill it mid-drain
+func (h *HealthHandler) StartDraining() {
+	h.draining.Store(true)
+}
+
+// Ready handles GET /ready
+// Kubernetes readiness probe: 503 while Redis is unreachable takes the pod out of the Service
+// endpoints until Redis recovers, without restarting it
+// Once StartDraining has been called it answers 503 without checking Redis
+func (h *HealthHandler) Ready(c *gin.Context) {
+	if h.draining.Load() {
 		c.JSON(http.StatusServiceUnavailable, HealthResponse{
-			Status:   "unhealthy",
+			Status:   "draining",
 			Service:  "cart-service",
 			PodName:  h.podName,
 			NodeName: h.nodeName,
-			Redis:    redisStatus,
 		})
 		return
 	}
+	h.respondWithChecks(c, "ready", "not_ready")
+}
 
-	// All checks passed
+// Live handles GET /live
+// Kubernetes liveness probe: only reports that the process is serving requests
+// Dependencies are deliberately not checked, since restarting the pod can't fix them
+func (h *HealthHandler) Live(c *gin.Context) {
 	c.JSON(http.StatusOK, HealthResponse{
-		Status:   "healthy",
+		Status:   "alive",
 		Service:  "cart-service",
 		PodName:  h.podName,
 		NodeName: h.nodeName,
-		Redis:    redisStatus,
 	})
 }
+
+// respondWithChecks runs the dependency checks and reports okStatus (200) or failStatus (503)
+// A slow dependency, or a failing non-critical one, keeps the 200 but reports "degraded" with a warning
+func (h *HealthHandler) respondWithChecks(c *gin.Context, okStatus, failStatus string) {
+	checks, healthy := h.checker.Run(c.Request.Context())
+
+	response := HealthResponse{
+		Status:   okStatus,
+		Service:  "cart-service",
+		PodName:  h.podName,
+		NodeName: h.nodeName,
+		Checks:   checks,
+	}
+	for _, check := range checks {
+		if check.Name == "redis" {
+			response.Redis = check.Status
+			response.RedisLatencyMs = check.LatencyMs
+		}
+		if check.Warning != "" {
+			response.Status = "degraded"
+			response.Warning = check.Name + " " + check.Warning
+			h.logger.Warn("Health check degraded: dependency slow",
+				zap.String("path", c.FullPath()),
+				zap.String("dependency", check.Name),
+				zap.Float64("latency_ms", check.LatencyMs),
+			)
+		}
+		if check.Error != "" && !check.Critical {
+			response.Status = "degraded"
+			response.Warning = check.Name + " unreachable: " + check.Error
+		}
+		if check.Error != "" {
+			h.logger.Error("Health check failed: dependency unreachable",
+				zap.String("path", c.FullPath()),
+				zap.String("dependency", check.Name),
+				zap.Float64("latency_ms", check.LatencyMs),
+				zap.String("error", check.Error),
+			)
+		}
+	}
+
+	if !healthy {
+		response.Status = failStatus
+		c.JSON(http.StatusServiceUnavailable, response)
+		return
+	}
+
+	// All critical checks passed
+	c.JSON(http.StatusOK, response)
+}
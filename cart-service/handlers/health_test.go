@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -14,7 +15,9 @@ import (
 	"github.com/gin-gonic/gin"
 	redisclient "github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 // setupHealthTest creates a test environment with Redis client
@@ -36,12 +39,7 @@ func setupHealthTest(t *testing.T) (*HealthHandler, *miniredis.Miniredis, func()
 		logger: logger,
 	}
 
-	handler := &HealthHandler{
-		redisClient: testClient,
-		logger:      logger,
-		podName:     "test-pod",
-		nodeName:    "test-node",
-	}
+	handler := NewHealthHandler(testClient, logger, "test-pod", "test-node", 0)
 
 	cleanup := func() {
 		rdb.Close()
@@ -99,6 +97,11 @@ func TestHealthz(t *testing.T) {
 		assert.Equal(t, "test-pod", response.PodName)
 		assert.Equal(t, "test-node", response.NodeName)
 		assert.Equal(t, "healthy", response.Redis)
+
+		require.Len(t, response.Checks, 1)
+		assert.Equal(t, "redis", response.Checks[0].Name)
+		assert.Equal(t, "healthy", response.Checks[0].Status)
+		assert.Empty(t, response.Checks[0].Error)
 	})
 
 	t.Run("should return unhealthy when Redis is down", func(t *testing.T) {
@@ -127,5 +130,142 @@ func TestHealthz(t *testing.T) {
 
 		assert.Equal(t, "unhealthy", response.Status)
 		assert.Equal(t, "unhealthy", response.Redis)
+
+		require.Len(t, response.Checks, 1)
+		assert.Equal(t, "unhealthy", response.Checks[0].Status)
+		assert.NotEmpty(t, response.Checks[0].Error)
+	})
+
+	t.Run("should return degraded when Redis is slower than the threshold", func(t *testing.T) {
+		core, logs := observer.New(zap.WarnLevel)
+		handler := NewHealthHandler(slowPinger{delay: 30 * time.Millisecond}, zap.New(core), "test-pod", "test-node", 10*time.Millisecond)
+
+		router := gin.New()
+		router.GET("/healthz", handler.Healthz)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/healthz", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code, "A slow Redis must not fail the probe")
+
+		var response HealthResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "degraded", response.Status)
+		assert.Equal(t, "degraded", response.Redis)
+		assert.GreaterOrEqual(t, response.RedisLatencyMs, float64(30))
+		assert.Contains(t, response.Warning, "redis responded in")
+
+		assert.Equal(t, 1, logs.FilterMessage("Health check degraded: dependency slow").Len())
+	})
+
+	t.Run("should return degraded when a non-critical check fails", func(t *testing.T) {
+		handler, _, cleanup := setupHealthTest(t)
+		defer cleanup()
+		handler.RegisterCheck(DependencyCheck{Name: "product-service", Check: func(ctx context.Context) error {
+			return errors.New("connection refused")
+		}})
+
+		router := gin.New()
+		router.GET("/healthz", handler.Healthz)
+		router.GET("/ready", handler.Ready)
+
+		for _, path := range []string{"/healthz", "/ready"} {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", path, nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code, path)
+
+			var response HealthResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, "degraded", response.Status, path)
+			assert.Equal(t, "healthy", response.Redis, path)
+			assert.Equal(t, "product-service unreachable: connection refused", response.Warning, path)
+			require.Len(t, response.Checks, 2, path)
+			assert.True(t, response.Checks[0].Critical)
+			assert.False(t, response.Checks[1].Critical)
+		}
+	})
+}
+
+// slowPinger is a Redis stand-in whose Ping succeeds after delay
+type slowPinger struct {
+	delay time.Duration
+}
+
+func (p slowPinger) Ping(ctx context.Context) error {
+	time.Sleep(p.delay)
+	return nil
+}
+
+
+func TestReadyAndLive(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	probe := func(handler *HealthHandler, path string) (int, HealthResponse) {
+		router := gin.New()
+		router.GET("/ready", handler.Ready)
+		router.GET("/live", handler.Live)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+		defer cancel()
+		req = req.WithContext(ctx)
+
+		router.ServeHTTP(w, req)
+
+		var response HealthResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w.Code, response
+	}
+
+	t.Run("should report ready and alive when Redis is reachable", func(t *testing.T) {
+		handler, _, cleanup := setupHealthTest(t)
+		defer cleanup()
+
+		code, response := probe(handler, "/ready")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "ready", response.Status)
+		assert.Equal(t, "healthy", response.Redis)
+
+		code, response = probe(handler, "/live")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "alive", response.Status)
+	})
+
+	t.Run("should fail readiness but not liveness when Redis is down", func(t *testing.T) {
+		handler, mr, cleanup := setupHealthTest(t)
+		defer cleanup()
+
+		// Stop miniredis to simulate Redis being down
+		mr.Close()
+
+		code, response := probe(handler, "/ready")
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, "not_ready", response.Status)
+		assert.Equal(t, "unhealthy", response.Redis)
+
+		code, response = probe(handler, "/live")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "alive", response.Status)
+		assert.Empty(t, response.Redis, "Liveness must not depend on Redis")
+	})
+
+	t.Run("should fail readiness but not liveness once draining", func(t *testing.T) {
+		handler, _, cleanup := setupHealthTest(t)
+		defer cleanup()
+
+		handler.StartDraining()
+
+		code, response := probe(handler, "/ready")
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, "draining", response.Status)
+		assert.Empty(t, response.Checks, "Draining must not wait on dependency checks")
+
+		code, response = probe(handler, "/live")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "alive", response.Status)
 	})
 }
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+
+	"cart-service/internal/apierror"
+	"cart-service/products"
+	"cart-service/telemetry"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+)
+
+// ProductCatalog defines the product lookups used to enrich cart lines
+type ProductCatalog interface {
+	GetProduct(ctx context.Context, productID string) (*products.Product, error)
+}
+
+// pricedLine is a cart line enriched with its product name and unit price in cents
+type pricedLine struct {
+	ProductID       string
+	Name            string
+	UnitAmountCents int64
+	Quantity        int
+}
+
+// lineItemFormatter maps priced cart lines to a payment provider's line-item JSON
+type lineItemFormatter func(lines []pricedLine, currency string) any
+
+// lineItemFormatters is the whitelist of supported ?provider= values
+var lineItemFormatters = map[string]lineItemFormatter{
+	"stripe": stripeLineItems,
+}
+
+// LineItemsHandler holds dependencies for the checkout line-items handler
+type LineItemsHandler struct {
+	redisClient CartStore
+	catalog     ProductCatalog
+	logger      *zap.Logger
+	currency    string
+}
+
+// LineItemsResponse represents the response for GET /v1/cart/:user_id/line-items
+type LineItemsResponse struct {
+	UserID           string `json:"user_id"`
+	Provider         string `json:"provider"`
+	Currency         string `json:"currency"`
+	AmountTotalCents int64  `json:"amount_total"`
+	LineItems        any    `json:"line_items"`
+}
+
+// NewLineItemsHandler creates a new line-items handler
+// currency is the ISO 4217 code (e.g. "usd") that product prices are expressed in
+func NewLineItemsHandler(redisClient CartStore, catalog ProductCatalog, logger *zap.Logger, currency string) *LineItemsHandler {
+	return &LineItemsHandler{
+		redisClient: redisClient,
+		catalog:     catalog,
+		logger:      logger,
+		currency:    strings.ToLower(currency),
+	}
+}
+
+// GetLineItems handles GET /v1/cart/:user_id/line-items
+// Enriches the cart with product names and prices from product-service and returns it
+// in the line-item format expected by a payment provider, with amounts in integer cents
+// Query parameters:
+// - provider: Payment provider format (default: stripe; supported: stripe)
+func (h *LineItemsHandler) GetLineItems(c *gin.Context) {
+	ctx := c.Request.Context()
+	tracer := otel.Tracer("cart-service")
+	ctx, span := tracer.Start(ctx, "handler.GetLineItems")
+	defer span.End()
+
+	userID := c.Param("user_id")
+	if !validateID(userID) {
+		span.SetStatus(codes.Error, "Invalid user_id")
+		apierror.RespondError(c, http.StatusBadRequest, CodeInvalidUserID, "user_id "+idRuleMessage)
+		return
+	}
+
+	provider := c.DefaultQuery("provider", "stripe")
+	format, ok := lineItemFormatters[provider]
+	if !ok {
+		span.SetStatus(codes.Error, "Unsupported provider")
+		apierror.RespondErrorDetails(c, http.StatusBadRequest, CodeUnsupportedProvider, "Unsupported provider", gin.H{
+			"supported": supportedProviders(),
+		})
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("user_id", userID),
+		attribute.String("provider", provider),
+	)
+
+	// Carry user_id to product-service in the baggage header
+	ctx = telemetry.ContextWithUserID(ctx, userID)
+
+	items, err := h.redisClient.GetCart(ctx, userID)
+	if err != nil {
+		span.SetStatus(codes.Error, "Failed to get cart")
+		span.RecordError(err)
+		h.logger.Error("Failed to get cart",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		apierror.RespondError(c, http.StatusInternalServerError, CodeRedisUnavailable, "Failed to retrieve cart")
+		return
+	}
+
+	if len(items) == 0 {
+		span.SetStatus(codes.Error, "Cart is empty")
+		apierror.RespondError(c, http.StatusBadRequest, CodeCartEmpty, "Cart is empty")
+		return
+	}
+
+	// Stable order so repeated checkouts produce identical payloads
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].ProductID < items[j].ProductID
+	})
+
+	lines := make([]pricedLine, len(items))
+	var total int64
+	for i, item := range items {
+		product, err := h.catalog.GetProduct(ctx, item.ProductID)
+		if err != nil {
+			span.SetStatus(codes.Error, "Failed to enrich cart")
+			span.RecordError(err)
+			if errors.Is(err, products.ErrProductNotFound) {
+				apierror.RespondErrorDetails(c, http.StatusUnprocessableEntity, CodeProductNotFound, "Product not found", gin.H{
+					"product_id": item.ProductID,
+				})
+				return
+			}
+			h.logger.Error("Failed to enrich cart line",
+				zap.String("user_id", userID),
+				zap.String("product_id", item.ProductID),
+				zap.Error(err),
+			)
+			apierror.RespondError(c, http.StatusBadGateway, CodeProductServiceUnavailable, "Product service unavailable")
+			return
+		}
+
+		lines[i] = pricedLine{
+			ProductID:       item.ProductID,
+			Name:            product.Name,
+			UnitAmountCents: toCents(product.Price),
+			Quantity:        item.Quantity,
+		}
+		total += lines[i].UnitAmountCents * int64(item.Quantity)
+	}
+
+	span.SetAttributes(
+		attribute.Int("line_count", len(lines)),
+		attribute.Int64("amount_total", total),
+	)
+	span.SetStatus(codes.Ok, "Line items built")
+
+	c.JSON(http.StatusOK, LineItemsResponse{
+		UserID:           userID,
+		Provider:         provider,
+		Currency:         h.currency,
+		AmountTotalCents: total,
+		LineItems:        format(lines, h.currency),
+	})
+}
+
+// toCents converts a decimal price to integer cents
+// Rounds to the nearest cent so float artifacts (19.99 * 100 = 1998.9999...) don't lose a cent
+func toCents(price float64) int64 {
+	return int64(math.Round(price * 100))
+}
+
+// supportedProviders lists the whitelisted providers in a stable order
+func supportedProviders() []string {
+	providers := make([]string, 0, len(lineItemFormatters))
+	for provider := range lineItemFormatters {
+		providers = append(providers, provider)
+	}
+	sort.Strings(providers)
+	return providers
+}
+
+// stripeLineItem mirrors a Stripe Checkout Session line_items entry using inline price_data
+type stripeLineItem struct {
+	PriceData stripePriceData `json:"price_data"`
+	Quantity  int             `json:"quantity"`
+}
+
+type stripePriceData struct {
+	Currency    string            `json:"currency"`
+	UnitAmount  int64             `json:"unit_amount"`
+	ProductData stripeProductData `json:"product_data"`
+}
+
+type stripeProductData struct {
+	Name     string            `json:"name"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+// stripeLineItems formats lines for Stripe Checkout
+func stripeLineItems(lines []pricedLine, currency string) any {
+	items := make([]stripeLineItem, len(lines))
+	for i, line := range lines {
+		items[i] = stripeLineItem{
+			PriceData: stripePriceData{
+				Currency:   currency,
+				UnitAmount: line.UnitAmountCents,
+				ProductData: stripeProductData{
+					Name:     line.Name,
+					Metadata: map[string]string{"product_id": line.ProductID},
+				},
+			},
+			Quantity: line.Quantity,
+		}
+	}
+	return items
+}
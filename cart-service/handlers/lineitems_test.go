@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"cart-service/products"
+	"cart-service/redis"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	redisclient "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// setupLineItemsTest creates a line-items handler backed by miniredis and a stub product catalog
+func setupLineItemsTest(t *testing.T, catalog map[string]products.Product) (*LineItemsHandler, *miniredis.Miniredis, func()) {
+	mr := miniredis.NewMiniRedis()
+	if err := mr.Start(); err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+
+	rdb := redisclient.NewClient(&redisclient.Options{
+		Addr: mr.Addr(),
+	})
+
+	// Serves GET /products/{id} like product-service
+	productService := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		product, ok := catalog[strings.TrimPrefix(r.URL.Path, "/products/")]
+		if r.Method != http.MethodGet || !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(product)
+	}))
+
+	logger := zap.NewNop()
+	handler := NewLineItemsHandler(
+		redis.NewClient(rdb, logger),
+		products.NewClient(productService.URL, time.Second, logger),
+		logger,
+		"USD",
+	)
+
+	cleanup := func() {
+		productService.Close()
+		rdb.Close()
+		mr.Close()
+	}
+
+	return handler, mr, cleanup
+}
+
+func TestGetLineItems(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	catalog := map[string]products.Product{
+		"1": {ID: 1, Name: "MacBook Pro 16\"", Price: 3499.00},
+		"2": {ID: 2, Name: "Cotton T-Shirt", Price: 19.99},
+		"3": {ID: 3, Name: "Sticker", Price: 0.1},
+	}
+
+	t.Run("should map the cart to Stripe line items in cents", func(t *testing.T) {
+		handler, mr, cleanup := setupLineItemsTest(t, catalog)
+		defer cleanup()
+
+		mr.HSet("cart:user-123", "1", "1")
+		mr.HSet("cart:user-123", "2", "3")
+		mr.HSet("cart:user-123", "3", "7")
+
+		router := gin.New()
+		router.GET("/v1/cart/:user_id/line-items", handler.GetLineItems)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/v1/cart/user-123/line-items?provider=stripe", nil)
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		// Decode generically so the assertions pin the provider's field names
+		var response map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+		assert.Equal(t, "stripe", response["provider"])
+		assert.Equal(t, "usd", response["currency"])
+		assert.Equal(t, float64(349900+3*1999+7*10), response["amount_total"])
+
+		lineItems := response["line_items"].([]any)
+		require.Len(t, lineItems, 3)
+
+		second := lineItems[1].(map[string]any)
+		assert.Equal(t, float64(3), second["quantity"])
+		priceData := second["price_data"].(map[string]any)
+		assert.Equal(t, "usd", priceData["currency"])
+		assert.Equal(t, float64(1999), priceData["unit_amount"])
+		productData := priceData["product_data"].(map[string]any)
+		assert.Equal(t, "Cotton T-Shirt", productData["name"])
+		assert.Equal(t, map[string]any{"product_id": "2"}, productData["metadata"])
+
+		third := lineItems[2].(map[string]any)
+		assert.Equal(t, float64(10), third["price_data"].(map[string]any)["unit_amount"])
+	})
+
+	t.Run("should default to Stripe", func(t *testing.T) {
+		handler, mr, cleanup := setupLineItemsTest(t, catalog)
+		defer cleanup()
+
+		mr.HSet("cart:user-123", "1", "1")
+
+		router := gin.New()
+		router.GET("/v1/cart/:user_id/line-items", handler.GetLineItems)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/v1/cart/user-123/line-items", nil)
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response LineItemsResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "stripe", response.Provider)
+		assert.Equal(t, int64(349900), response.AmountTotalCents)
+	})
+
+	t.Run("should reject providers outside the whitelist", func(t *testing.T) {
+		handler, mr, cleanup := setupLineItemsTest(t, catalog)
+		defer cleanup()
+
+		mr.HSet("cart:user-123", "1", "1")
+
+		router := gin.New()
+		router.GET("/v1/cart/:user_id/line-items", handler.GetLineItems)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/v1/cart/user-123/line-items?provider=paypal", nil)
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "stripe")
+	})
+
+	t.Run("should fail when a product is unknown", func(t *testing.T) {
+		handler, mr, cleanup := setupLineItemsTest(t, catalog)
+		defer cleanup()
+
+		mr.HSet("cart:user-123", "999", "1")
+
+		router := gin.New()
+		router.GET("/v1/cart/:user_id/line-items", handler.GetLineItems)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/v1/cart/user-123/line-items", nil)
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Contains(t, w.Body.String(), "999")
+	})
+}
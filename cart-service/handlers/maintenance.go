@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"cart-service/internal/apierror"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// CodeMaintenance is returned for cart writes while maintenance mode is on
+const CodeMaintenance = "MAINTENANCE_MODE"
+
+// Maintenance is the maintenance mode switch
+// While it is on, routes behind BlockWrites answer 503 with a Retry-After header; reads and the
+// health probes are not affected, so the pods stay in rotation during a migration
+type Maintenance struct {
+	enabled    atomic.Bool
+	retryAfter time.Duration
+	logger     *zap.Logger
+}
+
+// MaintenanceRequest is the body for PUT /admin/maintenance
+type MaintenanceRequest struct {
+	// A pointer so a missing field is rejected instead of read as false
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// MaintenanceResponse reports whether maintenance mode is on
+type MaintenanceResponse struct {
+	Enabled bool `json:"enabled"`
+}
+
+// NewMaintenance creates the switch, initially set to enabled
+// retryAfter is what blocked clients are told to wait, rounded up to whole seconds
+func NewMaintenance(enabled bool, retryAfter time.Duration, logger *zap.Logger) *Maintenance {
+	m := &Maintenance{
+		retryAfter: retryAfter,
+		logger:     logger,
+	}
+	if enabled {
+		m.enabled.Store(true)
+		logger.Warn("Maintenance mode enabled; cart writes return 503", zap.String("source", "startup"))
+	}
+	return m
+}
+
+// Enabled reports whether maintenance mode is on
+func (m *Maintenance) Enabled() bool {
+	return m.enabled.Load()
+}
+
+// Set turns maintenance mode on or off and logs the transition with its source
+// Setting the current value again is not logged
+func (m *Maintenance) Set(enabled bool, source string) {
+	if m.enabled.Swap(enabled) == enabled {
+		return
+	}
+	if enabled {
+		m.logger.Warn("Maintenance mode enabled; cart writes return 503", zap.String("source", source))
+	} else {
+		m.logger.Info("Maintenance mode disabled; cart writes resumed", zap.String("source", source))
+	}
+}
+
+// BlockWrites returns a Gin middleware that answers 503 while maintenance mode is on
+// Register it on the write routes only
+func (m *Maintenance) BlockWrites() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !m.Enabled() {
+			c.Next()
+			return
+		}
+
+		// Retry-After is whole seconds; round up so a client honouring it doesn't come back early
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(m.retryAfter.Seconds()))))
+		apierror.RespondError(c, http.StatusServiceUnavailable, CodeMaintenance, "Cart writes are paused for maintenance")
+	}
+}
+
+// GetMaintenance handles GET /admin/maintenance
+func (m *Maintenance) GetMaintenance(c *gin.Context) {
+	c.JSON(http.StatusOK, MaintenanceResponse{Enabled: m.Enabled()})
+}
+
+// SetMaintenance handles PUT /admin/maintenance
+// Turns maintenance mode on or off at runtime, for this pod only
+func (m *Maintenance) SetMaintenance(c *gin.Context) {
+	var req MaintenanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondInvalidBody(c, bindingErrors(err))
+		return
+	}
+
+	m.Set(*req.Enabled, "admin_api")
+	c.JSON(http.StatusOK, MaintenanceResponse{Enabled: m.Enabled()})
+}
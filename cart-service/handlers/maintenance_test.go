@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"cart-service/internal/apierror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestMaintenance(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// setupRouter registers the cart routes like main.go, with the writes behind BlockWrites
+	setupRouter := func(t *testing.T, maintenance *Maintenance) *gin.Engine {
+		handler, mr, cleanup := setupTest(t)
+		t.Cleanup(cleanup)
+		mr.HSet("cart:user-1", "prod-1", "2")
+
+		blockWrites := maintenance.BlockWrites()
+		router := gin.New()
+		router.POST("/v1/cart/:user_id", blockWrites, handler.AddItem)
+		router.GET("/v1/cart/:user_id", handler.GetCart)
+		router.DELETE("/v1/cart/:user_id", blockWrites, handler.DeleteCart)
+		router.GET("/admin/maintenance", maintenance.GetMaintenance)
+		router.PUT("/admin/maintenance", maintenance.SetMaintenance)
+		return router
+	}
+
+	do := func(router *gin.Engine, method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should block writes but serve reads while enabled", func(t *testing.T) {
+		router := setupRouter(t, NewMaintenance(true, 90*time.Second, zap.NewNop()))
+
+		for _, w := range []*httptest.ResponseRecorder{
+			do(router, "POST", "/v1/cart/user-1", `{"product_id":"prod-2","quantity":1}`),
+			do(router, "DELETE", "/v1/cart/user-1", ""),
+		} {
+			assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+			assert.Equal(t, "90", w.Header().Get("Retry-After"))
+
+			var response apierror.APIError
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, CodeMaintenance, response.Code)
+		}
+
+		w := do(router, "GET", "/v1/cart/user-1", "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		var cart CartResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &cart))
+		require.Len(t, cart.Items, 1, "The blocked writes must not have changed the cart")
+		assert.Equal(t, 2, cart.Items[0].Quantity)
+	})
+
+	t.Run("should let writes through while disabled", func(t *testing.T) {
+		router := setupRouter(t, NewMaintenance(false, time.Minute, zap.NewNop()))
+
+		w := do(router, "POST", "/v1/cart/user-1", `{"product_id":"prod-2","quantity":1}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Retry-After"))
+	})
+
+	t.Run("should toggle at runtime and log each transition once", func(t *testing.T) {
+		core, logs := observer.New(zapcore.InfoLevel)
+		router := setupRouter(t, NewMaintenance(false, time.Minute, zap.New(core)))
+
+		w := do(router, "PUT", "/admin/maintenance", `{"enabled":true}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"enabled":true}`, w.Body.String())
+		do(router, "PUT", "/admin/maintenance", `{"enabled":true}`)
+
+		assert.JSONEq(t, `{"enabled":true}`, do(router, "GET", "/admin/maintenance", "").Body.String())
+		assert.Equal(t, http.StatusServiceUnavailable, do(router, "DELETE", "/v1/cart/user-1", "").Code)
+
+		do(router, "PUT", "/admin/maintenance", `{"enabled":false}`)
+		assert.Equal(t, http.StatusOK, do(router, "DELETE", "/v1/cart/user-1", "").Code)
+
+		enabled := logs.FilterMessageSnippet("Maintenance mode enabled").All()
+		require.Len(t, enabled, 1, "Enabling twice should log once")
+		assert.Equal(t, "admin_api", enabled[0].ContextMap()["source"])
+		assert.Equal(t, 1, logs.FilterMessageSnippet("Maintenance mode disabled").Len())
+	})
+
+	t.Run("should reject a toggle without enabled", func(t *testing.T) {
+		router := setupRouter(t, NewMaintenance(true, time.Minute, zap.NewNop()))
+
+		w := do(router, "PUT", "/admin/maintenance", `{}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.JSONEq(t, `{"enabled":true}`, do(router, "GET", "/admin/maintenance", "").Body.String())
+	})
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"cart-service/internal/apierror"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+)
+
+// CartMetaResponse represents cart-level metadata in cart responses
+// Fields that were never set are omitted
+type CartMetaResponse struct {
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	Currency  string     `json:"currency,omitempty"`
+}
+
+// SetCurrencyRequest represents the request body for setting a cart's currency
+// Currency is an ISO 4217 code such as "EUR"; it is stored lowercased
+type SetCurrencyRequest struct {
+	Currency string `json:"currency" binding:"required,len=3,alpha"`
+}
+
+// SetCurrencyResponse represents the response for a currency update
+type SetCurrencyResponse struct {
+	UserID   string           `json:"user_id"`
+	Meta     CartMetaResponse `json:"meta"`
+	Degraded bool             `json:"degraded,omitempty"`
+}
+
+// cartMeta loads the cart's metadata for a response
+// Metadata is informational, so a lookup failure is logged and the field is omitted
+// rather than failing a request whose items were read successfully
+func (h *CartHandler) cartMeta(ctx context.Context, userID string) *CartMetaResponse {
+	meta, err := h.redisClient.GetCartMeta(ctx, userID)
+	if err != nil {
+		h.logger.Warn("Failed to get cart metadata",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		return nil
+	}
+
+	response := &CartMetaResponse{Currency: meta.Currency}
+	if !meta.CreatedAt.IsZero() {
+		createdAt := meta.CreatedAt
+		response.CreatedAt = &createdAt
+	}
+	if response.CreatedAt == nil && response.Currency == "" {
+		return nil
+	}
+	return response
+}
+
+// SetCartCurrency handles PUT /v1/cart/:user_id/currency
+// Records the currency the cart is priced in; the cart's items are left untouched
+func (h *CartHandler) SetCartCurrency(c *gin.Context) {
+	ctx := c.Request.Context()
+	tracer := otel.Tracer("cart-service")
+	ctx, span := tracer.Start(ctx, "handler.SetCartCurrency")
+	defer span.End()
+
+	userID := c.Param("user_id")
+	if !validateID(userID) {
+		span.SetStatus(codes.Error, "Invalid user_id")
+		apierror.RespondError(c, http.StatusBadRequest, CodeInvalidUserID, "user_id "+idRuleMessage)
+		return
+	}
+
+	span.SetAttributes(attribute.String("user_id", userID))
+
+	var req SetCurrencyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		span.SetStatus(codes.Error, "Invalid request body")
+		span.RecordError(err)
+		respondInvalidBody(c, bindingErrors(err))
+		return
+	}
+	currency := strings.ToLower(req.Currency)
+
+	span.SetAttributes(attribute.String("currency", currency))
+
+	if err := h.redisClient.SetCartCurrency(ctx, userID, currency); err != nil {
+		span.SetStatus(codes.Error, "Failed to set cart currency")
+		span.RecordError(err)
+		h.logger.Error("Failed to set cart currency",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		apierror.RespondError(c, http.StatusInternalServerError, CodeRedisUnavailable, "Failed to update cart")
+		return
+	}
+
+	response := SetCurrencyResponse{UserID: userID, Meta: CartMetaResponse{Currency: currency}}
+	if meta := h.cartMeta(ctx, userID); meta != nil {
+		response.Meta = *meta
+	}
+	response.Degraded = isDegraded(ctx)
+
+	span.SetStatus(codes.Ok, "Cart currency set")
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"context"
+
+	"cart-service/redis"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+	"go.uber.org/zap"
+)
+
+// cartSizeBuckets are the histogram bucket boundaries for cart size metrics
+// Most carts hold a handful of items, so the buckets are dense at the low end
+var cartSizeBuckets = []float64{1, 2, 5, 10, 20, 50}
+
+// cartMetrics records the distribution of cart sizes seen by AddItem and GetCart
+type cartMetrics struct {
+	itemCount     metric.Int64Histogram
+	totalQuantity metric.Int64Histogram
+}
+
+// newCartMetrics creates the cart.item_count and cart.total_quantity histograms
+// Without a meter provider the global meter is a no-op, so recording costs next to nothing;
+// if an instrument can't be created it is replaced by a no-op one and only the metric is lost
+func newCartMetrics(logger *zap.Logger) *cartMetrics {
+	meter := otel.Meter("cart-service")
+
+	itemCount, err := meter.Int64Histogram("cart.item_count",
+		metric.WithDescription("Distinct products in a cart after AddItem or GetCart"),
+		metric.WithUnit("{item}"),
+		metric.WithExplicitBucketBoundaries(cartSizeBuckets...),
+	)
+	if err != nil {
+		logger.Warn("Failed to create cart item count histogram", zap.Error(err))
+		itemCount = noop.Int64Histogram{}
+	}
+
+	totalQuantity, err := meter.Int64Histogram("cart.total_quantity",
+		metric.WithDescription("Sum of item quantities in a cart after AddItem or GetCart"),
+		metric.WithUnit("{unit}"),
+		metric.WithExplicitBucketBoundaries(cartSizeBuckets...),
+	)
+	if err != nil {
+		logger.Warn("Failed to create cart total quantity histogram", zap.Error(err))
+		totalQuantity = noop.Int64Histogram{}
+	}
+
+	return &cartMetrics{itemCount: itemCount, totalQuantity: totalQuantity}
+}
+
+// recordCartSize records the cart's size from the items the handler already loaded
+// It needs no extra Redis calls and never blocks: the SDK aggregates in memory and exports
+// in the background. The histograms carry no user attributes to keep cardinality low
+func (h *CartHandler) recordCartSize(ctx context.Context, items []redis.CartItem) {
+	if h.metrics == nil {
+		return
+	}
+
+	totalQuantity := 0
+	for _, item := range items {
+		totalQuantity += item.Quantity
+	}
+
+	h.metrics.itemCount.Record(ctx, int64(len(items)))
+	h.metrics.totalQuantity.Record(ctx, int64(totalQuantity))
+}
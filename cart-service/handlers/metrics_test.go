@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.uber.org/zap"
+)
+
+// recordMetrics installs a global meter provider that keeps measurements in a manual reader
+func recordMetrics(t *testing.T) *sdkmetric.ManualReader {
+	reader := sdkmetric.NewManualReader()
+	previous := otel.GetMeterProvider()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	t.Cleanup(func() {
+		otel.SetMeterProvider(previous)
+	})
+	return reader
+}
+
+// histogramPoint returns the single data point of the named int64 histogram
+func histogramPoint(t *testing.T, reader *sdkmetric.ManualReader, name string) metricdata.HistogramDataPoint[int64] {
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+
+	for _, scope := range rm.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			if m.Name == name {
+				points := m.Data.(metricdata.Histogram[int64]).DataPoints
+				require.Len(t, points, 1)
+				return points[0]
+			}
+		}
+	}
+	t.Fatalf("metric %q was not recorded", name)
+	return metricdata.HistogramDataPoint[int64]{}
+}
+
+func TestCartSizeMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("should record cart size after GetCart and AddItem", func(t *testing.T) {
+		reader := recordMetrics(t)
+
+		handler, mr, cleanup := setupTest(t)
+		defer cleanup()
+		handler.metrics = newCartMetrics(zap.NewNop())
+
+		mr.HSet("cart:user-123", "prod-1", "2")
+		mr.HSet("cart:user-123", "prod-2", "5")
+
+		router := gin.New()
+		router.GET("/v1/cart/:user_id", handler.GetCart)
+		router.POST("/v1/cart/:user_id", handler.AddItem)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/v1/cart/user-123", nil)
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		body, _ := json.Marshal(AddItemRequest{ProductID: "prod-3", Quantity: 1})
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("POST", "/v1/cart/user-123", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		// GetCart saw 2 items (7 units), AddItem then saw 3 items (8 units)
+		itemCount := histogramPoint(t, reader, "cart.item_count")
+		assert.Equal(t, uint64(2), itemCount.Count)
+		assert.Equal(t, int64(5), itemCount.Sum)
+		assert.Equal(t, cartSizeBuckets, itemCount.Bounds)
+
+		totalQuantity := histogramPoint(t, reader, "cart.total_quantity")
+		assert.Equal(t, uint64(2), totalQuantity.Count)
+		assert.Equal(t, int64(15), totalQuantity.Sum)
+	})
+
+	t.Run("should work without a meter provider", func(t *testing.T) {
+		handler, mr, cleanup := setupTest(t)
+		defer cleanup()
+		handler.metrics = newCartMetrics(zap.NewNop())
+
+		mr.HSet("cart:user-123", "prod-1", "2")
+
+		router := gin.New()
+		router.GET("/v1/cart/:user_id", handler.GetCart)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/v1/cart/user-123", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
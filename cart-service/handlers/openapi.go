@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+
+	"cart-service/internal/apierror"
+	"cart-service/internal/openapi"
+
+	"github.com/gin-gonic/gin"
+)
+
+// openAPIRoutes documents the bodies of the cart-service routes, keyed by method and gin path
+// Routes are listed in the spec as soon as they are registered; an entry here adds their types
+var openAPIRoutes = map[string]openapi.Route{
+	"POST /v1/cart/:user_id":                  {Summary: "Add an item to the cart", Request: AddItemRequest{}, Response: CartResponse{}},
+	"GET /v1/cart/:user_id":                   {Summary: "Get the cart", Response: CartResponse{}},
+	"DELETE /v1/cart/:user_id":                {Summary: "Clear the cart"},
+	"PUT /v1/cart/:user_id/items":             {Summary: "Set several item quantities", Request: []SetItemRequest{}, Response: CartResponse{}},
+	"PUT /v1/cart/:user_id/items/:product_id": {Summary: "Set one item quantity", Request: SetQuantityRequest{}, Response: CartResponse{}},
+	"PUT /v1/cart/:user_id/currency":          {Summary: "Set the cart currency", Request: SetCurrencyRequest{}, Response: SetCurrencyResponse{}},
+	"POST /v1/cart/:user_id/merge":            {Summary: "Merge another cart into the cart", Request: MergeCartRequest{}, Response: CartResponse{}},
+	"POST /v1/cart/:user_id/transfer":         {Summary: "Move an item to another cart", Request: TransferItemRequest{}, Response: CartResponse{}},
+	"POST /v1/cart/:user_id/reserve":          {Summary: "Reserve stock for every item", Response: ReservationResponse{}},
+	"GET /v1/cart/:user_id/line-items":        {Summary: "Get priced checkout line items", Response: LineItemsResponse{}, Query: []string{"provider"}},
+	"GET /v1/cart/:user_id/total":             {Summary: "Get the cart total at current prices", Response: CartTotalResponse{}},
+	"GET /v1/cart/:user_id/export":            {Summary: "Export the cart as JSON or CSV", Response: CartResponse{}, Query: []string{"format"}},
+	"GET /v1/cart/:user_id/raw":               {Summary: "Dump the unparsed cart hash", Response: RawCartResponse{}},
+	"POST /v1/cart/:user_id/repair":           {Summary: "Delete corrupted cart entries", Response: RepairCartResponse{}},
+	"GET /v1/carts":                           {Summary: "List users with a cart", Response: ListCartsResponse{}, Query: []string{"cursor", "count"}},
+	"GET /admin/carts/largest":                {Summary: "List the largest carts", Response: LargestCartsResponse{}, Query: []string{"limit"}},
+	"POST /admin/carts/:user_id/normalize":    {Summary: "Merge duplicate product IDs", Response: NormalizeCartResponse{}},
+	"GET /admin/carts/memory":                 {Summary: "Estimate cart memory usage", Response: CartMemoryResponse{}},
+	"GET /admin/maintenance":                  {Summary: "Get the maintenance mode", Response: MaintenanceResponse{}},
+	"PUT /admin/maintenance":                  {Summary: "Turn maintenance mode on or off", Request: MaintenanceRequest{}, Response: MaintenanceResponse{}},
+	"GET /healthz":                            {Summary: "Combined health check", Response: HealthResponse{}},
+	"GET /ready":                              {Summary: "Readiness probe", Response: HealthResponse{}},
+	"GET /live":                               {Summary: "Liveness probe", Response: HealthResponse{}},
+	"GET /startup":                            {Summary: "Startup probe"},
+	"POST /stress":                            {Summary: "Generate artificial load", Response: StressResponse{}, Query: []string{"cpu_iterations", "memory_mb", "workers"}},
+	"GET /openapi.json":                       {Summary: "This OpenAPI document"},
+}
+
+// OpenAPI handles GET /openapi.json
+// The document is built on the first request from the routes registered on router by then,
+// so it always matches the running server, and is cached afterwards
+// pprof routes are left out since they are not part of the API
+func OpenAPI(router *gin.Engine, version string) gin.HandlerFunc {
+	var (
+		once sync.Once
+		body []byte
+	)
+	return func(c *gin.Context) {
+		once.Do(func() {
+			var routes gin.RoutesInfo
+			for _, route := range router.Routes() {
+				if !strings.HasPrefix(route.Path, pprofPath) {
+					routes = append(routes, route)
+				}
+			}
+			doc := openapi.Build(openapi.Info{Title: "cart-service", Version: version}, routes, openAPIRoutes, apierror.APIError{})
+			// Only plain structs, maps and slices; marshalling cannot fail
+			body, _ = json.Marshal(doc)
+		})
+		c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+	}
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestOpenAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewCartHandler(nil, zap.NewNop(), CartHandlerConfig{})
+	router := gin.New()
+	RegisterPprof(router.Group(""), true)
+	router.POST("/v1/cart/:user_id", handler.AddItem)
+	router.GET("/v1/cart/:user_id", handler.GetCart)
+	router.GET("/healthz", func(c *gin.Context) {})
+	router.GET("/openapi.json", OpenAPI(router, "1.2.3"))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/openapi.json", nil)
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.True(t, json.Valid(w.Body.Bytes()), "The document should be valid JSON")
+
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+		Info    struct {
+			Version string `json:"version"`
+		} `json:"info"`
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+	assert.Equal(t, "1.2.3", doc.Info.Version)
+	assert.Contains(t, doc.Paths["/v1/cart/{user_id}"], "post")
+	assert.Contains(t, doc.Paths["/v1/cart/{user_id}"], "get")
+	assert.Contains(t, doc.Paths, "/healthz")
+	assert.Contains(t, doc.Paths, "/openapi.json")
+	for path := range doc.Paths {
+		assert.NotContains(t, path, "/debug/pprof", "pprof routes are not part of the API")
+	}
+	for _, schema := range []string{"AddItemRequest", "CartResponse", "HealthResponse", "APIError"} {
+		assert.Contains(t, doc.Components.Schemas, schema)
+	}
+}
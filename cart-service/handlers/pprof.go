@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http/pprof"
+
+	"github.com/gin-gonic/gin"
+)
+
+// pprofPath is where the profiling endpoints are mounted, matching the net/http/pprof defaults
+const pprofPath = "/debug/pprof"
+
+// RegisterPprof mounts the net/http/pprof handlers under /debug/pprof/ when enabled
+// Register it before router.Use adds the tracing, logging and rate limiting middleware:
+// Gin fixes a route's middleware when the route is added, so profile downloads stay out
+// of traces and access logs and are never rate limited
+// Profiles expose internals, so only enable this behind a network policy
+func RegisterPprof(router gin.IRoutes, enabled bool) {
+	if !enabled {
+		return
+	}
+
+	serve := func(c *gin.Context) {
+		switch c.Param("name") {
+		case "/cmdline":
+			pprof.Cmdline(c.Writer, c.Request)
+		case "/profile":
+			pprof.Profile(c.Writer, c.Request)
+		case "/symbol":
+			pprof.Symbol(c.Writer, c.Request)
+		case "/trace":
+			pprof.Trace(c.Writer, c.Request)
+		default:
+			// Index serves the listing for /debug/pprof/ and named profiles such as heap or goroutine
+			pprof.Index(c.Writer, c.Request)
+		}
+	}
+	router.GET(pprofPath+"/*name", serve)
+	// go tool pprof looks up symbols with POST
+	router.POST(pprofPath+"/*name", serve)
+}
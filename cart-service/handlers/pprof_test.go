@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterPprof(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	serve := func(enabled bool, path string) *httptest.ResponseRecorder {
+		router := gin.New()
+		RegisterPprof(router, enabled)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should serve the profile index when enabled", func(t *testing.T) {
+		w := serve(true, "/debug/pprof/")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "goroutine")
+	})
+
+	t.Run("should serve named profiles when enabled", func(t *testing.T) {
+		w := serve(true, "/debug/pprof/heap?debug=1")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "heap profile")
+	})
+
+	t.Run("should return 404 when disabled", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, serve(false, "/debug/pprof/").Code)
+	})
+}
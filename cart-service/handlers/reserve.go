@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"cart-service/internal/apierror"
+	"cart-service/products"
+	"cart-service/telemetry"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+)
+
+// Reservation line statuses reported by POST /v1/cart/:user_id/reserve
+const (
+	ReservationReserved      = "reserved"       // Stock is held for this line
+	ReservationFailed        = "failed"         // product-service rejected this line, or the call failed and was released
+	ReservationReleased      = "released"       // Stock was reserved, then given back after a later failure
+	ReservationReleaseFailed = "release_failed" // Stock was reserved but could not be given back
+	ReservationSkipped       = "skipped"        // Not attempted because an earlier line failed
+)
+
+// releaseTimeout bounds each compensating release, which runs even after the request is cancelled
+const releaseTimeout = 5 * time.Second
+
+// reserveKeyPrefix scopes reservation Idempotency-Keys apart from the add-item ones
+const reserveKeyPrefix = "reserve:"
+
+// StockReserver defines the product-service stock operations used at checkout
+type StockReserver interface {
+	ReserveStock(ctx context.Context, productID string, quantity int) error
+	ReleaseStock(ctx context.Context, productID string, quantity int) error
+}
+
+// ReservationHandler holds dependencies for stock reservation handlers
+type ReservationHandler struct {
+	redisClient    CartStore
+	productClient  StockReserver
+	logger         *zap.Logger
+	idempotencyTTL time.Duration
+}
+
+// ReservationLine reports the outcome for a single cart line
+type ReservationLine struct {
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ReservationResponse represents the response for POST /v1/cart/:user_id/reserve
+type ReservationResponse struct {
+	UserID  string            `json:"user_id"`
+	Success bool              `json:"success"`
+	Lines   []ReservationLine `json:"lines"`
+}
+
+// NewReservationHandler creates a new reservation handler
+// idempotencyTTL is how long a reservation's Idempotency-Key is remembered (defaultIdempotencyTTL if <= 0)
+func NewReservationHandler(redisClient CartStore, productClient StockReserver, logger *zap.Logger, idempotencyTTL time.Duration) *ReservationHandler {
+	if idempotencyTTL <= 0 {
+		idempotencyTTL = defaultIdempotencyTTL
+	}
+	return &ReservationHandler{
+		redisClient:    redisClient,
+		productClient:  productClient,
+		logger:         logger,
+		idempotencyTTL: idempotencyTTL,
+	}
+}
+
+// ReserveCart handles POST /v1/cart/:user_id/reserve
+// Reserves stock for every cart line or none of them: lines are reserved one by one,
+// and if any reservation fails the lines already reserved are released again (best effort)
+// Responds 200 when everything was reserved and 409 otherwise, with per-line results in both cases
+// An Idempotency-Key header is required so a client retrying after a timeout can't reserve twice;
+// a key already used for a reservation that may still hold stock is answered with 409
+func (h *ReservationHandler) ReserveCart(c *gin.Context) {
+	ctx := c.Request.Context()
+	tracer := otel.Tracer("cart-service")
+	ctx, span := tracer.Start(ctx, "handler.ReserveCart")
+	defer span.End()
+
+	userID := c.Param("user_id")
+	if !validateID(userID) {
+		span.SetStatus(codes.Error, "Invalid user_id")
+		apierror.RespondError(c, http.StatusBadRequest, CodeInvalidUserID, "user_id "+idRuleMessage)
+		return
+	}
+
+	span.SetAttributes(attribute.String("user_id", userID))
+
+	requestKey := c.GetHeader(IdempotencyKeyHeader)
+	if requestKey == "" || len(requestKey) > maxIdempotencyKeyLength {
+		span.SetStatus(codes.Error, "Invalid Idempotency-Key")
+		apierror.RespondError(c, http.StatusBadRequest, CodeInvalidIdempotencyKey, fmt.Sprintf("%s is required and must be at most %d characters", IdempotencyKeyHeader, maxIdempotencyKeyLength))
+		return
+	}
+	requestKey = reserveKeyPrefix + requestKey
+
+	// Carry user_id to product-service in the baggage header
+	ctx = telemetry.ContextWithUserID(ctx, userID)
+
+	claimed, err := h.redisClient.ClaimIdempotencyKey(ctx, userID, requestKey, h.idempotencyTTL)
+	if err != nil {
+		span.SetStatus(codes.Error, "Failed to check idempotency key")
+		span.RecordError(err)
+		apierror.RespondError(c, http.StatusInternalServerError, CodeRedisUnavailable, "Failed to reserve cart")
+		return
+	}
+
+	span.SetAttributes(attribute.Bool("idempotent_replay", !claimed))
+	if !claimed {
+		h.logger.Info("Duplicate reservation request ignored", zap.String("user_id", userID))
+		c.Header(idempotentReplayedHeader, "true")
+		span.SetStatus(codes.Error, "Duplicate reservation")
+		apierror.RespondError(c, http.StatusConflict, CodeDuplicateReservation, "A reservation with this Idempotency-Key was already made")
+		return
+	}
+
+	items, err := h.redisClient.GetCart(ctx, userID)
+	if err != nil {
+		// Nothing was reserved, so let the client retry with the same key
+		h.redisClient.ReleaseIdempotencyKey(ctx, userID, requestKey)
+		span.SetStatus(codes.Error, "Failed to get cart")
+		span.RecordError(err)
+		h.logger.Error("Failed to get cart",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		apierror.RespondError(c, http.StatusInternalServerError, CodeRedisUnavailable, "Failed to retrieve cart")
+		return
+	}
+
+	if len(items) == 0 {
+		h.redisClient.ReleaseIdempotencyKey(ctx, userID, requestKey)
+		span.SetStatus(codes.Error, "Cart is empty")
+		apierror.RespondError(c, http.StatusBadRequest, CodeCartEmpty, "Cart is empty")
+		return
+	}
+
+	// Reserve in a stable order so retries hit product-service the same way
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].ProductID < items[j].ProductID
+	})
+
+	lines := make([]ReservationLine, len(items))
+	failedAt := -1
+	var failedErr error
+	for i, item := range items {
+		lines[i] = ReservationLine{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+		}
+		if failedAt >= 0 {
+			lines[i].Status = ReservationSkipped
+			continue
+		}
+
+		if err := h.productClient.ReserveStock(ctx, item.ProductID, item.Quantity); err != nil {
+			lines[i].Status = ReservationFailed
+			lines[i].Error = reservationError(err)
+			failedAt = i
+			failedErr = err
+			h.logger.Warn("Stock reservation failed",
+				zap.String("user_id", userID),
+				zap.String("product_id", item.ProductID),
+				zap.Int("quantity", item.Quantity),
+				zap.Error(err),
+			)
+			continue
+		}
+		lines[i].Status = ReservationReserved
+	}
+
+	if failedAt < 0 {
+		span.SetAttributes(attribute.Int("reserved_lines", len(lines)))
+		span.SetStatus(codes.Ok, "Cart reserved")
+		h.logger.Info("Cart stock reserved",
+			zap.String("user_id", userID),
+			zap.Int("lines", len(lines)),
+		)
+		c.JSON(http.StatusOK, ReservationResponse{
+			UserID:  userID,
+			Success: true,
+			Lines:   lines,
+		})
+		return
+	}
+
+	// Compensate: give back everything reserved before the failure
+	// The line may have failed because the client went away or its deadline passed, so the
+	// releases are detached from the request's cancellation or the stock would stay held
+	// Unless product-service definitely refused the failed line (404/409), it may have committed
+	// the reservation before the call failed (a timeout or a 5xx), so that line is released too
+	releaseCtx := context.WithoutCancel(ctx)
+	releaseUpTo := failedAt
+	if !definiteReservationFailure(failedErr) {
+		releaseUpTo = failedAt + 1
+	}
+	allReleased := true
+	for i := 0; i < releaseUpTo; i++ {
+		if err := h.release(releaseCtx, lines[i].ProductID, lines[i].Quantity); err != nil {
+			allReleased = false
+			lines[i].Status = ReservationReleaseFailed
+			lines[i].Error = reservationError(err)
+			span.RecordError(err)
+			h.logger.Error("Failed to release reserved stock",
+				zap.String("user_id", userID),
+				zap.String("product_id", lines[i].ProductID),
+				zap.Int("quantity", lines[i].Quantity),
+				zap.Error(err),
+			)
+			continue
+		}
+		if i < failedAt {
+			lines[i].Status = ReservationReleased
+		}
+	}
+
+	// Stock that could not be given back may still be held, so the key stays claimed and a
+	// retry can't reserve on top of it; otherwise the client may retry with the same key
+	if allReleased {
+		h.redisClient.ReleaseIdempotencyKey(releaseCtx, userID, requestKey)
+	}
+
+	span.SetAttributes(attribute.String("failed_product_id", lines[failedAt].ProductID))
+	span.SetStatus(codes.Error, "Cart reservation rolled back")
+
+	c.JSON(http.StatusConflict, ReservationResponse{
+		UserID:  userID,
+		Success: false,
+		Lines:   lines,
+	})
+}
+
+// release gives back quantity units of a product within releaseTimeout
+func (h *ReservationHandler) release(ctx context.Context, productID string, quantity int) error {
+	ctx, cancel := context.WithTimeout(ctx, releaseTimeout)
+	defer cancel()
+	return h.productClient.ReleaseStock(ctx, productID, quantity)
+}
+
+// definiteReservationFailure reports whether product-service rejected the reservation outright,
+// so no stock was taken; any other failure leaves it unknown whether the reservation committed
+func definiteReservationFailure(err error) bool {
+	return errors.Is(err, products.ErrInsufficientStock) || errors.Is(err, products.ErrProductNotFound)
+}
+
+// reservationError converts a reservation failure into the message reported for the line
+func reservationError(err error) string {
+	switch {
+	case errors.Is(err, products.ErrInsufficientStock):
+		return "insufficient stock"
+	case errors.Is(err, products.ErrProductNotFound):
+		return "product not found"
+	default:
+		return "product service unavailable"
+	}
+}
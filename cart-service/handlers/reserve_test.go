@@ -0,0 +1,395 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"cart-service/products"
+	"cart-service/redis"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	redisclient "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// stubProductService mimics the product-service stock endpoints with an in-memory stock table
+type stubProductService struct {
+	mu    sync.Mutex
+	stock map[string]int
+	calls []string
+	// failAfterCommit lists products whose reservation is applied but answered with a 500
+	failAfterCommit map[string]bool
+}
+
+func (s *stubProductService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Paths look like /products/{id}/{reserve|release}
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if r.Method != http.MethodPost || len(parts) != 3 || parts[0] != "products" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	productID, action := parts[1], parts[2]
+
+	var req struct {
+		Quantity int `json:"quantity"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, fmt.Sprintf("%s %s %d", action, productID, req.Quantity))
+
+	stock, ok := s.stock[productID]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	switch action {
+	case "reserve":
+		if stock < req.Quantity {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		s.stock[productID] = stock - req.Quantity
+		if s.failAfterCommit[productID] {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	case "release":
+		s.stock[productID] = stock + req.Quantity
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// cancellingReserver reserves every product except failProductID, whose reservation cancels the
+// request context, as if the client disconnected mid-checkout; releases fail on a done context
+type cancellingReserver struct {
+	cancel        context.CancelFunc
+	failProductID string
+	released      []string
+}
+
+func (r *cancellingReserver) ReserveStock(ctx context.Context, productID string, quantity int) error {
+	if productID == r.failProductID {
+		r.cancel()
+		return ctx.Err()
+	}
+	return nil
+}
+
+func (r *cancellingReserver) ReleaseStock(ctx context.Context, productID string, quantity int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.released = append(r.released, fmt.Sprintf("%s %d", productID, quantity))
+	return nil
+}
+
+// setupReservationTest creates a reservation handler backed by miniredis and a stub product-service
+func setupReservationTest(t *testing.T, stock map[string]int) (*ReservationHandler, *miniredis.Miniredis, *stubProductService, func()) {
+	mr := miniredis.NewMiniRedis()
+	if err := mr.Start(); err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+
+	rdb := redisclient.NewClient(&redisclient.Options{
+		Addr: mr.Addr(),
+	})
+
+	stub := &stubProductService{stock: stock}
+	productService := httptest.NewServer(stub)
+
+	logger := zap.NewNop()
+	handler := NewReservationHandler(
+		redis.NewClient(rdb, logger),
+		products.NewClient(productService.URL, time.Second, logger),
+		logger,
+		time.Minute,
+	)
+
+	cleanup := func() {
+		productService.Close()
+		rdb.Close()
+		mr.Close()
+	}
+
+	return handler, mr, stub, cleanup
+}
+
+func TestReserveCart(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("should reserve every line when stock is available", func(t *testing.T) {
+		handler, mr, stub, cleanup := setupReservationTest(t, map[string]int{"1": 10, "2": 5})
+		defer cleanup()
+
+		mr.HSet("cart:user-123", "1", "3")
+		mr.HSet("cart:user-123", "2", "5")
+
+		router := gin.New()
+		router.POST("/v1/cart/:user_id/reserve", handler.ReserveCart)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/v1/cart/user-123/reserve", nil)
+		req.Header.Set(IdempotencyKeyHeader, "checkout-1")
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response ReservationResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+		assert.True(t, response.Success)
+		assert.Equal(t, []ReservationLine{
+			{ProductID: "1", Quantity: 3, Status: ReservationReserved},
+			{ProductID: "2", Quantity: 5, Status: ReservationReserved},
+		}, response.Lines)
+		assert.Equal(t, map[string]int{"1": 7, "2": 0}, stub.stock)
+	})
+
+	t.Run("should release reserved lines when a later line fails", func(t *testing.T) {
+		handler, mr, stub, cleanup := setupReservationTest(t, map[string]int{"1": 10, "2": 4, "3": 2, "4": 9})
+		defer cleanup()
+
+		mr.HSet("cart:user-123", "1", "3")
+		mr.HSet("cart:user-123", "2", "4")
+		mr.HSet("cart:user-123", "3", "5") // Only 2 in stock
+		mr.HSet("cart:user-123", "4", "1")
+
+		router := gin.New()
+		router.POST("/v1/cart/:user_id/reserve", handler.ReserveCart)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/v1/cart/user-123/reserve", nil)
+		req.Header.Set(IdempotencyKeyHeader, "checkout-1")
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		var response ReservationResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+		assert.False(t, response.Success)
+		assert.Equal(t, []ReservationLine{
+			{ProductID: "1", Quantity: 3, Status: ReservationReleased},
+			{ProductID: "2", Quantity: 4, Status: ReservationReleased},
+			{ProductID: "3", Quantity: 5, Status: ReservationFailed, Error: "insufficient stock"},
+			{ProductID: "4", Quantity: 1, Status: ReservationSkipped},
+		}, response.Lines)
+
+		// Stock is back where it started and line 4 was never touched
+		assert.Equal(t, map[string]int{"1": 10, "2": 4, "3": 2, "4": 9}, stub.stock)
+		assert.Equal(t, []string{
+			"reserve 1 3",
+			"reserve 2 4",
+			"reserve 3 5",
+			"release 1 3",
+			"release 2 4",
+		}, stub.calls)
+
+		// The cart itself is left intact
+		assert.Equal(t, "5", mr.HGet("cart:user-123", "3"))
+	})
+
+	t.Run("should release reserved lines even when the request was cancelled", func(t *testing.T) {
+		handler, mr, _, cleanup := setupReservationTest(t, map[string]int{})
+		defer cleanup()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		reserver := &cancellingReserver{cancel: cancel, failProductID: "3"}
+		handler.productClient = reserver
+
+		mr.HSet("cart:user-123", "1", "2")
+		mr.HSet("cart:user-123", "2", "1")
+		mr.HSet("cart:user-123", "3", "4")
+
+		router := gin.New()
+		router.POST("/v1/cart/:user_id/reserve", handler.ReserveCart)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequestWithContext(ctx, "POST", "/v1/cart/user-123/reserve", nil)
+		req.Header.Set(IdempotencyKeyHeader, "checkout-1")
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		// The cancelled line may have been reserved before the client went away, so it is released too
+		assert.Equal(t, []string{"1 2", "2 1", "3 4"}, reserver.released)
+
+		var response ReservationResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, ReservationReleased, response.Lines[0].Status)
+		assert.Equal(t, ReservationReleased, response.Lines[1].Status)
+		assert.Equal(t, ReservationFailed, response.Lines[2].Status)
+	})
+
+	t.Run("should report unknown products", func(t *testing.T) {
+		handler, mr, _, cleanup := setupReservationTest(t, map[string]int{})
+		defer cleanup()
+
+		mr.HSet("cart:user-123", "999", "1")
+
+		router := gin.New()
+		router.POST("/v1/cart/:user_id/reserve", handler.ReserveCart)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/v1/cart/user-123/reserve", nil)
+		req.Header.Set(IdempotencyKeyHeader, "checkout-1")
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		var response ReservationResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "product not found", response.Lines[0].Error)
+	})
+
+	t.Run("should reject an empty cart", func(t *testing.T) {
+		handler, _, stub, cleanup := setupReservationTest(t, map[string]int{"1": 10})
+		defer cleanup()
+
+		router := gin.New()
+		router.POST("/v1/cart/:user_id/reserve", handler.ReserveCart)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/v1/cart/user-123/reserve", nil)
+		req.Header.Set(IdempotencyKeyHeader, "checkout-1")
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Empty(t, stub.calls)
+	})
+
+	t.Run("should release a line whose reservation failed ambiguously", func(t *testing.T) {
+		handler, mr, stub, cleanup := setupReservationTest(t, map[string]int{"1": 10, "2": 5})
+		defer cleanup()
+		stub.failAfterCommit = map[string]bool{"2": true}
+
+		mr.HSet("cart:user-123", "1", "3")
+		mr.HSet("cart:user-123", "2", "2")
+
+		router := gin.New()
+		router.POST("/v1/cart/:user_id/reserve", handler.ReserveCart)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/v1/cart/user-123/reserve", nil)
+		req.Header.Set(IdempotencyKeyHeader, "checkout-1")
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		var response ReservationResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, []ReservationLine{
+			{ProductID: "1", Quantity: 3, Status: ReservationReleased},
+			{ProductID: "2", Quantity: 2, Status: ReservationFailed, Error: "product service unavailable"},
+		}, response.Lines)
+
+		// product-service committed line 2 before answering 500, and it was given back
+		assert.Equal(t, map[string]int{"1": 10, "2": 5}, stub.stock)
+		assert.Equal(t, []string{
+			"reserve 1 3",
+			"reserve 2 2",
+			"release 1 3",
+			"release 2 2",
+		}, stub.calls)
+	})
+
+	t.Run("should require an idempotency key", func(t *testing.T) {
+		handler, mr, stub, cleanup := setupReservationTest(t, map[string]int{"1": 10})
+		defer cleanup()
+
+		mr.HSet("cart:user-123", "1", "3")
+
+		router := gin.New()
+		router.POST("/v1/cart/:user_id/reserve", handler.ReserveCart)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/v1/cart/user-123/reserve", nil)
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), CodeInvalidIdempotencyKey)
+		assert.Empty(t, stub.calls)
+	})
+
+	t.Run("should not reserve twice for a repeated idempotency key", func(t *testing.T) {
+		handler, mr, stub, cleanup := setupReservationTest(t, map[string]int{"1": 10})
+		defer cleanup()
+
+		mr.HSet("cart:user-123", "1", "3")
+
+		router := gin.New()
+		router.POST("/v1/cart/:user_id/reserve", handler.ReserveCart)
+
+		for i := 0; i < 2; i++ {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/v1/cart/user-123/reserve", nil)
+			req.Header.Set(IdempotencyKeyHeader, "checkout-1")
+			router.ServeHTTP(w, req)
+
+			if i == 0 {
+				assert.Equal(t, http.StatusOK, w.Code)
+				continue
+			}
+			assert.Equal(t, http.StatusConflict, w.Code)
+			assert.Equal(t, "true", w.Header().Get(idempotentReplayedHeader))
+			assert.Contains(t, w.Body.String(), CodeDuplicateReservation)
+		}
+
+		assert.Equal(t, map[string]int{"1": 7}, stub.stock)
+		assert.Equal(t, []string{"reserve 1 3"}, stub.calls)
+	})
+
+	t.Run("should allow a retry with the same key after a full rollback", func(t *testing.T) {
+		handler, mr, stub, cleanup := setupReservationTest(t, map[string]int{"1": 10, "2": 1})
+		defer cleanup()
+
+		mr.HSet("cart:user-123", "1", "3")
+		mr.HSet("cart:user-123", "2", "2") // Only 1 in stock
+
+		router := gin.New()
+		router.POST("/v1/cart/:user_id/reserve", handler.ReserveCart)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/v1/cart/user-123/reserve", nil)
+		req.Header.Set(IdempotencyKeyHeader, "checkout-1")
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusConflict, w.Code)
+
+		// Restocked; the same key may reserve now that nothing is held
+		stub.stock["2"] = 5
+
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("POST", "/v1/cart/user-123/reserve", nil)
+		req.Header.Set(IdempotencyKeyHeader, "checkout-1")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, map[string]int{"1": 7, "2": 3}, stub.stock)
+	})
+}
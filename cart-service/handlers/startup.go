@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StartupProbe gates the Kubernetes startup probe on the first dependency connection
+// The HTTP server is started with the probe as its handler before connecting, so the kubelet
+// sees 503 from /startup (instead of liveness failures) while the connection is still being retried
+type StartupProbe struct {
+	started atomic.Bool
+	handler atomic.Pointer[http.Handler]
+}
+
+// NewStartupProbe creates a startup probe in the not-started state
+func NewStartupProbe() *StartupProbe {
+	return &StartupProbe{}
+}
+
+// MarkStarted records that startup finished and hands every request from now on to handler
+func (p *StartupProbe) MarkStarted(handler http.Handler) {
+	p.handler.Store(&handler)
+	p.started.Store(true)
+}
+
+// Started reports whether MarkStarted has been called
+func (p *StartupProbe) Started() bool {
+	return p.started.Load()
+}
+
+// ServeHTTP serves requests before startup finishes: every path answers 503 until MarkStarted
+func (p *StartupProbe) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if handler := p.handler.Load(); handler != nil {
+		(*handler).ServeHTTP(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Retry-After", "1")
+	w.WriteHeader(http.StatusServiceUnavailable)
+	w.Write([]byte(`{"status":"starting","service":"cart-service"}`))
+}
+
+// Startup handles GET /startup
+// Kubernetes startup probe: 200 once the initial dependency connection succeeded, 503 before that
+func (p *StartupProbe) Startup(c *gin.Context) {
+	if !p.Started() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status":  "starting",
+			"service": "cart-service",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "started",
+		"service": "cart-service",
+	})
+}
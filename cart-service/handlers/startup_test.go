@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStartupProbe(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	probe := NewStartupProbe()
+
+	router := gin.New()
+	router.GET("/startup", probe.Startup)
+	router.GET("/healthz", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		probe.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should answer 503 on every path before startup finished", func(t *testing.T) {
+		for _, path := range []string{"/startup", "/healthz", "/v1/cart/user-123"} {
+			w := serve(path)
+			assert.Equal(t, http.StatusServiceUnavailable, w.Code, path)
+			assert.JSONEq(t, `{"status":"starting","service":"cart-service"}`, w.Body.String())
+		}
+		assert.False(t, probe.Started())
+	})
+
+	t.Run("should hand requests to the router once started", func(t *testing.T) {
+		probe.MarkStarted(router)
+		assert.True(t, probe.Started())
+
+		w := serve("/startup")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"status":"started","service":"cart-service"}`, w.Body.String())
+
+		assert.Equal(t, http.StatusOK, serve("/healthz").Code)
+		assert.Equal(t, http.StatusNotFound, serve("/missing").Code)
+	})
+}
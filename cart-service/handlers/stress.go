@@ -1,12 +1,17 @@
 package handlers
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
+	"math"
 	"net/http"
+	"runtime"
 	"strconv"
 	"time"
 
+	"cart-service/internal/apierror"
+	"cart-service/internal/stress"
+
 	"github.com/gin-gonic/gin"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -17,29 +22,72 @@ import (
 // StressHandler holds dependencies for stress test handlers
 type StressHandler struct {
 	logger *zap.Logger
+	limits StressLimits
+}
+
+// StressLimits caps what a single /stress request may ask for
+type StressLimits struct {
+	// MaxCPUIterations caps cpu_iterations
+	MaxCPUIterations int
+	// MaxWorkers caps workers, including the GOMAXPROCS default
+	MaxWorkers int
+}
+
+// maxCPUIterations is the cpu_iterations cap on a pod with at least one full core
+const maxCPUIterations = 10000
+
+// DefaultStressLimits scales the caps to cpus, the CPUs the pod may use (see stress.AvailableCPUs)
+// Below one core cpu_iterations is capped in proportion, so a 0.25-core pod allows 2500 and a
+// capped request takes about as long as 10000 does on a full core, instead of starving the probes
+// workers is capped at cpus rounded up, since extra goroutines only queue for the same quota
+func DefaultStressLimits(cpus float64) StressLimits {
+	return StressLimits{
+		MaxCPUIterations: max(1, int(maxCPUIterations*min(cpus, 1))),
+		MaxWorkers:       min(stress.MaxWorkers, max(1, int(math.Ceil(cpus)))),
+	}
+}
+
+// WithOverrides replaces each cap with the matching positive field of overrides
+// workers never goes above stress.MaxWorkers
+func (l StressLimits) WithOverrides(overrides StressLimits) StressLimits {
+	if overrides.MaxCPUIterations > 0 {
+		l.MaxCPUIterations = overrides.MaxCPUIterations
+	}
+	if overrides.MaxWorkers > 0 {
+		l.MaxWorkers = min(overrides.MaxWorkers, stress.MaxWorkers)
+	}
+	return l
 }
 
 // StressResponse represents the response from the stress test endpoint
 type StressResponse struct {
 	CPUIterations    int    `json:"cpu_iterations"`
 	MemoryMB         int    `json:"memory_mb"`
+	Workers          int    `json:"workers"`
 	PrimesCalculated int    `json:"primes_calculated"`
 	ComputationTime  string `json:"computation_time"`
+	GCCycles         uint32 `json:"gc_cycles"`
+	GCPauseTotalNs   uint64 `json:"gc_pause_total_ns"`
 	Message          string `json:"message"`
 }
 
-// NewStressHandler creates a new stress handler
-func NewStressHandler(logger *zap.Logger) *StressHandler {
+// statusClientClosedRequest is nginx's non-standard 499 for a client that closed the request
+const statusClientClosedRequest = 499
+
+// NewStressHandler creates a new stress handler that enforces limits
+func NewStressHandler(logger *zap.Logger, limits StressLimits) *StressHandler {
 	return &StressHandler{
 		logger: logger,
+		limits: limits,
 	}
 }
 
 // StressTest handles POST /stress
 // Artificial CPU/Memory load generator for performance profiling and HPA testing
 // Query parameters:
-// - cpu_iterations: Number of iterations for prime calculation (default: 1000)
+// - cpu_iterations: Number of iterations for prime calculation (default: 1000, max: MaxCPUIterations)
 // - memory_mb: Amount of memory to allocate in MB (default: 100)
+// - workers: Goroutines the cpu_iterations are split across (default: GOMAXPROCS, max: MaxWorkers)
 func (h *StressHandler) StressTest(c *gin.Context) {
 	ctx := c.Request.Context()
 	tracer := otel.Tracer("cart-service")
@@ -51,67 +99,112 @@ func (h *StressHandler) StressTest(c *gin.Context) {
 	memoryMB, _ := strconv.Atoi(c.DefaultQuery("memory_mb", "100"))
 
 	// Validate parameters
-	if cpuIterations < 0 || cpuIterations > 10000 {
+	if cpuIterations < 0 || cpuIterations > h.limits.MaxCPUIterations {
 		span.SetStatus(codes.Error, "Invalid cpu_iterations")
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid cpu_iterations",
-			"message": "cpu_iterations must be between 0 and 10000",
-		})
+		apierror.RespondError(c, http.StatusBadRequest, apierror.CodeInvalidRequest,
+			fmt.Sprintf("cpu_iterations must be between 0 and %d", h.limits.MaxCPUIterations))
 		return
 	}
 
-	if memoryMB < 0 || memoryMB > 1000 {
+	if memoryMB < 0 || memoryMB > stress.MaxMemoryMB {
 		span.SetStatus(codes.Error, "Invalid memory_mb")
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid memory_mb",
-			"message": "memory_mb must be between 0 and 1000",
-		})
+		apierror.RespondError(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "memory_mb must be between 0 and 1000")
 		return
 	}
 
+	workers := min(stress.DefaultWorkers(), h.limits.MaxWorkers)
+	if workersStr, ok := c.GetQuery("workers"); ok {
+		var err error
+		workers, err = strconv.Atoi(workersStr)
+		if err != nil || workers < 1 || workers > h.limits.MaxWorkers {
+			span.SetStatus(codes.Error, "Invalid workers")
+			apierror.RespondError(c, http.StatusBadRequest, apierror.CodeInvalidRequest,
+				fmt.Sprintf("workers must be between 1 and %d", h.limits.MaxWorkers))
+			return
+		}
+	}
+
 	span.SetAttributes(
 		attribute.Int("cpu_iterations", cpuIterations),
 		attribute.Int("memory_mb", memoryMB),
+		attribute.Int("workers", workers),
 	)
 
 	h.logger.Info("Starting stress test",
 		zap.Int("cpu_iterations", cpuIterations),
 		zap.Int("memory_mb", memoryMB),
+		zap.Int("workers", workers),
 	)
 
+	// Snapshot GC counters so the GC impact of this request can be reported
+	// ReadMemStats briefly stops the world, so it is only called before and after the work
+	var memBefore, memAfter runtime.MemStats
+	runtime.ReadMemStats(&memBefore)
+
 	startTime := time.Now()
 
-	// CPU Stress: Calculate prime numbers
+	// CPU Stress: Calculate prime numbers, with the iterations split across workers so
+	// the load lands on several cores; workers stop early if the client disconnects
 	primesFound := 0
 	if cpuIterations > 0 {
-		primesFound = calculatePrimes(cpuIterations)
+		found := make([]int, workers)
+		stress.Parallel(ctx, workers, func(ctx context.Context, worker int) {
+			found[worker] = calculatePrimes(ctx, stress.Share(cpuIterations, workers, worker))
+		})
+		for _, primes := range found {
+			primesFound = max(primesFound, primes)
+		}
+	}
+
+	// The client went away mid-run: skip the memory stress and record a 499
+	// The status only reaches the access log and trace, since nobody reads the response
+	if ctx.Err() != nil {
+		span.SetAttributes(attribute.Int64("duration_ms", time.Since(startTime).Milliseconds()))
+		span.SetStatus(codes.Error, "Client disconnected")
+		h.logger.Info("Stress test aborted: client disconnected",
+			zap.Duration("duration", time.Since(startTime)),
+		)
+		c.AbortWithStatus(statusClientClosedRequest)
+		return
 	}
 
 	// Memory Stress: Allocate and populate byte slices
 	if memoryMB > 0 {
-		allocateMemory(memoryMB)
+		stress.AllocateMemory(memoryMB)
 	}
 
 	duration := time.Since(startTime)
 
+	runtime.ReadMemStats(&memAfter)
+	gcCycles := memAfter.NumGC - memBefore.NumGC
+	gcPauseTotalNs := memAfter.PauseTotalNs - memBefore.PauseTotalNs
+
 	span.SetAttributes(
 		attribute.Int("primes_calculated", primesFound),
 		attribute.Int64("duration_ms", duration.Milliseconds()),
+		attribute.Int64("gc.cycles", int64(gcCycles)),
+		attribute.Int64("gc.pause_total_ns", int64(gcPauseTotalNs)),
 	)
 	span.SetStatus(codes.Ok, "Stress test completed")
 
 	h.logger.Info("Stress test completed",
 		zap.Int("cpu_iterations", cpuIterations),
 		zap.Int("memory_mb", memoryMB),
+		zap.Int("workers", workers),
 		zap.Int("primes_calculated", primesFound),
 		zap.Duration("duration", duration),
+		zap.Uint32("gc_cycles", gcCycles),
+		zap.Duration("gc_pause_total", time.Duration(gcPauseTotalNs)),
 	)
 
 	response := StressResponse{
 		CPUIterations:    cpuIterations,
 		MemoryMB:         memoryMB,
+		Workers:          workers,
 		PrimesCalculated: primesFound,
 		ComputationTime:  duration.String(),
+		GCCycles:         gcCycles,
+		GCPauseTotalNs:   gcPauseTotalNs,
 		Message:          "Stress test completed successfully",
 	}
 
@@ -120,11 +213,12 @@ func (h *StressHandler) StressTest(c *gin.Context) {
 
 // calculatePrimes performs CPU-intensive prime number calculation
 // Uses trial division algorithm to find all primes up to maxNum over multiple iterations
-func calculatePrimes(iterations int) int {
+// Stops between iterations once ctx is done
+func calculatePrimes(ctx context.Context, iterations int) int {
 	const maxNum = 10000
 	totalPrimes := 0
 
-	for i := 0; i < iterations; i++ {
+	for i := 0; i < iterations && ctx.Err() == nil; i++ {
 		primeCount := 0
 		for num := 2; num <= maxNum; num++ {
 			if isPrime(num) {
@@ -155,43 +249,3 @@ func isPrime(n int) bool {
 	}
 	return true
 }
-
-// allocateMemory allocates large byte slices to stress memory
-// Also performs JSON marshalling to add CPU overhead
-func allocateMemory(sizeMB int) {
-	// Allocate byte slices
-	// Each chunk is 1MB
-	chunks := make([][]byte, sizeMB)
-	for i := 0; i < sizeMB; i++ {
-		// Allocate 1MB chunk
-		chunk := make([]byte, 1024*1024)
-
-		// Fill with pseudo-random data to prevent optimization
-		for j := range chunk {
-			chunk[j] = byte((i + j) % 256)
-		}
-
-		chunks[i] = chunk
-	}
-
-	// Perform heavy JSON marshalling to add CPU load
-	// This prevents the compiler from optimizing away the memory allocation
-	largeObject := make(map[string]interface{})
-	largeObject["chunks_count"] = sizeMB
-	largeObject["timestamp"] = time.Now().Unix()
-	largeObject["data"] = make([]map[string]int, 100)
-
-	for i := 0; i < 100; i++ {
-		largeObject["data"].([]map[string]int)[i] = map[string]int{
-			"index": i,
-			"value": i * 1000,
-		}
-	}
-
-	// Marshal to JSON (CPU-intensive operation)
-	_, _ = json.Marshal(largeObject)
-
-	// Keep chunks alive until this function returns
-	// This ensures memory stays allocated for the duration of the test
-	_ = fmt.Sprintf("%d chunks allocated", len(chunks))
-}
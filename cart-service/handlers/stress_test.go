@@ -1,10 +1,13 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"runtime"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -14,7 +17,7 @@ import (
 func TestStressTest(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	logger := zap.NewNop()
-	handler := NewStressHandler(logger)
+	handler := NewStressHandler(logger, StressLimits{MaxCPUIterations: 10000, MaxWorkers: 64})
 
 	t.Run("should handle default parameters", func(t *testing.T) {
 		router := gin.New()
@@ -97,6 +100,138 @@ func TestStressTest(t *testing.T) {
 		assert.Equal(t, 0, response.MemoryMB)
 		assert.Equal(t, 0, response.PrimesCalculated)
 	})
+
+	t.Run("should split the work across workers", func(t *testing.T) {
+		router := gin.New()
+		router.POST("/stress", handler.StressTest)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/stress?cpu_iterations=10&memory_mb=0&workers=4", nil)
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response StressResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+
+		assert.Equal(t, 4, response.Workers)
+		// 1229 primes below 10000, whichever worker reports them
+		assert.Equal(t, 1229, response.PrimesCalculated)
+	})
+
+	t.Run("should default workers to GOMAXPROCS", func(t *testing.T) {
+		router := gin.New()
+		router.POST("/stress", handler.StressTest)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/stress?cpu_iterations=1&memory_mb=0", nil)
+
+		router.ServeHTTP(w, req)
+
+		var response StressResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+
+		assert.Equal(t, min(runtime.GOMAXPROCS(0), 64), response.Workers)
+	})
+
+	t.Run("should reject invalid workers", func(t *testing.T) {
+		for _, workers := range []string{"0", "65", "many"} {
+			router := gin.New()
+			router.POST("/stress", handler.StressTest)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/stress?workers="+workers, nil)
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, "workers %q", workers)
+		}
+	})
+
+	t.Run("should stop workers when the client disconnects", func(t *testing.T) {
+		router := gin.New()
+		router.POST("/stress", handler.StressTest)
+
+		// Cancel mid-request, as when the client goes away
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequestWithContext(ctx, "POST", "/stress?cpu_iterations=10000&memory_mb=1000&workers=2", nil)
+
+		start := time.Now()
+		router.ServeHTTP(w, req)
+
+		assert.Less(t, time.Since(start), time.Second)
+		assert.Equal(t, statusClientClosedRequest, w.Code)
+	})
+
+	t.Run("should report GC impact", func(t *testing.T) {
+		router := gin.New()
+		router.POST("/stress", handler.StressTest)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/stress?cpu_iterations=10&memory_mb=50", nil)
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		// Decode generically to verify the fields are present and numeric
+		var raw map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &raw)
+		assert.NoError(t, err)
+
+		for _, field := range []string{"gc_cycles", "gc_pause_total_ns"} {
+			value, ok := raw[field]
+			assert.True(t, ok, "%s should be present", field)
+			number, isNumber := value.(float64)
+			assert.True(t, isNumber, "%s should be numeric", field)
+			assert.GreaterOrEqual(t, number, 0.0)
+		}
+	})
+}
+
+func TestStressLimits(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("should scale the caps to the available CPUs", func(t *testing.T) {
+		assert.Equal(t, StressLimits{MaxCPUIterations: 2500, MaxWorkers: 1}, DefaultStressLimits(0.25))
+		assert.Equal(t, StressLimits{MaxCPUIterations: 10000, MaxWorkers: 2}, DefaultStressLimits(1.5))
+		assert.Equal(t, StressLimits{MaxCPUIterations: 10000, MaxWorkers: 64}, DefaultStressLimits(128))
+	})
+
+	t.Run("should apply positive overrides only", func(t *testing.T) {
+		defaults := DefaultStressLimits(0.25)
+
+		assert.Equal(t, defaults, defaults.WithOverrides(StressLimits{}))
+		assert.Equal(t, StressLimits{MaxCPUIterations: 8000, MaxWorkers: 1}, defaults.WithOverrides(StressLimits{MaxCPUIterations: 8000, MaxWorkers: -1}))
+		assert.Equal(t, 64, defaults.WithOverrides(StressLimits{MaxWorkers: 1000}).MaxWorkers)
+	})
+
+	t.Run("should enforce the effective caps and report them", func(t *testing.T) {
+		limits := DefaultStressLimits(0.25).WithOverrides(StressLimits{MaxCPUIterations: 500, MaxWorkers: 2})
+		router := gin.New()
+		router.POST("/stress", NewStressHandler(zap.NewNop(), limits).StressTest)
+
+		post := func(query string) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/stress?memory_mb=0&"+query, nil)
+			router.ServeHTTP(w, req)
+			return w
+		}
+
+		assert.Equal(t, http.StatusOK, post("cpu_iterations=500&workers=2").Code)
+
+		w := post("cpu_iterations=501")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "cpu_iterations must be between 0 and 500")
+
+		w = post("cpu_iterations=1&workers=3")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "workers must be between 1 and 2")
+	})
 }
 
 func TestIsPrime(t *testing.T) {
@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"cart-service/internal/apierror"
+	"cart-service/products"
+	"cart-service/telemetry"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+)
+
+// maxPriceLookups is how many product lookups GetCartTotal runs at once
+const maxPriceLookups = 8
+
+// TotalHandler holds dependencies for the cart total handler
+type TotalHandler struct {
+	redisClient CartStore
+	catalog     ProductCatalog
+	logger      *zap.Logger
+	currency    string
+}
+
+// CartTotalLine is one priced cart line in a CartTotalResponse
+type CartTotalLine struct {
+	ProductID string  `json:"product_id"`
+	Name      string  `json:"name"`
+	Quantity  int     `json:"quantity"`
+	UnitPrice float64 `json:"unit_price"`
+	LineTotal float64 `json:"line_total"`
+}
+
+// CartTotalResponse represents the response for GET /v1/cart/:user_id/total
+// Incomplete is set when products in the cart no longer exist; they are listed in
+// MissingProducts and left out of Items and Total
+type CartTotalResponse struct {
+	UserID          string          `json:"user_id"`
+	Currency        string          `json:"currency"`
+	Items           []CartTotalLine `json:"items"`
+	Total           float64         `json:"total"`
+	Incomplete      bool            `json:"incomplete"`
+	MissingProducts []string        `json:"missing_products"`
+}
+
+// NewTotalHandler creates a new cart total handler
+// currency is the ISO 4217 code (e.g. "usd") that product prices are expressed in
+func NewTotalHandler(redisClient CartStore, catalog ProductCatalog, logger *zap.Logger, currency string) *TotalHandler {
+	return &TotalHandler{
+		redisClient: redisClient,
+		catalog:     catalog,
+		logger:      logger,
+		currency:    strings.ToLower(currency),
+	}
+}
+
+// GetCartTotal handles GET /v1/cart/:user_id/total
+// Prices every cart line with the product's current price from product-service and returns
+// the line totals and the grand total, so clients don't have to join the two themselves
+// Products are looked up concurrently; a product that no longer exists is skipped and reported
+// in missing_products instead of failing the whole request. An empty cart totals 0
+func (h *TotalHandler) GetCartTotal(c *gin.Context) {
+	ctx := c.Request.Context()
+	tracer := otel.Tracer("cart-service")
+	ctx, span := tracer.Start(ctx, "handler.GetCartTotal")
+	defer span.End()
+
+	userID := c.Param("user_id")
+	if !validateID(userID) {
+		span.SetStatus(codes.Error, "Invalid user_id")
+		apierror.RespondError(c, http.StatusBadRequest, CodeInvalidUserID, "user_id "+idRuleMessage)
+		return
+	}
+
+	span.SetAttributes(attribute.String("user_id", userID))
+
+	// Carry user_id to product-service in the baggage header
+	ctx = telemetry.ContextWithUserID(ctx, userID)
+
+	items, err := h.redisClient.GetCart(ctx, userID)
+	if err != nil {
+		span.SetStatus(codes.Error, "Failed to get cart")
+		span.RecordError(err)
+		h.logger.Error("Failed to get cart",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		apierror.RespondError(c, http.StatusInternalServerError, CodeRedisUnavailable, "Failed to retrieve cart")
+		return
+	}
+
+	// Stable order so repeated requests produce identical responses
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].ProductID < items[j].ProductID
+	})
+
+	productIDs := make([]string, len(items))
+	for i, item := range items {
+		productIDs[i] = item.ProductID
+	}
+	found, missing, err := h.lookupProducts(ctx, productIDs)
+	if err != nil {
+		span.SetStatus(codes.Error, "Failed to price cart")
+		span.RecordError(err)
+		h.logger.Error("Failed to price cart",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		apierror.RespondError(c, http.StatusBadGateway, CodeProductServiceUnavailable, "Product service unavailable")
+		return
+	}
+
+	lines := make([]CartTotalLine, 0, len(items))
+	var totalCents int64
+	for _, item := range items {
+		product, ok := found[item.ProductID]
+		if !ok {
+			continue
+		}
+		unitCents := toCents(product.Price)
+		lineCents := unitCents * int64(item.Quantity)
+		lines = append(lines, CartTotalLine{
+			ProductID: item.ProductID,
+			Name:      product.Name,
+			Quantity:  item.Quantity,
+			UnitPrice: float64(unitCents) / 100,
+			LineTotal: float64(lineCents) / 100,
+		})
+		totalCents += lineCents
+	}
+
+	if len(missing) > 0 {
+		h.logger.Warn("Cart holds products that no longer exist",
+			zap.String("user_id", userID),
+			zap.Strings("product_ids", missing),
+		)
+	}
+
+	span.SetAttributes(
+		attribute.Int("line_count", len(lines)),
+		attribute.Int("missing_count", len(missing)),
+		attribute.Int64("amount_total", totalCents),
+	)
+	span.SetStatus(codes.Ok, "Cart total computed")
+
+	c.JSON(http.StatusOK, CartTotalResponse{
+		UserID:          userID,
+		Currency:        h.currency,
+		Items:           lines,
+		Total:           float64(totalCents) / 100,
+		Incomplete:      len(missing) > 0,
+		MissingProducts: missing,
+	})
+}
+
+// lookupProducts fetches productIDs from the catalog, at most maxPriceLookups at a time
+// Products the catalog doesn't know are returned in missing, in productIDs order; any other
+// lookup error fails the whole call
+func (h *TotalHandler) lookupProducts(ctx context.Context, productIDs []string) (map[string]*products.Product, []string, error) {
+	results := make([]*products.Product, len(productIDs))
+	errs := make([]error, len(productIDs))
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, maxPriceLookups)
+	for i, productID := range productIDs {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, productID string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			results[i], errs[i] = h.catalog.GetProduct(ctx, productID)
+		}(i, productID)
+	}
+	wg.Wait()
+
+	found := make(map[string]*products.Product, len(productIDs))
+	missing := []string{}
+	for i, productID := range productIDs {
+		switch {
+		case errs[i] == nil:
+			found[productID] = results[i]
+		case errors.Is(errs[i], products.ErrProductNotFound):
+			missing = append(missing, productID)
+		default:
+			return nil, nil, errs[i]
+		}
+	}
+	return found, missing, nil
+}
+
+// CachedCatalog is a ProductCatalog that remembers products for a short TTL, so pricing a cart
+// repeatedly, or many carts holding the same products, doesn't call product-service every time
+// Only found products are cached; lookup errors, including not found, always go through
+type CachedCatalog struct {
+	catalog ProductCatalog
+	ttl     time.Duration
+	now     func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cachedProduct
+}
+
+type cachedProduct struct {
+	product   *products.Product
+	expiresAt time.Time
+}
+
+// NewCachedCatalog wraps catalog with a cache of the given TTL; a TTL of 0 disables caching
+func NewCachedCatalog(catalog ProductCatalog, ttl time.Duration) *CachedCatalog {
+	return &CachedCatalog{
+		catalog: catalog,
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[string]cachedProduct),
+	}
+}
+
+// GetProduct returns the cached product, or fetches and caches it when missing or expired
+func (c *CachedCatalog) GetProduct(ctx context.Context, productID string) (*products.Product, error) {
+	if c.ttl <= 0 {
+		return c.catalog.GetProduct(ctx, productID)
+	}
+
+	now := c.now()
+	c.mu.Lock()
+	entry, ok := c.entries[productID]
+	c.mu.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.product, nil
+	}
+
+	product, err := c.catalog.GetProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	// Sweep expired entries as new ones arrive, so products that left the catalog don't pile up
+	for id, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(c.entries, id)
+		}
+	}
+	c.entries[productID] = cachedProduct{product: product, expiresAt: now.Add(c.ttl)}
+	c.mu.Unlock()
+	return product, nil
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"cart-service/products"
+	"cart-service/redis"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	redisclient "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeCatalog serves products from a map and counts lookups per product
+// err, when set, is returned for every product
+type fakeCatalog struct {
+	products map[string]products.Product
+	err      error
+
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (f *fakeCatalog) GetProduct(ctx context.Context, productID string) (*products.Product, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.calls == nil {
+		f.calls = make(map[string]int)
+	}
+	f.calls[productID]++
+
+	if f.err != nil {
+		return nil, f.err
+	}
+	product, ok := f.products[productID]
+	if !ok {
+		return nil, fmt.Errorf("failed to get product %s: %w", productID, products.ErrProductNotFound)
+	}
+	return &product, nil
+}
+
+// setupTotalTest creates a router serving GET /v1/cart/:user_id/total backed by miniredis and catalog
+func setupTotalTest(t *testing.T, catalog ProductCatalog) (*gin.Engine, *miniredis.Miniredis) {
+	gin.SetMode(gin.TestMode)
+
+	mr := miniredis.RunT(t)
+	rdb := redisclient.NewClient(&redisclient.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+
+	logger := zap.NewNop()
+	handler := NewTotalHandler(redis.NewClient(rdb, logger), catalog, logger, "USD")
+
+	router := gin.New()
+	router.GET("/v1/cart/:user_id/total", handler.GetCartTotal)
+	return router, mr
+}
+
+func getTotal(router *gin.Engine, userID string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v1/cart/"+userID+"/total", nil)
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestGetCartTotal(t *testing.T) {
+	catalog := map[string]products.Product{
+		"1": {ID: 1, Name: "Laptop", Price: 999.99},
+		"2": {ID: 2, Name: "Cotton T-Shirt", Price: 19.99},
+	}
+
+	t.Run("should price every line and sum the total", func(t *testing.T) {
+		router, mr := setupTotalTest(t, &fakeCatalog{products: catalog})
+		mr.HSet("cart:user-123", "1", "1")
+		mr.HSet("cart:user-123", "2", "3")
+
+		w := getTotal(router, "user-123")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var response CartTotalResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, CartTotalResponse{
+			UserID:   "user-123",
+			Currency: "usd",
+			Items: []CartTotalLine{
+				{ProductID: "1", Name: "Laptop", Quantity: 1, UnitPrice: 999.99, LineTotal: 999.99},
+				{ProductID: "2", Name: "Cotton T-Shirt", Quantity: 3, UnitPrice: 19.99, LineTotal: 59.97},
+			},
+			Total:           1059.96,
+			MissingProducts: []string{},
+		}, response)
+	})
+
+	t.Run("should skip products that no longer exist and flag the total", func(t *testing.T) {
+		router, mr := setupTotalTest(t, &fakeCatalog{products: catalog})
+		mr.HSet("cart:user-123", "2", "2")
+		mr.HSet("cart:user-123", "99", "1")
+
+		w := getTotal(router, "user-123")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var response CartTotalResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Items, 1)
+		assert.Equal(t, "2", response.Items[0].ProductID)
+		assert.Equal(t, 39.98, response.Total)
+		assert.True(t, response.Incomplete)
+		assert.Equal(t, []string{"99"}, response.MissingProducts)
+	})
+
+	t.Run("should total an empty cart as 0", func(t *testing.T) {
+		router, _ := setupTotalTest(t, &fakeCatalog{products: catalog})
+
+		w := getTotal(router, "user-123")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"user_id":"user-123","currency":"usd","items":[],"total":0,"incomplete":false,"missing_products":[]}`, w.Body.String())
+	})
+
+	t.Run("should return 502 when product-service fails", func(t *testing.T) {
+		router, mr := setupTotalTest(t, &fakeCatalog{err: errors.New("connection refused")})
+		mr.HSet("cart:user-123", "1", "1")
+
+		w := getTotal(router, "user-123")
+
+		assert.Equal(t, http.StatusBadGateway, w.Code)
+		assert.Contains(t, w.Body.String(), CodeProductServiceUnavailable)
+	})
+
+	t.Run("should return 400 for an invalid user_id", func(t *testing.T) {
+		router, _ := setupTotalTest(t, &fakeCatalog{products: catalog})
+
+		w := getTotal(router, "user%20123")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestCachedCatalog(t *testing.T) {
+	ctx := context.Background()
+	catalog := map[string]products.Product{"1": {ID: 1, Name: "Laptop", Price: 999.99}}
+
+	t.Run("should serve repeated lookups from the cache until the TTL passes", func(t *testing.T) {
+		fake := &fakeCatalog{products: catalog}
+		cached := NewCachedCatalog(fake, time.Minute)
+		now := time.Now()
+		cached.now = func() time.Time { return now }
+
+		for i := 0; i < 3; i++ {
+			product, err := cached.GetProduct(ctx, "1")
+			require.NoError(t, err)
+			assert.Equal(t, 999.99, product.Price)
+		}
+		assert.Equal(t, 1, fake.calls["1"])
+
+		now = now.Add(time.Minute)
+		_, err := cached.GetProduct(ctx, "1")
+		require.NoError(t, err)
+		assert.Equal(t, 2, fake.calls["1"])
+	})
+
+	t.Run("should not cache missing products", func(t *testing.T) {
+		fake := &fakeCatalog{products: catalog}
+		cached := NewCachedCatalog(fake, time.Minute)
+
+		for i := 0; i < 2; i++ {
+			_, err := cached.GetProduct(ctx, "99")
+			assert.ErrorIs(t, err, products.ErrProductNotFound)
+		}
+		assert.Equal(t, 2, fake.calls["99"])
+	})
+
+	t.Run("should price a cart once per product across requests", func(t *testing.T) {
+		fake := &fakeCatalog{products: catalog}
+		router, mr := setupTotalTest(t, NewCachedCatalog(fake, time.Minute))
+		mr.HSet("cart:user-1", "1", "1")
+		mr.HSet("cart:user-2", "1", "2")
+
+		require.Equal(t, http.StatusOK, getTotal(router, "user-1").Code)
+		require.Equal(t, http.StatusOK, getTotal(router, "user-2").Code)
+
+		assert.Equal(t, 1, fake.calls["1"])
+	})
+}
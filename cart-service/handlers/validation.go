@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// numericProductIDMessage is reported when a non-numeric product ID is rejected
+const numericProductIDMessage = "must be a positive integer"
+
+// maxIDLength bounds user and product IDs, which end up in Redis keys and hash fields
+const maxIDLength = 128
+
+// idRuleMessage is reported when a user or product ID fails validateID
+var idRuleMessage = fmt.Sprintf("must be 1 to %d letters, digits, '_' or '-'", maxIDLength)
+
+// FieldError describes a single invalid field in a request body
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// init makes the validator report JSON field names (e.g. "quantity")
+// instead of Go struct field names (e.g. "Quantity")
+// This must run before the first request is validated because the validator caches struct metadata
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+			return name
+		})
+		// "safeid" applies validateID to request body fields carrying user or product IDs
+		_ = v.RegisterValidation("safeid", func(fl validator.FieldLevel) bool {
+			return validateID(fl.Field().String())
+		})
+	}
+}
+
+// validateID reports whether id is safe to use as a user or product ID
+// IDs are limited to [A-Za-z0-9_-] and maxIDLength bytes, which keeps the ":" key separator,
+// whitespace and control characters out of Redis keys, logs and span attributes
+func validateID(id string) bool {
+	if id == "" || len(id) > maxIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// bindingErrors translates an error returned by ShouldBindJSON into client-friendly field errors
+// Validator errors are reported per field; anything else (malformed JSON, wrong types)
+// becomes a single error describing the offending field or the body
+func bindingErrors(err error) []FieldError {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fieldErrs := make([]FieldError, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			fieldErrs = append(fieldErrs, FieldError{
+				Field:   fe.Field(),
+				Message: validationMessage(fe),
+			})
+		}
+		return fieldErrs
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return []FieldError{{
+			Field:   typeErr.Field,
+			Message: fmt.Sprintf("must be of type %s", typeErr.Type.Kind()),
+		}}
+	}
+
+	return []FieldError{{
+		Field:   "body",
+		Message: "must be valid JSON",
+	}}
+}
+
+// validationMessage maps a validator tag to a human readable message
+func validationMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "max":
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	case "safeid":
+		return idRuleMessage
+	default:
+		return fmt.Sprintf("failed %s validation", fe.Tag())
+	}
+}
+
+// validateEach validates every element of a decoded JSON array body
+// Field names are prefixed with the element index (e.g. "[1].quantity") so clients can locate the bad line
+func validateEach[T any](items []T) []FieldError {
+	var fieldErrs []FieldError
+	for i := range items {
+		err := binding.Validator.ValidateStruct(&items[i])
+		if err == nil {
+			continue
+		}
+		for _, fe := range bindingErrors(err) {
+			fieldErrs = append(fieldErrs, FieldError{
+				Field:   fmt.Sprintf("[%d].%s", i, fe.Field),
+				Message: fe.Message,
+			})
+		}
+	}
+	return fieldErrs
+}
+
+// invalidProductID reports whether productID must be rejected under the handler configuration
+// With ProductIDNumeric enabled, IDs must be canonical positive integers ("42", not "042" or "+42")
+func (h *CartHandler) invalidProductID(productID string) bool {
+	if !h.config.ProductIDNumeric {
+		return false
+	}
+	id, err := strconv.ParseInt(productID, 10, 64)
+	return err != nil || id <= 0 || strconv.FormatInt(id, 10) != productID
+}
+
+// exceedsMaxQuantity reports whether quantity is above the configured MaxItemQuantity
+func (h *CartHandler) exceedsMaxQuantity(quantity int) bool {
+	return h.config.MaxItemQuantity > 0 && quantity > h.config.MaxItemQuantity
+}
+
+// maxQuantityMessage is reported for a quantity rejected by exceedsMaxQuantity
+func (h *CartHandler) maxQuantityMessage() string {
+	return fmt.Sprintf("must be at most %d", h.config.MaxItemQuantity)
+}
@@ -0,0 +1,49 @@
+package apierror
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRespondError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	serve := func(handler gin.HandlerFunc) (*httptest.ResponseRecorder, bool) {
+		reached := false
+		router := gin.New()
+		router.GET("/", handler, func(c *gin.Context) {
+			reached = true
+		})
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		router.ServeHTTP(w, req)
+		return w, reached
+	}
+
+	t.Run("should write code and message and abort", func(t *testing.T) {
+		w, reached := serve(func(c *gin.Context) {
+			RespondError(c, http.StatusTooManyRequests, CodeRateLimited, "Too many requests")
+		})
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.JSONEq(t, `{"code":"RATE_LIMITED","message":"Too many requests"}`, w.Body.String())
+		assert.False(t, reached, "Later handlers should not run")
+	})
+
+	t.Run("should include details when given", func(t *testing.T) {
+		w, _ := serve(func(c *gin.Context) {
+			RespondErrorDetails(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid request body", []string{"quantity"})
+		})
+
+		var body APIError
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, CodeInvalidRequest, body.Code)
+		assert.Equal(t, []any{"quantity"}, body.Details)
+	})
+}
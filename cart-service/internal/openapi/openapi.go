@@ -0,0 +1,295 @@
+// Package openapi builds an OpenAPI 3.0 document from a gin router and the Go request and
+// response structs, so the published spec cannot drift from the code
+// cart-service and product-service keep identical copies; each service describes its own routes
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Document is the root of an OpenAPI 3.0 document
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+// Info identifies the API
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// PathItem maps lowercase HTTP methods to the operations of one path
+type PathItem map[string]*Operation
+
+// Operation describes one route
+type Operation struct {
+	Summary     string              `json:"summary,omitempty"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+// Parameter is a path or query parameter
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+// RequestBody is the JSON body an operation accepts
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response is one documented status of an operation
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema of a body
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components holds the named schemas operations refer to with $ref
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// Schema is the subset of JSON Schema the generator emits
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMaximum     bool               `json:"exclusiveMaximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+}
+
+// Route describes the body types of one registered route
+// Request and Response are zero values of the body types (e.g. AddItemRequest{}); nil means no body
+type Route struct {
+	Summary  string
+	Request  any
+	Response any
+	// Status is the success status; 0 means 200
+	Status int
+	// Query lists the optional query parameters
+	Query []string
+}
+
+// ErrorSchema is the component name of the error body every operation may return
+const ErrorSchema = "APIError"
+
+// Build describes every route registered on the router
+// Routes missing from described are still listed, with an untyped 200 response, so a new
+// endpoint shows up in the spec even before its types are documented
+// described is keyed by method and gin path, e.g. "POST /v1/cart/:user_id"
+func Build(info Info, routes gin.RoutesInfo, described map[string]Route, errorBody any) *Document {
+	g := &generator{schemas: make(map[string]*Schema)}
+	doc := &Document{
+		OpenAPI:    "3.0.3",
+		Info:       info,
+		Paths:      make(map[string]PathItem),
+		Components: Components{Schemas: g.schemas},
+	}
+
+	var errorResponse *Response
+	if errorBody != nil {
+		errorResponse = &Response{Description: "Error", Content: jsonContent(g.schemaFor(reflect.TypeOf(errorBody)))}
+	}
+
+	// Sorted so the generated document is stable between requests
+	sorted := append(gin.RoutesInfo(nil), routes...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Path != sorted[j].Path {
+			return sorted[i].Path < sorted[j].Path
+		}
+		return sorted[i].Method < sorted[j].Method
+	})
+
+	for _, r := range sorted {
+		route := described[r.Method+" "+r.Path]
+		path, params := convertPath(r.Path)
+
+		op := &Operation{Summary: route.Summary, Parameters: params, Responses: make(map[string]Response)}
+		for _, name := range route.Query {
+			op.Parameters = append(op.Parameters, Parameter{Name: name, In: "query", Schema: &Schema{Type: "string"}})
+		}
+		if route.Request != nil {
+			op.RequestBody = &RequestBody{Required: true, Content: jsonContent(g.schemaFor(reflect.TypeOf(route.Request)))}
+		}
+
+		status := route.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		success := Response{Description: http.StatusText(status)}
+		if route.Response != nil {
+			success.Content = jsonContent(g.schemaFor(reflect.TypeOf(route.Response)))
+		}
+		op.Responses[strconv.Itoa(status)] = success
+		if errorResponse != nil {
+			op.Responses["default"] = *errorResponse
+		}
+
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(PathItem)
+		}
+		doc.Paths[path][strings.ToLower(r.Method)] = op
+	}
+
+	return doc
+}
+
+// jsonContent wraps a schema as an application/json body
+func jsonContent(schema *Schema) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: schema}}
+}
+
+// convertPath turns gin's ":id" and "*path" segments into OpenAPI "{id}" path parameters
+func convertPath(ginPath string) (string, []Parameter) {
+	var params []Parameter
+	segments := strings.Split(ginPath, "/")
+	for i, segment := range segments {
+		if len(segment) > 1 && (segment[0] == ':' || segment[0] == '*') {
+			name := segment[1:]
+			segments[i] = "{" + name + "}"
+			params = append(params, Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// generator turns Go types into schemas, registering named structs as components
+type generator struct {
+	schemas map[string]*Schema
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaFor returns the schema of t; named structs are returned as a $ref
+func (g *generator) schemaFor(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Struct && t.Name() != "":
+		if _, ok := g.schemas[t.Name()]; !ok {
+			// Registered before the fields are walked so recursive types terminate
+			g.schemas[t.Name()] = &Schema{}
+			*g.schemas[t.Name()] = *g.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + t.Name()}
+	case t.Kind() == reflect.Struct:
+		return g.structSchema(t)
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaFor(t.Elem())}
+	default:
+		// interface{} and anything else accept any JSON value
+		return &Schema{}
+	}
+}
+
+// structSchema builds an object schema from the json and binding tags of t's exported fields
+func (g *generator) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := g.schemaFor(field.Type)
+		if applyBinding(property, field.Type, field.Tag.Get("binding")) {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = property
+	}
+	return schema
+}
+
+// applyBinding copies gin's validator rules onto the property and reports whether it is required
+// Only rules with a direct JSON Schema equivalent are mapped (required, min, max, lt, len)
+func applyBinding(property *Schema, t reflect.Type, binding string) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	isString := t.Kind() == reflect.String
+
+	required := false
+	for _, rule := range strings.Split(binding, ",") {
+		key, value, _ := strings.Cut(rule, "=")
+		if key == "required" {
+			required = true
+			continue
+		}
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil || property.Ref != "" {
+			continue
+		}
+
+		switch {
+		case isString && (key == "min" || key == "len"):
+			length := int(n)
+			property.MinLength = &length
+			if key == "len" {
+				property.MaxLength = &length
+			}
+		case isString && key == "max":
+			length := int(n)
+			property.MaxLength = &length
+		case key == "min":
+			property.Minimum = &n
+		case key == "max":
+			property.Maximum = &n
+		case key == "lt":
+			property.Maximum = &n
+			property.ExclusiveMaximum = true
+		}
+	}
+	return required
+}
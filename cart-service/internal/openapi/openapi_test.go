@@ -0,0 +1,83 @@
+package openapi
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testLine struct {
+	ProductID string `json:"product_id" binding:"required,max=64"`
+	Quantity  int    `json:"quantity" binding:"required,min=1"`
+}
+
+type testCart struct {
+	Lines     []testLine        `json:"lines"`
+	Currency  string            `json:"currency,omitempty" binding:"len=3"`
+	Labels    map[string]string `json:"labels"`
+	UpdatedAt *time.Time        `json:"updated_at"`
+	Internal  string            `json:"-"`
+	hidden    string
+}
+
+type testError struct {
+	Code string `json:"code"`
+}
+
+func TestBuild(t *testing.T) {
+	routes := gin.RoutesInfo{
+		{Method: http.MethodPost, Path: "/carts/:id"},
+		{Method: http.MethodGet, Path: "/carts/:id"},
+		{Method: http.MethodGet, Path: "/undocumented"},
+	}
+	described := map[string]Route{
+		"POST /carts/:id": {Summary: "Replace a cart", Request: testCart{}, Response: testCart{}, Status: http.StatusCreated},
+		"GET /carts/:id":  {Response: testCart{}, Query: []string{"format"}},
+	}
+
+	doc := Build(Info{Title: "test", Version: "1"}, routes, described, testError{})
+
+	t.Run("should convert gin paths and list path and query parameters", func(t *testing.T) {
+		require.Contains(t, doc.Paths, "/carts/{id}")
+		get := doc.Paths["/carts/{id}"]["get"]
+		require.NotNil(t, get)
+		require.Len(t, get.Parameters, 2)
+		assert.Equal(t, Parameter{Name: "id", In: "path", Required: true, Schema: &Schema{Type: "string"}}, get.Parameters[0])
+		assert.Equal(t, "query", get.Parameters[1].In)
+	})
+
+	t.Run("should reference named structs and document their bodies", func(t *testing.T) {
+		post := doc.Paths["/carts/{id}"]["post"]
+		require.NotNil(t, post)
+		assert.Equal(t, "#/components/schemas/testCart", post.RequestBody.Content["application/json"].Schema.Ref)
+		assert.Contains(t, post.Responses, "201")
+		assert.Equal(t, "#/components/schemas/testError", post.Responses["default"].Content["application/json"].Schema.Ref)
+	})
+
+	t.Run("should map json and binding tags onto the schema", func(t *testing.T) {
+		cart := doc.Components.Schemas["testCart"]
+		require.NotNil(t, cart)
+		assert.NotContains(t, cart.Properties, "Internal")
+		assert.NotContains(t, cart.Properties, "hidden")
+		assert.Equal(t, "array", cart.Properties["lines"].Type)
+		assert.Equal(t, "object", cart.Properties["labels"].Type)
+		assert.Equal(t, &Schema{Type: "string", Format: "date-time"}, cart.Properties["updated_at"])
+		assert.Equal(t, 3, *cart.Properties["currency"].MinLength)
+		assert.Equal(t, 3, *cart.Properties["currency"].MaxLength)
+
+		line := doc.Components.Schemas["testLine"]
+		require.NotNil(t, line)
+		assert.ElementsMatch(t, []string{"product_id", "quantity"}, line.Required)
+		assert.Equal(t, 64, *line.Properties["product_id"].MaxLength)
+		assert.Equal(t, 1.0, *line.Properties["quantity"].Minimum)
+	})
+
+	t.Run("should list routes without a description", func(t *testing.T) {
+		require.Contains(t, doc.Paths, "/undocumented")
+		assert.Contains(t, doc.Paths["/undocumented"]["get"].Responses, "200")
+	})
+}
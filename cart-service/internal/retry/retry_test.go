@@ -0,0 +1,133 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeBackoff(t *testing.T) {
+	noJitter := RetryConfig{InitialDelay: 100 * time.Millisecond, MaxDelay: 2 * time.Second}
+
+	t.Run("should double the delay up to the maximum", func(t *testing.T) {
+		expected := []time.Duration{
+			100 * time.Millisecond,
+			200 * time.Millisecond,
+			400 * time.Millisecond,
+			800 * time.Millisecond,
+			1600 * time.Millisecond,
+			2 * time.Second,
+			2 * time.Second,
+		}
+		for attempt, want := range expected {
+			assert.Equal(t, want, ComputeBackoff(attempt, noJitter), "attempt %d", attempt)
+		}
+	})
+
+	t.Run("should keep jitter within the ±JitterPct band", func(t *testing.T) {
+		config := noJitter
+		config.JitterPct = 0.1
+
+		config.Rand = func() float64 { return 0 }
+		assert.Equal(t, 180*time.Millisecond, ComputeBackoff(1, config))
+
+		config.Rand = func() float64 { return 0.5 }
+		assert.Equal(t, 200*time.Millisecond, ComputeBackoff(1, config))
+
+		config.Rand = func() float64 { return 0.75 }
+		assert.Equal(t, 2100*time.Millisecond, ComputeBackoff(10, config))
+
+		// Unseeded jitter never leaves the band either
+		config.Rand = nil
+		for i := 0; i < 100; i++ {
+			delay := ComputeBackoff(3, config)
+			assert.GreaterOrEqual(t, delay, 720*time.Millisecond)
+			assert.LessOrEqual(t, delay, 880*time.Millisecond)
+		}
+	})
+
+	t.Run("should not consult the source without jitter", func(t *testing.T) {
+		config := noJitter
+		config.Rand = func() float64 {
+			t.Fatal("Rand must not be called when JitterPct is 0")
+			return 0
+		}
+		assert.Equal(t, 400*time.Millisecond, ComputeBackoff(2, config))
+	})
+}
+
+func TestDo(t *testing.T) {
+	errDown := errors.New("connection refused")
+
+	// fastConfig retries quickly with deterministic jitter and records every backoff
+	fastConfig := func(delays *[]time.Duration) RetryConfig {
+		return RetryConfig{
+			InitialDelay: time.Millisecond,
+			MaxDelay:     4 * time.Millisecond,
+			MaxRetries:   3,
+			JitterPct:    0.1,
+			Rand:         func() float64 { return 0 },
+			OnRetry: func(attempt int, err error, delay time.Duration) {
+				*delays = append(*delays, delay)
+			},
+		}
+	}
+
+	t.Run("should retry until fn succeeds", func(t *testing.T) {
+		var delays []time.Duration
+		var attempts []int
+		err := Do(context.Background(), fastConfig(&delays), func(ctx context.Context, attempt int) error {
+			attempts = append(attempts, attempt)
+			if attempt < 2 {
+				return errDown
+			}
+			return nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, []int{0, 1, 2}, attempts)
+		assert.Equal(t, []time.Duration{900 * time.Microsecond, 1800 * time.Microsecond}, delays)
+	})
+
+	t.Run("should return the last error after MaxRetries retries", func(t *testing.T) {
+		var delays []time.Duration
+		calls := 0
+		err := Do(context.Background(), fastConfig(&delays), func(ctx context.Context, attempt int) error {
+			calls++
+			return errDown
+		})
+
+		assert.ErrorIs(t, err, errDown)
+		assert.Equal(t, 4, calls)
+		assert.Len(t, delays, 3)
+	})
+
+	t.Run("should stop on a permanent error", func(t *testing.T) {
+		var delays []time.Duration
+		calls := 0
+		err := Do(context.Background(), fastConfig(&delays), func(ctx context.Context, attempt int) error {
+			calls++
+			return Permanent(errDown)
+		})
+
+		assert.Equal(t, errDown, err)
+		assert.Equal(t, 1, calls)
+		assert.Empty(t, delays)
+	})
+
+	t.Run("should stop waiting when the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		config := RetryConfig{InitialDelay: time.Hour, MaxDelay: time.Hour, MaxRetries: 3}
+		config.OnRetry = func(int, error, time.Duration) { cancel() }
+
+		err := Do(ctx, config, func(ctx context.Context, attempt int) error {
+			return errDown
+		})
+
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
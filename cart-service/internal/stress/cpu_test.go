@@ -0,0 +1,44 @@
+package stress
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCPUMax(t *testing.T) {
+	tests := []struct {
+		content  string
+		expected float64
+		ok       bool
+	}{
+		{"25000 100000\n", 0.25, true},
+		{"200000 100000", 2, true},
+		{"max 100000\n", 0, false},
+		{"", 0, false},
+		{"abc 100000", 0, false},
+		{"25000 0", 0, false},
+	}
+
+	for _, tt := range tests {
+		quota, ok := parseCPUMax(tt.content)
+		assert.Equal(t, tt.ok, ok, "cpu.max %q", tt.content)
+		assert.Equal(t, tt.expected, quota, "cpu.max %q", tt.content)
+	}
+}
+
+func TestParseCFSQuota(t *testing.T) {
+	quota, ok := parseCFSQuota("50000\n", "100000\n")
+	assert.True(t, ok)
+	assert.Equal(t, 0.5, quota)
+
+	_, ok = parseCFSQuota("-1\n", "100000\n")
+	assert.False(t, ok, "-1 means no quota")
+}
+
+func TestAvailableCPUs(t *testing.T) {
+	cpus := AvailableCPUs()
+	assert.Greater(t, cpus, 0.0)
+	assert.LessOrEqual(t, cpus, float64(runtime.GOMAXPROCS(0)))
+}
@@ -0,0 +1,80 @@
+// Package stress holds the load generators behind the /stress endpoints
+// cart-service and product-service carry identical copies, like the middleware package
+package stress
+
+import (
+	"encoding/json"
+	"runtime"
+	"time"
+)
+
+// MaxMemoryMB is the largest allocation a single /stress request may ask for
+const MaxMemoryMB = 1000
+
+// AllocateMemory allocates sizeMB 1MB byte slices and writes every byte so the pages are
+// actually resident, then holds them until it returns; it reports the number of bytes allocated
+// Also performs JSON marshalling to add CPU overhead
+func AllocateMemory(sizeMB int) int {
+	// Allocate byte slices
+	// Each chunk is 1MB
+	chunks := make([][]byte, sizeMB)
+	allocated := 0
+	for i := 0; i < sizeMB; i++ {
+		// Allocate 1MB chunk
+		chunk := make([]byte, 1024*1024)
+
+		// Fill with pseudo-random data to prevent optimization
+		for j := range chunk {
+			chunk[j] = byte((i + j) % 256)
+		}
+
+		chunks[i] = chunk
+		allocated += len(chunk)
+	}
+
+	// Perform heavy JSON marshalling to add CPU load
+	largeObject := make(map[string]interface{})
+	largeObject["chunks_count"] = sizeMB
+	largeObject["timestamp"] = time.Now().Unix()
+	largeObject["data"] = make([]map[string]int, 100)
+
+	for i := 0; i < 100; i++ {
+		largeObject["data"].([]map[string]int)[i] = map[string]int{
+			"index": i,
+			"value": i * 1000,
+		}
+	}
+
+	// Marshal to JSON (CPU-intensive operation)
+	_, _ = json.Marshal(largeObject)
+
+	// Keep chunks alive until this function returns
+	// This ensures memory stays allocated for the duration of the test
+	runtime.KeepAlive(chunks)
+
+	return allocated
+}
+
+// pageSize is the granularity at which the kernel makes memory resident
+const pageSize = 4096
+
+// AllocateMemoryFixed allocates sizeMB 1MB byte slices like AllocateMemory, but writes one
+// constant byte per page instead of filling every byte, and skips the JSON marshalling
+// The pages are still resident, and the work depends only on sizeMB, so repeated runs cost
+// the same; used by deterministic stress runs
+func AllocateMemoryFixed(sizeMB int) int {
+	chunks := make([][]byte, sizeMB)
+	allocated := 0
+	for i := range chunks {
+		chunk := make([]byte, 1024*1024)
+		for j := 0; j < len(chunk); j += pageSize {
+			chunk[j] = 1
+		}
+		chunks[i] = chunk
+		allocated += len(chunk)
+	}
+
+	runtime.KeepAlive(chunks)
+
+	return allocated
+}
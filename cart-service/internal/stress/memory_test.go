@@ -0,0 +1,17 @@
+package stress
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllocateMemory(t *testing.T) {
+	assert.Equal(t, 0, AllocateMemory(0))
+	assert.Equal(t, 3*1024*1024, AllocateMemory(3))
+}
+
+func TestAllocateMemoryFixed(t *testing.T) {
+	assert.Equal(t, 0, AllocateMemoryFixed(0))
+	assert.Equal(t, 3*1024*1024, AllocateMemoryFixed(3))
+}
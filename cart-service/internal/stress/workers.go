@@ -0,0 +1,41 @@
+package stress
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// MaxWorkers caps ?workers= so a single request can't spawn an unbounded number of goroutines
+const MaxWorkers = 64
+
+// DefaultWorkers is one worker per CPU the Go scheduler may use, so one request can drive
+// every core the pod is allowed instead of a single one
+func DefaultWorkers() int {
+	return min(runtime.GOMAXPROCS(0), MaxWorkers)
+}
+
+// Parallel runs work on the given number of goroutines and waits for all of them to return
+// work receives its worker index and should check ctx between units of work so a client
+// disconnect stops every worker
+func Parallel(ctx context.Context, workers int, work func(ctx context.Context, worker int)) {
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			work(ctx, worker)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// Share splits total units of work evenly across workers, returning the given worker's part
+// The first total%workers workers each take one extra unit
+func Share(total, workers, worker int) int {
+	share := total / workers
+	if worker < total%workers {
+		share++
+	}
+	return share
+}
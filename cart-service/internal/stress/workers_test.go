@@ -0,0 +1,33 @@
+package stress
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParallel(t *testing.T) {
+	var ran atomic.Int32
+	seen := make([]bool, 8)
+
+	Parallel(context.Background(), 8, func(ctx context.Context, worker int) {
+		ran.Add(1)
+		seen[worker] = true
+	})
+
+	assert.Equal(t, int32(8), ran.Load())
+	assert.NotContains(t, seen, false)
+}
+
+func TestShare(t *testing.T) {
+	total := 0
+	for worker := 0; worker < 4; worker++ {
+		total += Share(10, 4, worker)
+	}
+	assert.Equal(t, 10, total)
+	assert.Equal(t, 3, Share(10, 4, 0))
+	assert.Equal(t, 2, Share(10, 4, 3))
+	assert.Equal(t, 0, Share(2, 4, 3))
+}
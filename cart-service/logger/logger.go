@@ -1,18 +1,123 @@
 package logger
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
 )
 
+// Default log file location, in the directory shared with the log-shipping sidecar
+const (
+	defaultLogDir  = "/var/log/app"
+	defaultLogFile = "cart-service.log"
+)
+
+// samplingTick is the window the sampling counts are kept for
+const samplingTick = time.Second
+
+// Config holds the configuration for the Zap logger
+type Config struct {
+	ServiceName        string
+	PodName            string
+	NodeName           string
+	Environment        string
+	Level              string // Minimum level: debug, info, warn, error (unrecognized values fall back to info)
+	MaxSizeMB          int    // Rotate the log file once it reaches this size
+	MaxBackups         int    // Number of rotated files to keep (0 keeps all)
+	MaxAgeDays         int    // Days to keep rotated files (0 keeps them regardless of age)
+	SamplingInitial    int    // Entries below warn logged per message each second before sampling (0 disables sampling)
+	SamplingThereafter int    // After SamplingInitial, log every Nth entry of that message in the second (0 drops the rest)
+	Dir                string // Directory of the log file ("" uses defaultLogDir)
+	File               string // Name of the log file inside Dir ("" uses defaultLogFile)
+	// FileRequired makes InitLogger fail when the log file cannot be written, instead of
+	// warning and logging to stdout only
+	FileRequired bool
+}
+
 // InitLogger initializes a Zap logger with production configuration
-// Logs are written to both stdout and /var/log/app/cart-service.log
+// Logs are written to both stdout and a file, /var/log/app/cart-service.log unless Dir or File is set
 // This supports both Docker logging driver capture and sidecar log shipping
-func InitLogger(serviceName, podName, nodeName, environment string) (*zap.Logger, error) {
-	// Create encoder config for JSON format
-	encoderConfig := zapcore.EncoderConfig{
+// The log file is rotated by size so long-running pods don't fill their volume
+// When the file can't be written the logger falls back to stdout with a warning, or returns an
+// error if FileRequired is set
+func InitLogger(config Config) (*zap.Logger, error) {
+	// Create JSON encoder
+	encoder := newEncoder()
+
+	// Resolve the minimum log level before building the core
+	level, levelOK := parseLevel(config.Level)
+
+	// Default to stdout only; the log file is added below when it can be opened
+	writer := zapcore.AddSync(os.Stdout)
+	var fileErr error
+	var fileErrMsg string
+
+	logDir, logPath := logFilePath(config)
+
+	// Create log directory if it doesn't exist (for local development)
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		// If we can't create the directory, only log to stdout
+		// This handles cases where we don't have permissions (e.g., in distroless)
+		fileErr = err
+		fileErrMsg = "Failed to create log directory, logging to stdout only"
+	} else if err := checkWritable(logPath); err != nil {
+		// If we can't open the file, only log to stdout
+		fileErr = err
+		fileErrMsg = "Failed to open log file, logging to stdout only"
+	} else {
+		// Rotate the file by size; lumberjack writes straight to the file without buffering,
+		// so Sync() on shutdown has nothing extra to flush for this output
+		rotatingFile := &lumberjack.Logger{
+			Filename:   logPath,
+			MaxSize:    config.MaxSizeMB,
+			MaxBackups: config.MaxBackups,
+			MaxAge:     config.MaxAgeDays,
+		}
+
+		// Create multi-writer to write to both stdout and file
+		// This allows Docker to capture logs via stdout while sidecar reads from file
+		// Stdout stays a plain (non-rotating) sync so Docker still captures everything
+		writer = zapcore.NewMultiWriteSyncer(
+			zapcore.AddSync(os.Stdout),
+			zapcore.AddSync(rotatingFile),
+		)
+	}
+
+	// Create core with the resolved outputs and level, sampling repetitive debug and info entries
+	core := newCore(encoder, writer, level, config)
+
+	// Create logger with caller information and environment-dependent stacktraces
+	// Add service metadata fields that will appear in every log entry
+	logger := zap.New(core, options(config)...).With(
+		zap.String("service", config.ServiceName),
+		zap.String("pod_name", config.PodName),
+		zap.String("node_name", config.NodeName),
+		zap.String("environment", config.Environment),
+	)
+
+	if fileErr != nil {
+		if config.FileRequired {
+			return nil, fmt.Errorf("log file %s is required but not writable: %w", logPath, fileErr)
+		}
+		logger.Warn(fileErrMsg, zap.String("log_path", logPath), zap.Error(fileErr))
+	}
+	if !levelOK {
+		logger.Warn("Unrecognized log level, defaulting to info", zap.String("log_level", config.Level))
+	}
+
+	return logger, nil
+}
+
+// newEncoder returns the JSON encoder for log entries
+// The stacktrace key is filled for entries at or above the level set by zap.AddStacktrace in options
+func newEncoder() zapcore.Encoder {
+	return zapcore.NewJSONEncoder(zapcore.EncoderConfig{
 		TimeKey:        "timestamp",
 		LevelKey:       "level",
 		NameKey:        "logger",
@@ -25,75 +130,90 @@ func InitLogger(serviceName, podName, nodeName, environment string) (*zap.Logger
 		EncodeTime:     zapcore.ISO8601TimeEncoder,
 		EncodeDuration: zapcore.SecondsDurationEncoder,
 		EncodeCaller:   zapcore.ShortCallerEncoder,
+	})
+}
+
+// newCore writes entries at or above level to writer
+// With SamplingInitial set, debug and info entries go through a sampler: each message is logged
+// SamplingInitial times per second, then only every SamplingThereafter-th time. Warnings and errors
+// use a separate unsampled core, so they are never dropped however often they repeat
+func newCore(encoder zapcore.Encoder, writer zapcore.WriteSyncer, level zapcore.LevelEnabler, config Config) zapcore.Core {
+	if config.SamplingInitial <= 0 {
+		return zapcore.NewCore(encoder, writer, level)
 	}
 
-	// Create JSON encoder
-	encoder := zapcore.NewJSONEncoder(encoderConfig)
+	belowWarn := zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+		return l < zapcore.WarnLevel && level.Enabled(l)
+	})
+	fromWarn := zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+		return l >= zapcore.WarnLevel && level.Enabled(l)
+	})
 
-	// Create log directory if it doesn't exist (for local development)
-	logDir := "/var/log/app"
-	if err := os.MkdirAll(logDir, 0755); err != nil {
-		// If we can't create the directory, only log to stdout
-		// This handles cases where we don't have permissions (e.g., in distroless)
-		core := zapcore.NewCore(
-			encoder,
-			zapcore.AddSync(os.Stdout),
-			zapcore.InfoLevel,
-		)
-		logger := zap.New(core, zap.AddCaller())
-		logger.Warn("Failed to create log directory, logging to stdout only", zap.Error(err))
-
-		// Add service metadata fields
-		return logger.With(
-			zap.String("service", serviceName),
-			zap.String("pod_name", podName),
-			zap.String("node_name", nodeName),
-			zap.String("environment", environment),
-		), nil
-	}
+	return zapcore.NewTee(
+		zapcore.NewSamplerWithOptions(
+			zapcore.NewCore(encoder, writer, belowWarn),
+			samplingTick, config.SamplingInitial, config.SamplingThereafter,
+		),
+		zapcore.NewCore(encoder.Clone(), writer, fromWarn),
+	)
+}
 
-	// Open log file for writing
-	logFile, err := os.OpenFile(logDir+"/cart-service.log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		// If we can't open the file, only log to stdout
-		core := zapcore.NewCore(
-			encoder,
-			zapcore.AddSync(os.Stdout),
-			zapcore.InfoLevel,
-		)
-		logger := zap.New(core, zap.AddCaller())
-		logger.Warn("Failed to open log file, logging to stdout only", zap.Error(err))
-
-		return logger.With(
-			zap.String("service", serviceName),
-			zap.String("pod_name", podName),
-			zap.String("node_name", nodeName),
-			zap.String("environment", environment),
-		), nil
+// options returns the zap options the logger is built with
+// Caller information is added to every entry, and stacktraces from stacktraceLevel up
+func options(config Config) []zap.Option {
+	return []zap.Option{
+		zap.AddCaller(),
+		zap.AddStacktrace(stacktraceLevel(config.Environment)),
 	}
+}
 
-	// Create multi-writer to write to both stdout and file
-	// This allows Docker to capture logs via stdout while sidecar reads from file
-	multiWriter := zapcore.NewMultiWriteSyncer(
-		zapcore.AddSync(os.Stdout),
-		zapcore.AddSync(logFile),
-	)
+// stacktraceLevel returns the lowest level whose entries carry a stacktrace for an ENVIRONMENT
+// Development gets them from warn so local problems are easy to locate; production (and any
+// other environment) only from error, keeping the frequent warnings short
+func stacktraceLevel(environment string) zapcore.Level {
+	if strings.EqualFold(strings.TrimSpace(environment), "development") {
+		return zapcore.WarnLevel
+	}
+	return zapcore.ErrorLevel
+}
 
-	// Create core with both outputs
-	core := zapcore.NewCore(
-		encoder,
-		multiWriter,
-		zapcore.InfoLevel,
-	)
+// logFilePath returns the log directory and the full path of the log file, applying the defaults
+func logFilePath(config Config) (dir, path string) {
+	dir = config.Dir
+	if dir == "" {
+		dir = defaultLogDir
+	}
+	file := config.File
+	if file == "" {
+		file = defaultLogFile
+	}
+	return dir, filepath.Join(dir, file)
+}
 
-	// Create logger with caller information
-	logger := zap.New(core, zap.AddCaller())
+// checkWritable verifies the log file can be opened for appending
+// lumberjack opens the file lazily on first write, so this surfaces permission problems up front
+func checkWritable(path string) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
 
-	// Add service metadata fields that will appear in every log entry
-	return logger.With(
-		zap.String("service", serviceName),
-		zap.String("pod_name", podName),
-		zap.String("node_name", nodeName),
-		zap.String("environment", environment),
-	), nil
+// parseLevel converts a LOG_LEVEL value into a zapcore.Level
+// Returns InfoLevel and false when the value is not one of debug, info, warn or error
+// An empty value is treated as info without being reported as unrecognized
+func parseLevel(logLevel string) (zapcore.Level, bool) {
+	switch strings.ToLower(strings.TrimSpace(logLevel)) {
+	case "debug":
+		return zapcore.DebugLevel, true
+	case "info", "":
+		return zapcore.InfoLevel, true
+	case "warn":
+		return zapcore.WarnLevel, true
+	case "error":
+		return zapcore.ErrorLevel, true
+	default:
+		return zapcore.InfoLevel, false
+	}
 }
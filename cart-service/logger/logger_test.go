@@ -0,0 +1,167 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// newBufferedLogger builds a logger like InitLogger does, writing JSON entries to a buffer
+func newBufferedLogger(environment string) (*zap.Logger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	core := zapcore.NewCore(newEncoder(), zapcore.AddSync(&buf), zapcore.DebugLevel)
+	return zap.New(core, options(Config{Environment: environment})...), &buf
+}
+
+// lastEntry decodes the last JSON entry written to buf
+func lastEntry(t *testing.T, buf *bytes.Buffer) map[string]any {
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(lines[len(lines)-1], &entry))
+	return entry
+}
+
+func TestStacktrace(t *testing.T) {
+	t.Run("should add a stacktrace to errors in development", func(t *testing.T) {
+		logger, buf := newBufferedLogger("development")
+
+		logger.Error("boom")
+
+		entry := lastEntry(t, buf)
+		require.Contains(t, entry, "stacktrace")
+		assert.Contains(t, entry["stacktrace"], "TestStacktrace")
+	})
+
+	t.Run("should add a stacktrace to warnings in development only", func(t *testing.T) {
+		logger, buf := newBufferedLogger("development")
+		logger.Warn("careful")
+		assert.Contains(t, lastEntry(t, buf), "stacktrace")
+
+		logger, buf = newBufferedLogger("production")
+		logger.Warn("careful")
+		assert.NotContains(t, lastEntry(t, buf), "stacktrace")
+	})
+
+	t.Run("should add a stacktrace to errors in production", func(t *testing.T) {
+		logger, buf := newBufferedLogger("production")
+
+		logger.Error("boom")
+
+		assert.Contains(t, lastEntry(t, buf), "stacktrace")
+	})
+
+	t.Run("should never add a stacktrace to info entries", func(t *testing.T) {
+		logger, buf := newBufferedLogger("development")
+
+		logger.Info("hello")
+
+		assert.NotContains(t, lastEntry(t, buf), "stacktrace")
+	})
+}
+
+func TestStacktraceLevel(t *testing.T) {
+	assert.Equal(t, zapcore.WarnLevel, stacktraceLevel("development"))
+	assert.Equal(t, zapcore.WarnLevel, stacktraceLevel(" Development "))
+	assert.Equal(t, zapcore.ErrorLevel, stacktraceLevel("production"))
+	assert.Equal(t, zapcore.ErrorLevel, stacktraceLevel("staging"))
+	assert.Equal(t, zapcore.ErrorLevel, stacktraceLevel(""))
+}
+
+func TestSampling(t *testing.T) {
+	// newSampledLogger builds a logger on the same core as InitLogger, writing to a buffer
+	newSampledLogger := func(config Config) (*zap.Logger, *bytes.Buffer) {
+		var buf bytes.Buffer
+		core := newCore(newEncoder(), zapcore.AddSync(&buf), zapcore.DebugLevel, config)
+		return zap.New(core), &buf
+	}
+	countLines := func(buf *bytes.Buffer) int {
+		return bytes.Count(buf.Bytes(), []byte("\n"))
+	}
+
+	t.Run("should sample repeated info entries", func(t *testing.T) {
+		logger, buf := newSampledLogger(Config{SamplingInitial: 2, SamplingThereafter: 0})
+
+		for i := 0; i < 10; i++ {
+			logger.Info("Item added to cart")
+		}
+
+		assert.Equal(t, 2, countLines(buf))
+	})
+
+	t.Run("should never sample warnings and errors", func(t *testing.T) {
+		logger, buf := newSampledLogger(Config{SamplingInitial: 1, SamplingThereafter: 0})
+
+		for i := 0; i < 10; i++ {
+			logger.Error("Failed to add item to cart")
+			logger.Warn("Cart item limit reached")
+		}
+
+		assert.Equal(t, 20, countLines(buf))
+	})
+
+	t.Run("should log every entry when sampling is disabled", func(t *testing.T) {
+		logger, buf := newSampledLogger(Config{})
+
+		for i := 0; i < 10; i++ {
+			logger.Info("Item added to cart")
+		}
+
+		assert.Equal(t, 10, countLines(buf))
+	})
+
+	t.Run("should still apply the minimum level", func(t *testing.T) {
+		var buf bytes.Buffer
+		core := newCore(newEncoder(), zapcore.AddSync(&buf), zapcore.ErrorLevel, Config{SamplingInitial: 100, SamplingThereafter: 100})
+		logger := zap.New(core)
+
+		logger.Info("Item added to cart")
+		logger.Warn("Cart item limit reached")
+		logger.Error("Failed to add item to cart")
+
+		assert.Equal(t, 1, countLines(&buf))
+	})
+}
+
+func TestInitLoggerFile(t *testing.T) {
+	// A regular file where the log directory should be makes MkdirAll fail, even as root
+	unwritableDir := func(t *testing.T) string {
+		blocker := filepath.Join(t.TempDir(), "not-a-dir")
+		require.NoError(t, os.WriteFile(blocker, nil, 0644))
+		return filepath.Join(blocker, "logs")
+	}
+
+	t.Run("should write to the configured directory and file", func(t *testing.T) {
+		dir := t.TempDir()
+
+		logger, err := InitLogger(Config{ServiceName: "cart-service", Dir: dir, File: "custom.log", FileRequired: true})
+		require.NoError(t, err)
+		// lumberjack writes straight to the file, so no Sync is needed
+		logger.Info("hello")
+
+		content, err := os.ReadFile(filepath.Join(dir, "custom.log"))
+		require.NoError(t, err)
+		assert.Contains(t, string(content), `"msg":"hello"`)
+	})
+
+	t.Run("should fall back to stdout when the file is not required", func(t *testing.T) {
+		logger, err := InitLogger(Config{ServiceName: "cart-service", Dir: unwritableDir(t)})
+		require.NoError(t, err)
+		assert.NotNil(t, logger)
+	})
+
+	t.Run("should fail when the file is required but not writable", func(t *testing.T) {
+		dir := unwritableDir(t)
+
+		logger, err := InitLogger(Config{ServiceName: "cart-service", Dir: dir, FileRequired: true})
+		require.Error(t, err)
+		assert.Nil(t, logger)
+		assert.Contains(t, err.Error(), filepath.Join(dir, defaultLogFile))
+	})
+}
@@ -6,12 +6,17 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"cart-service/handlers"
+	"cart-service/internal/stress"
 	"cart-service/logger"
 	"cart-service/middleware"
+	"cart-service/productclient"
+	"cart-service/products"
 	"cart-service/redis"
 	"cart-service/telemetry"
 
@@ -24,17 +29,114 @@ func main() {
 	serviceName := getEnv("SERVICE_NAME", "cart-service")
 	serviceVersion := getEnv("SERVICE_VERSION", "1.0.0")
 	environment := getEnv("ENVIRONMENT", "development")
+	logLevel := getEnv("LOG_LEVEL", "info")
 	otlpEndpoint := getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "localhost:4317")
+
+	// Batch span processor tuning (defaults match telemetry.DefaultBatchConfig)
+	// OTEL_BSP_SCHEDULE_DELAY is in milliseconds, as in the OpenTelemetry specification
+	defaultBatch := telemetry.DefaultBatchConfig()
+	tracerBatch := telemetry.BatchConfig{
+		MaxExportBatchSize: getEnvInt("OTEL_BSP_MAX_EXPORT_BATCH_SIZE", defaultBatch.MaxExportBatchSize),
+		ScheduleDelay:      time.Duration(getEnvInt("OTEL_BSP_SCHEDULE_DELAY", int(defaultBatch.ScheduleDelay.Milliseconds()))) * time.Millisecond,
+		MaxQueueSize:       getEnvInt("OTEL_BSP_MAX_QUEUE_SIZE", defaultBatch.MaxQueueSize),
+	}
+	redisMode := getEnv("REDIS_MODE", string(redis.ModeStandalone))
 	redisAddr := getEnv("REDIS_ADDR", "localhost:6379")
+	redisMasterName := getEnv("REDIS_MASTER_NAME", "")
+	redisSentinelPassword := getEnv("REDIS_SENTINEL_PASSWORD", "")
+	redisUsername := getEnv("REDIS_USERNAME", "")
+	redisPassword := getEnv("REDIS_PASSWORD", "")
+	redisDB := getEnvInt("REDIS_DB", 0)
+	redisTLSEnabled := getEnvBool("REDIS_TLS_ENABLED", false)
+	redisTLSInsecureSkipVerify := getEnvBool("REDIS_TLS_INSECURE_SKIP_VERIFY", false)
+
+	// Redis connection pool settings (defaults match redis.DefaultPoolConfig)
+	defaultPool := redis.DefaultPoolConfig()
+	redisPool := redis.PoolConfig{
+		PoolSize:        getEnvInt("REDIS_POOL_SIZE", defaultPool.PoolSize),
+		MinIdleConns:    getEnvInt("REDIS_MIN_IDLE_CONNS", defaultPool.MinIdleConns),
+		MaxRetries:      getEnvInt("REDIS_MAX_RETRIES", defaultPool.MaxRetries),
+		DialTimeout:     getEnvDuration("REDIS_DIAL_TIMEOUT", defaultPool.DialTimeout),
+		ReadTimeout:     getEnvDuration("REDIS_READ_TIMEOUT", defaultPool.ReadTimeout),
+		WriteTimeout:    getEnvDuration("REDIS_WRITE_TIMEOUT", defaultPool.WriteTimeout),
+		ConnMaxIdleTime: getEnvDuration("REDIS_CONN_MAX_IDLE_TIME", defaultPool.ConnMaxIdleTime),
+	}
+	// Deadline for a whole cart operation, retries included, so a slow Redis cannot hold a
+	// request past WRITE_TIMEOUT (0 disables it)
+	redisOpTimeout := getEnvDuration("REDIS_OP_TIMEOUT", time.Second)
+	// Cart operations slower than this are logged at warn and flagged on their span (0 disables it)
+	redisSlowThreshold := getEnvDuration("REDIS_SLOW_THRESHOLD", 200*time.Millisecond)
+	// Carts with more items than this are read with HSCAN instead of HGETALL (0 disables it)
+	redisScanThreshold := getEnvInt("REDIS_HSCAN_THRESHOLD", 500)
+	// Cart operations that fail with a transient error (failover, dropped connection) are retried
+	// with backoff inside REDIS_OP_TIMEOUT (0 retries disables it)
+	redisOpRetry := redis.DefaultOpRetryConfig()
+	redisOpRetry.MaxRetries = getEnvInt("REDIS_OP_MAX_RETRIES", redisOpRetry.MaxRetries)
+	redisOpRetry.InitialDelay = getEnvDuration("REDIS_OP_RETRY_INITIAL_DELAY", redisOpRetry.InitialDelay)
+	redisOpRetry.MaxDelay = getEnvDuration("REDIS_OP_RETRY_MAX_DELAY", redisOpRetry.MaxDelay)
+	// Publish add/remove/clear cart events to CART_EVENTS_CHANNEL for consumers such as analytics
+	var cartEventsChannel string
+	if getEnvBool("CART_EVENTS_ENABLED", false) {
+		cartEventsChannel = getEnv("CART_EVENTS_CHANNEL", "cart-events")
+	}
+	productServiceURL := getEnv("PRODUCT_SERVICE_URL", "http://localhost:8090")
+	productServiceTimeout := getEnvDuration("PRODUCT_SERVICE_TIMEOUT", 5*time.Second)
+	// product-service gRPC API used for stock checks when set (empty keeps them on HTTP)
+	productGRPCAddr := getEnv("PRODUCT_GRPC_ADDR", "")
+
+	// ISO 4217 currency that product-service prices are expressed in
+	checkoutCurrency := getEnv("CHECKOUT_CURRENCY", "usd")
 	port := getEnv("PORT", "8080")
 
+	// HTTP server timeouts, parsed as Go durations
+	readTimeout := getEnvDuration("READ_TIMEOUT", 15*time.Second)
+	writeTimeout := getEnvDuration("WRITE_TIMEOUT", 15*time.Second)
+	idleTimeout := getEnvDuration("IDLE_TIMEOUT", 60*time.Second)
+
+	// How long to keep serving after /ready starts failing on shutdown (0 shuts down immediately)
+	shutdownDrainDelay := getEnvDuration("SHUTDOWN_DRAIN_DELAY", 5*time.Second)
+
+	// Require product IDs to be positive integers like product-service uses (off by default)
+	productIDNumeric := getEnvBool("PRODUCT_ID_NUMERIC", false)
+
+	// Admin endpoints walk the whole keyspace, so they are opt-in and bounded
+	adminEnabled := getEnvBool("ADMIN_ENDPOINTS_ENABLED", false)
+	adminScanMaxKeys := getEnvInt("ADMIN_SCAN_MAX_KEYS", 10000)
+	normalizeOptions := redis.NormalizeOptions{
+		TrimSpace:         getEnvBool("CART_NORMALIZE_TRIM_SPACE", true),
+		StripLeadingZeros: getEnvBool("CART_NORMALIZE_STRIP_LEADING_ZEROS", true),
+	}
+	cartMemorySampleRate := getEnvFloat("CART_MEMORY_SAMPLE_RATE", 0.1)
+	cartMemoryCapBytes := int64(getEnvInt("CART_MEMORY_CAP_BYTES", 0))
+
 	// Kubernetes pod metadata (defaults to "local-dev" for local testing)
 	podName := getEnv("POD_NAME", "local-dev")
 	nodeName := getEnv("NODE_NAME", "local-dev")
+	podNamespace := getEnv("POD_NAMESPACE", "")
 
 	// Initialize logger first so we can use it for subsequent initialization
-	// This creates structured JSON logs to stdout and /var/log/app/cart-service.log
-	zapLogger, err := logger.InitLogger(serviceName, podName, nodeName, environment)
+	// This creates structured JSON logs to stdout and LOG_DIR/LOG_FILE (/var/log/app/cart-service.log)
+	// An unwritable log file only costs the file output, unless LOG_FILE_REQUIRED=true
+	// Repetitive debug and info entries are sampled per message; LOG_SAMPLING_ENABLED=false logs them all
+	samplingInitial := getEnvInt("LOG_SAMPLING_INITIAL", 100)
+	if !getEnvBool("LOG_SAMPLING_ENABLED", true) {
+		samplingInitial = 0
+	}
+	zapLogger, err := logger.InitLogger(logger.Config{
+		ServiceName:        serviceName,
+		PodName:            podName,
+		NodeName:           nodeName,
+		Environment:        environment,
+		Level:              logLevel,
+		MaxSizeMB:          getEnvInt("LOG_MAX_SIZE_MB", 100),
+		MaxBackups:         getEnvInt("LOG_MAX_BACKUPS", 3),
+		MaxAgeDays:         getEnvInt("LOG_MAX_AGE_DAYS", 7),
+		SamplingInitial:    samplingInitial,
+		SamplingThereafter: getEnvInt("LOG_SAMPLING_THEREAFTER", 100),
+		Dir:                getEnv("LOG_DIR", "/var/log/app"),
+		File:               getEnv("LOG_FILE", "cart-service.log"),
+		FileRequired:       getEnvBool("LOG_FILE_REQUIRED", false),
+	})
 	if err != nil {
 		log.Fatalf("Failed to initialize logger: %v", err)
 	}
@@ -55,16 +157,41 @@ func main() {
 		ServiceVersion: serviceVersion,
 		Environment:    environment,
 		OTLPEndpoint:   otlpEndpoint,
+		Batch:          tracerBatch,
+		PodName:        podName,
+		NodeName:       nodeName,
+		Namespace:      podNamespace,
 	})
 	if err != nil {
 		zapLogger.Fatal("Failed to initialize tracer", zap.Error(err))
 	}
-	// Ensure tracer shutdown on exit to flush remaining spans
-	defer func() {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		if err := shutdownTracer(ctx); err != nil {
-			zapLogger.Error("Error shutting down tracer", zap.Error(err))
+	// The tracer is flushed by App.Shutdown after the server has drained
+
+	// Start listening before connecting so the Kubernetes startup probe gets 503 from /startup
+	// while Redis is still being retried, rather than the pod being killed by its liveness probe
+	// The probe answers every request until MarkStarted hands them to the router
+	startupProbe := handlers.NewStartupProbe()
+
+	// Create HTTP server with timeouts
+	// These timeouts prevent resource exhaustion from slow clients
+	// WriteTimeout also caps how long a handler may run, so heavy /stress requests may need it raised
+	srv := &http.Server{
+		Addr:         ":" + port,
+		Handler:      startupProbe,
+		ReadTimeout:  readTimeout,
+		WriteTimeout: writeTimeout,
+		IdleTimeout:  idleTimeout,
+	}
+
+	// Start server in a goroutine to enable graceful shutdown
+	// This allows us to handle OS signals while the server runs
+	go func() {
+		zapLogger.Info("Starting HTTP server",
+			zap.String("port", port),
+			zap.String("environment", environment),
+		)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			zapLogger.Fatal("Failed to start server", zap.Error(err))
 		}
 	}()
 
@@ -73,16 +200,33 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	redisClient, err := redis.InitRedis(ctx, redisAddr, zapLogger)
+	redisClient, err := redis.InitRedis(ctx, redis.Config{
+		Mode:     redis.Mode(redisMode),
+		Addr:     redisAddr,
+		Username: redisUsername,
+		Password: redisPassword,
+		DB:       redisDB,
+		Pool:     redisPool,
+
+		OpTimeout:     redisOpTimeout,
+		SlowThreshold: redisSlowThreshold,
+		ScanThreshold: redisScanThreshold,
+		OpRetry:       redisOpRetry,
+		EventsChannel: cartEventsChannel,
+
+		MasterName:       redisMasterName,
+		SentinelPassword: redisSentinelPassword,
+
+		TLSEnabled:            redisTLSEnabled,
+		TLSInsecureSkipVerify: redisTLSInsecureSkipVerify,
+	}, zapLogger)
 	if err != nil {
 		zapLogger.Fatal("Failed to initialize Redis client", zap.Error(err))
 	}
-	// Ensure Redis connection is closed on exit
-	defer func() {
-		if err := redisClient.Close(); err != nil {
-			zapLogger.Error("Error closing Redis connection", zap.Error(err))
-		}
-	}()
+	if redisTLSEnabled && redisTLSInsecureSkipVerify {
+		zapLogger.Warn("Redis TLS certificate verification is disabled")
+	}
+	// The Redis connection is closed by App.Shutdown once no request can still use it
 
 	// Set Gin mode based on environment
 	if environment == "production" {
@@ -92,77 +236,270 @@ func main() {
 	// Create Gin router
 	router := gin.New()
 
+	// Panics are logged through Zap, recorded on the request span and answered with a 500 APIError
+	recovery := middleware.RecoveryMiddleware(zapLogger)
+
+	// Profiling endpoints (/debug/pprof/) - off unless PPROF_ENABLED=true
+	// Registered before the middleware below, so profiles are not traced, access-logged or rate limited
+	handlers.RegisterPprof(router.Group("", recovery), getEnvBool("PPROF_ENABLED", false))
+
 	// Add middleware in order of execution:
-	// 1. Recovery middleware - recovers from panics and returns 500
-	router.Use(gin.Recovery())
+	// 1. CORS middleware - lets browser clients call the API directly
+	// Runs before tracing so OPTIONS preflights are answered without creating spans
+	router.Use(middleware.CORSMiddleware(middleware.CORSConfig{
+		AllowedOrigins: getEnvList("CORS_ALLOWED_ORIGINS", []string{"*"}),
+		AllowedMethods: getEnvList("CORS_ALLOWED_METHODS", []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}),
+		AllowedHeaders: getEnvList("CORS_ALLOWED_HEADERS", []string{"Content-Type", "X-API-Key", "Idempotency-Key", "If-Match", "X-Request-ID", "traceparent", "tracestate"}),
+		ExposedHeaders: []string{"X-Request-ID", "Idempotent-Replayed", "Retry-After"},
+		MaxAge:         getEnvDuration("CORS_MAX_AGE", 10*time.Minute),
+	}))
 
 	// 2. OpenTelemetry tracing middleware - creates parent span and extracts W3C Trace Context
 	// This must come before logging middleware to ensure trace_id is available in logs
 	router.Use(middleware.TracingMiddleware(serviceName))
 
-	// 3. Zap logging middleware - logs all requests with trace_id correlation
-	router.Use(middleware.ZapMiddleware(zapLogger))
+	// 3. Baggage middleware - copies allowlisted W3C Baggage members (e.g. user_id) onto the request span
+	// Runs after tracing, which extracts the baggage header into the request context
+	router.Use(middleware.BaggageMiddleware(getEnvList("BAGGAGE_SPAN_ATTRIBUTES", []string{"user_id"})))
+
+	// 4. Request ID middleware - reuses X-Request-ID or generates a UUID, echoed in the response
+	// Runs after tracing so the ID is recorded on the request span, and before logging so it is logged
+	router.Use(middleware.RequestIDMiddleware())
+
+	// 5. Zap logging middleware - logs all requests with trace_id correlation
+	// Probe requests are sampled (suppressed by default) so they don't drown out business routes
+	router.Use(middleware.ZapMiddleware(zapLogger, middleware.AccessLogConfig{
+		QuietPaths:       getEnvList("ACCESS_LOG_QUIET_PATHS", []string{"/healthz", "/live", "/ready", "/startup", "/metrics"}),
+		QuietSampleEvery: uint64(getEnvInt("ACCESS_LOG_QUIET_SAMPLE_EVERY", 0)),
+	}))
+
+	// 6. Body logging middleware - logs request and response bodies to diagnose malformed requests
+	// Only with LOG_BODIES=true and LOG_LEVEL=debug; probe and /stress bodies are never logged
+	router.Use(middleware.BodyLogMiddleware(zapLogger, middleware.BodyLogConfig{
+		Enabled:      getEnvBool("LOG_BODIES", false),
+		MaxBytes:     getEnvInt("LOG_BODIES_MAX_BYTES", 4096),
+		RedactFields: getEnvList("LOG_BODIES_REDACT_FIELDS", []string{"password", "token", "api_key", "card_number"}),
+		ExemptPaths:  []string{"/healthz", "/ready", "/live", "/startup", "/stress"},
+	}))
+
+	// 7. Recovery middleware - recovers from panics and returns 500
+	// Runs inside tracing so the span is still open to record the panic, and inside logging
+	// so the 500 is access-logged like any other response
+	router.Use(recovery)
+
+	// 8. Rate limiting middleware - per-client-IP token buckets, 429 + Retry-After when exceeded
+	// Disabled unless RATE_LIMIT_RPS is set; probes are exempt so Kubernetes never sees a 429
+	router.Use(middleware.RateLimitMiddleware(middleware.RateLimitConfig{
+		RPS:         getEnvFloat("RATE_LIMIT_RPS", 0),
+		Burst:       getEnvInt("RATE_LIMIT_BURST", 20),
+		ExemptPaths: getEnvList("RATE_LIMIT_EXEMPT_PATHS", []string{"/healthz", "/ready", "/live", "/startup"}),
+		IdleTTL:     getEnvDuration("RATE_LIMIT_IDLE_TTL", 10*time.Minute),
+		MaxClients:  getEnvInt("RATE_LIMIT_MAX_CLIENTS", 10000),
+	}))
+
+	// 9. Concurrency limit middleware - sheds load with 503 + Retry-After once
+	// MAX_CONCURRENT_REQUESTS are in flight, before the excess piles up on the Redis pool
+	// Disabled unless MAX_CONCURRENT_REQUESTS is set; probes are exempt and don't take a slot
+	router.Use(middleware.ConcurrencyLimitMiddleware(middleware.ConcurrencyLimitConfig{
+		MaxInFlight: getEnvInt("MAX_CONCURRENT_REQUESTS", 0),
+		ExemptPaths: getEnvList("MAX_CONCURRENT_EXEMPT_PATHS", []string{"/healthz", "/ready", "/live", "/startup"}),
+		RetryAfter:  getEnvDuration("MAX_CONCURRENT_RETRY_AFTER", time.Second),
+	}))
 
 	// Initialize handlers with dependencies
-	cartHandler := handlers.NewCartHandler(redisClient, zapLogger)
-	healthHandler := handlers.NewHealthHandler(redisClient, zapLogger, podName, nodeName)
-	stressHandler := handlers.NewStressHandler(zapLogger)
+	productClient := products.NewClient(productServiceURL, productServiceTimeout, zapLogger)
+	cartConfig := handlers.CartHandlerConfig{
+		ProductIDNumeric: productIDNumeric,
+		IdempotencyTTL:   getEnvDuration("IDEMPOTENCY_TTL", 10*time.Minute),
+		MaxCartItems:     getEnvInt("MAX_CART_ITEMS", 50),
+		MaxItemQuantity:  getEnvInt("MAX_ITEM_QUANTITY", 999),
+
+		BusinessSpanAttributes: getEnvBool("TRACE_BUSINESS_ATTRIBUTES", true),
+	}
+	// Validate product existence and stock with product-service before adding (off by default)
+	// With PRODUCT_GRPC_ADDR set the check goes over one long-lived gRPC connection instead of HTTP
+	var productGRPCClient *productclient.Client
+	if getEnvBool("STOCK_CHECK_ENABLED", false) {
+		cartConfig.StockChecker = productClient
+		if productGRPCAddr != "" {
+			productGRPCClient, err = productclient.NewClient(productclient.Config{
+				Addr:             productGRPCAddr,
+				Timeout:          productServiceTimeout,
+				KeepaliveTime:    getEnvDuration("PRODUCT_GRPC_KEEPALIVE_TIME", 30*time.Second),
+				KeepaliveTimeout: getEnvDuration("PRODUCT_GRPC_KEEPALIVE_TIMEOUT", 10*time.Second),
+			}, zapLogger)
+			if err != nil {
+				zapLogger.Fatal("Failed to create product-service gRPC client", zap.Error(err))
+			}
+			defer productGRPCClient.Close()
+			cartConfig.StockChecker = productGRPCClient
+			zapLogger.Info("Stock checks use the product-service gRPC API", zap.String("product_grpc_addr", productGRPCAddr))
+		}
+	}
+	// Keep carts usable through brief Redis outages by serving them from memory (off by default)
+	var cartStore handlers.CartStore = redisClient
+	fallbackEnabled := getEnvBool("CART_FALLBACK_ENABLED", false)
+	if fallbackEnabled {
+		cartStore = handlers.NewFallbackStore(redisClient, zapLogger, handlers.FallbackConfig{
+			MaxCarts: getEnvInt("CART_FALLBACK_MAX_CARTS", 1000),
+			TTL:      getEnvDuration("CART_FALLBACK_TTL", 15*time.Minute),
+		})
+		zapLogger.Info("In-memory cart fallback enabled")
+	}
+	cartHandler := handlers.NewCartHandler(cartStore, zapLogger, cartConfig)
+	reservationHandler := handlers.NewReservationHandler(redisClient, productClient, zapLogger, cartConfig.IdempotencyTTL)
+	lineItemsHandler := handlers.NewLineItemsHandler(redisClient, productClient, zapLogger, checkoutCurrency)
+	// Prices are cached briefly so pricing carts doesn't call product-service for every line
+	priceCatalog := handlers.NewCachedCatalog(productClient, getEnvDuration("PRODUCT_PRICE_CACHE_TTL", 30*time.Second))
+	totalHandler := handlers.NewTotalHandler(redisClient, priceCatalog, zapLogger, checkoutCurrency)
+	// A Redis ping slower than this is reported as degraded by the health endpoints
+	redisHealthMaxLatency := getEnvDuration("REDIS_HEALTH_MAX_LATENCY", 500*time.Millisecond)
+	healthHandler := handlers.NewHealthHandler(redisClient, zapLogger, podName, nodeName, redisHealthMaxLatency)
+	// Only stock-checked adds need product-service (502 while it is down); every other cart call still
+	// works, so losing it degrades the health endpoints instead of taking the pod out of service
+	if productGRPCClient != nil {
+		healthHandler.RegisterCheck(handlers.DependencyCheck{
+			Name:    "product-service",
+			Check:   productGRPCClient.Ping,
+			Timeout: getEnvDuration("PRODUCT_HEALTH_TIMEOUT", time.Second),
+		})
+	}
+	// /stress caps shrink with the pod's CPU quota so a request can't starve the probes
+	// on a fractional-core pod; STRESS_MAX_* override them
+	availableCPUs := stress.AvailableCPUs()
+	stressLimits := handlers.DefaultStressLimits(availableCPUs).WithOverrides(handlers.StressLimits{
+		MaxCPUIterations: getEnvInt("STRESS_MAX_CPU_ITERATIONS", 0),
+		MaxWorkers:       getEnvInt("STRESS_MAX_WORKERS", 0),
+	})
+	zapLogger.Info("Stress limits configured",
+		zap.Float64("available_cpus", availableCPUs),
+		zap.Int("max_cpu_iterations", stressLimits.MaxCPUIterations),
+		zap.Int("max_workers", stressLimits.MaxWorkers),
+	)
+	stressHandler := handlers.NewStressHandler(zapLogger, stressLimits)
+
+	// Cart writes require an X-API-Key from API_KEY (comma-separated allowlist)
+	// Without keys the writes stay open, as before, so local development keeps working
+	requireAPIKey := func(c *gin.Context) { c.Next() }
+	if apiKeys := getEnvList("API_KEY", nil); len(apiKeys) > 0 {
+		requireAPIKey = middleware.APIKeyMiddleware(apiKeys)
+	} else {
+		zapLogger.Warn("API_KEY is not set; cart write endpoints are unauthenticated")
+	}
+
+	// Routes that read a JSON body answer 415 for any other Content-Type instead of a confusing bind error
+	requireJSON := middleware.RequireJSON()
+
+	// Maintenance mode answers cart writes with 503 + Retry-After during migrations, while reads
+	// and probes keep working; with the admin endpoints enabled it can also be toggled at runtime
+	maintenance := handlers.NewMaintenance(getEnvBool("MAINTENANCE_MODE", false),
+		getEnvDuration("MAINTENANCE_RETRY_AFTER", time.Minute), zapLogger)
+	blockWrites := maintenance.BlockWrites()
 
 	// Register API routes
 	// Cart operations - v1 API versioning
 	v1 := router.Group("/v1")
+	if fallbackEnabled {
+		v1.Use(handlers.TrackDegraded())
+	}
 	{
-		v1.POST("/cart/:user_id", cartHandler.AddItem)
+		v1.POST("/cart/:user_id", requireAPIKey, blockWrites, requireJSON, cartHandler.AddItem)
 		v1.GET("/cart/:user_id", cartHandler.GetCart)
-		v1.DELETE("/cart/:user_id", cartHandler.DeleteCart)
+		v1.PUT("/cart/:user_id/items", requireAPIKey, blockWrites, requireJSON, cartHandler.SetItems)
+		v1.PUT("/cart/:user_id/items/:product_id", requireAPIKey, blockWrites, requireJSON, cartHandler.SetItemQuantity)
+		v1.DELETE("/cart/:user_id", requireAPIKey, blockWrites, cartHandler.DeleteCart)
+		v1.PUT("/cart/:user_id/currency", requireAPIKey, blockWrites, requireJSON, cartHandler.SetCartCurrency)
+		v1.POST("/cart/:user_id/merge", requireAPIKey, blockWrites, requireJSON, cartHandler.MergeCart)
+		v1.POST("/cart/:user_id/transfer", requireAPIKey, blockWrites, requireJSON, cartHandler.TransferItem)
+		v1.POST("/cart/:user_id/reserve", requireAPIKey, blockWrites, reservationHandler.ReserveCart)
+		v1.GET("/cart/:user_id/line-items", lineItemsHandler.GetLineItems)
+		v1.GET("/cart/:user_id/total", totalHandler.GetCartTotal)
+		v1.GET("/cart/:user_id/export", cartHandler.ExportCart)
 	}
 
-	// Health check endpoint for Kubernetes liveness/readiness probes
+	// Admin endpoints - only registered when explicitly enabled
+	if adminEnabled {
+		if adminScanMaxKeys <= 0 {
+			zapLogger.Fatal("ADMIN_SCAN_MAX_KEYS must be positive", zap.Int("admin_scan_max_keys", adminScanMaxKeys))
+		}
+		adminHandler := handlers.NewAdminHandler(redisClient, zapLogger, handlers.AdminHandlerConfig{
+			ScanMaxKeys: adminScanMaxKeys,
+			Normalize:   normalizeOptions,
+
+			MemorySampleRate: cartMemorySampleRate,
+			MemoryCapBytes:   cartMemoryCapBytes,
+		})
+		admin := router.Group("/admin")
+		{
+			// Every admin route exposes or rewrites carts, so all require the API key when one is configured
+			admin.GET("/carts/largest", requireAPIKey, adminHandler.LargestCarts)
+			admin.POST("/carts/:user_id/normalize", requireAPIKey, blockWrites, adminHandler.NormalizeCart)
+			admin.GET("/carts/memory", requireAPIKey, adminHandler.CartMemory)
+			// Flips maintenance mode on this pod; requires the API key when one is configured
+			admin.GET("/maintenance", requireAPIKey, maintenance.GetMaintenance)
+			admin.PUT("/maintenance", requireAPIKey, requireJSON, maintenance.SetMaintenance)
+		}
+		// Lists every user with a cart, so it also requires the API key when one is configured
+		router.GET("/v1/carts", requireAPIKey, adminHandler.ListCarts)
+		// Raw dump of one cart hash, including entries GetCart skips as corrupted, and their cleanup
+		router.GET("/v1/cart/:user_id/raw", requireAPIKey, adminHandler.RawCart)
+		router.POST("/v1/cart/:user_id/repair", requireAPIKey, blockWrites, adminHandler.RepairCart)
+		zapLogger.Info("Admin endpoints enabled", zap.Int("scan_max_keys", adminScanMaxKeys))
+	}
+
+	// Health check endpoints for Kubernetes probes
+	// /live never checks Redis, so a Redis outage only pulls the pod from rotation via /ready
+	// /healthz keeps the combined check for existing probe configurations
 	router.GET("/healthz", healthHandler.Healthz)
+	router.GET("/ready", healthHandler.Ready)
+	router.GET("/live", healthHandler.Live)
+	// Startup probe - 200 once Redis has been reached; gates liveness during warm-up
+	router.GET("/startup", startupProbe.Startup)
 
 	// Stress test endpoint for HPA testing and performance profiling
 	router.POST("/stress", stressHandler.StressTest)
 
-	// Create HTTP server with timeouts
-	// These timeouts prevent resource exhaustion from slow clients
-	srv := &http.Server{
-		Addr:         ":" + port,
-		Handler:      router,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
-	}
+	// OpenAPI 3 description of every route above, generated from the handler structs
+	router.GET("/openapi.json", handlers.OpenAPI(router, serviceVersion))
 
-	// Start server in a goroutine to enable graceful shutdown
-	// This allows us to handle OS signals while the server runs
-	go func() {
-		zapLogger.Info("Starting HTTP server",
-			zap.String("port", port),
-			zap.String("environment", environment),
-		)
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			zapLogger.Fatal("Failed to start server", zap.Error(err))
-		}
-	}()
+	// Redis is connected and every route is registered, so hand requests to the router
+	startupProbe.MarkStarted(router)
+	zapLogger.Info("Startup complete")
+
+	app := &App{
+		server:         srv,
+		redisClient:    redisClient,
+		shutdownTracer: shutdownTracer,
+		logger:         zapLogger,
+	}
 
 	// Wait for interrupt signal for graceful shutdown
 	// This handles SIGINT (Ctrl+C) and SIGTERM (Docker/Kubernetes stop)
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	waitForShutdown(quit, healthHandler.StartDraining, zapLogger)
+	shutdownStarted := time.Now()
 
-	zapLogger.Info("Shutting down server...")
+	waitDrainDelay(shutdownDrainDelay, zapLogger)
+	zapLogger.Info("Shutting down server...",
+		zap.Duration("drain_delay", shutdownDrainDelay),
+		zap.Duration("elapsed", time.Since(shutdownStarted)),
+	)
 
-	// Graceful shutdown with 5 second timeout
-	// This allows in-flight requests and Redis operations to complete
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	// Graceful shutdown with 10 second timeout shared by every step
+	// In-flight requests and their Redis operations complete before Redis is closed
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer shutdownCancel()
 
-	if err := srv.Shutdown(shutdownCtx); err != nil {
-		zapLogger.Fatal("Server forced to shutdown", zap.Error(err))
+	if err := app.Shutdown(shutdownCtx); err != nil {
+		zapLogger.Error("Shutdown completed with errors",
+			zap.Duration("elapsed", time.Since(shutdownStarted)),
+			zap.Error(err),
+		)
+		return
 	}
 
-	zapLogger.Info("Server exited cleanly")
+	zapLogger.Info("Server exited cleanly", zap.Duration("elapsed", time.Since(shutdownStarted)))
 }
 
 // getEnv retrieves an environment variable or returns a default value
@@ -173,3 +510,59 @@ func getEnv(key, defaultValue string) string {
 	}
 	return value
 }
+
+// getEnvInt retrieves an integer environment variable or returns a default value
+// The default is also used when the value cannot be parsed
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvFloat retrieves a floating-point environment variable or returns a default value
+// The default is also used when the value cannot be parsed
+func getEnvFloat(key string, defaultValue float64) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvBool retrieves a boolean environment variable or returns a default value
+// Accepts the values understood by strconv.ParseBool (true, false, 1, 0, ...)
+func getEnvBool(key string, defaultValue bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvDuration retrieves a duration environment variable (e.g. "15s", "2m") or returns a default value
+// The default is also used when the value cannot be parsed
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvList retrieves a comma-separated environment variable or returns a default value
+// Surrounding whitespace and empty entries are dropped
+func getEnvList(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+
+	"cart-service/internal/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHeader is the request header carrying the client's API key
+const APIKeyHeader = "X-API-Key"
+
+// Error codes returned by APIKeyMiddleware
+const (
+	CodeAPIKeyMissing = "API_KEY_MISSING"
+	CodeAPIKeyInvalid = "API_KEY_INVALID"
+)
+
+// APIKeyMiddleware returns a Gin middleware that requires one of the allowed keys in the X-API-Key header
+// Responds 401 when the header is missing and 403 when the key is not allowed
+// Keys are compared as SHA-256 digests with subtle.ConstantTimeCompare, so neither the
+// content nor the length of a valid key leaks through response timing
+func APIKeyMiddleware(allowedKeys []string) gin.HandlerFunc {
+	digests := make([][sha256.Size]byte, 0, len(allowedKeys))
+	for _, key := range allowedKeys {
+		if key != "" {
+			digests = append(digests, sha256.Sum256([]byte(key)))
+		}
+	}
+
+	return func(c *gin.Context) {
+		key := c.GetHeader(APIKeyHeader)
+		if key == "" {
+			apierror.RespondError(c, http.StatusUnauthorized, CodeAPIKeyMissing, "Missing "+APIKeyHeader+" header")
+			return
+		}
+
+		digest := sha256.Sum256([]byte(key))
+		valid := 0
+		// Check every key without stopping early so the position of a match doesn't show in timing
+		for i := range digests {
+			valid |= subtle.ConstantTimeCompare(digest[:], digests[i][:])
+		}
+		if valid != 1 {
+			apierror.RespondError(c, http.StatusForbidden, CodeAPIKeyInvalid, "Invalid API key")
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeyMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.POST("/v1/cart/:user_id", APIKeyMiddleware([]string{"key-one", "key-two"}), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"user_id": c.Param("user_id")})
+	})
+
+	tests := []struct {
+		name           string
+		apiKey         string
+		expectedStatus int
+		expectedCode   string
+	}{
+		{"should accept the first key", "key-one", http.StatusOK, ""},
+		{"should accept any key in the allowlist", "key-two", http.StatusOK, ""},
+		{"should return 401 without a key", "", http.StatusUnauthorized, CodeAPIKeyMissing},
+		{"should return 403 for an unknown key", "key-three", http.StatusForbidden, CodeAPIKeyInvalid},
+		{"should return 403 for a prefix of a valid key", "key-", http.StatusForbidden, CodeAPIKeyInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/v1/cart/user-1", nil)
+			if tt.apiKey != "" {
+				req.Header.Set(APIKeyHeader, tt.apiKey)
+			}
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedCode != "" {
+				assert.Contains(t, w.Body.String(), `"code":"`+tt.expectedCode+`"`)
+			}
+		})
+	}
+
+	t.Run("should reject every key when the allowlist is empty", func(t *testing.T) {
+		router := gin.New()
+		router.POST("/v1/cart/:user_id", APIKeyMiddleware(nil), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/v1/cart/user-1", nil)
+		req.Header.Set(APIKeyHeader, "anything")
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// BaggageMiddleware returns a Gin middleware that copies W3C Baggage members onto the request span
+// Only members named in keys are copied (e.g. user_id set by an upstream service), so a client
+// can't attach arbitrary attributes through the baggage header; each keeps its name as attribute key
+// The baggage is read from the context extracted by the tracing middleware, so it must run after it
+func BaggageMiddleware(keys []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		bag := baggage.FromContext(c.Request.Context())
+		if bag.Len() > 0 {
+			span := trace.SpanFromContext(c.Request.Context())
+			for _, key := range keys {
+				if member := bag.Member(key); member.Key() != "" {
+					span.SetAttributes(attribute.String(key, member.Value()))
+				}
+			}
+		}
+
+		c.Next()
+	}
+}
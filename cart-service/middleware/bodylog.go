@@ -0,0 +1,150 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// redactedValue replaces the value of every redacted field in a logged body
+const redactedValue = `"[REDACTED]"`
+
+// BodyLogConfig controls BodyLogMiddleware
+type BodyLogConfig struct {
+	// Enabled turns body logging on; it also needs the logger to be at debug level
+	Enabled bool
+	// MaxBytes caps how much of each body is logged; longer bodies are cut and marked truncated
+	MaxBytes int
+	// RedactFields lists JSON field names (case-insensitive) whose values are replaced with
+	// [REDACTED] wherever they appear in a body, nested or not
+	RedactFields []string
+	// ExemptPaths lists routes (e.g. probes and /stress) whose bodies are never logged
+	ExemptPaths []string
+}
+
+// BodyLogMiddleware returns a Gin middleware that logs request and response bodies at debug
+// level, to see exactly what a client sent when its request was rejected
+// The request body is copied as the handler reads it, so binding works as before and a body the
+// handler never reads is not logged; the response body is copied as it is written
+// It does nothing unless config.Enabled is set and the logger is at debug level
+func BodyLogMiddleware(logger *zap.Logger, config BodyLogConfig) gin.HandlerFunc {
+	if !config.Enabled || !logger.Core().Enabled(zapcore.DebugLevel) {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+
+	exempt := make(map[string]bool, len(config.ExemptPaths))
+	for _, path := range config.ExemptPaths {
+		exempt[path] = true
+	}
+	redact := redactPattern(config.RedactFields)
+
+	return func(c *gin.Context) {
+		if exempt[c.Request.URL.Path] {
+			c.Next()
+			return
+		}
+
+		request := &cappedBuffer{max: config.MaxBytes}
+		if c.Request.Body != nil {
+			c.Request.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.TeeReader(c.Request.Body, request), c.Request.Body}
+		}
+		writer := &bodyCaptureWriter{ResponseWriter: c.Writer, body: &cappedBuffer{max: config.MaxBytes}}
+		c.Writer = writer
+
+		c.Next()
+
+		fields := []zap.Field{
+			zap.String("method", c.Request.Method),
+			zap.String("path", c.Request.URL.Path),
+			zap.String("route", c.FullPath()),
+			zap.Int("status", c.Writer.Status()),
+			zap.String("request_body", redactBody(request.String(), redact)),
+			zap.Bool("request_body_truncated", request.truncated),
+			zap.String("response_body", redactBody(writer.body.String(), redact)),
+			zap.Bool("response_body_truncated", writer.body.truncated),
+		}
+		if spanContext := trace.SpanContextFromContext(c.Request.Context()); spanContext.IsValid() {
+			fields = append(fields,
+				zap.String("trace_id", spanContext.TraceID().String()),
+				zap.String("span_id", spanContext.SpanID().String()),
+			)
+		}
+		if requestID := RequestIDFromContext(c.Request.Context()); requestID != "" {
+			fields = append(fields, zap.String("request_id", requestID))
+		}
+
+		logger.Debug("HTTP request body", fields...)
+	}
+}
+
+// redactPattern matches "field": value for any of fields, or returns nil when there are none
+// It works on text rather than parsed JSON so malformed and truncated bodies, the ones worth
+// logging, are redacted too; a string value cut off by truncation is matched to the end
+func redactPattern(fields []string) *regexp.Regexp {
+	if len(fields) == 0 {
+		return nil
+	}
+	quoted := make([]string, len(fields))
+	for i, field := range fields {
+		quoted[i] = regexp.QuoteMeta(field)
+	}
+	return regexp.MustCompile(`(?i)("(?:` + strings.Join(quoted, "|") + `)"\s*:\s*)("(?:[^"\\]|\\.)*(?:"|$)|[^,}\]\s]+)`)
+}
+
+// redactBody replaces the values matched by pattern in body with redactedValue
+func redactBody(body string, pattern *regexp.Regexp) string {
+	if pattern == nil {
+		return body
+	}
+	return pattern.ReplaceAllString(body, "${1}"+redactedValue)
+}
+
+// cappedBuffer keeps the first max bytes written to it and notes whether more were written
+// Writes never fail, so it can sit behind a TeeReader without disturbing the reader
+type cappedBuffer struct {
+	bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.Len(); len(p) > room {
+		b.truncated = true
+		if room > 0 {
+			b.Buffer.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
+
+func (b *cappedBuffer) WriteString(s string) (int, error) {
+	return b.Write([]byte(s))
+}
+
+// bodyCaptureWriter copies everything written to the response into body
+type bodyCaptureWriter struct {
+	gin.ResponseWriter
+	body *cappedBuffer
+}
+
+func (w *bodyCaptureWriter) Write(p []byte) (int, error) {
+	w.body.Write(p)
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *bodyCaptureWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// setupBodyLogTest creates a router with BodyLogMiddleware logging at level to an in-memory log observer
+// POST /v1/cart/:user_id binds a JSON body and echoes it back, as the cart handlers do
+func setupBodyLogTest(level zapcore.Level, config BodyLogConfig) (*gin.Engine, *observer.ObservedLogs) {
+	gin.SetMode(gin.TestMode)
+
+	core, logs := observer.New(level)
+	router := gin.New()
+	router.Use(BodyLogMiddleware(zap.New(core), config))
+	router.POST("/v1/cart/:user_id", func(c *gin.Context) {
+		var req map[string]interface{}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": "INVALID_REQUEST"})
+			return
+		}
+		c.JSON(http.StatusOK, req)
+	})
+	router.POST("/stress", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "completed"})
+	})
+
+	return router, logs
+}
+
+func post(router *gin.Engine, path, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestBodyLogMiddleware(t *testing.T) {
+	config := BodyLogConfig{
+		Enabled:      true,
+		MaxBytes:     1024,
+		RedactFields: []string{"password"},
+		ExemptPaths:  []string{"/stress"},
+	}
+
+	t.Run("should log request and response bodies when enabled", func(t *testing.T) {
+		router, logs := setupBodyLogTest(zapcore.DebugLevel, config)
+
+		w := post(router, "/v1/cart/user-123", `{"product_id":"prod-1","quantity":2}`)
+
+		// The handler still binds the body
+		assert.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, 1, logs.Len())
+		fields := logs.All()[0].ContextMap()
+		assert.Equal(t, `{"product_id":"prod-1","quantity":2}`, fields["request_body"])
+		assert.JSONEq(t, `{"product_id":"prod-1","quantity":2}`, fields["response_body"].(string))
+		assert.Equal(t, "/v1/cart/:user_id", fields["route"])
+		assert.Equal(t, int64(http.StatusOK), fields["status"])
+	})
+
+	t.Run("should log malformed request bodies", func(t *testing.T) {
+		router, logs := setupBodyLogTest(zapcore.DebugLevel, config)
+
+		w := post(router, "/v1/cart/user-123", `{"product_id":"prod-1",`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		require.Equal(t, 1, logs.Len())
+		assert.Equal(t, `{"product_id":"prod-1",`, logs.All()[0].ContextMap()["request_body"])
+	})
+
+	t.Run("should not log bodies when the flag is off", func(t *testing.T) {
+		disabled := config
+		disabled.Enabled = false
+		router, logs := setupBodyLogTest(zapcore.DebugLevel, disabled)
+
+		w := post(router, "/v1/cart/user-123", `{"product_id":"prod-1","quantity":2}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, 0, logs.Len())
+	})
+
+	t.Run("should not log bodies above debug level", func(t *testing.T) {
+		router, logs := setupBodyLogTest(zapcore.InfoLevel, config)
+
+		post(router, "/v1/cart/user-123", `{"product_id":"prod-1","quantity":2}`)
+
+		assert.Equal(t, 0, logs.Len())
+	})
+
+	t.Run("should not log bodies for exempt paths", func(t *testing.T) {
+		router, logs := setupBodyLogTest(zapcore.DebugLevel, config)
+
+		w := post(router, "/stress", `{"duration":"1s"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, 0, logs.Len())
+	})
+
+	t.Run("should redact configured fields", func(t *testing.T) {
+		router, logs := setupBodyLogTest(zapcore.DebugLevel, config)
+
+		post(router, "/v1/cart/user-123", `{"user":{"Password": "hunter2"},"quantity":2}`)
+
+		require.Equal(t, 1, logs.Len())
+		fields := logs.All()[0].ContextMap()
+		assert.Equal(t, `{"user":{"Password": "[REDACTED]"},"quantity":2}`, fields["request_body"])
+		assert.NotContains(t, fields["response_body"], "hunter2")
+	})
+
+	t.Run("should truncate bodies over MaxBytes", func(t *testing.T) {
+		capped := config
+		capped.MaxBytes = 20
+		router, logs := setupBodyLogTest(zapcore.DebugLevel, capped)
+
+		w := post(router, "/v1/cart/user-123", `{"product_id":"prod-1","password":"hunter2"}`)
+
+		// The handler still sees the whole body
+		assert.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, 1, logs.Len())
+		fields := logs.All()[0].ContextMap()
+		assert.Equal(t, `{"product_id":"prod-`, fields["request_body"])
+		assert.Equal(t, true, fields["request_body_truncated"])
+		// The response's sorted keys put the password first; its cut-off value is still redacted
+		assert.Equal(t, `{"password":"[REDACTED]"`, fields["response_body"])
+		assert.Equal(t, true, fields["response_body_truncated"])
+	})
+}
+
+func TestRedactBody(t *testing.T) {
+	pattern := redactPattern([]string{"password", "card_number"})
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"string value", `{"password":"hunter2"}`, `{"password":"[REDACTED]"}`},
+		{"escaped quote", `{"password":"hun\"ter2","a":1}`, `{"password":"[REDACTED]","a":1}`},
+		{"number value", `{"card_number":4111111111111111}`, `{"card_number":"[REDACTED]"}`},
+		{"cut off by truncation", `{"password":"hunt`, `{"password":"[REDACTED]"`},
+		{"other fields untouched", `{"product_id":"prod-1"}`, `{"product_id":"prod-1"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, redactBody(tt.body, pattern))
+		})
+	}
+}
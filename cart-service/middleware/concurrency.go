@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"cart-service/internal/apierror"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ConcurrencyLimitConfig controls ConcurrencyLimitMiddleware
+type ConcurrencyLimitConfig struct {
+	// MaxInFlight is how many requests may be served at once; 0 disables the limit
+	MaxInFlight int
+	// ExemptPaths lists routes (e.g. Kubernetes probes) that are never shed and don't take a slot
+	ExemptPaths []string
+	// RetryAfter is sent with shed requests, rounded up to whole seconds
+	RetryAfter time.Duration
+}
+
+// ConcurrencyLimitMiddleware returns a Gin middleware that sheds load once MaxInFlight requests
+// are in flight: further requests get 503 with a Retry-After header straight away instead of
+// queueing for the Redis connection pool and timing out there
+// The limit is per pod, across all clients; a slot is held until the handler chain returns
+func ConcurrencyLimitMiddleware(config ConcurrencyLimitConfig) gin.HandlerFunc {
+	if config.MaxInFlight <= 0 {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+
+	exempt := make(map[string]bool, len(config.ExemptPaths))
+	for _, path := range config.ExemptPaths {
+		exempt[path] = true
+	}
+	retryAfter := strconv.Itoa(max(1, int(math.Ceil(config.RetryAfter.Seconds()))))
+
+	// A buffered channel is the semaphore: sending takes a slot, receiving frees it
+	slots := make(chan struct{}, config.MaxInFlight)
+
+	return func(c *gin.Context) {
+		if exempt[c.Request.URL.Path] {
+			c.Next()
+			return
+		}
+
+		select {
+		case slots <- struct{}{}:
+			defer func() { <-slots }()
+			c.Next()
+		default:
+			trace.SpanFromContext(c.Request.Context()).SetAttributes(attribute.Bool("http.load_shed", true))
+			c.Header("Retry-After", retryAfter)
+			apierror.RespondError(c, http.StatusServiceUnavailable, apierror.CodeOverloaded, "Too many concurrent requests")
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupConcurrencyTest creates a router whose cart route blocks until release is closed
+// entered is signalled each time a request reaches the handler
+func setupConcurrencyTest(config ConcurrencyLimitConfig) (router *gin.Engine, entered chan struct{}, release chan struct{}) {
+	gin.SetMode(gin.TestMode)
+
+	entered = make(chan struct{}, 16)
+	release = make(chan struct{})
+
+	router = gin.New()
+	router.Use(ConcurrencyLimitMiddleware(config))
+	router.GET("/healthz", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "healthy"})
+	})
+	router.GET("/v1/cart/:user_id", func(c *gin.Context) {
+		entered <- struct{}{}
+		<-release
+		c.JSON(http.StatusOK, gin.H{"user_id": c.Param("user_id")})
+	})
+
+	return router, entered, release
+}
+
+// get sends a GET request to router
+func get(router *gin.Engine, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", path, nil)
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestConcurrencyLimitMiddleware(t *testing.T) {
+	t.Run("should reject the N+1th concurrent request with 503 and Retry-After", func(t *testing.T) {
+		const limit = 3
+		router, entered, release := setupConcurrencyTest(ConcurrencyLimitConfig{
+			MaxInFlight: limit,
+			ExemptPaths: []string{"/healthz"},
+			RetryAfter:  1500 * time.Millisecond,
+		})
+
+		// Fill every slot with a request that stays in flight
+		var wg sync.WaitGroup
+		codes := make([]int, limit)
+		for i := 0; i < limit; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				codes[i] = get(router, "/v1/cart/user-1").Code
+			}(i)
+		}
+		for i := 0; i < limit; i++ {
+			<-entered
+		}
+
+		w := get(router, "/v1/cart/user-2")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Contains(t, w.Body.String(), `"code":"OVERLOADED"`)
+		assert.Equal(t, "2", w.Header().Get("Retry-After"))
+
+		// Probes are never shed
+		assert.Equal(t, http.StatusOK, get(router, "/healthz").Code)
+
+		close(release)
+		wg.Wait()
+		for _, code := range codes {
+			assert.Equal(t, http.StatusOK, code)
+		}
+
+		// The slots are freed once the requests finish
+		assert.Equal(t, http.StatusOK, get(router, "/v1/cart/user-2").Code)
+	})
+
+	t.Run("should pass everything through when disabled", func(t *testing.T) {
+		router, _, release := setupConcurrencyTest(ConcurrencyLimitConfig{})
+		close(release)
+
+		for i := 0; i < 5; i++ {
+			assert.Equal(t, http.StatusOK, get(router, "/v1/cart/user-1").Code)
+		}
+	})
+}
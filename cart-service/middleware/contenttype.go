@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+
+	"cart-service/internal/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CodeUnsupportedMediaType is returned by RequireJSON for a body that is not declared as JSON
+const CodeUnsupportedMediaType = "UNSUPPORTED_MEDIA_TYPE"
+
+// RequireJSON returns a Gin middleware that answers 415 when a request body is not declared as JSON
+// application/json and "+json" types are accepted, with parameters such as charset
+// A request without a body passes through, so the handler can report the missing body as a
+// validation error; register it only on routes that read a JSON body
+func RequireJSON() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.ContentLength == 0 {
+			c.Next()
+			return
+		}
+
+		contentType := c.GetHeader("Content-Type")
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || (mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json")) {
+			if contentType == "" {
+				contentType = "none"
+			}
+			apierror.RespondErrorDetails(c, http.StatusUnsupportedMediaType, CodeUnsupportedMediaType,
+				"Content-Type must be application/json", gin.H{"content_type": contentType})
+			return
+		}
+
+		c.Next()
+	}
+}
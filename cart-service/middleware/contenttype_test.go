@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.POST("/v1/cart/:user_id", RequireJSON(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name           string
+		contentType    string
+		body           string
+		expectedStatus int
+	}{
+		{"json", "application/json", `{"product_id":"1","quantity":1}`, http.StatusOK},
+		{"json with charset", "application/json; charset=utf-8", `{}`, http.StatusOK},
+		{"json suffix type", "application/merge-patch+json", `{}`, http.StatusOK},
+		{"text/plain", "text/plain", `{"product_id":"1","quantity":1}`, http.StatusUnsupportedMediaType},
+		{"form encoded", "application/x-www-form-urlencoded", "product_id=1&quantity=1", http.StatusUnsupportedMediaType},
+		{"missing content type", "", `{}`, http.StatusUnsupportedMediaType},
+		{"malformed content type", "application/json;;", `{}`, http.StatusUnsupportedMediaType},
+		{"empty body without content type", "", "", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/v1/cart/user-1", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusUnsupportedMediaType {
+				assert.Contains(t, w.Body.String(), CodeUnsupportedMediaType)
+			}
+		})
+	}
+}
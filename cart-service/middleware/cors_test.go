@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupCORSTest creates a router with CORSMiddleware in front of a cart route
+func setupCORSTest(config CORSConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(CORSMiddleware(config))
+	router.POST("/v1/cart/:user_id", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"user_id": c.Param("user_id")})
+	})
+
+	return router
+}
+
+// serveCORS sends a request with an Origin header and optional preflight headers
+func serveCORS(router *gin.Engine, method, origin string, headers map[string]string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, "/v1/cart/user-1", nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCORSMiddleware(t *testing.T) {
+	preflightHeaders := map[string]string{
+		"Access-Control-Request-Method":  "POST",
+		"Access-Control-Request-Headers": "Content-Type, X-API-Key",
+	}
+
+	config := CORSConfig{
+		AllowedOrigins: []string{"https://shop.example.com"},
+		AllowedMethods: []string{"GET", "POST"},
+		AllowedHeaders: []string{"Content-Type", "X-API-Key"},
+		ExposedHeaders: []string{"Retry-After"},
+		MaxAge:         10 * time.Minute,
+	}
+
+	t.Run("should answer a preflight from an allowed origin", func(t *testing.T) {
+		router := setupCORSTest(config)
+
+		w := serveCORS(router, "OPTIONS", "https://shop.example.com", preflightHeaders)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "https://shop.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "GET, POST", w.Header().Get("Access-Control-Allow-Methods"))
+		assert.Equal(t, "Content-Type, X-API-Key", w.Header().Get("Access-Control-Allow-Headers"))
+		assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+		assert.Equal(t, "Origin", w.Header().Get("Vary"))
+	})
+
+	t.Run("should add headers to an actual request from an allowed origin", func(t *testing.T) {
+		router := setupCORSTest(config)
+
+		w := serveCORS(router, "POST", "https://shop.example.com", nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "https://shop.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "Retry-After", w.Header().Get("Access-Control-Expose-Headers"))
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Methods"))
+	})
+
+	t.Run("should reject a preflight from another origin", func(t *testing.T) {
+		router := setupCORSTest(config)
+
+		w := serveCORS(router, "OPTIONS", "https://evil.example.com", preflightHeaders)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("should not add headers for another origin", func(t *testing.T) {
+		router := setupCORSTest(config)
+
+		w := serveCORS(router, "POST", "https://evil.example.com", nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("should allow any origin with a wildcard", func(t *testing.T) {
+		router := setupCORSTest(CORSConfig{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"POST"}})
+
+		w := serveCORS(router, "OPTIONS", "http://localhost:3000", preflightHeaders)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Empty(t, w.Header().Get("Access-Control-Max-Age"))
+	})
+
+	t.Run("should ignore requests without an Origin", func(t *testing.T) {
+		router := setupCORSTest(config)
+
+		w := serveCORS(router, "POST", "", nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Empty(t, w.Header().Get("Vary"))
+	})
+}
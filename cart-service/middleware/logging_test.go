@@ -0,0 +1,169 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// setupLoggingTest creates a router with ZapMiddleware writing to an in-memory log observer
+func setupLoggingTest(config AccessLogConfig) (*gin.Engine, *observer.ObservedLogs) {
+	gin.SetMode(gin.TestMode)
+
+	core, logs := observer.New(zap.InfoLevel)
+	router := gin.New()
+	router.Use(ZapMiddleware(zap.New(core), config))
+	router.GET("/healthz", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "healthy"})
+	})
+	router.GET("/ready", func(c *gin.Context) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unhealthy"})
+	})
+	router.GET("/v1/cart/:user_id", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"user_id": c.Param("user_id")})
+	})
+
+	return router, logs
+}
+
+// loggedPaths returns the path field of every access log entry
+func loggedPaths(logs *observer.ObservedLogs) []string {
+	var paths []string
+	for _, entry := range logs.All() {
+		paths = append(paths, entry.ContextMap()["path"].(string))
+	}
+	return paths
+}
+
+func serve(router *gin.Engine, path string) {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", path, nil)
+	router.ServeHTTP(w, req)
+}
+
+func TestZapMiddlewareQuietPaths(t *testing.T) {
+	t.Run("should suppress health requests but log cart requests", func(t *testing.T) {
+		router, logs := setupLoggingTest(AccessLogConfig{
+			QuietPaths: []string{"/healthz"},
+		})
+
+		serve(router, "/healthz")
+		serve(router, "/v1/cart/user-123")
+		serve(router, "/healthz")
+
+		assert.Equal(t, []string{"/v1/cart/user-123"}, loggedPaths(logs))
+	})
+
+	t.Run("should sample quiet paths when configured", func(t *testing.T) {
+		router, logs := setupLoggingTest(AccessLogConfig{
+			QuietPaths:       []string{"/healthz"},
+			QuietSampleEvery: 3,
+		})
+
+		for i := 0; i < 7; i++ {
+			serve(router, "/healthz")
+		}
+
+		// Requests 1, 4 and 7 are logged
+		assert.Len(t, loggedPaths(logs), 3)
+	})
+
+	t.Run("should always log failed requests to quiet paths", func(t *testing.T) {
+		router, logs := setupLoggingTest(AccessLogConfig{
+			QuietPaths: []string{"/healthz", "/ready"},
+		})
+
+		serve(router, "/ready")
+
+		assert.Equal(t, []string{"/ready"}, loggedPaths(logs))
+		assert.Equal(t, "HTTP request failed", logs.All()[0].Message)
+	})
+
+	t.Run("should log everything without quiet paths", func(t *testing.T) {
+		router, logs := setupLoggingTest(AccessLogConfig{})
+
+		serve(router, "/healthz")
+		serve(router, "/v1/cart/user-123")
+
+		assert.Equal(t, []string{"/healthz", "/v1/cart/user-123"}, loggedPaths(logs))
+	})
+}
+
+func TestZapMiddlewareFields(t *testing.T) {
+	t.Run("should log the route template, protocol and response size", func(t *testing.T) {
+		router, logs := setupLoggingTest(AccessLogConfig{})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/v1/cart/user-123", nil)
+		router.ServeHTTP(w, req)
+
+		entries := logs.All()
+		assert.Len(t, entries, 1)
+		fields := entries[0].ContextMap()
+		assert.Equal(t, "/v1/cart/user-123", fields["path"])
+		assert.Equal(t, "/v1/cart/:user_id", fields["route"])
+		assert.Equal(t, "HTTP/1.1", fields["proto"])
+		assert.Equal(t, int64(w.Body.Len()), fields["response_size"])
+	})
+
+	t.Run("should log an empty route and zero size for unmatched requests without a body", func(t *testing.T) {
+		core, logs := observer.New(zap.InfoLevel)
+		router := gin.New()
+		router.Use(ZapMiddleware(zap.New(core), AccessLogConfig{}))
+		router.HEAD("/healthz", func(c *gin.Context) {
+			c.Status(http.StatusNoContent)
+		})
+
+		serve(router, "/unknown")
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("HEAD", "/healthz", nil)
+		router.ServeHTTP(w, req)
+
+		entries := logs.All()
+		assert.Len(t, entries, 2)
+		assert.Equal(t, "", entries[0].ContextMap()["route"])
+		assert.Equal(t, "/healthz", entries[1].ContextMap()["route"])
+		assert.Equal(t, int64(0), entries[1].ContextMap()["response_size"])
+	})
+
+	t.Run("should log the trace and span IDs of the request span", func(t *testing.T) {
+		recorder := tracetest.NewSpanRecorder()
+		provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+		core, logs := observer.New(zap.InfoLevel)
+		router := gin.New()
+		router.Use(otelgin.Middleware("test", otelgin.WithTracerProvider(provider)))
+		router.Use(ZapMiddleware(zap.New(core), AccessLogConfig{}))
+		router.GET("/v1/cart/:user_id", func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		serve(router, "/v1/cart/user-123")
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 1)
+		require.Len(t, logs.All(), 1)
+		fields := logs.All()[0].ContextMap()
+		assert.Equal(t, spans[0].SpanContext().TraceID().String(), fields["trace_id"])
+		assert.Equal(t, spans[0].SpanContext().SpanID().String(), fields["span_id"])
+	})
+
+	t.Run("should leave out the trace and span IDs without a span", func(t *testing.T) {
+		router, logs := setupLoggingTest(AccessLogConfig{})
+
+		serve(router, "/v1/cart/user-123")
+
+		require.Len(t, logs.All(), 1)
+		fields := logs.All()[0].ContextMap()
+		assert.NotContains(t, fields, "trace_id")
+		assert.NotContains(t, fields, "span_id")
+	})
+}
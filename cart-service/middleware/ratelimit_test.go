@@ -0,0 +1,122 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupRateLimitTest creates a router with RateLimitMiddleware in front of a probe and a cart route
+func setupRateLimitTest(config RateLimitConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(RateLimitMiddleware(config))
+	router.GET("/healthz", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "healthy"})
+	})
+	router.GET("/v1/cart/:user_id", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"user_id": c.Param("user_id")})
+	})
+
+	return router
+}
+
+// serveFrom sends a GET request that appears to come from the given client IP
+func serveFrom(router *gin.Engine, ip, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", path, nil)
+	req.RemoteAddr = ip + ":40000"
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	t.Run("should return 429 with Retry-After once the burst is spent", func(t *testing.T) {
+		router := setupRateLimitTest(RateLimitConfig{RPS: 0.5, Burst: 2})
+
+		assert.Equal(t, http.StatusOK, serveFrom(router, "192.0.2.1", "/v1/cart/user-1").Code)
+		assert.Equal(t, http.StatusOK, serveFrom(router, "192.0.2.1", "/v1/cart/user-1").Code)
+
+		w := serveFrom(router, "192.0.2.1", "/v1/cart/user-1")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Contains(t, w.Body.String(), `"code":"RATE_LIMITED"`)
+		// One token every 2 seconds
+		assert.Equal(t, "2", w.Header().Get("Retry-After"))
+	})
+
+	t.Run("should limit each client IP separately", func(t *testing.T) {
+		router := setupRateLimitTest(RateLimitConfig{RPS: 1, Burst: 1})
+
+		assert.Equal(t, http.StatusOK, serveFrom(router, "192.0.2.1", "/v1/cart/user-1").Code)
+		assert.Equal(t, http.StatusTooManyRequests, serveFrom(router, "192.0.2.1", "/v1/cart/user-1").Code)
+		assert.Equal(t, http.StatusOK, serveFrom(router, "192.0.2.2", "/v1/cart/user-1").Code)
+	})
+
+	t.Run("should never limit exempt paths", func(t *testing.T) {
+		router := setupRateLimitTest(RateLimitConfig{RPS: 1, Burst: 1, ExemptPaths: []string{"/healthz"}})
+
+		for i := 0; i < 5; i++ {
+			assert.Equal(t, http.StatusOK, serveFrom(router, "192.0.2.1", "/healthz").Code)
+		}
+		// Probes don't use up the client's tokens either
+		assert.Equal(t, http.StatusOK, serveFrom(router, "192.0.2.1", "/v1/cart/user-1").Code)
+	})
+
+	t.Run("should pass everything through when disabled", func(t *testing.T) {
+		router := setupRateLimitTest(RateLimitConfig{})
+
+		for i := 0; i < 5; i++ {
+			assert.Equal(t, http.StatusOK, serveFrom(router, "192.0.2.1", "/v1/cart/user-1").Code)
+		}
+	})
+}
+
+func TestClientBuckets(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	t.Run("should refill tokens over time", func(t *testing.T) {
+		buckets := newClientBuckets(RateLimitConfig{RPS: 1, Burst: 1}, clock)
+
+		assert.Zero(t, buckets.reserve("192.0.2.1"))
+		assert.Equal(t, time.Second, buckets.reserve("192.0.2.1"))
+
+		// A rejected request doesn't push the next token further out
+		assert.Equal(t, time.Second, buckets.reserve("192.0.2.1"))
+
+		now = now.Add(time.Second)
+		assert.Zero(t, buckets.reserve("192.0.2.1"))
+	})
+
+	t.Run("should expire idle clients", func(t *testing.T) {
+		buckets := newClientBuckets(RateLimitConfig{RPS: 1, Burst: 1, IdleTTL: time.Minute}, clock)
+
+		buckets.reserve("192.0.2.1")
+		now = now.Add(30 * time.Second)
+		buckets.reserve("192.0.2.2")
+		assert.Len(t, buckets.buckets, 2)
+
+		now = now.Add(30 * time.Second)
+		buckets.reserve("192.0.2.2")
+		assert.Len(t, buckets.buckets, 1)
+		assert.NotContains(t, buckets.buckets, "192.0.2.1")
+	})
+
+	t.Run("should keep at most MaxClients buckets", func(t *testing.T) {
+		buckets := newClientBuckets(RateLimitConfig{RPS: 1, Burst: 1, MaxClients: 2}, clock)
+
+		buckets.reserve("192.0.2.1")
+		buckets.reserve("192.0.2.2")
+		buckets.reserve("192.0.2.1") // 192.0.2.2 is now the least recently seen
+		buckets.reserve("192.0.2.3")
+
+		assert.Len(t, buckets.buckets, 2)
+		assert.Contains(t, buckets.buckets, "192.0.2.1")
+		assert.Contains(t, buckets.buckets, "192.0.2.3")
+	})
+}
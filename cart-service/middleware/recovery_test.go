@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"cart-service/internal/apierror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRecoveryMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("should answer a panic with a JSON 500, an error log and a failed span", func(t *testing.T) {
+		recorder := tracetest.NewSpanRecorder()
+		provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+		core, logs := observer.New(zap.InfoLevel)
+
+		router := gin.New()
+		router.Use(otelgin.Middleware("test", otelgin.WithTracerProvider(provider)))
+		router.Use(RequestIDMiddleware())
+		router.Use(RecoveryMiddleware(zap.New(core)))
+		router.GET("/v1/cart/:user_id", func(c *gin.Context) {
+			panic("something went wrong")
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/v1/cart/user-123", nil)
+		req.Header.Set(RequestIDHeader, "req-1")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+		var apiErr apierror.APIError
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &apiErr))
+		assert.Equal(t, apierror.CodeInternal, apiErr.Code)
+		assert.NotContains(t, w.Body.String(), "something went wrong", "Panic values must not leak to clients")
+
+		entries := logs.FilterMessage("Panic recovered").All()
+		require.Len(t, entries, 1)
+		assert.Equal(t, zapcore.ErrorLevel, entries[0].Level)
+		fields := entries[0].ContextMap()
+		assert.Equal(t, "something went wrong", fields["panic"])
+		assert.Equal(t, "/v1/cart/:user_id", fields["route"])
+		assert.Equal(t, "req-1", fields["request_id"])
+		assert.NotEmpty(t, fields["trace_id"])
+		assert.Contains(t, fields["stack"], "recovery_test.go")
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 1)
+		assert.Equal(t, codes.Error, spans[0].Status().Code)
+		assert.Equal(t, fields["trace_id"], spans[0].SpanContext().TraceID().String())
+		require.NotEmpty(t, spans[0].Events())
+		assert.Equal(t, "exception", spans[0].Events()[0].Name)
+	})
+
+	t.Run("should pass requests that don't panic through untouched", func(t *testing.T) {
+		core, logs := observer.New(zap.InfoLevel)
+
+		router := gin.New()
+		router.Use(RecoveryMiddleware(zap.New(core)))
+		router.GET("/healthz", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"status": "healthy"})
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/healthz", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Zero(t, logs.Len())
+	})
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// setupRequestIDTest creates a router whose handler echoes the ID it sees via RequestIDFromContext
+func setupRequestIDTest() (*gin.Engine, *observer.ObservedLogs) {
+	gin.SetMode(gin.TestMode)
+
+	core, logs := observer.New(zap.InfoLevel)
+	router := gin.New()
+	router.Use(RequestIDMiddleware())
+	router.Use(ZapMiddleware(zap.New(core), AccessLogConfig{}))
+	router.GET("/v1/cart/:user_id", func(c *gin.Context) {
+		c.String(http.StatusOK, RequestIDFromContext(c))
+	})
+
+	return router, logs
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	t.Run("should generate a UUID when none is sent", func(t *testing.T) {
+		router, logs := setupRequestIDTest()
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/v1/cart/user-1", nil)
+		router.ServeHTTP(w, req)
+
+		requestID := w.Header().Get(RequestIDHeader)
+		_, err := uuid.Parse(requestID)
+		require.NoError(t, err)
+
+		// Handlers and the access log see the same ID as the response header
+		assert.Equal(t, requestID, w.Body.String())
+		require.Equal(t, 1, logs.Len())
+		assert.Equal(t, requestID, logs.All()[0].ContextMap()["request_id"])
+	})
+
+	t.Run("should reuse an incoming X-Request-ID", func(t *testing.T) {
+		router, logs := setupRequestIDTest()
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/v1/cart/user-1", nil)
+		req.Header.Set(RequestIDHeader, "gw-7f3a9c")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, "gw-7f3a9c", w.Header().Get(RequestIDHeader))
+		assert.Equal(t, "gw-7f3a9c", w.Body.String())
+		assert.Equal(t, "gw-7f3a9c", logs.All()[0].ContextMap()["request_id"])
+	})
+
+	t.Run("should replace unsafe or oversized IDs", func(t *testing.T) {
+		router, _ := setupRequestIDTest()
+
+		for _, incoming := range []string{"abc\tdef", "has space", strings.Repeat("a", maxRequestIDLength+1)} {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/v1/cart/user-1", nil)
+			req.Header.Set(RequestIDHeader, incoming)
+			router.ServeHTTP(w, req)
+
+			_, err := uuid.Parse(w.Header().Get(RequestIDHeader))
+			assert.NoError(t, err, "incoming %q", incoming)
+		}
+	})
+}
+
+func TestRequestIDFromContext(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/", nil)
+	assert.Empty(t, RequestIDFromContext(req.Context()))
+}
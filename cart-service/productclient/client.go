@@ -0,0 +1,153 @@
+package productclient
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"cart-service/products"
+	"cart-service/proto/productpb"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
+)
+
+// Config holds the product-service gRPC connection settings
+type Config struct {
+	// Addr is the product-service gRPC address (e.g. product-service:9090)
+	Addr string
+	// Timeout bounds each call; 0 leaves calls bounded only by the caller's context
+	Timeout time.Duration
+	// KeepaliveTime is how long the connection may be idle before it is pinged
+	// gRPC raises values below 10s to 10s
+	KeepaliveTime time.Duration
+	// KeepaliveTimeout is how long to wait for a ping ack before the connection is closed
+	KeepaliveTimeout time.Duration
+}
+
+// Client fetches products from product-service over gRPC
+// All calls share one HTTP/2 connection, which multiplexes concurrent requests and is
+// re-established by gRPC when it drops, so there is no per-request connection setup
+type Client struct {
+	conn    *grpc.ClientConn
+	client  productpb.ProductServiceClient
+	timeout time.Duration
+	logger  *zap.Logger
+}
+
+// NewClient creates a client for the product-service gRPC API
+// The connection is made lazily on the first call, so product-service does not have to be up yet
+// Calls are traced by the otelgrpc stats handler, which injects W3C Trace Context into the
+// request metadata; opts are appended to the defaults (tests use them to dial an in-memory listener)
+func NewClient(cfg Config, logger *zap.Logger, opts ...grpc.DialOption) (*Client, error) {
+	dialOpts := append([]grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                cfg.KeepaliveTime,
+			Timeout:             cfg.KeepaliveTimeout,
+			PermitWithoutStream: true,
+		}),
+	}, opts...)
+
+	conn, err := grpc.Dial(cfg.Addr, dialOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create product-service gRPC connection to %s: %w", cfg.Addr, err)
+	}
+
+	return &Client{
+		conn:    conn,
+		client:  productpb.NewProductServiceClient(conn),
+		timeout: cfg.Timeout,
+		logger:  logger,
+	}, nil
+}
+
+// GetProduct fetches a product from product-service
+// Returns products.ErrProductNotFound when product-service does not know the product, or when
+// productID is not a positive integer and so cannot name one (like a 400 from the REST API)
+func (c *Client) GetProduct(ctx context.Context, productID string) (*products.Product, error) {
+	// Create a child span for this operation
+	tracer := otel.Tracer("cart-service")
+	ctx, span := tracer.Start(ctx, "productclient.GetProduct")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("product_id", productID))
+
+	id, err := strconv.ParseInt(productID, 10, 64)
+	if err != nil || id <= 0 {
+		span.SetStatus(codes.Error, "Product not found")
+		return nil, fmt.Errorf("failed to get product %s: %w", productID, products.ErrProductNotFound)
+	}
+
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	product, err := c.client.GetProduct(ctx, &productpb.GetProductRequest{Id: id})
+	if err != nil {
+		code := status.Code(err)
+		span.SetAttributes(attribute.String("rpc.grpc.status", code.String()))
+		if code == grpccodes.NotFound || code == grpccodes.InvalidArgument {
+			span.SetStatus(codes.Error, "Product not found")
+			return nil, fmt.Errorf("failed to get product %s: %w", productID, products.ErrProductNotFound)
+		}
+
+		span.SetStatus(codes.Error, "Product service request failed")
+		span.RecordError(err)
+		c.logger.Error("Product service gRPC request failed",
+			zap.String("action", "get"),
+			zap.String("product_id", productID),
+			zap.String("grpc_code", code.String()),
+			zap.Error(err),
+		)
+		return nil, fmt.Errorf("failed to get product %s: %w", productID, err)
+	}
+
+	span.SetStatus(codes.Ok, "Product retrieved")
+	return &products.Product{
+		ID:    int(product.GetId()),
+		Name:  product.GetName(),
+		Price: product.GetPrice(),
+		Stock: int(product.GetStock()),
+	}, nil
+}
+
+// Ping reports whether the connection to product-service is ready, for health checks
+// An idle connection is asked to connect, and Ping waits for it until ctx is done
+func (c *Client) Ping(ctx context.Context) error {
+	for {
+		state := c.conn.GetState()
+		switch state {
+		case connectivity.Ready:
+			return nil
+		case connectivity.Idle:
+			c.conn.Connect()
+		case connectivity.Shutdown:
+			return fmt.Errorf("product-service gRPC connection to %s is closed", c.conn.Target())
+		}
+		if !c.conn.WaitForStateChange(ctx, state) {
+			return fmt.Errorf("product-service gRPC connection to %s is %s: %w", c.conn.Target(), state, ctx.Err())
+		}
+	}
+}
+
+// Close closes the connection; calls made afterwards fail
+func (c *Client) Close() error {
+	if err := c.conn.Close(); err != nil {
+		return fmt.Errorf("failed to close product-service gRPC connection: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package productclient
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"cart-service/products"
+	"cart-service/proto/productpb"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// fakeProductServer serves a fixed catalog and records the traceparent of the last call
+type fakeProductServer struct {
+	productpb.UnimplementedProductServiceServer
+	products    map[int64]*productpb.Product
+	err         error
+	traceparent string
+}
+
+func (s *fakeProductServer) GetProduct(ctx context.Context, req *productpb.GetProductRequest) (*productpb.Product, error) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("traceparent"); len(values) > 0 {
+			s.traceparent = values[0]
+		}
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	product, ok := s.products[req.GetId()]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "Product not found")
+	}
+	return product, nil
+}
+
+// newTestClient connects a Client to server over an in-memory bufconn listener
+func newTestClient(t *testing.T, server *fakeProductServer) *Client {
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer()
+	productpb.RegisterProductServiceServer(grpcServer, server)
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	client, err := NewClient(Config{Addr: "bufnet", Timeout: time.Second}, zap.NewNop(),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestGetProduct(t *testing.T) {
+	ctx := context.Background()
+	catalog := map[int64]*productpb.Product{
+		1: {Id: 1, Name: "Laptop", Price: 999.99, Stock: 7, Category: "Electronics"},
+	}
+
+	t.Run("should return the product", func(t *testing.T) {
+		client := newTestClient(t, &fakeProductServer{products: catalog})
+
+		product, err := client.GetProduct(ctx, "1")
+		require.NoError(t, err)
+		assert.Equal(t, &products.Product{ID: 1, Name: "Laptop", Price: 999.99, Stock: 7}, product)
+	})
+
+	t.Run("should map NotFound to ErrProductNotFound", func(t *testing.T) {
+		client := newTestClient(t, &fakeProductServer{products: catalog})
+
+		_, err := client.GetProduct(ctx, "42")
+		assert.ErrorIs(t, err, products.ErrProductNotFound)
+	})
+
+	t.Run("should reject non-numeric IDs without calling the server", func(t *testing.T) {
+		server := &fakeProductServer{products: catalog, err: status.Error(codes.Internal, "should not be called")}
+		client := newTestClient(t, server)
+
+		_, err := client.GetProduct(ctx, "prod-1")
+		assert.ErrorIs(t, err, products.ErrProductNotFound)
+	})
+
+	t.Run("should return other errors as they are", func(t *testing.T) {
+		client := newTestClient(t, &fakeProductServer{err: status.Error(codes.Unavailable, "database down")})
+
+		_, err := client.GetProduct(ctx, "1")
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, products.ErrProductNotFound)
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+	})
+
+	t.Run("should propagate the trace context", func(t *testing.T) {
+		previousProvider := otel.GetTracerProvider()
+		previousPropagator := otel.GetTextMapPropagator()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider())
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+		t.Cleanup(func() {
+			otel.SetTracerProvider(previousProvider)
+			otel.SetTextMapPropagator(previousPropagator)
+		})
+
+		server := &fakeProductServer{products: catalog}
+		client := newTestClient(t, server)
+
+		spanCtx, span := otel.Tracer("test").Start(ctx, "parent")
+		_, err := client.GetProduct(spanCtx, "1")
+		span.End()
+		require.NoError(t, err)
+
+		assert.Contains(t, server.traceparent, span.SpanContext().TraceID().String())
+	})
+}
+
+func TestPing(t *testing.T) {
+	t.Run("should connect and report a ready connection", func(t *testing.T) {
+		client := newTestClient(t, &fakeProductServer{})
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		assert.NoError(t, client.Ping(ctx))
+	})
+
+	t.Run("should fail when product-service is unreachable", func(t *testing.T) {
+		client, err := NewClient(Config{Addr: "unreachable"}, zap.NewNop(),
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+				return nil, errors.New("connection refused")
+			}),
+		)
+		require.NoError(t, err)
+		defer client.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		err = client.Ping(ctx)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("should fail once closed", func(t *testing.T) {
+		client := newTestClient(t, &fakeProductServer{})
+		require.NoError(t, client.Close())
+
+		assert.ErrorContains(t, client.Ping(context.Background()), "is closed")
+	})
+}
@@ -0,0 +1,179 @@
+package products
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.uber.org/zap"
+)
+
+// ErrProductNotFound is returned when product-service does not know the product
+var ErrProductNotFound = errors.New("product not found")
+
+// ErrInsufficientStock is returned when product-service cannot reserve the requested quantity
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+// Client wraps HTTP calls to product-service
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	logger     *zap.Logger
+}
+
+// Product is the subset of a product-service product that cart-service uses
+type Product struct {
+	ID    int     `json:"id"`
+	Name  string  `json:"name"`
+	Price float64 `json:"price"`
+	Stock int     `json:"stock"`
+}
+
+// stockRequest is the body sent to the product-service stock endpoints
+type stockRequest struct {
+	Quantity int `json:"quantity"`
+}
+
+// NewClient creates a product-service client
+// baseURL is the service root (e.g. http://product-service:8090); timeout bounds each call
+func NewClient(baseURL string, timeout time.Duration, logger *zap.Logger) *Client {
+	return &Client{
+		baseURL:    baseURL,
+		httpClient: &http.Client{Timeout: timeout},
+		logger:     logger,
+	}
+}
+
+// GetProduct fetches a product from product-service
+// Returns ErrProductNotFound when product-service does not know the product
+func (c *Client) GetProduct(ctx context.Context, productID string) (*Product, error) {
+	// Create a child span for this operation
+	tracer := otel.Tracer("cart-service")
+	ctx, span := tracer.Start(ctx, "products.get")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("product_id", productID))
+
+	endpoint := fmt.Sprintf("%s/products/%s", c.baseURL, url.PathEscape(productID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		span.SetStatus(codes.Error, "Failed to build request")
+		return nil, fmt.Errorf("failed to build product request: %w", err)
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		span.SetStatus(codes.Error, "Product service request failed")
+		span.RecordError(err)
+		c.logger.Error("Product service request failed",
+			zap.String("action", "get"),
+			zap.String("product_id", productID),
+			zap.Error(err),
+		)
+		return nil, fmt.Errorf("failed to get product %s: %w", productID, err)
+	}
+	defer resp.Body.Close()
+
+	span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusBadRequest:
+		span.SetStatus(codes.Error, "Product not found")
+		return nil, fmt.Errorf("failed to get product %s: %w", productID, ErrProductNotFound)
+	default:
+		span.SetStatus(codes.Error, "Unexpected product service response")
+		return nil, fmt.Errorf("failed to get product %s: unexpected status %d", productID, resp.StatusCode)
+	}
+
+	var product Product
+	if err := json.NewDecoder(resp.Body).Decode(&product); err != nil {
+		span.SetStatus(codes.Error, "Failed to decode product")
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to decode product %s: %w", productID, err)
+	}
+
+	span.SetStatus(codes.Ok, "Product retrieved")
+	return &product, nil
+}
+
+// ReserveStock takes quantity units of a product out of stock
+// Returns ErrInsufficientStock or ErrProductNotFound when product-service rejects the reservation
+func (c *Client) ReserveStock(ctx context.Context, productID string, quantity int) error {
+	return c.adjustStock(ctx, "reserve", productID, quantity)
+}
+
+// ReleaseStock returns previously reserved units of a product to stock
+func (c *Client) ReleaseStock(ctx context.Context, productID string, quantity int) error {
+	return c.adjustStock(ctx, "release", productID, quantity)
+}
+
+// adjustStock calls POST /products/:id/{action} on product-service
+// The W3C trace context is injected so the call joins the caller's trace
+func (c *Client) adjustStock(ctx context.Context, action, productID string, quantity int) error {
+	// Create a child span for this operation
+	tracer := otel.Tracer("cart-service")
+	ctx, span := tracer.Start(ctx, "products."+action)
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("product_id", productID),
+		attribute.Int("quantity", quantity),
+	)
+
+	body, err := json.Marshal(stockRequest{Quantity: quantity})
+	if err != nil {
+		span.SetStatus(codes.Error, "Failed to encode request")
+		return fmt.Errorf("failed to encode %s request: %w", action, err)
+	}
+
+	endpoint := fmt.Sprintf("%s/products/%s/%s", c.baseURL, url.PathEscape(productID), action)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		span.SetStatus(codes.Error, "Failed to build request")
+		return fmt.Errorf("failed to build %s request: %w", action, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		span.SetStatus(codes.Error, "Product service request failed")
+		span.RecordError(err)
+		c.logger.Error("Product service request failed",
+			zap.String("action", action),
+			zap.String("product_id", productID),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to %s stock for product %s: %w", action, productID, err)
+	}
+	defer resp.Body.Close()
+
+	span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		span.SetStatus(codes.Ok, "Stock updated")
+		return nil
+	case http.StatusNotFound, http.StatusBadRequest:
+		// product-service answers 400 for IDs that aren't integers, so they can't exist either
+		span.SetStatus(codes.Error, "Product not found")
+		return fmt.Errorf("failed to %s stock for product %s: %w", action, productID, ErrProductNotFound)
+	case http.StatusConflict:
+		span.SetStatus(codes.Error, "Insufficient stock")
+		return fmt.Errorf("failed to %s stock for product %s: %w", action, productID, ErrInsufficientStock)
+	default:
+		span.SetStatus(codes.Error, "Unexpected product service response")
+		return fmt.Errorf("failed to %s stock for product %s: unexpected status %d", action, productID, resp.StatusCode)
+	}
+}
@@ -0,0 +1,5 @@
+// Package proto holds the product-service gRPC definition used by productclient; the generated code is in productpb
+// Generating needs protoc with protoc-gen-go v1.31.0 and protoc-gen-go-grpc v1.3.0 on the PATH
+package proto
+
+//go:generate protoc -I . --go_out=.. --go_opt=module=cart-service --go-grpc_out=.. --go-grpc_opt=module=cart-service product.proto
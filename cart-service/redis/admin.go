@@ -0,0 +1,454 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+)
+
+// cartKeyPrefix is the prefix shared by all cart hashes ("cart:{userID}")
+const cartKeyPrefix = "cart:"
+
+// scanBatchSize is the COUNT hint passed to SCAN for admin keyspace walks
+const scanBatchSize = 100
+
+// CartSize describes how many distinct items a user's cart holds
+type CartSize struct {
+	UserID    string
+	ItemCount int64
+}
+
+// LargestCartsResult holds the outcome of a LargestCarts scan
+type LargestCartsResult struct {
+	Carts       []CartSize
+	ScannedKeys int
+	Truncated   bool // true when the scan stopped at maxKeys before walking the whole keyspace
+}
+
+// LargestCarts returns the limit carts with the most distinct items
+// Walks the keyspace with SCAN (never KEYS) and pipelines one HLEN per batch of keys
+// At most maxKeys cart keys are inspected so the cost of a single call stays bounded
+func (c *Client) LargestCarts(ctx context.Context, limit, maxKeys int) (*LargestCartsResult, error) {
+	// Create a child span for this operation
+	tracer := otel.Tracer("cart-service")
+	ctx, span := tracer.Start(ctx, "redis.LargestCarts")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.Int("limit", limit),
+		attribute.Int("max_keys", maxKeys),
+	)
+
+	if maxKeys <= 0 {
+		span.SetStatus(codes.Error, "Invalid key budget")
+		return nil, fmt.Errorf("max keys must be positive, got %d", maxKeys)
+	}
+
+	result := &LargestCartsResult{}
+	var sizes []CartSize
+	var cursor uint64
+
+	for {
+		keys, next, err := c.rdb.Scan(ctx, cursor, cartKeyPrefix+"*", scanBatchSize).Result()
+		if err != nil {
+			span.SetStatus(codes.Error, "Redis SCAN failed")
+			span.RecordError(err)
+			c.logger.Error("Failed to scan cart keys", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan cart keys: %w", err)
+		}
+
+		// Respect the key budget even when a batch overshoots it
+		if remaining := maxKeys - result.ScannedKeys; len(keys) > remaining {
+			keys = keys[:remaining]
+			result.Truncated = true
+		}
+
+		if len(keys) > 0 {
+			batch, err := c.hlenBatch(ctx, keys)
+			if err != nil {
+				span.SetStatus(codes.Error, "Redis HLEN failed")
+				span.RecordError(err)
+				c.logger.Error("Failed to count cart items", zap.Error(err))
+				return nil, fmt.Errorf("failed to count cart items: %w", err)
+			}
+			sizes = append(sizes, batch...)
+			result.ScannedKeys += len(keys)
+		}
+
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+		if result.ScannedKeys >= maxKeys {
+			result.Truncated = true
+			break
+		}
+	}
+
+	// Largest first; ties broken by user ID for stable output
+	sort.Slice(sizes, func(i, j int) bool {
+		if sizes[i].ItemCount != sizes[j].ItemCount {
+			return sizes[i].ItemCount > sizes[j].ItemCount
+		}
+		return sizes[i].UserID < sizes[j].UserID
+	})
+	if len(sizes) > limit {
+		sizes = sizes[:limit]
+	}
+	result.Carts = sizes
+
+	span.SetAttributes(
+		attribute.Int("scanned_keys", result.ScannedKeys),
+		attribute.Bool("truncated", result.Truncated),
+	)
+	span.SetStatus(codes.Ok, "Largest carts retrieved")
+
+	return result, nil
+}
+
+// ListCartUserIDs returns one page of user IDs that have a cart, with the cart: prefix stripped
+// Runs a single SCAN (never KEYS) from cursor with count as the batch size hint, so a call never
+// blocks Redis; pass the returned cursor to fetch the next page until it is 0
+// As with any SCAN, a page may hold more or fewer IDs than count, and an ID may appear twice
+// if the keyspace is resized while paging
+func (c *Client) ListCartUserIDs(ctx context.Context, cursor uint64, count int64) ([]string, uint64, error) {
+	// Create a child span for this operation
+	tracer := otel.Tracer("cart-service")
+	ctx, span := tracer.Start(ctx, "redis.ListCartUserIDs")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.Int64("cursor", int64(cursor)),
+		attribute.Int64("count", count),
+	)
+
+	keys, next, err := c.rdb.Scan(ctx, cursor, cartKeyPrefix+"*", count).Result()
+	if err != nil {
+		span.SetStatus(codes.Error, "Redis SCAN failed")
+		span.RecordError(err)
+		c.logger.Error("Failed to scan cart keys", zap.Error(err))
+		return nil, 0, fmt.Errorf("failed to scan cart keys: %w", err)
+	}
+
+	userIDs := make([]string, len(keys))
+	for i, key := range keys {
+		userIDs[i] = strings.TrimPrefix(key, cartKeyPrefix)
+	}
+
+	span.SetAttributes(
+		attribute.Int("user_ids", len(userIDs)),
+		attribute.Bool("last_page", next == 0),
+	)
+	span.SetStatus(codes.Ok, "Cart user IDs listed")
+
+	return userIDs, next, nil
+}
+
+// RawCart returns a user's cart hash exactly as stored, without parsing the quantities
+// Unlike GetCart it keeps corrupted entries, so they can be inspected during incidents
+func (c *Client) RawCart(ctx context.Context, userID string) (map[string]string, error) {
+	// Create a child span for this operation
+	tracer := otel.Tracer("cart-service")
+	ctx, span := tracer.Start(ctx, "redis.RawCart")
+	defer span.End()
+
+	key := cartKeyPrefix + userID
+	ctx, cancel := c.opContext(ctx)
+	defer cancel()
+	defer c.trackSlow(span, "RawCart", time.Now(), key)
+
+	span.SetAttributes(attribute.String("user_id", userID))
+
+	var fields map[string]string
+	err := c.withRetry(ctx, span, "RawCart", true, func(ctx context.Context) error {
+		var err error
+		fields, err = c.rdb.HGetAll(ctx, key).Result()
+		return err
+	})
+	if err != nil {
+		err = c.checkTimeout(ctx, span, err)
+		span.SetStatus(codes.Error, "Redis HGETALL failed")
+		span.RecordError(err)
+		c.spanLogger(ctx).Error("Failed to get raw cart",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		return nil, fmt.Errorf("failed to get raw cart: %w", err)
+	}
+
+	span.SetAttributes(attribute.Int("field_count", len(fields)))
+	span.SetStatus(codes.Ok, "Raw cart retrieved")
+
+	return fields, nil
+}
+
+// RepairCart deletes the fields of a user's cart whose quantity is not an integer
+// These are the entries GetCart skips; without a repair they stay in the hash forever
+// The read and the HDEL run in a WATCH transaction, so a field fixed concurrently is kept
+// Returns the number of fields removed; their names are logged at warn
+func (c *Client) RepairCart(ctx context.Context, userID string) (removed int, err error) {
+	// Create a child span for this operation
+	tracer := otel.Tracer("cart-service")
+	ctx, span := tracer.Start(ctx, "redis.RepairCart")
+	defer span.End()
+
+	key := cartKeyPrefix + userID
+	ctx, cancel := c.opContext(ctx)
+	defer cancel()
+	defer c.trackSlow(span, "RepairCart", time.Now(), key)
+
+	span.SetAttributes(attribute.String("user_id", userID))
+
+	var corrupt []string
+	txf := func(tx *redis.Tx) error {
+		fields, err := tx.HGetAll(ctx, key).Result()
+		if err != nil {
+			return err
+		}
+
+		corrupt = corrupt[:0]
+		for field, quantityStr := range fields {
+			if _, err := strconv.Atoi(quantityStr); err != nil {
+				corrupt = append(corrupt, field)
+			}
+		}
+		if len(corrupt) == 0 {
+			return nil
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.HDel(ctx, key, corrupt...)
+			return nil
+		})
+		return err
+	}
+
+	// A re-run after an applied EXEC finds no corrupted fields left and removes nothing
+	err = c.withRetry(ctx, span, "RepairCart", true, func(ctx context.Context) error {
+		var err error
+		for attempt := 0; attempt < maxTxRetries; attempt++ {
+			err = c.rdb.Watch(ctx, txf, key)
+			if !errors.Is(err, redis.TxFailedErr) {
+				break
+			}
+		}
+		return err
+	})
+	if err != nil {
+		err = c.checkTimeout(ctx, span, err)
+		span.SetStatus(codes.Error, "Redis repair transaction failed")
+		span.RecordError(err)
+		c.spanLogger(ctx).Error("Failed to repair cart",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		return 0, fmt.Errorf("failed to repair cart: %w", err)
+	}
+
+	span.SetAttributes(attribute.Int("removed_fields", len(corrupt)))
+	span.SetStatus(codes.Ok, "Cart repaired")
+	if len(corrupt) > 0 {
+		sort.Strings(corrupt)
+		c.spanLogger(ctx).Warn("Removed corrupted cart fields",
+			zap.String("user_id", userID),
+			zap.Strings("product_ids", corrupt),
+		)
+	}
+
+	return len(corrupt), nil
+}
+
+// hlenBatch pipelines HLEN for a batch of cart keys
+// Keys that reply with an error (e.g. WRONGTYPE for a non-hash key) are skipped
+func (c *Client) hlenBatch(ctx context.Context, keys []string) ([]CartSize, error) {
+	cmds := make([]*redis.IntCmd, len(keys))
+	_, err := c.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			cmds[i] = pipe.HLen(ctx, key)
+		}
+		return nil
+	})
+	var replyErr redis.Error
+	if err != nil && !errors.As(err, &replyErr) {
+		return nil, err
+	}
+
+	sizes := make([]CartSize, 0, len(keys))
+	for i, key := range keys {
+		if cmds[i].Err() != nil {
+			continue
+		}
+		sizes = append(sizes, CartSize{
+			UserID:    strings.TrimPrefix(key, cartKeyPrefix),
+			ItemCount: cmds[i].Val(),
+		})
+	}
+	return sizes, nil
+}
+
+// maxTxRetries bounds how often an optimistic WATCH transaction is retried after a conflict
+const maxTxRetries = 3
+
+// NormalizeOptions selects the steps applied when normalizing product IDs
+type NormalizeOptions struct {
+	TrimSpace         bool // Remove surrounding whitespace (" 42 " -> "42")
+	StripLeadingZeros bool // Strip leading zeros from all-digit IDs ("007" -> "7")
+}
+
+// Normalize applies the configured steps to a product ID
+func (o NormalizeOptions) Normalize(productID string) string {
+	if o.TrimSpace {
+		productID = strings.TrimSpace(productID)
+	}
+	if o.StripLeadingZeros && isDigits(productID) {
+		productID = strings.TrimLeft(productID, "0")
+		if productID == "" {
+			productID = "0"
+		}
+	}
+	return productID
+}
+
+// isDigits reports whether s is a non-empty string of ASCII digits
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// CartMerge describes cart lines that were folded into a single normalized product ID
+type CartMerge struct {
+	ProductID string   // Normalized ID the lines now live under
+	SourceIDs []string // Original field names that were merged, sorted
+	Quantity  int      // Resulting total quantity
+}
+
+// NormalizeCart rewrites a user's cart so every product ID is in normalized form
+// Lines whose IDs normalize to the same value have their quantities summed
+// Runs as an optimistic WATCH/MULTI/EXEC transaction and retries if the cart changes concurrently
+// Fields with non-integer quantities are left untouched
+func (c *Client) NormalizeCart(ctx context.Context, userID string, opts NormalizeOptions) ([]CartMerge, error) {
+	// Create a child span for this operation
+	tracer := otel.Tracer("cart-service")
+	ctx, span := tracer.Start(ctx, "redis.NormalizeCart")
+	defer span.End()
+
+	ctx, cancel := c.opContext(ctx)
+	defer cancel()
+	defer c.trackSlow(span, "NormalizeCart", time.Now(), fmt.Sprintf("cart:%s", userID))
+
+	span.SetAttributes(
+		attribute.String("user_id", userID),
+		attribute.Bool("normalize.trim_space", opts.TrimSpace),
+		attribute.Bool("normalize.strip_leading_zeros", opts.StripLeadingZeros),
+	)
+
+	key := fmt.Sprintf("cart:%s", userID)
+
+	var merges []CartMerge
+	txf := func(tx *redis.Tx) error {
+		fields, err := tx.HGetAll(ctx, key).Result()
+		if err != nil {
+			return err
+		}
+
+		// Group fields by their normalized ID
+		groups := make(map[string][]string)
+		totals := make(map[string]int)
+		for field, quantityStr := range fields {
+			quantity, err := strconv.Atoi(quantityStr)
+			if err != nil {
+				continue
+			}
+			normalized := opts.Normalize(field)
+			if normalized == "" {
+				continue
+			}
+			groups[normalized] = append(groups[normalized], field)
+			totals[normalized] += quantity
+		}
+
+		// Only groups that were renamed or merged need rewriting
+		merges = merges[:0]
+		for normalized, sources := range groups {
+			if len(sources) == 1 && sources[0] == normalized {
+				continue
+			}
+			sort.Strings(sources)
+			merges = append(merges, CartMerge{
+				ProductID: normalized,
+				SourceIDs: sources,
+				Quantity:  totals[normalized],
+			})
+		}
+		sort.Slice(merges, func(i, j int) bool {
+			return merges[i].ProductID < merges[j].ProductID
+		})
+
+		if len(merges) == 0 {
+			return nil
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, merge := range merges {
+				for _, source := range merge.SourceIDs {
+					if source != merge.ProductID {
+						pipe.HDel(ctx, key, source)
+					}
+				}
+				pipe.HSet(ctx, key, merge.ProductID, merge.Quantity)
+			}
+			return nil
+		})
+		return err
+	}
+
+	// A re-run after an applied EXEC finds the IDs already normalized and rewrites nothing
+	err := c.withRetry(ctx, span, "NormalizeCart", true, func(ctx context.Context) error {
+		var err error
+		for attempt := 0; attempt < maxTxRetries; attempt++ {
+			err = c.rdb.Watch(ctx, txf, key)
+			if !errors.Is(err, redis.TxFailedErr) {
+				break
+			}
+		}
+		return err
+	})
+	if err != nil {
+		err = c.checkTimeout(ctx, span, err)
+		span.SetStatus(codes.Error, "Redis normalize transaction failed")
+		span.RecordError(err)
+		c.spanLogger(ctx).Error("Failed to normalize cart",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		return nil, fmt.Errorf("failed to normalize cart: %w", err)
+	}
+
+	span.SetAttributes(attribute.Int("merge_count", len(merges)))
+	span.SetStatus(codes.Ok, "Cart normalized")
+	if len(merges) > 0 {
+		c.spanLogger(ctx).Info("Cart normalized",
+			zap.String("user_id", userID),
+			zap.Int("merge_count", len(merges)),
+		)
+	}
+
+	return merges, nil
+}
@@ -0,0 +1,104 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+	"go.uber.org/zap"
+)
+
+// ErrRedisUnauthorized is returned when Redis rejects the client's credentials or ACL permissions
+// It signals a configuration problem (rotated password, missing ACL rule) rather than bad data,
+// so callers can tell it apart with errors.Is even through the "failed to ..." wrapping
+var ErrRedisUnauthorized = errors.New("redis credentials or permissions rejected")
+
+// authErrorPrefixes are the Redis error codes that mean the client is not allowed to run a command
+// NOPERM: the ACL user lacks the command or key; WRONGPASS: AUTH failed; NOAUTH: AUTH is required
+var authErrorPrefixes = []string{"NOPERM", "WRONGPASS", "NOAUTH"}
+
+// ignoredAuthCommands are sent by go-redis itself when a connection is set up (CLIENT SETINFO),
+// which tolerates their failure; a restricted ACL user would otherwise log on every new connection
+var ignoredAuthCommands = map[string]bool{"client": true}
+
+// isAuthError reports whether err is a Redis server error carrying one of authErrorPrefixes
+func isAuthError(err error) bool {
+	var redisErr redis.Error
+	if !errors.As(err, &redisErr) {
+		return false
+	}
+	message := redisErr.Error()
+	for _, prefix := range authErrorPrefixes {
+		if strings.HasPrefix(message, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// authErrorHook rewrites auth and permission failures into ErrRedisUnauthorized
+// Every command passes through it, so operators get one distinct log line and metric
+// no matter which cart operation tripped over the credentials
+type authErrorHook struct {
+	logger   *zap.Logger
+	failures metric.Int64Counter
+}
+
+// newAuthErrorHook creates the hook and its redis.auth.failures counter (no-op without a meter provider)
+func newAuthErrorHook(logger *zap.Logger) *authErrorHook {
+	failures, err := otel.Meter("cart-service").Int64Counter("redis.auth.failures",
+		metric.WithDescription("Redis commands rejected with NOPERM, WRONGPASS or NOAUTH"),
+	)
+	if err != nil {
+		// The log line is still emitted; only the metric is lost
+		logger.Warn("Failed to create Redis auth failure counter", zap.Error(err))
+		failures = noop.Int64Counter{}
+	}
+	return &authErrorHook{logger: logger, failures: failures}
+}
+
+func (h *authErrorHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h *authErrorHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		err := next(ctx, cmd)
+		if isAuthError(err) && !ignoredAuthCommands[cmd.Name()] {
+			err = h.unauthorized(ctx, cmd.Name(), err)
+			cmd.SetErr(err)
+		}
+		return err
+	}
+}
+
+func (h *authErrorHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		err := next(ctx, cmds)
+		for _, cmd := range cmds {
+			if isAuthError(cmd.Err()) && !ignoredAuthCommands[cmd.Name()] {
+				cmd.SetErr(h.unauthorized(ctx, cmd.Name(), cmd.Err()))
+			}
+		}
+		if isAuthError(err) {
+			err = fmt.Errorf("%w: %w", ErrRedisUnauthorized, err)
+		}
+		return err
+	}
+}
+
+// unauthorized records the failure and wraps err so errors.Is(err, ErrRedisUnauthorized) holds
+func (h *authErrorHook) unauthorized(ctx context.Context, command string, err error) error {
+	h.failures.Add(ctx, 1, metric.WithAttributes(attribute.String("db.operation", command)))
+	h.logger.Error("Redis rejected credentials or permissions; check REDIS_USERNAME, REDIS_PASSWORD and the ACL rules",
+		zap.String("command", command),
+		zap.Error(err),
+	)
+	return fmt.Errorf("%w: %w", ErrRedisUnauthorized, err)
+}
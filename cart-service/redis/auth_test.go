@@ -0,0 +1,99 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	redisclient "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// authFailureCount reads the redis.auth.failures counter total from a manual reader
+func authFailureCount(t *testing.T, reader *sdkmetric.ManualReader) int64 {
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+
+	var total int64
+	for _, scope := range rm.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			if m.Name != "redis.auth.failures" {
+				continue
+			}
+			for _, point := range m.Data.(metricdata.Sum[int64]).DataPoints {
+				total += point.Value
+			}
+		}
+	}
+	return total
+}
+
+// setupAuthTest returns a client whose auth hook reports to an in-memory meter and log observer
+func setupAuthTest(t *testing.T) (*Client, *miniredis.Miniredis, *sdkmetric.ManualReader, *observer.ObservedLogs) {
+	reader := sdkmetric.NewManualReader()
+	previous := otel.GetMeterProvider()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	t.Cleanup(func() {
+		otel.SetMeterProvider(previous)
+	})
+
+	mr := miniredis.RunT(t)
+	rdb := redisclient.NewClient(&redisclient.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+
+	core, logs := observer.New(zap.ErrorLevel)
+	client := NewClient(rdb, zap.New(core))
+
+	// Open the connection first: the failures below are permissions revoked after connecting
+	require.NoError(t, rdb.Ping(context.Background()).Err())
+
+	return client, mr, reader, logs
+}
+
+func TestAuthErrors(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should map NOPERM to ErrRedisUnauthorized", func(t *testing.T) {
+		client, mr, reader, logs := setupAuthTest(t)
+		mr.SetError("NOPERM User cart has no permissions to run the 'hincrby' command")
+
+		err := client.AddItem(ctx, "user-1", "1", 2)
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrRedisUnauthorized))
+		// The original server message is kept for the logs
+		assert.Contains(t, err.Error(), "NOPERM")
+
+		authLogs := logs.FilterField(zap.String("command", "hincrby")).All()
+		require.Len(t, authLogs, 1)
+		assert.Contains(t, authLogs[0].Message, "credentials or permissions")
+		assert.Equal(t, int64(1), authFailureCount(t, reader))
+	})
+
+	t.Run("should map WRONGPASS inside a transaction", func(t *testing.T) {
+		client, mr, reader, _ := setupAuthTest(t)
+		mr.SetError("WRONGPASS invalid username-password pair or user is disabled.")
+
+		err := client.SetItems(ctx, "user-1", []CartItem{{ProductID: "1", Quantity: 2}}, 0, 0)
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrRedisUnauthorized))
+		assert.Positive(t, authFailureCount(t, reader))
+	})
+
+	t.Run("should leave other errors alone", func(t *testing.T) {
+		client, mr, reader, logs := setupAuthTest(t)
+		mr.SetError("ERR something else went wrong")
+
+		_, err := client.GetCart(ctx, "user-1")
+		require.Error(t, err)
+		assert.False(t, errors.Is(err, ErrRedisUnauthorized))
+		assert.Zero(t, logs.FilterMessageSnippet("credentials or permissions").Len())
+		assert.Zero(t, authFailureCount(t, reader))
+	})
+}
@@ -2,119 +2,355 @@ package redis
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
-	"math"
-	"math/rand"
+	"net"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	"cart-service/internal/retry"
+
 	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
 // Client wraps the Redis client with additional functionality
+// rdb is a standalone, cluster or sentinel-backed client depending on Config.Mode
 type Client struct {
-	rdb    *redis.Client
+	rdb    redis.UniversalClient
 	logger *zap.Logger
+	// opTimeout bounds each cart operation; zero means no per-operation deadline
+	opTimeout time.Duration
+	// slowThreshold is the duration above which a cart operation is logged as slow; zero disables it
+	slowThreshold time.Duration
+	// scanThreshold is the cart size above which GetCart reads with HSCAN; zero always uses HGETALL
+	scanThreshold int
+	// opRetry is the backoff for retrying cart operations on transient errors; zero MaxRetries disables it
+	opRetry retry.RetryConfig
+
+	// eventsChannel is the pub/sub channel cart events are published to; events is nil when
+	// they are disabled, and eventsDone is closed by Close to stop the publisher
+	eventsChannel string
+	events        chan CartEvent
+	eventsDone    chan struct{}
+
+	// lastMemoryEstimate backs the cart.memory.estimated_bytes gauge
+	lastMemoryEstimate atomic.Pointer[MemoryEstimate]
+}
+
+// Mode selects the Redis deployment InitRedis connects to
+type Mode string
+
+const (
+	// ModeStandalone connects to a single Redis node
+	ModeStandalone Mode = "standalone"
+	// ModeCluster connects to a Redis Cluster through one or more seed nodes
+	ModeCluster Mode = "cluster"
+	// ModeSentinel connects to the current master of a Sentinel-managed deployment
+	ModeSentinel Mode = "sentinel"
+)
+
+// Config holds the connection settings for InitRedis
+type Config struct {
+	Mode Mode // Defaults to ModeStandalone when empty
+	// Addr is the node address; in cluster and sentinel mode a comma-separated list of
+	// seed nodes or sentinels
+	Addr     string
+	Username string // Redis 6+ ACL user; empty uses the default user
+	Password string // Never logged
+	DB       int    // Must be 0 in cluster mode
+	Pool     PoolConfig
+
+	// MasterName is the Sentinel master set to follow (sentinel mode only)
+	MasterName string
+	// SentinelPassword authenticates against the sentinels themselves; never logged
+	SentinelPassword string
+
+	// TLSEnabled connects over TLS, verifying the server certificate against the host in Addr
+	TLSEnabled bool
+	// TLSInsecureSkipVerify disables certificate verification (self-signed test clusters only)
+	TLSInsecureSkipVerify bool
+
+	// OpTimeout is the deadline for a whole cart operation, including transaction retries
+	// Operations that exceed it fail with ErrOpTimeout; zero disables the deadline
+	OpTimeout time.Duration
+	// SlowThreshold logs a warning and sets redis.slow on the span for cart operations that
+	// take longer; zero disables the check
+	SlowThreshold time.Duration
+	// ScanThreshold makes GetCart read carts with more items than this in HSCAN batches
+	// instead of one HGETALL; zero always uses HGETALL
+	ScanThreshold int
+	// OpRetry retries a cart operation that failed with a transient error (failover, dropped
+	// connection) on top of go-redis's per-command PoolConfig.MaxRetries; zero MaxRetries disables it
+	OpRetry retry.RetryConfig
+	// EventsChannel makes cart writes publish a CartEvent to this pub/sub channel;
+	// empty disables events
+	EventsChannel string
 }
 
-// RetryConfig holds configuration for exponential backoff retry logic
-type RetryConfig struct {
-	InitialDelay time.Duration // Starting delay (e.g., 100ms)
-	MaxDelay     time.Duration // Maximum delay (e.g., 2s)
-	MaxRetries   int           // Maximum number of retries (e.g., 5)
-	JitterPct    float64       // Jitter percentage (e.g., 0.1 for ±10%)
+// PoolConfig holds the connection pool and timeout settings applied to redis.Options
+type PoolConfig struct {
+	PoolSize        int           // Maximum number of socket connections
+	MinIdleConns    int           // Minimum number of idle connections
+	MaxRetries      int           // Automatic retries for failed commands
+	DialTimeout     time.Duration // Timeout for establishing new connections
+	ReadTimeout     time.Duration // Timeout for socket reads
+	WriteTimeout    time.Duration // Timeout for socket writes
+	ConnMaxIdleTime time.Duration // Close idle connections after this duration
 }
 
-// DefaultRetryConfig returns the default retry configuration
-// Initial delay: 100ms, Max delay: 2s, Max retries: 5, Jitter: ±10%
-func DefaultRetryConfig() RetryConfig {
-	return RetryConfig{
-		InitialDelay: 100 * time.Millisecond,
-		MaxDelay:     2 * time.Second,
-		MaxRetries:   5,
-		JitterPct:    0.1,
+// DefaultPoolConfig returns the default pool configuration
+// Pool size: 10, Min idle: 2, Max retries: 3, Dial: 5s, Read/Write: 3s, Max idle time: 5m
+func DefaultPoolConfig() PoolConfig {
+	return PoolConfig{
+		PoolSize:        10,
+		MinIdleConns:    2,
+		MaxRetries:      3,
+		DialTimeout:     5 * time.Second,
+		ReadTimeout:     3 * time.Second,
+		WriteTimeout:    3 * time.Second,
+		ConnMaxIdleTime: 5 * time.Minute,
+	}
+}
+
+// NewClient wraps an existing go-redis client without pinging it or adding tracing
+// This is primarily useful for tests that point the wrapper at miniredis
+func NewClient(rdb redis.UniversalClient, logger *zap.Logger) *Client {
+	// Surface NOPERM/WRONGPASS/NOAUTH as ErrRedisUnauthorized from every command
+	rdb.AddHook(newAuthErrorHook(logger))
+
+	return &Client{
+		rdb:    rdb,
+		logger: logger,
 	}
 }
 
 // InitRedis initializes a Redis client with connection pooling and instrumentation
 // The client is instrumented with OpenTelemetry for automatic span creation
 // Connection is verified by pinging Redis with retry logic
-func InitRedis(ctx context.Context, addr string, logger *zap.Logger) (*Client, error) {
-	// Create Redis client with connection pool settings
-	rdb := redis.NewClient(&redis.Options{
-		Addr:            addr,
-		Password:        "", // No password for local development
-		DB:              0,  // Use default DB
-		MaxRetries:      3,  // Automatic retry for failed commands
-		DialTimeout:     5 * time.Second,
-		ReadTimeout:     3 * time.Second,
-		WriteTimeout:    3 * time.Second,
-		PoolSize:        10,              // Maximum number of socket connections
-		MinIdleConns:    2,               // Minimum number of idle connections
-		ConnMaxIdleTime: 5 * time.Minute, // Close idle connections after this duration
-	})
+func InitRedis(ctx context.Context, config Config, logger *zap.Logger) (*Client, error) {
+	// Span covering the whole connection setup, so retries show up on the startup timeline
+	tracer := otel.Tracer("cart-service")
+	ctx, span := tracer.Start(ctx, "redis.InitRedis")
+	defer span.End()
+
+	pool := config.Pool
+	if config.Mode == "" {
+		config.Mode = ModeStandalone
+	}
+
+	// Create Redis client for the configured topology with connection pool settings
+	span.SetAttributes(
+		attribute.String("redis.mode", string(config.Mode)),
+		attribute.String("redis.addr", config.Addr),
+	)
+
+	rdb, err := newUniversalClient(config)
+	if err != nil {
+		span.SetStatus(codes.Error, "Invalid Redis configuration")
+		span.RecordError(err)
+		return nil, err
+	}
 
 	// Add OpenTelemetry instrumentation
 	// This automatically creates child spans for all Redis operations (HGET, HSET, etc.)
-	// Each Redis command will appear as a child span in the trace
+	// Each Redis command will appear as a child span in the trace; in cluster mode every
+	// node client is instrumented as the cluster discovers it
 	if err := redisotel.InstrumentTracing(rdb); err != nil {
+		span.SetStatus(codes.Error, "Failed to instrument Redis")
+		span.RecordError(err)
 		return nil, fmt.Errorf("failed to instrument Redis with OpenTelemetry: %w", err)
 	}
 
+	// Report the latest cart memory estimate as a metric (no-op without a meter provider)
+	client := NewClient(rdb, logger)
+	client.opTimeout = config.OpTimeout
+	client.slowThreshold = config.SlowThreshold
+	client.scanThreshold = config.ScanThreshold
+	client.opRetry = config.OpRetry
+	if err := client.registerMemoryGauge(); err != nil {
+		span.SetStatus(codes.Error, "Failed to register memory gauge")
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to register cart memory gauge: %w", err)
+	}
+
 	// Verify connection with retry logic
-	retryConfig := DefaultRetryConfig()
+	// Each attempt is recorded as an event on the span, giving a timeline of the retries
+	retryConfig := retry.DefaultRetryConfig()
 	if err := pingWithRetry(ctx, rdb, retryConfig, logger); err != nil {
-		return nil, fmt.Errorf("failed to connect to Redis at %s after %d retries: %w", addr, retryConfig.MaxRetries, err)
+		span.SetStatus(codes.Error, "Redis unreachable")
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to connect to Redis at %s after %d retries: %w", config.Addr, retryConfig.MaxRetries, err)
+	}
+	span.SetStatus(codes.Ok, "Redis connected")
+
+	if config.EventsChannel != "" {
+		client.startEvents(config.EventsChannel)
 	}
 
 	logger.Info("Redis client initialized successfully",
-		zap.String("addr", addr),
-		zap.Int("pool_size", 10),
-		zap.Duration("max_idle_time", 5*time.Minute),
+		zap.String("mode", string(config.Mode)),
+		zap.String("addr", config.Addr),
+		zap.String("master_name", config.MasterName),
+		zap.String("username", config.Username),
+		zap.Bool("password_set", config.Password != ""),
+		zap.Int("db", config.DB),
+		zap.Bool("tls_enabled", config.TLSEnabled),
+		zap.Int("pool_size", pool.PoolSize),
+		zap.Int("min_idle_conns", pool.MinIdleConns),
+		zap.Int("max_retries", pool.MaxRetries),
+		zap.Duration("dial_timeout", pool.DialTimeout),
+		zap.Duration("read_timeout", pool.ReadTimeout),
+		zap.Duration("write_timeout", pool.WriteTimeout),
+		zap.Duration("max_idle_time", pool.ConnMaxIdleTime),
+		zap.Duration("op_timeout", config.OpTimeout),
+		zap.Duration("slow_threshold", config.SlowThreshold),
+		zap.Int("op_max_retries", config.OpRetry.MaxRetries),
+		zap.String("events_channel", config.EventsChannel),
 	)
 
-	return &Client{
-		rdb:    rdb,
-		logger: logger,
-	}, nil
+	return client, nil
 }
 
-// pingWithRetry attempts to ping Redis with exponential backoff retry logic
-// Implements: Starting delay 100ms, max delay 2s, max 5 retries, ±10% jitter
-func pingWithRetry(ctx context.Context, rdb *redis.Client, config RetryConfig, logger *zap.Logger) error {
-	var lastErr error
+// newUniversalClient builds the go-redis client matching config.Mode
+// Standalone returns a *redis.Client, cluster a *redis.ClusterClient and sentinel a failover
+// *redis.Client that follows the master elected by the sentinels
+func newUniversalClient(config Config) (redis.UniversalClient, error) {
+	pool := config.Pool
 
-	for attempt := 0; attempt <= config.MaxRetries; attempt++ {
-		// Try to ping Redis
-		err := rdb.Ping(ctx).Err()
-		if err == nil {
-			if attempt > 0 {
-				logger.Info("Redis connection successful after retry",
-					zap.Int("attempts", attempt+1),
-				)
-			}
-			return nil
+	addrs := splitAddrs(config.Addr)
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no Redis address configured")
+	}
+
+	// Plaintext unless TLS is enabled; redisotel hooks sit above the connection,
+	// so instrumentation works the same over TLS
+	var tlsConfig *tls.Config
+	if config.TLSEnabled {
+		var err error
+		tlsConfig, err = newTLSConfig(addrs[0], config.TLSInsecureSkipVerify)
+		if err != nil {
+			return nil, err
+		}
+		if config.Mode != ModeStandalone {
+			// Nodes are discovered at runtime, so verify each against its own host
+			tlsConfig.ServerName = ""
 		}
+	}
 
-		lastErr = err
+	switch config.Mode {
+	case ModeStandalone:
+		if len(addrs) > 1 {
+			return nil, fmt.Errorf("standalone mode takes a single Redis address, got %d", len(addrs))
+		}
+		return redis.NewClient(&redis.Options{
+			Addr:            addrs[0],
+			Username:        config.Username,
+			Password:        config.Password, // Empty for local development
+			DB:              config.DB,
+			MaxRetries:      pool.MaxRetries,
+			DialTimeout:     pool.DialTimeout,
+			ReadTimeout:     pool.ReadTimeout,
+			WriteTimeout:    pool.WriteTimeout,
+			PoolSize:        pool.PoolSize,
+			MinIdleConns:    pool.MinIdleConns,
+			ConnMaxIdleTime: pool.ConnMaxIdleTime,
+			// Lets per-operation deadlines (Config.OpTimeout) cut blocked socket reads short
+			ContextTimeoutEnabled: true,
+			TLSConfig:             tlsConfig,
+		}), nil
 
-		// If this was the last attempt, don't wait
-		if attempt == config.MaxRetries {
-			break
+	case ModeCluster:
+		// Redis Cluster only has database 0
+		if config.DB != 0 {
+			return nil, fmt.Errorf("cluster mode does not support Redis database %d", config.DB)
 		}
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:                 addrs,
+			Username:              config.Username,
+			Password:              config.Password,
+			MaxRetries:            pool.MaxRetries,
+			DialTimeout:           pool.DialTimeout,
+			ReadTimeout:           pool.ReadTimeout,
+			WriteTimeout:          pool.WriteTimeout,
+			PoolSize:              pool.PoolSize,
+			MinIdleConns:          pool.MinIdleConns,
+			ConnMaxIdleTime:       pool.ConnMaxIdleTime,
+			ContextTimeoutEnabled: true,
+			TLSConfig:             tlsConfig,
+		}), nil
+
+	case ModeSentinel:
+		if config.MasterName == "" {
+			return nil, fmt.Errorf("sentinel mode requires a Redis master name")
+		}
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:            config.MasterName,
+			SentinelAddrs:         addrs,
+			SentinelPassword:      config.SentinelPassword,
+			Username:              config.Username,
+			Password:              config.Password,
+			DB:                    config.DB,
+			MaxRetries:            pool.MaxRetries,
+			DialTimeout:           pool.DialTimeout,
+			ReadTimeout:           pool.ReadTimeout,
+			WriteTimeout:          pool.WriteTimeout,
+			PoolSize:              pool.PoolSize,
+			MinIdleConns:          pool.MinIdleConns,
+			ConnMaxIdleTime:       pool.ConnMaxIdleTime,
+			ContextTimeoutEnabled: true,
+			TLSConfig:             tlsConfig,
+		}), nil
+
+	default:
+		return nil, fmt.Errorf("unknown Redis mode %q (supported: standalone, cluster, sentinel)", config.Mode)
+	}
+}
 
-		// Calculate exponential backoff delay
-		// Formula: min(initialDelay * 2^attempt, maxDelay)
-		delay := time.Duration(float64(config.InitialDelay) * math.Pow(2, float64(attempt)))
-		if delay > config.MaxDelay {
-			delay = config.MaxDelay
+// splitAddrs parses a comma-separated address list, dropping empty entries
+func splitAddrs(addr string) []string {
+	var addrs []string
+	for _, a := range strings.Split(addr, ",") {
+		if a = strings.TrimSpace(a); a != "" {
+			addrs = append(addrs, a)
 		}
+	}
+	return addrs
+}
+
+// newTLSConfig builds the TLS settings for a Redis address
+// ServerName is taken from the host part of addr so the certificate is checked against it
+func newTLSConfig(addr string, insecureSkipVerify bool) (*tls.Config, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Redis address %q for TLS: %w", addr, err)
+	}
+
+	return &tls.Config{
+		ServerName:         host,
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: insecureSkipVerify,
+	}, nil
+}
+
+// pingWithRetry attempts to ping Redis with exponential backoff retry logic
+// Implements: Starting delay 100ms, max delay 2s, max 5 retries, ±10% jitter
+// Every attempt adds a redis.ping.failed or redis.ping.succeeded event to the span in ctx
+func pingWithRetry(ctx context.Context, rdb redis.UniversalClient, config retry.RetryConfig, logger *zap.Logger) error {
+	span := trace.SpanFromContext(ctx)
 
-		// Add jitter to prevent thundering herd
-		// Jitter range: delay * (1 ± jitterPct)
-		jitter := 1.0 + (rand.Float64()*2-1)*config.JitterPct
-		delay = time.Duration(float64(delay) * jitter)
+	config.OnRetry = func(attempt int, err error, delay time.Duration) {
+		span.AddEvent("redis.ping.failed", trace.WithAttributes(
+			attribute.Int("attempt", attempt+1),
+			attribute.String("error", err.Error()),
+			attribute.Int64("retry_delay_ms", delay.Milliseconds()),
+		))
 
 		logger.Warn("Redis connection failed, retrying with exponential backoff",
 			zap.Error(err),
@@ -122,22 +358,37 @@ func pingWithRetry(ctx context.Context, rdb *redis.Client, config RetryConfig, l
 			zap.Int("max_retries", config.MaxRetries),
 			zap.Duration("retry_delay", delay),
 		)
+	}
 
-		// Wait before retrying
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-time.After(delay):
-			// Continue to next attempt
+	return retry.Do(ctx, config, func(ctx context.Context, attempt int) error {
+		// Try to ping Redis
+		err := rdb.Ping(ctx).Err()
+		if err != nil {
+			// The last attempt is not retried, so OnRetry never sees it
+			if attempt == config.MaxRetries {
+				span.AddEvent("redis.ping.failed", trace.WithAttributes(
+					attribute.Int("attempt", attempt+1),
+					attribute.String("error", err.Error()),
+				))
+			}
+			return err
 		}
-	}
 
-	return lastErr
+		span.AddEvent("redis.ping.succeeded", trace.WithAttributes(
+			attribute.Int("attempt", attempt+1),
+		))
+		if attempt > 0 {
+			logger.Info("Redis connection successful after retry",
+				zap.Int("attempts", attempt+1),
+			)
+		}
+		return nil
+	})
 }
 
 // GetClient returns the underlying Redis client
 // This is useful for operations not wrapped by the Client methods
-func (c *Client) GetClient() *redis.Client {
+func (c *Client) GetClient() redis.UniversalClient {
 	return c.rdb
 }
 
@@ -148,8 +399,11 @@ func (c *Client) Ping(ctx context.Context) error {
 }
 
 // Close closes the Redis connection
-// Should be called during graceful shutdown
+// Should be called during graceful shutdown; cart events still queued are dropped
 func (c *Client) Close() error {
 	c.logger.Info("Closing Redis connection")
+	if c.eventsDone != nil {
+		close(c.eventsDone)
+	}
 	return c.rdb.Close()
 }
@@ -0,0 +1,256 @@
+package redis
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"cart-service/internal/retry"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestInitRedisAuthentication(t *testing.T) {
+	t.Run("should authenticate with username and password", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		mr.RequireUserAuth("cart", "s3cret")
+
+		core, logs := observer.New(zap.InfoLevel)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		client, err := InitRedis(ctx, Config{
+			Addr:     mr.Addr(),
+			Username: "cart",
+			Password: "s3cret",
+			DB:       2,
+			Pool:     DefaultPoolConfig(),
+		}, zap.New(core))
+		require.NoError(t, err)
+		defer client.Close()
+
+		// Writes land in the selected database
+		require.NoError(t, client.AddItem(ctx, "user-1", "1", 2))
+		mr.Select(2)
+		assert.Equal(t, "2", mr.HGet("cart:user-1", "1"))
+
+		// The password must never reach the logs
+		for _, entry := range logs.All() {
+			for key, value := range entry.ContextMap() {
+				assert.NotContains(t, fmt.Sprint(value), "s3cret", "log field %q of %q", key, entry.Message)
+			}
+		}
+		initLogs := logs.FilterMessage("Redis client initialized successfully").All()
+		require.Len(t, initLogs, 1)
+		assert.Equal(t, true, initLogs[0].ContextMap()["password_set"])
+	})
+
+	t.Run("should fail with the wrong password", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		mr.RequireAuth("s3cret")
+
+		// Cut the retry loop short; the error is the same on every attempt
+		ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+		defer cancel()
+
+		_, err := InitRedis(ctx, Config{
+			Addr:     mr.Addr(),
+			Password: "wrong",
+			Pool:     DefaultPoolConfig(),
+		}, zap.NewNop())
+		require.Error(t, err)
+	})
+}
+
+// selfSignedCert creates a throwaway certificate that no client trusts by default
+func selfSignedCert(t *testing.T) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "miniredis"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"localhost"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestInitRedisTLS(t *testing.T) {
+	t.Run("should connect over TLS", func(t *testing.T) {
+		mr := miniredis.NewMiniRedis()
+		require.NoError(t, mr.StartTLS(&tls.Config{Certificates: []tls.Certificate{selfSignedCert(t)}}))
+		defer mr.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		// The certificate is self-signed, so verification has to be skipped
+		client, err := InitRedis(ctx, Config{
+			Addr:                  mr.Addr(),
+			Pool:                  DefaultPoolConfig(),
+			TLSEnabled:            true,
+			TLSInsecureSkipVerify: true,
+		}, zap.NewNop())
+		require.NoError(t, err)
+		defer client.Close()
+
+		require.NoError(t, client.AddItem(ctx, "user-1", "1", 2))
+		assert.Equal(t, "2", mr.HGet("cart:user-1", "1"))
+	})
+
+	t.Run("should reject an unverifiable certificate", func(t *testing.T) {
+		mr := miniredis.NewMiniRedis()
+		require.NoError(t, mr.StartTLS(&tls.Config{Certificates: []tls.Certificate{selfSignedCert(t)}}))
+		defer mr.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+		defer cancel()
+
+		_, err := InitRedis(ctx, Config{
+			Addr:       mr.Addr(),
+			Pool:       DefaultPoolConfig(),
+			TLSEnabled: true,
+		}, zap.NewNop())
+		require.Error(t, err)
+	})
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	config, err := newTLSConfig("my-cache.abc123.use1.cache.amazonaws.com:6379", false)
+	require.NoError(t, err)
+	assert.Equal(t, "my-cache.abc123.use1.cache.amazonaws.com", config.ServerName)
+	assert.False(t, config.InsecureSkipVerify)
+	assert.Equal(t, uint16(tls.VersionTLS12), config.MinVersion)
+
+	_, err = newTLSConfig("missing-port", false)
+	assert.Error(t, err)
+}
+
+func TestInitRedisModes(t *testing.T) {
+	t.Run("should run cart operations through a cluster client", func(t *testing.T) {
+		// miniredis answers CLUSTER SLOTS as a single node owning every slot
+		mr := miniredis.RunT(t)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		client, err := InitRedis(ctx, Config{
+			Mode: ModeCluster,
+			Addr: mr.Addr(),
+			Pool: DefaultPoolConfig(),
+		}, zap.NewNop())
+		require.NoError(t, err)
+		defer client.Close()
+
+		assert.IsType(t, &redis.ClusterClient{}, client.GetClient())
+
+		require.NoError(t, client.AddItem(ctx, "user-1", "1", 2))
+		items, err := client.GetCart(ctx, "user-1")
+		require.NoError(t, err)
+		assert.Equal(t, []CartItem{{ProductID: "1", Quantity: 2}}, items)
+	})
+
+	t.Run("should build the client matching the mode", func(t *testing.T) {
+		standalone, err := newUniversalClient(Config{Mode: ModeStandalone, Addr: "localhost:6379"})
+		require.NoError(t, err)
+		defer standalone.Close()
+		assert.IsType(t, &redis.Client{}, standalone)
+
+		cluster, err := newUniversalClient(Config{Mode: ModeCluster, Addr: "node-1:6379, node-2:6379"})
+		require.NoError(t, err)
+		defer cluster.Close()
+		assert.Equal(t, []string{"node-1:6379", "node-2:6379"}, cluster.(*redis.ClusterClient).Options().Addrs)
+
+		failover, err := newUniversalClient(Config{Mode: ModeSentinel, Addr: "sentinel-1:26379", MasterName: "mymaster"})
+		require.NoError(t, err)
+		defer failover.Close()
+		assert.IsType(t, &redis.Client{}, failover)
+	})
+
+	t.Run("should reject invalid configurations", func(t *testing.T) {
+		for name, config := range map[string]Config{
+			"unknown mode":               {Mode: "ring", Addr: "localhost:6379"},
+			"no address":                 {Mode: ModeStandalone, Addr: " , "},
+			"standalone with many addrs": {Mode: ModeStandalone, Addr: "a:6379,b:6379"},
+			"cluster with a database":    {Mode: ModeCluster, Addr: "a:6379", DB: 1},
+			"sentinel without master":    {Mode: ModeSentinel, Addr: "a:26379"},
+		} {
+			_, err := newUniversalClient(config)
+			assert.Error(t, err, name)
+		}
+	})
+}
+
+func TestPingWithRetrySpanEvents(t *testing.T) {
+	// fastRetry keeps the test quick; jitter does not matter for the event count
+	fastRetry := retry.RetryConfig{InitialDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond, MaxRetries: 2}
+
+	// pingEvents runs pingWithRetry inside a recorded span and returns the span
+	pingEvents := func(t *testing.T, addr string) (sdktrace.ReadOnlySpan, error) {
+		recorder := tracetest.NewSpanRecorder()
+		provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+		ctx, span := provider.Tracer("test").Start(context.Background(), "redis.InitRedis")
+		rdb := redis.NewClient(&redis.Options{Addr: addr, MaxRetries: -1})
+		defer rdb.Close()
+
+		err := pingWithRetry(ctx, rdb, fastRetry, zap.NewNop())
+		span.End()
+
+		require.Len(t, recorder.Ended(), 1)
+		return recorder.Ended()[0], err
+	}
+
+	t.Run("should record one event per failed attempt", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		addr := mr.Addr()
+		mr.Close()
+
+		span, err := pingEvents(t, addr)
+		require.Error(t, err)
+
+		events := span.Events()
+		require.Len(t, events, 3)
+		for i, event := range events {
+			assert.Equal(t, "redis.ping.failed", event.Name)
+			attrs := attribute.NewSet(event.Attributes...)
+			attempt, _ := attrs.Value("attempt")
+			assert.Equal(t, int64(i+1), attempt.AsInt64())
+			assert.True(t, attrs.HasValue("error"))
+			// Only attempts that are retried carry a delay
+			assert.Equal(t, i < 2, attrs.HasValue("retry_delay_ms"))
+		}
+	})
+
+	t.Run("should record the successful attempt", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+
+		span, err := pingEvents(t, mr.Addr())
+		require.NoError(t, err)
+
+		require.Len(t, span.Events(), 1)
+		assert.Equal(t, "redis.ping.succeeded", span.Events()[0].Name)
+	})
+}
@@ -0,0 +1,106 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Cart event actions
+const (
+	// EventActionAdd is published when a quantity is added to a product in the cart
+	EventActionAdd = "add"
+	// EventActionRemove is published when units of a product are taken out of the cart
+	EventActionRemove = "remove"
+	// EventActionClear is published when the whole cart is deleted
+	EventActionClear = "clear"
+)
+
+// eventQueueSize is how many events may wait for the publisher before new ones are dropped
+const eventQueueSize = 1024
+
+// eventPublishTimeout bounds each PUBLISH, so a stalled Redis can't back up the queue for long
+const eventPublishTimeout = time.Second
+
+// CartEvent is the JSON message published to Config.EventsChannel after a cart changes
+// Quantity is the number of units added or removed; ProductID is empty and Quantity 0 for
+// EventActionClear. A merge or transfer publishes a remove for the source cart and an add for
+// the destination
+type CartEvent struct {
+	UserID    string    `json:"user_id"`
+	ProductID string    `json:"product_id"`
+	Action    string    `json:"action"`
+	Quantity  int       `json:"quantity"`
+	Timestamp time.Time `json:"ts"`
+}
+
+// startEvents makes the client publish cart events to channel from a background goroutine,
+// which runs until Close
+// Events go through a bounded queue so publishing adds no latency to cart writes and they
+// reach subscribers in the order the writes completed
+func (c *Client) startEvents(channel string) {
+	c.eventsChannel = channel
+	c.events = make(chan CartEvent, eventQueueSize)
+	c.eventsDone = make(chan struct{})
+	go c.publishEvents()
+}
+
+// publishEvent queues event for publishing; it is a no-op unless events are enabled
+// A full queue drops the event with a warning rather than block the caller
+func (c *Client) publishEvent(ctx context.Context, userID, productID, action string, quantity int) {
+	if c.events == nil {
+		return
+	}
+
+	event := CartEvent{
+		UserID:    userID,
+		ProductID: productID,
+		Action:    action,
+		Quantity:  quantity,
+		Timestamp: time.Now().UTC(),
+	}
+	select {
+	case c.events <- event:
+	case <-c.eventsDone:
+	default:
+		c.spanLogger(ctx).Warn("Cart event queue full, dropping event",
+			zap.String("channel", c.eventsChannel),
+			zap.String("user_id", userID),
+			zap.String("action", action),
+		)
+	}
+}
+
+// publishEvents PUBLISHes queued events until Close; failures are logged and the event dropped
+func (c *Client) publishEvents() {
+	for {
+		select {
+		case event := <-c.events:
+			c.publish(event)
+		case <-c.eventsDone:
+			return
+		}
+	}
+}
+
+func (c *Client) publish(event CartEvent) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		c.logger.Error("Failed to encode cart event", zap.String("user_id", event.UserID), zap.Error(err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), eventPublishTimeout)
+	defer cancel()
+	if err := c.rdb.Publish(ctx, c.eventsChannel, payload).Err(); err != nil {
+		c.logger.Error("Failed to publish cart event",
+			zap.String("channel", c.eventsChannel),
+			zap.String("user_id", event.UserID),
+			zap.String("product_id", event.ProductID),
+			zap.String("action", event.Action),
+			zap.Error(err),
+		)
+	}
+}
@@ -0,0 +1,161 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// subscribe returns the messages published to channel on client's Redis
+func subscribe(t *testing.T, client *Client, channel string) <-chan *redis.Message {
+	pubsub := client.rdb.Subscribe(context.Background(), channel)
+	t.Cleanup(func() { pubsub.Close() })
+	// Wait for the subscription to be confirmed so no event is published before it
+	_, err := pubsub.Receive(context.Background())
+	require.NoError(t, err)
+	return pubsub.Channel()
+}
+
+// nextEvent decodes the next message from messages, failing the test if none arrives in time
+func nextEvent(t *testing.T, messages <-chan *redis.Message) CartEvent {
+	select {
+	case message := <-messages:
+		var event CartEvent
+		require.NoError(t, json.Unmarshal([]byte(message.Payload), &event))
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("no cart event published")
+		return CartEvent{}
+	}
+}
+
+func TestCartEvents(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should publish add, remove and clear events in order", func(t *testing.T) {
+		client, _ := newTestClient(t)
+		client.startEvents("cart-events")
+		t.Cleanup(func() { client.Close() })
+		messages := subscribe(t, client, "cart-events")
+
+		before := time.Now().UTC()
+		require.NoError(t, client.AddItem(ctx, "user-1", "prod-1", 2))
+		require.NoError(t, client.AddItemWithLimit(ctx, "user-1", "prod-2", 1, 10, 0))
+		matched, err := client.SetItemQuantityIfMatch(ctx, "user-1", "prod-1", 2, 0, 0, 0)
+		require.NoError(t, err)
+		require.True(t, matched)
+		require.NoError(t, client.ClearCart(ctx, "user-1"))
+
+		event := nextEvent(t, messages)
+		assert.Equal(t, "user-1", event.UserID)
+		assert.Equal(t, "prod-1", event.ProductID)
+		assert.Equal(t, EventActionAdd, event.Action)
+		assert.Equal(t, 2, event.Quantity)
+		assert.False(t, event.Timestamp.Before(before.Truncate(time.Second)))
+
+		assert.Equal(t, CartEvent{UserID: "user-1", ProductID: "prod-2", Action: EventActionAdd, Quantity: 1},
+			withoutTimestamp(nextEvent(t, messages)))
+		assert.Equal(t, CartEvent{UserID: "user-1", ProductID: "prod-1", Action: EventActionRemove, Quantity: 2},
+			withoutTimestamp(nextEvent(t, messages)))
+		assert.Equal(t, CartEvent{UserID: "user-1", Action: EventActionClear},
+			withoutTimestamp(nextEvent(t, messages)))
+	})
+
+	t.Run("should publish removes from the source and adds to the destination on transfer and merge", func(t *testing.T) {
+		client, mr := newTestClient(t)
+		client.startEvents("cart-events")
+		t.Cleanup(func() { client.Close() })
+		messages := subscribe(t, client, "cart-events")
+		mr.HSet("cart:guest-1", "prod-1", "3")
+
+		require.NoError(t, client.TransferItem(ctx, "guest-1", "saved-1", "prod-1", 1, 0, 0))
+		require.NoError(t, client.MergeCart(ctx, "guest-1", "user-1", 0, 0))
+
+		assert.Equal(t, []CartEvent{
+			{UserID: "guest-1", ProductID: "prod-1", Action: EventActionRemove, Quantity: 1},
+			{UserID: "saved-1", ProductID: "prod-1", Action: EventActionAdd, Quantity: 1},
+			{UserID: "guest-1", ProductID: "prod-1", Action: EventActionRemove, Quantity: 2},
+			{UserID: "user-1", ProductID: "prod-1", Action: EventActionAdd, Quantity: 2},
+		}, []CartEvent{
+			withoutTimestamp(nextEvent(t, messages)),
+			withoutTimestamp(nextEvent(t, messages)),
+			withoutTimestamp(nextEvent(t, messages)),
+			withoutTimestamp(nextEvent(t, messages)),
+		})
+	})
+
+	t.Run("should publish nothing for a rejected transfer", func(t *testing.T) {
+		client, mr := newTestClient(t)
+		client.startEvents("cart-events")
+		t.Cleanup(func() { client.Close() })
+		messages := subscribe(t, client, "cart-events")
+		mr.HSet("cart:guest-1", "prod-1", "1")
+
+		assert.ErrorIs(t, client.TransferItem(ctx, "guest-1", "saved-1", "prod-1", 2, 0, 0), ErrInsufficientQuantity)
+		require.NoError(t, client.ClearCart(ctx, "guest-1"))
+
+		assert.Equal(t, EventActionClear, nextEvent(t, messages).Action)
+	})
+
+	t.Run("should not publish when events are disabled", func(t *testing.T) {
+		client, _ := newTestClient(t)
+		messages := subscribe(t, client, "cart-events")
+
+		require.NoError(t, client.AddItem(ctx, "user-1", "prod-1", 2))
+
+		select {
+		case message := <-messages:
+			t.Fatalf("unexpected cart event %s", message.Payload)
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+
+	t.Run("should not publish for rejected writes", func(t *testing.T) {
+		client, _ := newTestClient(t)
+		client.startEvents("cart-events")
+		t.Cleanup(func() { client.Close() })
+		messages := subscribe(t, client, "cart-events")
+
+		require.Error(t, client.AddItem(ctx, "user-1", "prod-1", 0))
+		matched, err := client.SetItemQuantityIfMatch(ctx, "user-1", "prod-1", 5, 0, 0, 0)
+		require.NoError(t, err)
+		require.False(t, matched)
+		require.NoError(t, client.ClearCart(ctx, "user-1"))
+
+		// Only the clear is published
+		assert.Equal(t, EventActionClear, nextEvent(t, messages).Action)
+	})
+
+	t.Run("should log publish failures without failing the write", func(t *testing.T) {
+		core, logs := observer.New(zap.ErrorLevel)
+		client, _ := newTestClient(t)
+		client.logger = zap.New(core)
+		hook := &failingHook{command: "publish", err: serverError("ERR publish failed")}
+		hook.failures.Store(1)
+		client.rdb.AddHook(hook)
+		client.startEvents("cart-events")
+		t.Cleanup(func() { client.Close() })
+
+		require.NoError(t, client.AddItem(ctx, "user-1", "prod-1", 2))
+
+		require.Eventually(t, func() bool {
+			return logs.FilterMessage("Failed to publish cart event").Len() == 1
+		}, time.Second, 10*time.Millisecond)
+		fields := logs.FilterMessage("Failed to publish cart event").All()[0].ContextMap()
+		assert.Equal(t, "cart-events", fields["channel"])
+		assert.Equal(t, EventActionAdd, fields["action"])
+	})
+}
+
+// withoutTimestamp zeroes event.Timestamp so events can be compared whole
+func withoutTimestamp(event CartEvent) CartEvent {
+	event.Timestamp = time.Time{}
+	return event
+}
@@ -0,0 +1,91 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+)
+
+// idempotencyKey returns the Redis key recording a processed request
+// Keys are scoped per user so two users can't collide on the same client-supplied ID
+func idempotencyKey(userID, requestKey string) string {
+	return fmt.Sprintf("idem:%s:%s", userID, requestKey)
+}
+
+// ClaimIdempotencyKey records that a request is being processed
+// Uses SET NX with a TTL: returns true for the first caller and false when the key was already claimed
+func (c *Client) ClaimIdempotencyKey(ctx context.Context, userID, requestKey string, ttl time.Duration) (bool, error) {
+	// Create a child span for this operation
+	tracer := otel.Tracer("cart-service")
+	ctx, span := tracer.Start(ctx, "redis.ClaimIdempotencyKey")
+	defer span.End()
+
+	key := idempotencyKey(userID, requestKey)
+	ctx, cancel := c.opContext(ctx)
+	defer cancel()
+	defer c.trackSlow(span, "ClaimIdempotencyKey", time.Now(), key)
+
+	span.SetAttributes(attribute.String("user_id", userID))
+
+	// Not idempotent: a re-run after an applied SETNX would report the key as already claimed,
+	// so it is only retried when Redis did not run the command
+	var claimed bool
+	err := c.withRetry(ctx, span, "ClaimIdempotencyKey", false, func(ctx context.Context) error {
+		var err error
+		claimed, err = c.rdb.SetNX(ctx, key, 1, ttl).Result()
+		return err
+	})
+	if err != nil {
+		err = c.checkTimeout(ctx, span, err)
+		span.SetStatus(codes.Error, "Redis SETNX failed")
+		span.RecordError(err)
+		c.spanLogger(ctx).Error("Failed to claim idempotency key",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		return false, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+
+	span.SetAttributes(attribute.Bool("idempotency.claimed", claimed))
+	span.SetStatus(codes.Ok, "Idempotency key checked")
+
+	return claimed, nil
+}
+
+// ReleaseIdempotencyKey forgets a claimed key so the request can be retried
+// Called when the operation guarded by the key failed and nothing was written
+func (c *Client) ReleaseIdempotencyKey(ctx context.Context, userID, requestKey string) error {
+	// Create a child span for this operation
+	tracer := otel.Tracer("cart-service")
+	ctx, span := tracer.Start(ctx, "redis.ReleaseIdempotencyKey")
+	defer span.End()
+
+	key := idempotencyKey(userID, requestKey)
+	ctx, cancel := c.opContext(ctx)
+	defer cancel()
+	defer c.trackSlow(span, "ReleaseIdempotencyKey", time.Now(), key)
+
+	span.SetAttributes(attribute.String("user_id", userID))
+
+	err := c.withRetry(ctx, span, "ReleaseIdempotencyKey", true, func(ctx context.Context) error {
+		return c.rdb.Del(ctx, key).Err()
+	})
+	if err != nil {
+		err = c.checkTimeout(ctx, span, err)
+		span.SetStatus(codes.Error, "Redis DEL failed")
+		span.RecordError(err)
+		c.spanLogger(ctx).Error("Failed to release idempotency key",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+
+	span.SetStatus(codes.Ok, "Idempotency key released")
+	return nil
+}
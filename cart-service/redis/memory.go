@@ -0,0 +1,173 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+)
+
+// MemoryEstimate holds the outcome of an EstimateCartMemory scan
+type MemoryEstimate struct {
+	TotalKeys      int     // Cart keys seen by the scan
+	SampledKeys    int     // Cart keys measured with MEMORY USAGE
+	SampledBytes   int64   // Bytes reported for the sampled keys
+	EstimatedBytes int64   // SampledBytes extrapolated to TotalKeys
+	SampleRate     float64 // Fraction of keys that was requested to be sampled
+	Truncated      bool    // true when the scan stopped at maxKeys before walking the whole keyspace
+}
+
+// EstimateCartMemory estimates the total memory used by all cart keys
+// MEMORY USAGE is costly, so only every 1/sampleRate-th key is measured and the
+// average is extrapolated to every cart key seen; SCAN order is effectively random,
+// so a fixed stride behaves like a uniform sample
+// At most maxKeys cart keys are inspected so the cost of a single call stays bounded
+func (c *Client) EstimateCartMemory(ctx context.Context, sampleRate float64, maxKeys int) (*MemoryEstimate, error) {
+	// Create a child span for this operation
+	tracer := otel.Tracer("cart-service")
+	ctx, span := tracer.Start(ctx, "redis.EstimateCartMemory")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.Float64("sample_rate", sampleRate),
+		attribute.Int("max_keys", maxKeys),
+	)
+
+	if maxKeys <= 0 {
+		span.SetStatus(codes.Error, "Invalid key budget")
+		return nil, fmt.Errorf("max keys must be positive, got %d", maxKeys)
+	}
+
+	estimate := &MemoryEstimate{SampleRate: sampleRate}
+	stride := sampleStride(sampleRate)
+	var cursor uint64
+
+	for {
+		keys, next, err := c.rdb.Scan(ctx, cursor, cartKeyPrefix+"*", scanBatchSize).Result()
+		if err != nil {
+			span.SetStatus(codes.Error, "Redis SCAN failed")
+			span.RecordError(err)
+			c.logger.Error("Failed to scan cart keys", zap.Error(err))
+			return nil, fmt.Errorf("failed to scan cart keys: %w", err)
+		}
+
+		// Respect the key budget even when a batch overshoots it
+		if remaining := maxKeys - estimate.TotalKeys; len(keys) > remaining {
+			keys = keys[:remaining]
+			estimate.Truncated = true
+		}
+
+		// Pick every stride-th key across batches
+		var sample []string
+		for i, key := range keys {
+			if (estimate.TotalKeys+i)%stride == 0 {
+				sample = append(sample, key)
+			}
+		}
+		estimate.TotalKeys += len(keys)
+
+		if len(sample) > 0 {
+			measured, bytes, err := c.memoryUsageBatch(ctx, sample)
+			if err != nil {
+				span.SetStatus(codes.Error, "Redis MEMORY USAGE failed")
+				span.RecordError(err)
+				c.logger.Error("Failed to measure cart memory", zap.Error(err))
+				return nil, fmt.Errorf("failed to measure cart memory: %w", err)
+			}
+			estimate.SampledKeys += measured
+			estimate.SampledBytes += bytes
+		}
+
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+		if estimate.TotalKeys >= maxKeys {
+			estimate.Truncated = true
+			break
+		}
+	}
+
+	estimate.EstimatedBytes = extrapolate(estimate.SampledBytes, estimate.SampledKeys, estimate.TotalKeys)
+	c.lastMemoryEstimate.Store(estimate)
+
+	span.SetAttributes(
+		attribute.Int("total_keys", estimate.TotalKeys),
+		attribute.Int("sampled_keys", estimate.SampledKeys),
+		attribute.Int64("estimated_bytes", estimate.EstimatedBytes),
+		attribute.Bool("truncated", estimate.Truncated),
+	)
+	span.SetStatus(codes.Ok, "Cart memory estimated")
+
+	return estimate, nil
+}
+
+// memoryUsageBatch pipelines MEMORY USAGE for a batch of cart keys
+// Keys that disappeared between SCAN and MEMORY USAGE are not counted as sampled
+func (c *Client) memoryUsageBatch(ctx context.Context, keys []string) (int, int64, error) {
+	cmds := make([]*redis.IntCmd, len(keys))
+	_, err := c.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			cmds[i] = pipe.MemoryUsage(ctx, key)
+		}
+		return nil
+	})
+	var replyErr redis.Error
+	if err != nil && !errors.Is(err, redis.Nil) && !errors.As(err, &replyErr) {
+		return 0, 0, err
+	}
+
+	var measured int
+	var bytes int64
+	for _, cmd := range cmds {
+		if cmd.Err() != nil {
+			continue
+		}
+		measured++
+		bytes += cmd.Val()
+	}
+	return measured, bytes, nil
+}
+
+// sampleStride converts a sample rate into "measure every Nth key"
+// Rates of 1 or more, and rates that aren't positive, measure every key
+func sampleStride(sampleRate float64) int {
+	if sampleRate <= 0 || sampleRate >= 1 || math.IsNaN(sampleRate) {
+		return 1
+	}
+	return int(math.Round(1 / sampleRate))
+}
+
+// extrapolate scales the bytes measured on sampledKeys up to totalKeys
+// Returns 0 when nothing was sampled
+func extrapolate(sampledBytes int64, sampledKeys, totalKeys int) int64 {
+	if sampledKeys == 0 {
+		return 0
+	}
+	return int64(math.Round(float64(sampledBytes) / float64(sampledKeys) * float64(totalKeys)))
+}
+
+// registerMemoryGauge exposes the most recent cart memory estimate as the
+// cart.memory.estimated_bytes gauge; nothing is observed until an estimate has run
+// Observing never triggers a scan, since MEMORY USAGE is too costly to run per collection
+func (c *Client) registerMemoryGauge() error {
+	meter := otel.Meter("cart-service")
+	_, err := meter.Int64ObservableGauge("cart.memory.estimated_bytes",
+		metric.WithDescription("Estimated memory used by all cart keys, from the last sampled scan"),
+		metric.WithUnit("By"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			if estimate := c.lastMemoryEstimate.Load(); estimate != nil {
+				o.Observe(estimate.EstimatedBytes)
+			}
+			return nil
+		}),
+	)
+	return err
+}
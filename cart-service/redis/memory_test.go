@@ -0,0 +1,53 @@
+package redis
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtrapolate(t *testing.T) {
+	tests := []struct {
+		name         string
+		sampledBytes int64
+		sampledKeys  int
+		totalKeys    int
+		expected     int64
+	}{
+		{"full sample", 1000, 10, 10, 1000},
+		{"ten percent sample", 2000, 10, 100, 20000},
+		{"rounds to nearest byte", 100, 3, 10, 333},
+		{"rounds half up", 5, 2, 3, 8},
+		{"nothing sampled", 0, 0, 50, 0},
+		{"no keys", 0, 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, extrapolate(tt.sampledBytes, tt.sampledKeys, tt.totalKeys))
+		})
+	}
+}
+
+func TestSampleStride(t *testing.T) {
+	tests := []struct {
+		sampleRate float64
+		expected   int
+	}{
+		{1, 1},
+		{0.5, 2},
+		{0.1, 10},
+		{0.3, 3},
+		{0.01, 100},
+		{0, 1},
+		{-0.5, 1},
+		{2, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("rate %v", tt.sampleRate), func(t *testing.T) {
+			assert.Equal(t, tt.expected, sampleStride(tt.sampleRate))
+		})
+	}
+}
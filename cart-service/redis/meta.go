@@ -0,0 +1,188 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// Cart metadata lives in a parallel "cartmeta:{userID}" hash rather than in the cart hash itself,
+// so HLEN, HGETALL and the merge/transfer/limit scripts keep seeing product fields only
+const (
+	cartMetaKeyPrefix    = "cartmeta:"
+	cartMetaCreatedAt    = "created_at"
+	cartMetaCurrency     = "currency"
+	cartMetaCreatedBy    = "created_by"
+	cartMetaTimeEncoding = time.RFC3339Nano
+)
+
+// CartMeta holds cart-level attributes that are not products
+// Zero values mean the attribute was never set (e.g. carts created before metadata existed)
+type CartMeta struct {
+	CreatedAt time.Time
+	Currency  string
+}
+
+// cartMetaKey returns the Redis key of a user's cart metadata hash
+func cartMetaKey(userID string) string {
+	return cartMetaKeyPrefix + userID
+}
+
+// GetCartMeta returns the metadata of a user's cart
+// Uses HGETALL on the metadata hash; a cart without metadata returns a zero CartMeta
+func (c *Client) GetCartMeta(ctx context.Context, userID string) (CartMeta, error) {
+	// Create a child span for this operation
+	tracer := otel.Tracer("cart-service")
+	ctx, span := tracer.Start(ctx, "redis.GetCartMeta")
+	defer span.End()
+
+	ctx, cancel := c.opContext(ctx)
+	defer cancel()
+	defer c.trackSlow(span, "GetCartMeta", time.Now(), cartMetaKey(userID))
+
+	span.SetAttributes(attribute.String("user_id", userID))
+
+	fields, err := c.rdb.HGetAll(ctx, cartMetaKey(userID)).Result()
+	if err != nil {
+		err = c.checkTimeout(ctx, span, err)
+		span.SetStatus(codes.Error, "Redis HGETALL failed")
+		span.RecordError(err)
+		c.logger.Error("Failed to get cart metadata",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		return CartMeta{}, fmt.Errorf("failed to get cart metadata: %w", err)
+	}
+
+	meta := CartMeta{Currency: fields[cartMetaCurrency]}
+	if createdAt, ok := fields[cartMetaCreatedAt]; ok {
+		meta.CreatedAt, err = time.Parse(cartMetaTimeEncoding, createdAt)
+		if err != nil {
+			// Keep serving the cart; a corrupt timestamp is only reported
+			c.logger.Warn("Ignoring invalid cart created_at",
+				zap.String("user_id", userID),
+				zap.String("created_at", createdAt),
+			)
+		}
+	}
+
+	span.SetStatus(codes.Ok, "Cart metadata retrieved")
+	return meta, nil
+}
+
+// SetCartCurrency records the currency a user's cart is priced in
+func (c *Client) SetCartCurrency(ctx context.Context, userID, currency string) error {
+	// Create a child span for this operation
+	tracer := otel.Tracer("cart-service")
+	ctx, span := tracer.Start(ctx, "redis.SetCartCurrency")
+	defer span.End()
+
+	ctx, cancel := c.opContext(ctx)
+	defer cancel()
+	defer c.trackSlow(span, "SetCartCurrency", time.Now(), cartMetaKey(userID))
+
+	span.SetAttributes(
+		attribute.String("user_id", userID),
+		attribute.String("currency", currency),
+	)
+
+	if err := c.rdb.HSet(ctx, cartMetaKey(userID), cartMetaCurrency, currency).Err(); err != nil {
+		err = c.checkTimeout(ctx, span, err)
+		span.SetStatus(codes.Error, "Redis HSET failed")
+		span.RecordError(err)
+		c.logger.Error("Failed to set cart currency",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to set cart currency: %w", err)
+	}
+
+	span.SetStatus(codes.Ok, "Cart currency set")
+	return nil
+}
+
+// markCartCreated stamps created_at on the cart's metadata unless it is already set
+// Called after items were added; HSETNX keeps the first timestamp across later adds
+// When ctx carries a sampled span, its W3C traceparent is kept as created_by the same way, so
+// later operations on the cart can link back to the trace that created it
+// A failure only loses the timestamp, so it is logged instead of failing the add
+func (c *Client) markCartCreated(ctx context.Context, userID string) {
+	key := cartMetaKey(userID)
+	createdAt := time.Now().UTC().Format(cartMetaTimeEncoding)
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+
+	_, err := c.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSetNX(ctx, key, cartMetaCreatedAt, createdAt)
+		if traceparent := carrier.Get("traceparent"); traceparent != "" {
+			pipe.HSetNX(ctx, key, cartMetaCreatedBy, traceparent)
+		}
+		return nil
+	})
+	if err != nil {
+		c.logger.Warn("Failed to record cart creation time",
+			zap.String("user_id", userID),
+			zap.Error(err),
+		)
+	}
+}
+
+// linkedCart names a cart taking part in a multi-cart operation and its role in it
+type linkedCart struct {
+	userID string
+	role   string
+}
+
+// cartCreationLinks returns span links to the spans that created the given carts, for operations
+// that relate several carts (e.g. a merge) and so cannot be the child of each cart's trace
+// Each link records the cart's user_id and its role in the operation as cart.role
+// Carts created before created_by existed, or while tracing was off, are left out; a failed
+// lookup only costs the links, so it is logged instead of failing the operation
+func (c *Client) cartCreationLinks(ctx context.Context, carts ...linkedCart) []trace.Link {
+	ctx, cancel := c.opContext(ctx)
+	defer cancel()
+
+	cmds := make([]*redis.StringCmd, len(carts))
+	_, err := c.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, cart := range carts {
+			cmds[i] = pipe.HGet(ctx, cartMetaKey(cart.userID), cartMetaCreatedBy)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		c.logger.Warn("Failed to read cart creation trace context",
+			zap.Error(err),
+		)
+		return nil
+	}
+
+	var links []trace.Link
+	for i, cmd := range cmds {
+		traceparent, err := cmd.Result()
+		if err != nil {
+			continue
+		}
+		carrier := propagation.MapCarrier{"traceparent": traceparent}
+		spanCtx := trace.SpanContextFromContext(propagation.TraceContext{}.Extract(context.Background(), carrier))
+		if !spanCtx.IsValid() {
+			continue
+		}
+		links = append(links, trace.Link{
+			SpanContext: spanCtx,
+			Attributes: []attribute.KeyValue{
+				attribute.String("user_id", carts[i].userID),
+				attribute.String("cart.role", carts[i].role),
+			},
+		})
+	}
+	return links
+}
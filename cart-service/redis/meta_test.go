@@ -0,0 +1,146 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestCartMeta(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should stamp created_at on the first add only", func(t *testing.T) {
+		client, mr := newTestClient(t)
+
+		require.NoError(t, client.AddItem(ctx, "user-1", "prod-1", 1))
+		first := mr.HGet("cartmeta:user-1", "created_at")
+		require.NotEmpty(t, first)
+
+		require.NoError(t, client.AddItemWithLimit(ctx, "user-1", "prod-2", 1, 10, 0))
+		assert.Equal(t, first, mr.HGet("cartmeta:user-1", "created_at"))
+
+		meta, err := client.GetCartMeta(ctx, "user-1")
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now(), meta.CreatedAt, time.Minute)
+	})
+
+	t.Run("should stamp created_at on carts created by SetItems", func(t *testing.T) {
+		client, mr := newTestClient(t)
+
+		require.NoError(t, client.SetItems(ctx, "user-1", []CartItem{{ProductID: "prod-1", Quantity: 0}}, 0, 0))
+		assert.False(t, mr.Exists("cartmeta:user-1"), "Removing from a missing cart creates nothing")
+
+		require.NoError(t, client.SetItems(ctx, "user-1", []CartItem{{ProductID: "prod-1", Quantity: 2}}, 0, 0))
+		assert.NotEmpty(t, mr.HGet("cartmeta:user-1", "created_at"))
+	})
+
+	t.Run("should delete the source metadata on merge and stamp the destination", func(t *testing.T) {
+		client, mr := newTestClient(t)
+
+		require.NoError(t, client.AddItem(ctx, "guest-1", "prod-1", 1))
+		require.NoError(t, client.SetCartCurrency(ctx, "guest-1", "usd"))
+		require.NoError(t, client.MergeCart(ctx, "guest-1", "user-1", 0, 0))
+
+		assert.False(t, mr.Exists("cart:guest-1"))
+		assert.False(t, mr.Exists("cartmeta:guest-1"), "The source metadata goes with the source cart")
+		assert.NotEmpty(t, mr.HGet("cartmeta:user-1", "created_at"))
+	})
+
+	t.Run("should keep metadata out of the cart hash", func(t *testing.T) {
+		client, mr := newTestClient(t)
+
+		require.NoError(t, client.AddItem(ctx, "user-1", "prod-1", 2))
+		require.NoError(t, client.SetCartCurrency(ctx, "user-1", "eur"))
+
+		items, err := client.GetCart(ctx, "user-1")
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, "prod-1", items[0].ProductID)
+		assert.Equal(t, "eur", mr.HGet("cartmeta:user-1", "currency"))
+	})
+
+	t.Run("should return a zero CartMeta for a cart without metadata", func(t *testing.T) {
+		client, _ := newTestClient(t)
+
+		meta, err := client.GetCartMeta(ctx, "nobody")
+		require.NoError(t, err)
+		assert.True(t, meta.CreatedAt.IsZero())
+		assert.Empty(t, meta.Currency)
+	})
+
+	t.Run("should delete metadata when the cart is cleared", func(t *testing.T) {
+		client, mr := newTestClient(t)
+
+		require.NoError(t, client.AddItem(ctx, "user-1", "prod-1", 1))
+		require.NoError(t, client.SetCartCurrency(ctx, "user-1", "usd"))
+		require.NoError(t, client.ClearCart(ctx, "user-1"))
+
+		assert.False(t, mr.Exists("cart:user-1"))
+		assert.False(t, mr.Exists("cartmeta:user-1"))
+	})
+}
+
+func TestMergeCartLinks(t *testing.T) {
+	ctx := context.Background()
+
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	// addInTrace adds an item under its own root span and returns that span's context
+	// The link points at the redis.AddItem child span, so only the trace ID is compared
+	addInTrace := func(t *testing.T, client *Client, userID string) trace.SpanContext {
+		spanCtx, span := otel.Tracer("test").Start(ctx, "add")
+		defer span.End()
+		require.NoError(t, client.AddItem(spanCtx, userID, "prod-1", 1))
+		return span.SpanContext()
+	}
+
+	// lastMergeSpan returns the most recently ended redis.MergeCart span
+	lastMergeSpan := func(t *testing.T) sdktrace.ReadOnlySpan {
+		spans := recorder.Ended()
+		for i := len(spans) - 1; i >= 0; i-- {
+			if spans[i].Name() == "redis.MergeCart" {
+				return spans[i]
+			}
+		}
+		require.FailNow(t, "redis.MergeCart span not recorded")
+		return nil
+	}
+
+	t.Run("should link the spans that created the source and destination carts", func(t *testing.T) {
+		client, _ := newTestClient(t)
+		guest := addInTrace(t, client, "guest-1")
+		account := addInTrace(t, client, "user-1")
+
+		require.NoError(t, client.MergeCart(ctx, "guest-1", "user-1", 0, 0))
+
+		links := lastMergeSpan(t).Links()
+		require.Len(t, links, 2)
+		assert.Equal(t, guest.TraceID(), links[0].SpanContext.TraceID())
+		assert.Contains(t, links[0].Attributes, attribute.String("cart.role", "source"))
+		assert.Equal(t, account.TraceID(), links[1].SpanContext.TraceID())
+		assert.Contains(t, links[1].Attributes, attribute.String("cart.role", "destination"))
+	})
+
+	t.Run("should leave out carts without a recorded trace", func(t *testing.T) {
+		client, _ := newTestClient(t)
+		guest := addInTrace(t, client, "guest-1")
+
+		require.NoError(t, client.MergeCart(ctx, "guest-1", "user-1", 0, 0))
+
+		links := lastMergeSpan(t).Links()
+		require.Len(t, links, 1)
+		assert.Equal(t, guest.TraceID(), links[0].SpanContext.TraceID())
+		assert.Contains(t, links[0].Attributes, attribute.String("user_id", "guest-1"))
+	})
+}
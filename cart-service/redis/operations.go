@@ -2,21 +2,93 @@ package redis
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/redis/go-redis/v9"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
+// ErrInsufficientQuantity is returned by TransferItem when the source cart holds less of the
+// product than requested; nothing is moved in that case
+var ErrInsufficientQuantity = errors.New("insufficient quantity in source cart")
+
+// ErrInvalidQuantity is returned when an operation is called with a negative or, for adds, zero
+// quantity or limit; it is the caller's mistake, not a Redis failure, and nothing is written
+var ErrInvalidQuantity = errors.New("invalid quantity")
+
+// ErrCartFull is returned by the limited writes (AddItemWithLimit, SetItems, MergeCart, ...)
+// when adding new products would take the cart past its maximum number of distinct items;
+// nothing is written in that case
+var ErrCartFull = errors.New("cart has reached the maximum number of items")
+
+// ErrQuantityLimit is returned by the limited writes when a product's quantity would end up
+// above the per-item maximum; nothing is written in that case
+var ErrQuantityLimit = errors.New("item quantity would exceed the maximum per item")
+
+// ErrCrossSlot is returned by MergeCart and TransferItem in Redis Cluster mode when the two carts
+// hash to different slots, so they can't be watched and written in one transaction; nothing is
+// written in that case, and retrying won't help
+var ErrCrossSlot = errors.New("carts are stored in different cluster hash slots")
+
+// isCrossSlot reports whether err is a transaction spanning cluster hash slots being refused,
+// either by the server (CROSSSLOT) or by go-redis checking the WATCH keys before sending them
+func isCrossSlot(err error) bool {
+	if err == nil {
+		return false
+	}
+	var redisErr redis.Error
+	if errors.As(err, &redisErr) && strings.HasPrefix(redisErr.Error(), "CROSSSLOT") {
+		return true
+	}
+	return strings.Contains(err.Error(), "requires all keys to be in the same slot")
+}
+
+// addItemWithLimitScript increments a product's quantity unless the product is new and the cart
+// already holds ARGV[3] distinct items (returns -1), or the new quantity would exceed ARGV[4]
+// (returns -2); a limit of 0 is not enforced and nothing is written when one is hit
+// Running HGET, HLEN and HINCRBY in one script keeps two concurrent adds from both
+// passing the checks
+var addItemWithLimitScript = redis.NewScript(`
+local current = redis.call('HGET', KEYS[1], ARGV[1])
+local maxItems = tonumber(ARGV[3])
+if not current and maxItems > 0 and redis.call('HLEN', KEYS[1]) >= maxItems then
+	return -1
+end
+local maxQuantity = tonumber(ARGV[4])
+if maxQuantity > 0 and (tonumber(current) or 0) + tonumber(ARGV[2]) > maxQuantity then
+	return -2
+end
+return redis.call('HINCRBY', KEYS[1], ARGV[1], ARGV[2])
+`)
+
 // CartItem represents an item in a user's cart
 type CartItem struct {
 	ProductID string
 	Quantity  int
 }
 
+// spanLogger returns the client's logger with the trace_id and span_id of the span in ctx
+// Operation logs then point at their exact redis.* span, whose parent is the handler span that
+// also issued the product-service calls; without a valid span the plain logger is returned
+func (c *Client) spanLogger(ctx context.Context) *zap.Logger {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return c.logger
+	}
+	return c.logger.With(
+		zap.String("trace_id", spanContext.TraceID().String()),
+		zap.String("span_id", spanContext.SpanID().String()),
+	)
+}
+
 // AddItem adds an item to a user's cart or increments the quantity if it already exists
 // Redis data structure: Hash key = "cart:{userID}", field = productID, value = quantity
 // Uses HINCRBY to atomically increment the quantity
@@ -27,6 +99,10 @@ func (c *Client) AddItem(ctx context.Context, userID, productID string, quantity
 	ctx, span := tracer.Start(ctx, "redis.AddItem")
 	defer span.End()
 
+	ctx, cancel := c.opContext(ctx)
+	defer cancel()
+	defer c.trackSlow(span, "AddItem", time.Now(), fmt.Sprintf("cart:%s", userID))
+
 	// Add span attributes for observability
 	span.SetAttributes(
 		attribute.String("user_id", userID),
@@ -36,7 +112,7 @@ func (c *Client) AddItem(ctx context.Context, userID, productID string, quantity
 
 	if quantity <= 0 {
 		span.SetStatus(codes.Error, "Invalid quantity")
-		return fmt.Errorf("quantity must be positive, got %d", quantity)
+		return fmt.Errorf("quantity must be positive, got %d: %w", quantity, ErrInvalidQuantity)
 	}
 
 	// Redis key for user's cart
@@ -44,11 +120,80 @@ func (c *Client) AddItem(ctx context.Context, userID, productID string, quantity
 
 	// Use HINCRBY to atomically increment the quantity
 	// This handles both adding new items and updating existing ones
-	err := c.rdb.HIncrBy(ctx, key, productID, int64(quantity)).Err()
+	// Not idempotent: only retried when Redis did not run the HINCRBY
+	err := c.withRetry(ctx, span, "AddItem", false, func(ctx context.Context) error {
+		return c.rdb.HIncrBy(ctx, key, productID, int64(quantity)).Err()
+	})
 	if err != nil {
+		err = c.checkTimeout(ctx, span, err)
 		span.SetStatus(codes.Error, "Redis HINCRBY failed")
 		span.RecordError(err)
-		c.logger.Error("Failed to add item to cart",
+		c.spanLogger(ctx).Error("Failed to add item to cart",
+			zap.String("user_id", userID),
+			zap.String("product_id", productID),
+			zap.Int("quantity", quantity),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to add item to cart: %w", err)
+	}
+	c.markCartCreated(ctx, userID)
+	c.publishEvent(ctx, userID, productID, EventActionAdd, quantity)
+
+	span.SetStatus(codes.Ok, "Item added successfully")
+	c.spanLogger(ctx).Info("Item added to cart",
+		zap.String("user_id", userID),
+		zap.String("product_id", productID),
+		zap.Int("quantity", quantity),
+	)
+
+	return nil
+}
+
+// AddItemWithLimit behaves like AddItem but refuses to add a product that is not yet in the cart
+// once the cart holds maxItems distinct products, returning ErrCartFull, and refuses any add that
+// would take the product's quantity above maxQuantity, returning ErrQuantityLimit
+// Products already in the cart can be incremented up to maxQuantity. A limit of 0 is not enforced.
+// The checks and the write run as one Lua script, so the limits hold under concurrent adds
+func (c *Client) AddItemWithLimit(ctx context.Context, userID, productID string, quantity, maxItems, maxQuantity int) error {
+	// Create a child span for this operation
+	tracer := otel.Tracer("cart-service")
+	ctx, span := tracer.Start(ctx, "redis.AddItemWithLimit")
+	defer span.End()
+
+	ctx, cancel := c.opContext(ctx)
+	defer cancel()
+	defer c.trackSlow(span, "AddItemWithLimit", time.Now(), fmt.Sprintf("cart:%s", userID))
+
+	span.SetAttributes(
+		attribute.String("user_id", userID),
+		attribute.String("product_id", productID),
+		attribute.Int("quantity", quantity),
+		attribute.Int("cart.max_items", maxItems),
+		attribute.Int("cart.max_quantity", maxQuantity),
+	)
+
+	if quantity <= 0 {
+		span.SetStatus(codes.Error, "Invalid quantity")
+		return fmt.Errorf("quantity must be positive, got %d: %w", quantity, ErrInvalidQuantity)
+	}
+	if maxItems < 0 || maxQuantity < 0 {
+		span.SetStatus(codes.Error, "Invalid item limit")
+		return fmt.Errorf("limits must not be negative, got max items %d and max quantity %d: %w", maxItems, maxQuantity, ErrInvalidQuantity)
+	}
+
+	key := fmt.Sprintf("cart:%s", userID)
+
+	var result int64
+	err := c.withRetry(ctx, span, "AddItemWithLimit", false, func(ctx context.Context) error {
+		var err error
+		result, err = addItemWithLimitScript.Run(ctx, c.rdb, []string{key}, productID, quantity, maxItems, maxQuantity).Int64()
+		return err
+	})
+	if err != nil {
+		err = c.checkTimeout(ctx, span, err)
+		span.SetStatus(codes.Error, "Redis add item script failed")
+		span.RecordError(err)
+		c.spanLogger(ctx).Error("Failed to add item to cart",
 			zap.String("user_id", userID),
 			zap.String("product_id", productID),
 			zap.Int("quantity", quantity),
@@ -57,8 +202,30 @@ func (c *Client) AddItem(ctx context.Context, userID, productID string, quantity
 		return fmt.Errorf("failed to add item to cart: %w", err)
 	}
 
+	if result == -2 {
+		span.SetStatus(codes.Error, "Item quantity limit reached")
+		c.spanLogger(ctx).Warn("Item quantity limit reached",
+			zap.String("user_id", userID),
+			zap.String("product_id", productID),
+			zap.Int("quantity", quantity),
+			zap.Int("max_quantity", maxQuantity),
+		)
+		return fmt.Errorf("cannot add %d of product %s to cart of user %s: %w", quantity, productID, userID, ErrQuantityLimit)
+	}
+	if result < 0 {
+		span.SetStatus(codes.Error, "Cart is full")
+		c.spanLogger(ctx).Warn("Cart item limit reached",
+			zap.String("user_id", userID),
+			zap.String("product_id", productID),
+			zap.Int("max_items", maxItems),
+		)
+		return fmt.Errorf("cannot add product %s to cart of user %s: %w", productID, userID, ErrCartFull)
+	}
+	c.markCartCreated(ctx, userID)
+	c.publishEvent(ctx, userID, productID, EventActionAdd, quantity)
+
 	span.SetStatus(codes.Ok, "Item added successfully")
-	c.logger.Info("Item added to cart",
+	c.spanLogger(ctx).Info("Item added to cart",
 		zap.String("user_id", userID),
 		zap.String("product_id", productID),
 		zap.Int("quantity", quantity),
@@ -68,7 +235,8 @@ func (c *Client) AddItem(ctx context.Context, userID, productID string, quantity
 }
 
 // GetCart retrieves all items in a user's cart
-// Uses HGETALL to fetch all product_id:quantity pairs
+// Uses HGETALL to fetch all product_id:quantity pairs, or HSCAN batches for carts larger
+// than Config.ScanThreshold
 // Returns an empty slice if cart doesn't exist
 func (c *Client) GetCart(ctx context.Context, userID string) ([]CartItem, error) {
 	// Create a child span for this operation
@@ -76,17 +244,28 @@ func (c *Client) GetCart(ctx context.Context, userID string) ([]CartItem, error)
 	ctx, span := tracer.Start(ctx, "redis.GetCart")
 	defer span.End()
 
+	ctx, cancel := c.opContext(ctx)
+	defer cancel()
+	defer c.trackSlow(span, "GetCart", time.Now(), fmt.Sprintf("cart:%s", userID))
+
 	span.SetAttributes(attribute.String("user_id", userID))
 
 	key := fmt.Sprintf("cart:%s", userID)
 
-	// Use HGETALL to fetch all fields and values
-	// Returns map[string]string where key=productID, value=quantity
-	result, err := c.rdb.HGetAll(ctx, key).Result()
+	// Fetch all fields and values as map[string]string where key=productID, value=quantity
+	var result map[string]string
+	var scanned bool
+	err := c.withRetry(ctx, span, "GetCart", true, func(ctx context.Context) error {
+		var err error
+		result, scanned, err = c.readCart(ctx, key)
+		return err
+	})
+	span.SetAttributes(attribute.Bool("redis.hscan", scanned))
 	if err != nil {
-		span.SetStatus(codes.Error, "Redis HGETALL failed")
+		err = c.checkTimeout(ctx, span, err)
+		span.SetStatus(codes.Error, "Redis cart read failed")
 		span.RecordError(err)
-		c.logger.Error("Failed to get cart",
+		c.spanLogger(ctx).Error("Failed to get cart",
 			zap.String("user_id", userID),
 			zap.Error(err),
 		)
@@ -99,7 +278,7 @@ func (c *Client) GetCart(ctx context.Context, userID string) ([]CartItem, error)
 		quantity, err := strconv.Atoi(quantityStr)
 		if err != nil {
 			// Skip invalid entries
-			c.logger.Warn("Invalid quantity in cart, skipping",
+			c.spanLogger(ctx).Warn("Invalid quantity in cart, skipping",
 				zap.String("user_id", userID),
 				zap.String("product_id", productID),
 				zap.String("quantity_str", quantityStr),
@@ -120,32 +299,646 @@ func (c *Client) GetCart(ctx context.Context, userID string) ([]CartItem, error)
 	return items, nil
 }
 
+// SetItems overwrites the quantities of several items in a user's cart at once
+// A quantity of 0 removes the item (HDEL), any other value is stored as-is (HSET)
+// All commands run in a single MULTI/EXEC transaction so the cart is never partially updated
+// A quantity above maxQuantity returns ErrQuantityLimit, and an update that adds products to the
+// cart returns ErrCartFull when the cart would end up with more than maxItems distinct products;
+// a limit of 0 is not enforced and nothing is written when one is hit
+func (c *Client) SetItems(ctx context.Context, userID string, items []CartItem, maxItems, maxQuantity int) error {
+	// Create a child span for this operation
+	tracer := otel.Tracer("cart-service")
+	ctx, span := tracer.Start(ctx, "redis.SetItems")
+	defer span.End()
+
+	ctx, cancel := c.opContext(ctx)
+	defer cancel()
+	defer c.trackSlow(span, "SetItems", time.Now(), fmt.Sprintf("cart:%s", userID))
+
+	span.SetAttributes(
+		attribute.String("user_id", userID),
+		attribute.Int("line_count", len(items)),
+	)
+
+	// Validate every line before touching Redis so a bad line can't leave a half-applied update
+	if maxItems < 0 || maxQuantity < 0 {
+		span.SetStatus(codes.Error, "Invalid limit")
+		return fmt.Errorf("limits must not be negative, got max items %d and max quantity %d: %w", maxItems, maxQuantity, ErrInvalidQuantity)
+	}
+	for _, item := range items {
+		if item.Quantity < 0 {
+			span.SetStatus(codes.Error, "Invalid quantity")
+			return fmt.Errorf("quantity must not be negative, got %d for product %s: %w", item.Quantity, item.ProductID, ErrInvalidQuantity)
+		}
+		if maxQuantity > 0 && item.Quantity > maxQuantity {
+			span.SetStatus(codes.Error, "Item quantity limit reached")
+			return fmt.Errorf("cannot set %d of product %s in cart of user %s: %w", item.Quantity, item.ProductID, userID, ErrQuantityLimit)
+		}
+	}
+
+	key := fmt.Sprintf("cart:%s", userID)
+
+	productIDs := make([]string, len(items))
+	for i, item := range items {
+		productIDs[i] = item.ProductID
+	}
+
+	// The cart key is WATCHed while the current lines and size are read, so the size check and
+	// the removed quantities (for their events) hold for the HSET/HDEL queued after them
+	// Absolute quantities make the transaction safe to re-run
+	var removed []CartItem
+	txf := func(tx *redis.Tx) error {
+		previous, err := tx.HMGet(ctx, key, productIDs...).Result()
+		if err != nil {
+			return err
+		}
+		size, err := tx.HLen(ctx, key).Result()
+		if err != nil {
+			return err
+		}
+
+		present := make(map[string]int, len(items))
+		for i, value := range previous {
+			// Lines that are not in the cart (nil) count as absent
+			if quantityStr, ok := value.(string); ok {
+				present[productIDs[i]], _ = strconv.Atoi(quantityStr)
+			}
+		}
+
+		removed = removed[:0]
+		added := false
+		for _, item := range items {
+			quantity, exists := present[item.ProductID]
+			switch {
+			case item.Quantity == 0 && exists:
+				size--
+				delete(present, item.ProductID)
+				if quantity > 0 {
+					removed = append(removed, CartItem{ProductID: item.ProductID, Quantity: quantity})
+				}
+			case item.Quantity > 0 && !exists:
+				size++
+				added = true
+				present[item.ProductID] = item.Quantity
+			}
+		}
+		if added && maxItems > 0 && size > int64(maxItems) {
+			return ErrCartFull
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, item := range items {
+				if item.Quantity == 0 {
+					pipe.HDel(ctx, key, item.ProductID)
+				} else {
+					pipe.HSet(ctx, key, item.ProductID, item.Quantity)
+				}
+			}
+			return nil
+		})
+		return err
+	}
+
+	err := c.withRetry(ctx, span, "SetItems", true, func(ctx context.Context) error {
+		var err error
+		for attempt := 0; attempt < maxTxRetries; attempt++ {
+			err = c.rdb.Watch(ctx, txf, key)
+			if !errors.Is(err, redis.TxFailedErr) {
+				break
+			}
+		}
+		return err
+	})
+	if errors.Is(err, ErrCartFull) {
+		span.SetStatus(codes.Error, "Cart is full")
+		c.spanLogger(ctx).Warn("Cart item limit reached",
+			zap.String("user_id", userID),
+			zap.Int("max_items", maxItems),
+		)
+		return fmt.Errorf("cannot set %d lines in cart of user %s: %w", len(items), userID, ErrCartFull)
+	}
+	if err != nil {
+		err = c.checkTimeout(ctx, span, err)
+		span.SetStatus(codes.Error, "Redis MULTI/EXEC failed")
+		span.RecordError(err)
+		c.spanLogger(ctx).Error("Failed to set cart items",
+			zap.String("user_id", userID),
+			zap.Int("line_count", len(items)),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to set cart items: %w", err)
+	}
+	for _, item := range items {
+		if item.Quantity > 0 {
+			c.markCartCreated(ctx, userID)
+			break
+		}
+	}
+	for _, item := range removed {
+		c.publishEvent(ctx, userID, item.ProductID, EventActionRemove, item.Quantity)
+	}
+
+	span.SetStatus(codes.Ok, "Items set successfully")
+	c.spanLogger(ctx).Info("Cart items set",
+		zap.String("user_id", userID),
+		zap.Int("line_count", len(items)),
+	)
+
+	return nil
+}
+
+// SetItemQuantityIfMatch overwrites the quantity of one product only if it currently equals
+// expected (0 meaning the product is not in the cart), so clients can do a safe
+// read-modify-write. A newQty of 0 removes the product
+// The cart key is WATCHed between the read and the MULTI/EXEC; if another writer changes it
+// in between, the compare is re-run against the new value. Returns false on mismatch
+// Like SetItems, a newQty above maxQuantity returns ErrQuantityLimit and adding the product to a
+// cart that already holds maxItems distinct products returns ErrCartFull (0 = unlimited)
+func (c *Client) SetItemQuantityIfMatch(ctx context.Context, userID, productID string, expected, newQty, maxItems, maxQuantity int) (bool, error) {
+	// Create a child span for this operation
+	tracer := otel.Tracer("cart-service")
+	ctx, span := tracer.Start(ctx, "redis.SetItemQuantityIfMatch")
+	defer span.End()
+
+	ctx, cancel := c.opContext(ctx)
+	defer cancel()
+	defer c.trackSlow(span, "SetItemQuantityIfMatch", time.Now(), fmt.Sprintf("cart:%s", userID))
+
+	span.SetAttributes(
+		attribute.String("user_id", userID),
+		attribute.String("product_id", productID),
+		attribute.Int("expected_quantity", expected),
+		attribute.Int("quantity", newQty),
+	)
+
+	if newQty < 0 {
+		span.SetStatus(codes.Error, "Invalid quantity")
+		return false, fmt.Errorf("quantity must not be negative, got %d for product %s: %w", newQty, productID, ErrInvalidQuantity)
+	}
+	if maxItems < 0 || maxQuantity < 0 {
+		span.SetStatus(codes.Error, "Invalid limit")
+		return false, fmt.Errorf("limits must not be negative, got max items %d and max quantity %d: %w", maxItems, maxQuantity, ErrInvalidQuantity)
+	}
+	if maxQuantity > 0 && newQty > maxQuantity {
+		span.SetStatus(codes.Error, "Item quantity limit reached")
+		return false, fmt.Errorf("cannot set %d of product %s in cart of user %s: %w", newQty, productID, userID, ErrQuantityLimit)
+	}
+
+	key := fmt.Sprintf("cart:%s", userID)
+
+	matched := false
+	txf := func(tx *redis.Tx) error {
+		current := 0
+		exists := true
+		quantityStr, err := tx.HGet(ctx, key, productID).Result()
+		switch {
+		case errors.Is(err, redis.Nil):
+			exists = false
+		case err != nil:
+			return err
+		default:
+			current, err = strconv.Atoi(quantityStr)
+			if err != nil {
+				// A corrupted quantity can never match
+				current = -1
+			}
+		}
+
+		matched = current == expected
+		if !matched {
+			return nil
+		}
+
+		if !exists && newQty > 0 && maxItems > 0 {
+			size, err := tx.HLen(ctx, key).Result()
+			if err != nil {
+				return err
+			}
+			if size >= int64(maxItems) {
+				return ErrCartFull
+			}
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			if newQty == 0 {
+				pipe.HDel(ctx, key, productID)
+			} else {
+				pipe.HSet(ctx, key, productID, newQty)
+			}
+			return nil
+		})
+		return err
+	}
+
+	// A re-run after an applied EXEC would report a mismatch, so it is not treated as idempotent
+	err := c.withRetry(ctx, span, "SetItemQuantityIfMatch", false, func(ctx context.Context) error {
+		var err error
+		for attempt := 0; attempt < maxTxRetries; attempt++ {
+			err = c.rdb.Watch(ctx, txf, key)
+			if !errors.Is(err, redis.TxFailedErr) {
+				break
+			}
+		}
+		return err
+	})
+	if errors.Is(err, ErrCartFull) {
+		span.SetStatus(codes.Error, "Cart is full")
+		c.spanLogger(ctx).Warn("Cart item limit reached",
+			zap.String("user_id", userID),
+			zap.String("product_id", productID),
+			zap.Int("max_items", maxItems),
+		)
+		return false, fmt.Errorf("cannot add product %s to cart of user %s: %w", productID, userID, ErrCartFull)
+	}
+	if err != nil {
+		err = c.checkTimeout(ctx, span, err)
+		span.SetStatus(codes.Error, "Redis compare-and-set transaction failed")
+		span.RecordError(err)
+		c.spanLogger(ctx).Error("Failed to set item quantity",
+			zap.String("user_id", userID),
+			zap.String("product_id", productID),
+			zap.Error(err),
+		)
+		return false, fmt.Errorf("failed to set item quantity: %w", err)
+	}
+
+	span.SetAttributes(attribute.Bool("matched", matched))
+	if !matched {
+		span.SetStatus(codes.Ok, "Quantity precondition failed")
+		return false, nil
+	}
+
+	if newQty > 0 {
+		c.markCartCreated(ctx, userID)
+	}
+	if newQty == 0 && expected != 0 {
+		c.publishEvent(ctx, userID, productID, EventActionRemove, expected)
+	}
+
+	span.SetStatus(codes.Ok, "Item quantity set successfully")
+	c.spanLogger(ctx).Info("Item quantity set",
+		zap.String("user_id", userID),
+		zap.String("product_id", productID),
+		zap.Int("quantity", newQty),
+	)
+
+	return true, nil
+}
+
+// MergeCart folds one user's cart into another's, e.g. a guest cart into the cart of the account
+// the shopper just logged in to: every source quantity is HINCRBY'd into the destination and
+// the source cart is deleted, in one MULTI/EXEC transaction
+// Both keys are WATCHed so lines added to the source mid-merge are not lost and the limits are
+// checked against the destination as merged into; the transaction is retried if either changes
+// When the merged cart would hold more than maxItems distinct products ErrCartFull is returned,
+// and ErrQuantityLimit when a product would exceed maxQuantity (0 = unlimited); both carts are
+// then left as they were. An empty or missing source cart is a no-op
+// The source cart's metadata is deleted in the same transaction; like the two cart keys it must
+// then share a hash slot in cluster mode
+func (c *Client) MergeCart(ctx context.Context, fromUserID, toUserID string, maxItems, maxQuantity int) error {
+	// The merge joins two carts' histories, so link the traces that created them
+	links := c.cartCreationLinks(ctx,
+		linkedCart{userID: fromUserID, role: "source"},
+		linkedCart{userID: toUserID, role: "destination"},
+	)
+
+	// Create a child span for this operation
+	tracer := otel.Tracer("cart-service")
+	ctx, span := tracer.Start(ctx, "redis.MergeCart", trace.WithLinks(links...))
+	defer span.End()
+
+	ctx, cancel := c.opContext(ctx)
+	defer cancel()
+	defer c.trackSlow(span, "MergeCart", time.Now(), fmt.Sprintf("cart:%s", fromUserID), fmt.Sprintf("cart:%s", toUserID))
+
+	span.SetAttributes(
+		attribute.String("from_user_id", fromUserID),
+		attribute.String("user_id", toUserID),
+	)
+
+	if maxItems < 0 || maxQuantity < 0 {
+		span.SetStatus(codes.Error, "Invalid limit")
+		return fmt.Errorf("limits must not be negative, got max items %d and max quantity %d: %w", maxItems, maxQuantity, ErrInvalidQuantity)
+	}
+
+	fromKey := fmt.Sprintf("cart:%s", fromUserID)
+	toKey := fmt.Sprintf("cart:%s", toUserID)
+
+	var merged []CartItem
+	txf := func(tx *redis.Tx) error {
+		fields, err := tx.HGetAll(ctx, fromKey).Result()
+		if err != nil {
+			return err
+		}
+
+		merged = merged[:0]
+		if len(fields) == 0 {
+			return nil
+		}
+
+		for productID, quantityStr := range fields {
+			quantity, err := strconv.Atoi(quantityStr)
+			if err != nil || quantity <= 0 {
+				// Dropped with the source cart, like GetCart skips it
+				c.spanLogger(ctx).Warn("Invalid quantity in merged cart, skipping",
+					zap.String("user_id", fromUserID),
+					zap.String("product_id", productID),
+					zap.String("quantity_str", quantityStr),
+				)
+				continue
+			}
+			merged = append(merged, CartItem{ProductID: productID, Quantity: quantity})
+		}
+
+		if err := checkAddLimits(ctx, tx, toKey, merged, maxItems, maxQuantity); err != nil {
+			return err
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, item := range merged {
+				pipe.HIncrBy(ctx, toKey, item.ProductID, int64(item.Quantity))
+			}
+			pipe.Del(ctx, fromKey, cartMetaKey(fromUserID))
+			return nil
+		})
+		return err
+	}
+
+	// A re-run after an applied EXEC finds the source cart gone and merges nothing
+	err := c.withRetry(ctx, span, "MergeCart", true, func(ctx context.Context) error {
+		var err error
+		for attempt := 0; attempt < maxTxRetries; attempt++ {
+			err = c.rdb.Watch(ctx, txf, fromKey, toKey)
+			if !errors.Is(err, redis.TxFailedErr) {
+				break
+			}
+		}
+		return err
+	})
+	if errors.Is(err, ErrCartFull) || errors.Is(err, ErrQuantityLimit) {
+		span.SetStatus(codes.Error, "Cart limit reached")
+		c.spanLogger(ctx).Warn("Cart limit reached, merge rejected",
+			zap.String("from_user_id", fromUserID),
+			zap.String("user_id", toUserID),
+			zap.Int("max_items", maxItems),
+			zap.Int("max_quantity", maxQuantity),
+			zap.Error(err),
+		)
+		return fmt.Errorf("cannot merge cart of user %s into cart of user %s: %w", fromUserID, toUserID, err)
+	}
+	if isCrossSlot(err) {
+		span.SetStatus(codes.Error, "Carts in different cluster slots")
+		span.RecordError(err)
+		c.spanLogger(ctx).Warn("Carts hash to different cluster slots, merge rejected",
+			zap.String("from_user_id", fromUserID),
+			zap.String("user_id", toUserID),
+			zap.Error(err),
+		)
+		return fmt.Errorf("cannot merge cart of user %s into cart of user %s: %w", fromUserID, toUserID, ErrCrossSlot)
+	}
+	if err != nil {
+		err = c.checkTimeout(ctx, span, err)
+		span.SetStatus(codes.Error, "Redis merge transaction failed")
+		span.RecordError(err)
+		c.spanLogger(ctx).Error("Failed to merge cart",
+			zap.String("from_user_id", fromUserID),
+			zap.String("user_id", toUserID),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to merge cart: %w", err)
+	}
+
+	if len(merged) > 0 {
+		c.markCartCreated(ctx, toUserID)
+	}
+	for _, item := range merged {
+		c.publishEvent(ctx, fromUserID, item.ProductID, EventActionRemove, item.Quantity)
+		c.publishEvent(ctx, toUserID, item.ProductID, EventActionAdd, item.Quantity)
+	}
+
+	span.SetAttributes(attribute.Int("merged_lines", len(merged)))
+	span.SetStatus(codes.Ok, "Cart merged successfully")
+	if len(merged) > 0 {
+		c.spanLogger(ctx).Info("Cart merged",
+			zap.String("from_user_id", fromUserID),
+			zap.String("user_id", toUserID),
+			zap.Int("merged_lines", len(merged)),
+		)
+	}
+
+	return nil
+}
+
+// checkAddLimits reads the cart at key inside a WATCH transaction and returns ErrCartFull or
+// ErrQuantityLimit when incrementing it by items would break maxItems or maxQuantity (0 = unlimited)
+func checkAddLimits(ctx context.Context, tx *redis.Tx, key string, items []CartItem, maxItems, maxQuantity int) error {
+	if len(items) == 0 || (maxItems == 0 && maxQuantity == 0) {
+		return nil
+	}
+
+	productIDs := make([]string, len(items))
+	for i, item := range items {
+		productIDs[i] = item.ProductID
+	}
+	current, err := tx.HMGet(ctx, key, productIDs...).Result()
+	if err != nil {
+		return err
+	}
+
+	added := 0
+	for i, value := range current {
+		quantityStr, exists := value.(string)
+		if !exists {
+			added++
+		}
+		// A corrupted quantity counts as 0; the HINCRBY that follows fails on it anyway
+		quantity, _ := strconv.Atoi(quantityStr)
+		if maxQuantity > 0 && quantity+items[i].Quantity > maxQuantity {
+			return ErrQuantityLimit
+		}
+	}
+
+	if added > 0 && maxItems > 0 {
+		size, err := tx.HLen(ctx, key).Result()
+		if err != nil {
+			return err
+		}
+		if size+int64(added) > int64(maxItems) {
+			return ErrCartFull
+		}
+	}
+	return nil
+}
+
+// TransferItem moves quantity units of a product from one user's cart to another's, e.g. from
+// the active cart to a "saved for later" cart: the source is decremented (and the field removed
+// when it reaches zero) and the destination incremented in one MULTI/EXEC transaction
+// Both keys are WATCHed so a concurrent update cannot cause an over-transfer or push the
+// destination past its limits; the transaction is retried if either changes. Returns
+// ErrInsufficientQuantity when the source holds fewer than quantity units, and ErrCartFull or
+// ErrQuantityLimit when the destination would break maxItems or maxQuantity (0 = unlimited)
+func (c *Client) TransferItem(ctx context.Context, fromUserID, toUserID, productID string, quantity, maxItems, maxQuantity int) error {
+	// Create a child span for this operation
+	tracer := otel.Tracer("cart-service")
+	ctx, span := tracer.Start(ctx, "redis.TransferItem")
+	defer span.End()
+
+	ctx, cancel := c.opContext(ctx)
+	defer cancel()
+	defer c.trackSlow(span, "TransferItem", time.Now(), fmt.Sprintf("cart:%s", fromUserID), fmt.Sprintf("cart:%s", toUserID))
+
+	span.SetAttributes(
+		attribute.String("from_user_id", fromUserID),
+		attribute.String("user_id", toUserID),
+		attribute.String("product_id", productID),
+		attribute.Int("quantity", quantity),
+	)
+
+	if maxItems < 0 || maxQuantity < 0 {
+		span.SetStatus(codes.Error, "Invalid limit")
+		return fmt.Errorf("limits must not be negative, got max items %d and max quantity %d: %w", maxItems, maxQuantity, ErrInvalidQuantity)
+	}
+
+	fromKey := fmt.Sprintf("cart:%s", fromUserID)
+	toKey := fmt.Sprintf("cart:%s", toUserID)
+
+	txf := func(tx *redis.Tx) error {
+		available := 0
+		quantityStr, err := tx.HGet(ctx, fromKey, productID).Result()
+		switch {
+		case errors.Is(err, redis.Nil):
+		case err != nil:
+			return err
+		default:
+			// A corrupted quantity counts as nothing to transfer
+			available, _ = strconv.Atoi(quantityStr)
+		}
+
+		if available < quantity {
+			return ErrInsufficientQuantity
+		}
+
+		if err := checkAddLimits(ctx, tx, toKey, []CartItem{{ProductID: productID, Quantity: quantity}}, maxItems, maxQuantity); err != nil {
+			return err
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			if available == quantity {
+				pipe.HDel(ctx, fromKey, productID)
+			} else {
+				pipe.HIncrBy(ctx, fromKey, productID, int64(-quantity))
+			}
+			pipe.HIncrBy(ctx, toKey, productID, int64(quantity))
+			return nil
+		})
+		return err
+	}
+
+	err := c.withRetry(ctx, span, "TransferItem", false, func(ctx context.Context) error {
+		var err error
+		for attempt := 0; attempt < maxTxRetries; attempt++ {
+			err = c.rdb.Watch(ctx, txf, fromKey, toKey)
+			if !errors.Is(err, redis.TxFailedErr) {
+				break
+			}
+		}
+		return err
+	})
+	if errors.Is(err, ErrInsufficientQuantity) {
+		span.SetStatus(codes.Error, "Insufficient quantity")
+		return err
+	}
+	if errors.Is(err, ErrCartFull) || errors.Is(err, ErrQuantityLimit) {
+		span.SetStatus(codes.Error, "Cart limit reached")
+		c.spanLogger(ctx).Warn("Cart limit reached, transfer rejected",
+			zap.String("from_user_id", fromUserID),
+			zap.String("user_id", toUserID),
+			zap.String("product_id", productID),
+			zap.Int("max_items", maxItems),
+			zap.Int("max_quantity", maxQuantity),
+			zap.Error(err),
+		)
+		return fmt.Errorf("cannot transfer product %s to cart of user %s: %w", productID, toUserID, err)
+	}
+	if isCrossSlot(err) {
+		span.SetStatus(codes.Error, "Carts in different cluster slots")
+		span.RecordError(err)
+		c.spanLogger(ctx).Warn("Carts hash to different cluster slots, transfer rejected",
+			zap.String("from_user_id", fromUserID),
+			zap.String("user_id", toUserID),
+			zap.String("product_id", productID),
+			zap.Error(err),
+		)
+		return fmt.Errorf("cannot transfer product %s to cart of user %s: %w", productID, toUserID, ErrCrossSlot)
+	}
+	if err != nil {
+		err = c.checkTimeout(ctx, span, err)
+		span.SetStatus(codes.Error, "Redis transfer transaction failed")
+		span.RecordError(err)
+		c.spanLogger(ctx).Error("Failed to transfer item",
+			zap.String("from_user_id", fromUserID),
+			zap.String("user_id", toUserID),
+			zap.String("product_id", productID),
+			zap.Error(err),
+		)
+		return fmt.Errorf("failed to transfer item: %w", err)
+	}
+	c.publishEvent(ctx, fromUserID, productID, EventActionRemove, quantity)
+	c.publishEvent(ctx, toUserID, productID, EventActionAdd, quantity)
+
+	span.SetStatus(codes.Ok, "Item transferred successfully")
+	c.spanLogger(ctx).Info("Item transferred",
+		zap.String("from_user_id", fromUserID),
+		zap.String("user_id", toUserID),
+		zap.String("product_id", productID),
+		zap.Int("quantity", quantity),
+	)
+
+	return nil
+}
+
 // ClearCart removes all items from a user's cart
-// Uses DEL to delete the entire hash
+// Uses DEL to delete the entire hash, along with the cart's metadata
+// The two keys are deleted in a plain pipeline, not a transaction, since in cluster mode
+// they can live on different slots
 func (c *Client) ClearCart(ctx context.Context, userID string) error {
 	// Create a child span for this operation
 	tracer := otel.Tracer("cart-service")
 	ctx, span := tracer.Start(ctx, "redis.ClearCart")
 	defer span.End()
 
+	ctx, cancel := c.opContext(ctx)
+	defer cancel()
+	defer c.trackSlow(span, "ClearCart", time.Now(), fmt.Sprintf("cart:%s", userID))
+
 	span.SetAttributes(attribute.String("user_id", userID))
 
 	key := fmt.Sprintf("cart:%s", userID)
 
 	// Use DEL to remove the entire hash
-	err := c.rdb.Del(ctx, key).Err()
+	err := c.withRetry(ctx, span, "ClearCart", true, func(ctx context.Context) error {
+		_, err := c.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Del(ctx, key)
+			pipe.Del(ctx, cartMetaKey(userID))
+			return nil
+		})
+		return err
+	})
 	if err != nil {
+		err = c.checkTimeout(ctx, span, err)
 		span.SetStatus(codes.Error, "Redis DEL failed")
 		span.RecordError(err)
-		c.logger.Error("Failed to clear cart",
+		c.spanLogger(ctx).Error("Failed to clear cart",
 			zap.String("user_id", userID),
 			zap.Error(err),
 		)
 		return fmt.Errorf("failed to clear cart: %w", err)
 	}
 
+	c.publishEvent(ctx, userID, "", EventActionClear, 0)
+
 	span.SetStatus(codes.Ok, "Cart cleared successfully")
-	c.logger.Info("Cart cleared", zap.String("user_id", userID))
+	c.spanLogger(ctx).Info("Cart cleared", zap.String("user_id", userID))
 
 	return nil
 }
@@ -158,12 +951,22 @@ func (c *Client) ItemCount(ctx context.Context, userID string) (int64, error) {
 	ctx, span := tracer.Start(ctx, "redis.ItemCount")
 	defer span.End()
 
+	ctx, cancel := c.opContext(ctx)
+	defer cancel()
+	defer c.trackSlow(span, "ItemCount", time.Now(), fmt.Sprintf("cart:%s", userID))
+
 	span.SetAttributes(attribute.String("user_id", userID))
 
 	key := fmt.Sprintf("cart:%s", userID)
 
-	count, err := c.rdb.HLen(ctx, key).Result()
+	var count int64
+	err := c.withRetry(ctx, span, "ItemCount", true, func(ctx context.Context) error {
+		var err error
+		count, err = c.rdb.HLen(ctx, key).Result()
+		return err
+	})
 	if err != nil {
+		err = c.checkTimeout(ctx, span, err)
 		span.SetStatus(codes.Error, "Redis HLEN failed")
 		span.RecordError(err)
 		return 0, fmt.Errorf("failed to get item count: %w", err)
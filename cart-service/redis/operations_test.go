@@ -0,0 +1,178 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// newTestClient returns a Client backed by a fresh miniredis instance
+func newTestClient(t *testing.T) (*Client, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	return NewClient(rdb, zap.NewNop()), mr
+}
+
+func TestAddItemWithLimit(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should add new products up to the limit and reject the next one", func(t *testing.T) {
+		client, mr := newTestClient(t)
+
+		for i := 1; i <= 3; i++ {
+			require.NoError(t, client.AddItemWithLimit(ctx, "user-1", fmt.Sprintf("prod-%d", i), 1, 3, 0))
+		}
+
+		err := client.AddItemWithLimit(ctx, "user-1", "prod-4", 1, 3, 0)
+		assert.ErrorIs(t, err, ErrCartFull)
+
+		keys, err := mr.HKeys("cart:user-1")
+		require.NoError(t, err)
+		assert.Len(t, keys, 3, "The rejected product must not be written")
+	})
+
+	t.Run("should still increment products already in a full cart", func(t *testing.T) {
+		client, mr := newTestClient(t)
+
+		require.NoError(t, client.AddItemWithLimit(ctx, "user-1", "prod-1", 1, 2, 0))
+		require.NoError(t, client.AddItemWithLimit(ctx, "user-1", "prod-2", 1, 2, 0))
+
+		require.NoError(t, client.AddItemWithLimit(ctx, "user-1", "prod-1", 4, 2, 0))
+		assert.Equal(t, "5", mr.HGet("cart:user-1", "prod-1"))
+	})
+
+	t.Run("should never exceed the limit under concurrent adds", func(t *testing.T) {
+		client, mr := newTestClient(t)
+
+		const limit = 5
+		var wg sync.WaitGroup
+		errs := make(chan error, 20)
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				errs <- client.AddItemWithLimit(ctx, "user-1", fmt.Sprintf("prod-%d", i), 1, limit, 0)
+			}(i)
+		}
+		wg.Wait()
+		close(errs)
+
+		added := 0
+		for err := range errs {
+			if err == nil {
+				added++
+				continue
+			}
+			assert.ErrorIs(t, err, ErrCartFull)
+		}
+		assert.Equal(t, limit, added)
+
+		keys, err := mr.HKeys("cart:user-1")
+		require.NoError(t, err)
+		assert.Len(t, keys, limit)
+	})
+
+	t.Run("should reject invalid arguments", func(t *testing.T) {
+		client, _ := newTestClient(t)
+
+		assert.Error(t, client.AddItemWithLimit(ctx, "user-1", "prod-1", 0, 3, 0))
+		assert.Error(t, client.AddItemWithLimit(ctx, "user-1", "prod-1", 1, -1, 0))
+		assert.Error(t, client.AddItemWithLimit(ctx, "user-1", "prod-1", 1, 3, -1))
+	})
+
+	t.Run("should reject an add that takes the quantity past the per-item limit", func(t *testing.T) {
+		client, mr := newTestClient(t)
+
+		require.NoError(t, client.AddItemWithLimit(ctx, "user-1", "prod-1", 6, 0, 10))
+		require.NoError(t, client.AddItemWithLimit(ctx, "user-1", "prod-1", 4, 0, 10))
+
+		err := client.AddItemWithLimit(ctx, "user-1", "prod-1", 1, 0, 10)
+		assert.ErrorIs(t, err, ErrQuantityLimit)
+		assert.Equal(t, "10", mr.HGet("cart:user-1", "prod-1"), "The rejected add must not be written")
+
+		err = client.AddItemWithLimit(ctx, "user-1", "prod-2", 11, 0, 10)
+		assert.ErrorIs(t, err, ErrQuantityLimit)
+		keys, err := mr.HKeys("cart:user-1")
+		require.NoError(t, err)
+		assert.NotContains(t, keys, "prod-2")
+	})
+
+	t.Run("should not enforce limits of zero", func(t *testing.T) {
+		client, mr := newTestClient(t)
+
+		for i := 1; i <= 3; i++ {
+			require.NoError(t, client.AddItemWithLimit(ctx, "user-1", fmt.Sprintf("prod-%d", i), 1, 0, 0))
+		}
+		require.NoError(t, client.AddItemWithLimit(ctx, "user-1", "prod-1", 4999, 0, 0))
+		assert.Equal(t, "5000", mr.HGet("cart:user-1", "prod-1"))
+	})
+}
+
+func TestOperationLogTraceFields(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	core, logs := observer.New(zap.InfoLevel)
+	client := NewClient(rdb, zap.New(core))
+
+	require.NoError(t, client.AddItem(context.Background(), "user-1", "prod-1", 1))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	entries := logs.FilterMessage("Item added to cart").All()
+	require.Len(t, entries, 1)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, spans[0].SpanContext().TraceID().String(), fields["trace_id"])
+	assert.Equal(t, spans[0].SpanContext().SpanID().String(), fields["span_id"])
+}
+
+func TestCrossSlotTransactions(t *testing.T) {
+	ctx := context.Background()
+
+	// miniredis answers CLUSTER SLOTS as a single node, but go-redis still refuses to WATCH keys
+	// from different slots, exactly as it would against a real cluster
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClusterClient(&redis.ClusterOptions{Addrs: []string{mr.Addr()}})
+	t.Cleanup(func() { rdb.Close() })
+	client := NewClient(rdb, zap.NewNop())
+
+	require.NoError(t, client.AddItem(ctx, "user-1", "1", 2))
+
+	t.Run("should reject a merge across slots without writing", func(t *testing.T) {
+		err := client.MergeCart(ctx, "user-1", "user-2", 0, 0)
+		assert.ErrorIs(t, err, ErrCrossSlot)
+		assert.Equal(t, "2", mr.HGet("cart:user-1", "1"))
+		assert.False(t, mr.Exists("cart:user-2"))
+	})
+
+	t.Run("should reject a transfer across slots without writing", func(t *testing.T) {
+		err := client.TransferItem(ctx, "user-1", "user-2", "1", 1, 0, 0)
+		assert.ErrorIs(t, err, ErrCrossSlot)
+		assert.Equal(t, "2", mr.HGet("cart:user-1", "1"))
+		assert.False(t, mr.Exists("cart:user-2"))
+	})
+
+	t.Run("should recognise the server's CROSSSLOT error", func(t *testing.T) {
+		assert.True(t, isCrossSlot(redis.ErrCrossSlot))
+		assert.True(t, isCrossSlot(fmt.Errorf("wrapped: %w", redis.ErrCrossSlot)))
+		assert.False(t, isCrossSlot(redis.TxFailedErr))
+		assert.False(t, isCrossSlot(nil))
+	})
+}
@@ -0,0 +1,122 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+
+	"cart-service/internal/retry"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// DefaultOpRetryConfig returns the default retry policy for cart operations
+// Max retries: 2, Initial delay: 50ms, Max delay: 500ms, Jitter: ±10%
+// The delays are short because the whole operation, retries included, must fit in Config.OpTimeout
+func DefaultOpRetryConfig() retry.RetryConfig {
+	return retry.RetryConfig{
+		InitialDelay: 50 * time.Millisecond,
+		MaxDelay:     500 * time.Millisecond,
+		MaxRetries:   2,
+		JitterPct:    0.1,
+	}
+}
+
+// unexecutedErrorPrefixes are Redis errors returned instead of running the command, typically
+// while a replica is promoted or a cluster slot moves, so a later attempt may succeed
+var unexecutedErrorPrefixes = []string{"LOADING", "READONLY", "MASTERDOWN", "CLUSTERDOWN", "TRYAGAIN"}
+
+// isTransient reports whether a failed command is worth retrying
+// Server rejections in unexecutedErrorPrefixes and refused connections never reached Redis, so
+// they are always transient. A connection dropped mid-command may or may not have applied it,
+// so those are only transient for idempotent operations. redis.Nil, aborted transactions,
+// deadlines and auth failures are never retried
+func isTransient(err error, idempotent bool) bool {
+	switch {
+	case err == nil,
+		errors.Is(err, redis.Nil),
+		errors.Is(err, redis.TxFailedErr),
+		errors.Is(err, context.Canceled),
+		errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, ErrRedisUnauthorized):
+		return false
+	}
+
+	var redisErr redis.Error
+	if errors.As(err, &redisErr) {
+		message := redisErr.Error()
+		for _, prefix := range unexecutedErrorPrefixes {
+			if strings.HasPrefix(message, prefix) {
+				return true
+			}
+		}
+		// Any other server error is a logical one (WRONGTYPE, script errors, ...)
+		return false
+	}
+
+	var opErr *net.OpError
+	if errors.Is(err, syscall.ECONNREFUSED) || (errors.As(err, &opErr) && opErr.Op == "dial") {
+		return true
+	}
+
+	if !idempotent {
+		return false
+	}
+	var netErr net.Error
+	return errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.As(err, &netErr)
+}
+
+// IsUnavailable reports whether err means Redis could not serve the command right now: it was
+// unreachable, the connection failed, the operation timed out, or the server answered with one of
+// unexecutedErrorPrefixes (LOADING, CLUSTERDOWN, ...). Errors Redis would return again on any
+// attempt (WRONGTYPE, CROSSSLOT, script errors, rejected credentials) and the cart operations'
+// own rejections are not outages
+func IsUnavailable(err error) bool {
+	return errors.Is(err, ErrOpTimeout) || isTransient(err, true)
+}
+
+// withRetry runs fn, retrying it with backoff from Config.OpRetry while it fails with a
+// transient error (see isTransient); go-redis only retries single commands, this retries the
+// operation's whole read or transaction, e.g. an HGETALL that hit a failover
+// Every retry adds a redis.retry event to span. Retries stop at the operation's deadline, in
+// which case the context error is returned for checkTimeout to report
+func (c *Client) withRetry(ctx context.Context, span trace.Span, operation string, idempotent bool, fn func(ctx context.Context) error) error {
+	if c.opRetry.MaxRetries <= 0 {
+		return fn(ctx)
+	}
+
+	config := c.opRetry
+	config.OnRetry = func(attempt int, err error, delay time.Duration) {
+		span.AddEvent("redis.retry", trace.WithAttributes(
+			attribute.Int("attempt", attempt+1),
+			attribute.String("error", err.Error()),
+			attribute.Int64("retry_delay_ms", delay.Milliseconds()),
+		))
+		c.spanLogger(ctx).Warn("Transient Redis error, retrying operation",
+			zap.String("operation", operation),
+			zap.Int("attempt", attempt+1),
+			zap.Int("max_retries", config.MaxRetries),
+			zap.Duration("retry_delay", delay),
+			zap.Error(err),
+		)
+	}
+
+	return retry.Do(ctx, config, func(ctx context.Context, attempt int) error {
+		err := fn(ctx)
+		if err != nil && !isTransient(err, idempotent) {
+			return retry.Permanent(err)
+		}
+		return err
+	})
+}
@@ -0,0 +1,214 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"cart-service/internal/retry"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// serverError stands in for an error reply from Redis
+type serverError string
+
+func (e serverError) Error() string { return string(e) }
+func (serverError) RedisError()     {}
+
+// failingHook fails the next failures calls of command with err before they reach Redis
+// calls counts the calls of command, failed or not
+type failingHook struct {
+	command  string
+	failures atomic.Int32
+	err      error
+	calls    atomic.Int32
+}
+
+func (h *failingHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h *failingHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if cmd.Name() != h.command {
+			return next(ctx, cmd)
+		}
+		h.calls.Add(1)
+		if h.failures.Add(-1) >= 0 {
+			cmd.SetErr(h.err)
+			return h.err
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func (h *failingHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+// newFlakyClient returns a test Client whose next failures calls of command fail with err
+// Operation retries are enabled with 1ms delays to keep the test fast
+func newFlakyClient(t *testing.T, command string, failures int32, err error) (*Client, *failingHook) {
+	client, _ := newTestClient(t)
+	hook := &failingHook{command: command, err: err}
+	hook.failures.Store(failures)
+	client.rdb.AddHook(hook)
+	client.opRetry = retry.RetryConfig{InitialDelay: time.Millisecond, MaxDelay: time.Millisecond, MaxRetries: 2}
+	return client, hook
+}
+
+func TestOpRetry(t *testing.T) {
+	ctx := context.Background()
+	connReset := &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}
+
+	t.Run("should retry a read after a transient failure and record the retry", func(t *testing.T) {
+		recorder := tracetest.NewSpanRecorder()
+		previous := otel.GetTracerProvider()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+		t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+		client, hook := newFlakyClient(t, "hgetall", 0, connReset)
+		require.NoError(t, client.AddItem(ctx, "user-1", "prod-1", 2))
+		hook.failures.Store(1)
+
+		items, err := client.GetCart(ctx, "user-1")
+		require.NoError(t, err)
+		assert.Equal(t, []CartItem{{ProductID: "prod-1", Quantity: 2}}, items)
+
+		spans := recorder.Ended()
+		span := spans[len(spans)-1]
+		require.Equal(t, "redis.GetCart", span.Name())
+		require.Len(t, span.Events(), 1)
+		assert.Equal(t, "redis.retry", span.Events()[0].Name)
+		attrs := attribute.NewSet(span.Events()[0].Attributes...)
+		attempt, _ := attrs.Value("attempt")
+		assert.Equal(t, int64(1), attempt.AsInt64())
+	})
+
+	t.Run("should give up after MaxRetries", func(t *testing.T) {
+		client, hook := newFlakyClient(t, "hlen", 10, connReset)
+
+		_, err := client.ItemCount(ctx, "user-1")
+		assert.ErrorIs(t, err, syscall.ECONNRESET)
+		assert.Equal(t, int32(3), hook.calls.Load())
+	})
+
+	t.Run("should retry writes Redis rejected without running them", func(t *testing.T) {
+		client, hook := newFlakyClient(t, "hincrby", 1, serverError("LOADING Redis is loading the dataset in memory"))
+
+		require.NoError(t, client.AddItem(ctx, "user-1", "prod-1", 1))
+		assert.Equal(t, int32(2), hook.calls.Load())
+	})
+
+	t.Run("should not retry a non-idempotent write after a dropped connection", func(t *testing.T) {
+		client, hook := newFlakyClient(t, "hincrby", 1, connReset)
+
+		assert.Error(t, client.AddItem(ctx, "user-1", "prod-1", 1))
+		assert.Equal(t, int32(1), hook.calls.Load())
+	})
+
+	t.Run("should retry the idempotency and admin operations", func(t *testing.T) {
+		client, hook := newFlakyClient(t, "hgetall", 1, connReset)
+		require.NoError(t, client.AddItem(ctx, "user-1", "prod-1", 2))
+
+		fields, err := client.RawCart(ctx, "user-1")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"prod-1": "2"}, fields)
+		assert.Equal(t, int32(2), hook.calls.Load())
+
+		for _, run := range []func() error{
+			func() error { _, err := client.RepairCart(ctx, "user-1"); return err },
+			func() error {
+				_, err := client.NormalizeCart(ctx, "user-1", NormalizeOptions{TrimSpace: true})
+				return err
+			},
+		} {
+			hook.calls.Store(0)
+			hook.failures.Store(1)
+			require.NoError(t, run())
+			assert.Equal(t, int32(2), hook.calls.Load())
+		}
+
+		client, hook = newFlakyClient(t, "set", 1, serverError("LOADING Redis is loading the dataset in memory"))
+		claimed, err := client.ClaimIdempotencyKey(ctx, "user-1", "req-1", time.Minute)
+		require.NoError(t, err)
+		assert.True(t, claimed)
+		assert.Equal(t, int32(2), hook.calls.Load())
+
+		client, hook = newFlakyClient(t, "del", 1, connReset)
+		require.NoError(t, client.ReleaseIdempotencyKey(ctx, "user-1", "req-1"))
+		assert.Equal(t, int32(2), hook.calls.Load())
+	})
+
+	t.Run("should not retry logical errors", func(t *testing.T) {
+		client, hook := newFlakyClient(t, "hgetall", 1, serverError("WRONGTYPE Operation against a key holding the wrong kind of value"))
+
+		_, err := client.GetCart(ctx, "user-1")
+		assert.Error(t, err)
+		assert.Equal(t, int32(1), hook.calls.Load())
+	})
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		idempotent bool
+		want       bool
+	}{
+		{"redis.Nil", redis.Nil, true, false},
+		{"aborted transaction", redis.TxFailedErr, true, false},
+		{"deadline", context.DeadlineExceeded, true, false},
+		{"unauthorized", ErrRedisUnauthorized, true, false},
+		{"logical error", serverError("WRONGTYPE wrong kind of value"), true, false},
+		{"failover", serverError("READONLY You can't write against a read only replica"), false, true},
+		{"refused dial", &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}, false, true},
+		{"reset, idempotent", &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}, true, true},
+		{"reset, not idempotent", &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}, false, false},
+		{"other error", errors.New("quantity must be positive"), true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isTransient(tt.err, tt.idempotent))
+		})
+	}
+}
+
+func TestIsUnavailable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"refused dial", &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}, true},
+		{"dropped connection", &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}, true},
+		{"operation timeout", fmt.Errorf("%w after 1s: %v", ErrOpTimeout, context.DeadlineExceeded), true},
+		{"loading", serverError("LOADING Redis is loading the dataset in memory"), true},
+		{"cluster down", serverError("CLUSTERDOWN The cluster is down"), true},
+		{"wrong type", serverError("WRONGTYPE Operation against a key holding the wrong kind of value"), false},
+		{"cross slot", serverError("CROSSSLOT Keys in request don't hash to the same slot"), false},
+		{"script error", serverError("ERR Error running script (call to f_1234): user_script:1: oops"), false},
+		{"unauthorized", fmt.Errorf("failed to get cart: %w", ErrRedisUnauthorized), false},
+		{"cart full", ErrCartFull, false},
+		{"cross-slot carts", ErrCrossSlot, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsUnavailable(tt.err))
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package redis
+
+import (
+	"context"
+)
+
+// hscanBatchSize is the COUNT hint for each HSCAN call when reading a large cart
+const hscanBatchSize = 100
+
+// readCart loads a cart hash, with HGETALL for small carts and HSCAN above Config.ScanThreshold
+// HGETALL is one round trip but builds the whole reply at once, which blocks Redis on a huge
+// hash; HSCAN fetches it in hscanBatchSize batches so other clients are served in between
+// scanned reports which path was taken
+func (c *Client) readCart(ctx context.Context, key string) (fields map[string]string, scanned bool, err error) {
+	if c.scanThreshold > 0 {
+		size, err := c.rdb.HLen(ctx, key).Result()
+		if err != nil {
+			return nil, false, err
+		}
+		if size > int64(c.scanThreshold) {
+			fields, err := c.scanHash(ctx, key, size)
+			return fields, true, err
+		}
+	}
+
+	fields, err = c.rdb.HGetAll(ctx, key).Result()
+	return fields, false, err
+}
+
+// scanHash reads every field of a hash with HSCAN, about hscanBatchSize fields at a time
+// A field may be returned twice if the hash is resized mid-scan; the map keeps one copy
+func (c *Client) scanHash(ctx context.Context, key string, sizeHint int64) (map[string]string, error) {
+	fields := make(map[string]string, sizeHint)
+	var cursor uint64
+	for {
+		// Replies alternate field and value
+		pairs, next, err := c.rdb.HScan(ctx, key, cursor, "", hscanBatchSize).Result()
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i+1 < len(pairs); i += 2 {
+			fields[pairs[i]] = pairs[i+1]
+		}
+		if next == 0 {
+			return fields, nil
+		}
+		cursor = next
+	}
+}
@@ -0,0 +1,60 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetCartScan(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should return the same large cart with HSCAN as with HGETALL", func(t *testing.T) {
+		client, mr := newTestClient(t)
+		for i := 0; i < 1200; i++ {
+			mr.HSet("cart:user-1", fmt.Sprintf("prod-%d", i), fmt.Sprintf("%d", i%7+1))
+		}
+		// Invalid quantities are skipped on both paths
+		mr.HSet("cart:user-1", "prod-bad", "many")
+
+		client.scanThreshold = 0
+		viaHGetAll, err := client.GetCart(ctx, "user-1")
+		require.NoError(t, err)
+
+		client.scanThreshold = 500
+		fields, scanned, err := client.readCart(ctx, "cart:user-1")
+		require.NoError(t, err)
+		assert.True(t, scanned, "1201 fields is above the threshold")
+		assert.Len(t, fields, 1201)
+
+		viaHScan, err := client.GetCart(ctx, "user-1")
+		require.NoError(t, err)
+
+		assert.Len(t, viaHScan, 1200)
+		assert.ElementsMatch(t, viaHGetAll, viaHScan)
+	})
+
+	t.Run("should keep HGETALL for carts at or below the threshold", func(t *testing.T) {
+		client, mr := newTestClient(t)
+		client.scanThreshold = 2
+		mr.HSet("cart:user-1", "prod-1", "1")
+		mr.HSet("cart:user-1", "prod-2", "2")
+
+		fields, scanned, err := client.readCart(ctx, "cart:user-1")
+		require.NoError(t, err)
+		assert.False(t, scanned)
+		assert.Equal(t, map[string]string{"prod-1": "1", "prod-2": "2"}, fields)
+	})
+
+	t.Run("should return an empty cart for a missing key", func(t *testing.T) {
+		client, _ := newTestClient(t)
+		client.scanThreshold = 2
+
+		items, err := client.GetCart(ctx, "nobody")
+		require.NoError(t, err)
+		assert.Empty(t, items)
+	})
+}
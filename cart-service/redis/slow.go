@@ -0,0 +1,37 @@
+package redis
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// trackSlow flags an operation that took longer than Config.SlowThreshold
+// Deferred right after the span starts, so the attributes land before span.End; fast
+// operations are left alone and keep their usual info/debug logging
+// keys lists the Redis keys the operation touched, e.g. both carts of a merge
+// The warning carries the trace_id and span_id of span, so it leads straight to the slow trace
+func (c *Client) trackSlow(span trace.Span, operation string, start time.Time, keys ...string) {
+	if c.slowThreshold <= 0 {
+		return
+	}
+	elapsed := time.Since(start)
+	if elapsed < c.slowThreshold {
+		return
+	}
+
+	span.SetAttributes(
+		attribute.Bool("redis.slow", true),
+		attribute.Int64("redis.duration_ms", elapsed.Milliseconds()),
+	)
+	c.spanLogger(trace.ContextWithSpan(context.Background(), span)).Warn("Slow Redis operation",
+		zap.String("operation", operation),
+		zap.String("key", strings.Join(keys, ",")),
+		zap.Duration("duration", elapsed),
+		zap.Duration("threshold", c.slowThreshold),
+	)
+}
@@ -0,0 +1,60 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSlowOperations(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should log and flag an operation slower than the threshold", func(t *testing.T) {
+		recorder := tracetest.NewSpanRecorder()
+		previous := otel.GetTracerProvider()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+		t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+		core, logs := observer.New(zap.WarnLevel)
+		client := newDelayedClient(t, 100*time.Millisecond, 0)
+		client.logger = zap.New(core)
+		client.slowThreshold = 50 * time.Millisecond
+
+		_, err := client.GetCart(ctx, "user-1")
+		require.NoError(t, err)
+
+		entries := logs.FilterMessage("Slow Redis operation").All()
+		require.Len(t, entries, 1)
+		fields := entries[0].ContextMap()
+		assert.Equal(t, "GetCart", fields["operation"])
+		assert.Equal(t, "cart:user-1", fields["key"])
+		assert.GreaterOrEqual(t, fields["duration"], 50*time.Millisecond)
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 1)
+		assert.Equal(t, spans[0].SpanContext().TraceID().String(), fields["trace_id"])
+		assert.Equal(t, spans[0].SpanContext().SpanID().String(), fields["span_id"])
+		attrs := attribute.NewSet(spans[0].Attributes()...)
+		slow, _ := attrs.Value("redis.slow")
+		assert.True(t, slow.AsBool())
+	})
+
+	t.Run("should stay quiet for operations under the threshold", func(t *testing.T) {
+		core, logs := observer.New(zap.WarnLevel)
+		client, _ := newTestClient(t)
+		client.logger = zap.New(core)
+		client.slowThreshold = time.Second
+
+		require.NoError(t, client.AddItem(ctx, "user-1", "prod-1", 1))
+		assert.Zero(t, logs.Len())
+	})
+}
@@ -0,0 +1,38 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrOpTimeout is returned when a cart operation runs past Config.OpTimeout
+// The socket read/write timeouts only bound single round trips; this bounds the whole call
+var ErrOpTimeout = errors.New("redis operation timed out")
+
+// opContext bounds one cart operation by the configured per-operation timeout
+// A zero timeout leaves ctx unchanged
+func (c *Client) opContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.opTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.opTimeout)
+}
+
+// checkTimeout turns an error caused by the operation's deadline into ErrOpTimeout
+// and records the timeout on span; any other error is returned unchanged
+// go-redis reports an expired deadline as an i/o timeout, so the context is checked instead of err
+func (c *Client) checkTimeout(ctx context.Context, span trace.Span, err error) error {
+	if err == nil || !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+
+	span.SetAttributes(attribute.Bool("redis.timeout", true))
+	span.AddEvent("redis.op_timeout", trace.WithAttributes(
+		attribute.Int64("timeout_ms", c.opTimeout.Milliseconds()),
+	))
+	return fmt.Errorf("%w after %s: %v", ErrOpTimeout, c.opTimeout, err)
+}
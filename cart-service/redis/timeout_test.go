@@ -0,0 +1,137 @@
+package redis
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap"
+)
+
+// newDelayedClient returns a Client whose replies from miniredis arrive delay late,
+// through a local proxy standing in for a slow HGETALL on a huge cart
+func newDelayedClient(t *testing.T, delay, opTimeout time.Duration) *Client {
+	mr := miniredis.RunT(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			upstream, err := net.Dial("tcp", mr.Addr())
+			if err != nil {
+				conn.Close()
+				continue
+			}
+			go func() {
+				_, _ = io.Copy(upstream, conn)
+				upstream.Close()
+			}()
+			go func() {
+				defer conn.Close()
+				buf := make([]byte, 4096)
+				for {
+					n, err := upstream.Read(buf)
+					if n > 0 {
+						time.Sleep(delay)
+						if _, werr := conn.Write(buf[:n]); werr != nil {
+							return
+						}
+					}
+					if err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+
+	rdb := redis.NewClient(&redis.Options{
+		Addr:                  ln.Addr().String(),
+		MaxRetries:            -1,
+		ContextTimeoutEnabled: true,
+	})
+	t.Cleanup(func() { rdb.Close() })
+
+	client := NewClient(rdb, zap.NewNop())
+	client.opTimeout = opTimeout
+	return client
+}
+
+func TestOpTimeout(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should fail a delayed operation with ErrOpTimeout", func(t *testing.T) {
+		client := newDelayedClient(t, 300*time.Millisecond, 50*time.Millisecond)
+
+		start := time.Now()
+		_, err := client.GetCart(ctx, "user-1")
+
+		assert.ErrorIs(t, err, ErrOpTimeout)
+		assert.Less(t, time.Since(start), 300*time.Millisecond, "The call should return at the op timeout, not the reply")
+	})
+
+	t.Run("should bound the idempotency and admin operations", func(t *testing.T) {
+		client := newDelayedClient(t, 300*time.Millisecond, 50*time.Millisecond)
+
+		_, err := client.ClaimIdempotencyKey(ctx, "user-1", "req-1", time.Minute)
+		assert.ErrorIs(t, err, ErrOpTimeout)
+		assert.ErrorIs(t, client.ReleaseIdempotencyKey(ctx, "user-1", "req-1"), ErrOpTimeout)
+		_, err = client.RawCart(ctx, "user-1")
+		assert.ErrorIs(t, err, ErrOpTimeout)
+		_, err = client.RepairCart(ctx, "user-1")
+		assert.ErrorIs(t, err, ErrOpTimeout)
+		_, err = client.NormalizeCart(ctx, "user-1", NormalizeOptions{TrimSpace: true})
+		assert.ErrorIs(t, err, ErrOpTimeout)
+	})
+
+	t.Run("should record the timeout on the span", func(t *testing.T) {
+		recorder := tracetest.NewSpanRecorder()
+		previous := otel.GetTracerProvider()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+		t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+		client := newDelayedClient(t, 300*time.Millisecond, 50*time.Millisecond)
+		require.Error(t, client.AddItem(ctx, "user-1", "prod-1", 1))
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 1)
+		attrs := attribute.NewSet(spans[0].Attributes()...)
+		timedOut, _ := attrs.Value("redis.timeout")
+		assert.True(t, timedOut.AsBool())
+		require.NotEmpty(t, spans[0].Events())
+		assert.Equal(t, "redis.op_timeout", spans[0].Events()[0].Name)
+	})
+
+	t.Run("should let slow operations finish without an op timeout", func(t *testing.T) {
+		client := newDelayedClient(t, 100*time.Millisecond, 0)
+
+		_, err := client.GetCart(ctx, "user-1")
+		assert.NoError(t, err)
+	})
+
+	t.Run("should leave errors other than the deadline unchanged", func(t *testing.T) {
+		client, mr := newTestClient(t)
+		client.opTimeout = 10 * time.Second
+		mr.Close()
+
+		_, err := client.GetCart(ctx, "user-1")
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, ErrOpTimeout)
+	})
+}
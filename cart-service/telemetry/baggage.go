@@ -0,0 +1,26 @@
+package telemetry
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/baggage"
+)
+
+// UserIDBaggageKey is the W3C Baggage member that carries the cart's user ID downstream
+const UserIDBaggageKey = "user_id"
+
+// ContextWithUserID returns ctx with user_id set in its baggage, replacing any earlier value
+// Outgoing requests that inject the global propagator (e.g. the product-service client) then
+// carry it in the baggage header, so downstream spans can be correlated without an explicit field
+// ctx is returned unchanged if the user ID cannot be encoded as a baggage member
+func ContextWithUserID(ctx context.Context, userID string) context.Context {
+	member, err := baggage.NewMemberRaw(UserIDBaggageKey, userID)
+	if err != nil {
+		return ctx
+	}
+	bag, err := baggage.FromContext(ctx).SetMember(member)
+	if err != nil {
+		return ctx
+	}
+	return baggage.ContextWithBaggage(ctx, bag)
+}
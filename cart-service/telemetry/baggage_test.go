@@ -0,0 +1,33 @@
+package telemetry
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+func TestContextWithUserID(t *testing.T) {
+	t.Run("should set and replace user_id in the baggage", func(t *testing.T) {
+		ctx := ContextWithUserID(context.Background(), "guest-1")
+		ctx = ContextWithUserID(ctx, "user-1")
+
+		bag := baggage.FromContext(ctx)
+		assert.Equal(t, "user-1", bag.Member(UserIDBaggageKey).Value())
+		assert.Equal(t, 1, bag.Len())
+	})
+
+	t.Run("should round-trip through the baggage header", func(t *testing.T) {
+		ctx := ContextWithUserID(context.Background(), "user 1;x=y")
+
+		header := http.Header{}
+		propagation.Baggage{}.Inject(ctx, propagation.HeaderCarrier(header))
+		assert.NotEmpty(t, header.Get("baggage"))
+
+		extracted := propagation.Baggage{}.Extract(context.Background(), propagation.HeaderCarrier(header))
+		assert.Equal(t, "user 1;x=y", baggage.FromContext(extracted).Member(UserIDBaggageKey).Value())
+	})
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net"
 	"time"
 
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
@@ -20,27 +22,64 @@ type TracerConfig struct {
 	ServiceVersion string
 	Environment    string
 	OTLPEndpoint   string
+	Batch          BatchConfig
+	// Kubernetes identity added to every span's resource so a slow span can be traced to its pod
+	// Empty values are left out of the resource
+	PodName   string
+	NodeName  string
+	Namespace string
+}
+
+// BatchConfig tunes the batch span processor that buffers spans before export
+// Raise MaxQueueSize (and MaxExportBatchSize) if bursts of traffic cause spans to be dropped
+type BatchConfig struct {
+	MaxExportBatchSize int           // Spans sent per export call
+	ScheduleDelay      time.Duration // Maximum delay between two exports
+	MaxQueueSize       int           // Spans buffered before new ones are dropped
+}
+
+// DefaultBatchConfig returns the default batch span processor configuration
+// Batch size: 512, Schedule delay: 5s, Queue size: 2048
+func DefaultBatchConfig() BatchConfig {
+	return BatchConfig{
+		MaxExportBatchSize: 512,
+		ScheduleDelay:      5 * time.Second,
+		MaxQueueSize:       2048,
+	}
+}
+
+// effective replaces unset values with the defaults and caps the batch size at the queue size,
+// mirroring what the SDK does, so the logged values are the ones actually applied
+func (b BatchConfig) effective() BatchConfig {
+	defaults := DefaultBatchConfig()
+	if b.MaxExportBatchSize <= 0 {
+		b.MaxExportBatchSize = defaults.MaxExportBatchSize
+	}
+	if b.ScheduleDelay <= 0 {
+		b.ScheduleDelay = defaults.ScheduleDelay
+	}
+	if b.MaxQueueSize <= 0 {
+		b.MaxQueueSize = defaults.MaxQueueSize
+	}
+	if b.MaxExportBatchSize > b.MaxQueueSize {
+		b.MaxExportBatchSize = b.MaxQueueSize
+	}
+	return b
 }
 
 // tracerProvider holds the global tracer provider for cleanup
 var tracerProvider *sdktrace.TracerProvider
 
+// collectorCheckTimeout bounds the startup connectivity check against the OTLP collector
+const collectorCheckTimeout = 2 * time.Second
+
 // InitTracer initializes the OpenTelemetry tracer with OTLP/gRPC exporter
 // It sets up W3C Trace Context propagation and batch span processing
 // Returns a shutdown function that should be called on application exit
 func InitTracer(config TracerConfig) (func(context.Context) error, error) {
 	ctx := context.Background()
 
-	// Create resource with service information
-	// These attributes identify the service in the observability backend
-	res, err := resource.New(ctx,
-		resource.WithAttributes(
-			// Service identification attributes
-			semconv.ServiceName(config.ServiceName),
-			semconv.ServiceVersion(config.ServiceVersion),
-			semconv.DeploymentEnvironment(config.Environment),
-		),
-	)
+	res, err := newResource(ctx, config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create resource: %w", err)
 	}
@@ -56,13 +95,23 @@ func InitTracer(config TracerConfig) (func(context.Context) error, error) {
 		return nil, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
 	}
 
+	// The gRPC exporter connects lazily, so an unreachable collector would otherwise go unnoticed
+	// until spans are silently dropped; warn loudly but keep starting without tracing
+	// The exporter keeps retrying, so spans flow once the collector becomes reachable
+	if err := checkCollector(ctx, config.OTLPEndpoint, collectorCheckTimeout); err != nil {
+		log.Printf("WARNING: OTLP collector at %s is unreachable, traces are dropped until it is available: %v",
+			config.OTLPEndpoint, err)
+	}
+
 	// Create tracer provider with batch span processor
 	// BatchSpanProcessor batches spans before export for better performance
 	// This reduces the number of network calls to the collector
+	batch := config.Batch.effective()
 	tracerProvider = sdktrace.NewTracerProvider(
 		sdktrace.WithBatcher(exporter,
-			sdktrace.WithMaxExportBatchSize(512),
-			sdktrace.WithBatchTimeout(5*time.Second),
+			sdktrace.WithMaxExportBatchSize(batch.MaxExportBatchSize),
+			sdktrace.WithBatchTimeout(batch.ScheduleDelay),
+			sdktrace.WithMaxQueueSize(batch.MaxQueueSize),
 		),
 		sdktrace.WithResource(res),
 		sdktrace.WithSampler(sdktrace.AlwaysSample()), // Sample all traces for demo
@@ -80,14 +129,53 @@ func InitTracer(config TracerConfig) (func(context.Context) error, error) {
 		propagation.Baggage{},      // W3C Baggage
 	))
 
-	log.Printf("OpenTelemetry tracer initialized: service=%s, version=%s, environment=%s, endpoint=%s",
-		config.ServiceName, config.ServiceVersion, config.Environment, config.OTLPEndpoint)
+	log.Printf("OpenTelemetry tracer initialized: service=%s, version=%s, environment=%s, endpoint=%s, "+
+		"batch_max_export_size=%d, batch_schedule_delay=%s, batch_max_queue_size=%d",
+		config.ServiceName, config.ServiceVersion, config.Environment, config.OTLPEndpoint,
+		batch.MaxExportBatchSize, batch.ScheduleDelay, batch.MaxQueueSize)
 
 	// Return shutdown function
 	// This should be called on application shutdown to flush remaining spans
 	return tracerProvider.Shutdown, nil
 }
 
+// newResource describes the service, and the pod it runs in, for every exported span
+// These attributes identify the service in the observability backend
+func newResource(ctx context.Context, config TracerConfig) (*resource.Resource, error) {
+	// Service identification attributes
+	attrs := []attribute.KeyValue{
+		semconv.ServiceName(config.ServiceName),
+		semconv.ServiceVersion(config.ServiceVersion),
+		semconv.DeploymentEnvironment(config.Environment),
+	}
+
+	// Kubernetes identity attributes
+	if config.PodName != "" {
+		attrs = append(attrs, semconv.K8SPodName(config.PodName))
+	}
+	if config.NodeName != "" {
+		attrs = append(attrs, semconv.K8SNodeName(config.NodeName))
+	}
+	if config.Namespace != "" {
+		attrs = append(attrs, semconv.K8SNamespaceName(config.Namespace))
+	}
+
+	return resource.New(ctx, resource.WithAttributes(attrs...))
+}
+
+// checkCollector verifies that a TCP connection to the OTLP endpoint can be opened within timeout
+func checkCollector(ctx context.Context, endpoint string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", endpoint)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
 // Shutdown gracefully shuts down the tracer provider
 // This should be called before application exit to ensure all spans are exported
 func Shutdown(ctx context.Context) error {
@@ -0,0 +1,101 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+)
+
+// unusedAddr returns a local address that nothing listens on
+func unusedAddr(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	require.NoError(t, listener.Close())
+	return addr
+}
+
+func TestCheckCollector(t *testing.T) {
+	t.Run("should succeed when the collector accepts connections", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer listener.Close()
+
+		assert.NoError(t, checkCollector(context.Background(), listener.Addr().String(), time.Second))
+	})
+
+	t.Run("should fail for an unreachable collector", func(t *testing.T) {
+		assert.Error(t, checkCollector(context.Background(), unusedAddr(t), time.Second))
+	})
+}
+
+func TestInitTracerUnreachableCollector(t *testing.T) {
+	var logs bytes.Buffer
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(&logs)
+
+	endpoint := unusedAddr(t)
+	shutdown, err := InitTracer(TracerConfig{
+		ServiceName:  "test",
+		OTLPEndpoint: endpoint,
+	})
+
+	// Startup continues without tracing instead of failing
+	require.NoError(t, err)
+	assert.Contains(t, logs.String(), "WARNING: OTLP collector at "+endpoint+" is unreachable")
+	assert.Contains(t, logs.String(), "batch_max_export_size=512, batch_schedule_delay=5s, batch_max_queue_size=2048")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_ = shutdown(ctx)
+}
+
+func TestNewResource(t *testing.T) {
+	t.Run("should identify the pod, node and namespace", func(t *testing.T) {
+		res, err := newResource(context.Background(), TracerConfig{
+			ServiceName: "cart-service",
+			PodName:     "cart-service-abc123",
+			NodeName:    "node-1",
+			Namespace:   "shop",
+		})
+		require.NoError(t, err)
+
+		attrs := res.Set()
+		for key, want := range map[attribute.Key]string{
+			semconv.ServiceNameKey:      "cart-service",
+			semconv.K8SPodNameKey:       "cart-service-abc123",
+			semconv.K8SNodeNameKey:      "node-1",
+			semconv.K8SNamespaceNameKey: "shop",
+		} {
+			value, ok := attrs.Value(key)
+			assert.True(t, ok, "resource is missing %s", key)
+			assert.Equal(t, want, value.AsString())
+		}
+	})
+
+	t.Run("should leave out empty Kubernetes attributes", func(t *testing.T) {
+		res, err := newResource(context.Background(), TracerConfig{ServiceName: "cart-service"})
+		require.NoError(t, err)
+
+		attrs := res.Set()
+		assert.False(t, attrs.HasValue(semconv.K8SPodNameKey))
+		assert.False(t, attrs.HasValue(semconv.K8SNodeNameKey))
+		assert.False(t, attrs.HasValue(semconv.K8SNamespaceNameKey))
+	})
+}
+
+func TestBatchConfigEffective(t *testing.T) {
+	assert.Equal(t, DefaultBatchConfig(), BatchConfig{}.effective())
+
+	// The SDK never exports batches larger than its queue
+	batch := BatchConfig{MaxExportBatchSize: 4096, ScheduleDelay: time.Second, MaxQueueSize: 1024}.effective()
+	assert.Equal(t, BatchConfig{MaxExportBatchSize: 1024, ScheduleDelay: time.Second, MaxQueueSize: 1024}, batch)
+}
@@ -0,0 +1,242 @@
+package database
+
+import (
+	"container/list"
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// allProductsCacheKey is the cache key for the GetAllProducts result
+const allProductsCacheKey = "all"
+
+// CacheConfig holds the settings for CachingProductRepository
+type CacheConfig struct {
+	TTL        time.Duration // How long an entry is served before the database is asked again
+	MaxEntries int           // Least recently used entries are evicted beyond this many (0 = unbounded)
+}
+
+// CachingProductRepository is a read-through cache in front of another ProductRepository
+// Single products and the full product list are kept in an in-memory LRU with a TTL;
+// every write through the repository invalidates the entries it affects
+// The cache is per process, so writes made by other replicas are only seen once entries expire
+type CachingProductRepository struct {
+	next   ProductRepository
+	config CacheConfig
+	now    func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // Front is most recently used
+}
+
+// cacheEntry is a cached lookup result; exactly one of product and products is set
+type cacheEntry struct {
+	key       string
+	product   *Product
+	products  []Product
+	expiresAt time.Time
+}
+
+// NewCachingProductRepository wraps next with a read-through cache
+// It satisfies ProductRepository, so it is a drop-in replacement for the wrapped repository
+func NewCachingProductRepository(next ProductRepository, config CacheConfig) ProductRepository {
+	return &CachingProductRepository{
+		next:    next,
+		config:  config,
+		now:     time.Now,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// GetAllProducts returns the cached product list or loads it from the wrapped repository
+func (r *CachingProductRepository) GetAllProducts(ctx context.Context) ([]Product, error) {
+	if entry, ok := r.get(allProductsCacheKey); ok {
+		recordCacheHit(ctx, true)
+		return copyProducts(entry.products), nil
+	}
+	recordCacheHit(ctx, false)
+
+	products, err := r.next.GetAllProducts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r.put(&cacheEntry{key: allProductsCacheKey, products: copyProducts(products)})
+	return products, nil
+}
+
+// GetAllProductsIncludingDeleted is passed through uncached, so admin listings are always current
+func (r *CachingProductRepository) GetAllProductsIncludingDeleted(ctx context.Context) ([]Product, error) {
+	return r.next.GetAllProductsIncludingDeleted(ctx)
+}
+
+// GetProductByID returns the cached product or loads it from the wrapped repository
+// Errors, including not found, are never cached
+func (r *CachingProductRepository) GetProductByID(ctx context.Context, id int) (*Product, error) {
+	if entry, ok := r.get(productCacheKey(id)); ok {
+		recordCacheHit(ctx, true)
+		product := *entry.product
+		return &product, nil
+	}
+	recordCacheHit(ctx, false)
+
+	product, err := r.next.GetProductByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	cached := *product
+	r.put(&cacheEntry{key: productCacheKey(id), product: &cached})
+	return product, nil
+}
+
+// GetProductByIDIncludingDeleted is passed through uncached
+func (r *CachingProductRepository) GetProductByIDIncludingDeleted(ctx context.Context, id int) (*Product, error) {
+	return r.next.GetProductByIDIncludingDeleted(ctx, id)
+}
+
+// GetProductsByCategory is passed through uncached
+func (r *CachingProductRepository) GetProductsByCategory(ctx context.Context, category string) ([]Product, error) {
+	return r.next.GetProductsByCategory(ctx, category)
+}
+
+// GetProductsByPriceRange is passed through uncached
+func (r *CachingProductRepository) GetProductsByPriceRange(ctx context.Context, min, max float64) ([]Product, error) {
+	return r.next.GetProductsByPriceRange(ctx, min, max)
+}
+
+// SearchProducts is passed through uncached
+func (r *CachingProductRepository) SearchProducts(ctx context.Context, query string, limit int) ([]Product, error) {
+	return r.next.SearchProducts(ctx, query, limit)
+}
+
+// GetCategoryCounts is passed through uncached
+func (r *CachingProductRepository) GetCategoryCounts(ctx context.Context) (map[string]int, error) {
+	return r.next.GetCategoryCounts(ctx)
+}
+
+// GetRelatedProducts is passed through uncached
+func (r *CachingProductRepository) GetRelatedProducts(ctx context.Context, id, limit int) ([]Product, error) {
+	return r.next.GetRelatedProducts(ctx, id, limit)
+}
+
+// CreateProduct inserts through the wrapped repository and drops the cached product list
+func (r *CachingProductRepository) CreateProduct(ctx context.Context, product *Product) error {
+	if err := r.next.CreateProduct(ctx, product); err != nil {
+		return err
+	}
+	r.invalidate(allProductsCacheKey)
+	return nil
+}
+
+// CreateProducts inserts through the wrapped repository and drops the cached product list
+func (r *CachingProductRepository) CreateProducts(ctx context.Context, products []Product) error {
+	if err := r.next.CreateProducts(ctx, products); err != nil {
+		return err
+	}
+	r.invalidate(allProductsCacheKey)
+	return nil
+}
+
+// DecrementStock updates stock through the wrapped repository and drops the affected entries
+func (r *CachingProductRepository) DecrementStock(ctx context.Context, id, quantity int) (int, error) {
+	stock, err := r.next.DecrementStock(ctx, id, quantity)
+	if err != nil {
+		return 0, err
+	}
+	r.invalidate(productCacheKey(id), allProductsCacheKey)
+	return stock, nil
+}
+
+// ReleaseStock updates stock through the wrapped repository and drops the affected entries
+func (r *CachingProductRepository) ReleaseStock(ctx context.Context, id, quantity int) (int, error) {
+	stock, err := r.next.ReleaseStock(ctx, id, quantity)
+	if err != nil {
+		return 0, err
+	}
+	r.invalidate(productCacheKey(id), allProductsCacheKey)
+	return stock, nil
+}
+
+// DeleteProduct deletes through the wrapped repository and drops the affected entries
+func (r *CachingProductRepository) DeleteProduct(ctx context.Context, id int) error {
+	if err := r.next.DeleteProduct(ctx, id); err != nil {
+		return err
+	}
+	r.invalidate(productCacheKey(id), allProductsCacheKey)
+	return nil
+}
+
+// get returns a live entry and marks it as recently used; expired entries are removed
+func (r *CachingProductRepository) get(key string) (*cacheEntry, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	element, ok := r.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*cacheEntry)
+	if !r.now().Before(entry.expiresAt) {
+		r.order.Remove(element)
+		delete(r.entries, key)
+		return nil, false
+	}
+	r.order.MoveToFront(element)
+	return entry, true
+}
+
+// put stores an entry, evicting the least recently used ones beyond MaxEntries
+func (r *CachingProductRepository) put(entry *cacheEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry.expiresAt = r.now().Add(r.config.TTL)
+	if element, ok := r.entries[entry.key]; ok {
+		element.Value = entry
+		r.order.MoveToFront(element)
+	} else {
+		r.entries[entry.key] = r.order.PushFront(entry)
+	}
+
+	for r.config.MaxEntries > 0 && r.order.Len() > r.config.MaxEntries {
+		oldest := r.order.Back()
+		r.order.Remove(oldest)
+		delete(r.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// invalidate removes the given keys from the cache
+func (r *CachingProductRepository) invalidate(keys ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, key := range keys {
+		if element, ok := r.entries[key]; ok {
+			r.order.Remove(element)
+			delete(r.entries, key)
+		}
+	}
+}
+
+// productCacheKey is the cache key for a single product
+func productCacheKey(id int) string {
+	return "product:" + strconv.Itoa(id)
+}
+
+// copyProducts returns a copy so callers can't modify cached data
+func copyProducts(products []Product) []Product {
+	if products == nil {
+		return nil
+	}
+	return append([]Product(nil), products...)
+}
+
+// recordCacheHit notes on the request span whether the lookup was served from the cache
+func recordCacheHit(ctx context.Context, hit bool) {
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Bool("product.cache_hit", hit))
+}
@@ -0,0 +1,254 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingRepository is an in-memory ProductRepository that counts reads reaching it
+type countingRepository struct {
+	products  map[int]Product
+	byIDCalls int
+	allCalls  int
+	nextID    int
+}
+
+func newCountingRepository() *countingRepository {
+	return &countingRepository{
+		products: map[int]Product{
+			1: {ID: 1, Name: "MacBook Pro 16\"", Price: 3499.00, Stock: 25, Category: "Electronics"},
+			2: {ID: 2, Name: "Atomic Habits", Price: 27.00, Stock: 150, Category: "Books"},
+		},
+		nextID: 3,
+	}
+}
+
+func (r *countingRepository) GetAllProducts(ctx context.Context) ([]Product, error) {
+	r.allCalls++
+	products := make([]Product, 0, len(r.products))
+	for id := 1; id < r.nextID; id++ {
+		if p, ok := r.products[id]; ok {
+			products = append(products, p)
+		}
+	}
+	return products, nil
+}
+
+func (r *countingRepository) GetAllProductsIncludingDeleted(ctx context.Context) ([]Product, error) {
+	return r.GetAllProducts(ctx)
+}
+
+func (r *countingRepository) GetProductByIDIncludingDeleted(ctx context.Context, id int) (*Product, error) {
+	return r.GetProductByID(ctx, id)
+}
+
+func (r *countingRepository) GetProductByID(ctx context.Context, id int) (*Product, error) {
+	r.byIDCalls++
+	p, ok := r.products[id]
+	if !ok {
+		return nil, ErrProductNotFound
+	}
+	return &p, nil
+}
+
+func (r *countingRepository) GetProductsByCategory(ctx context.Context, category string) ([]Product, error) {
+	return nil, nil
+}
+
+func (r *countingRepository) GetProductsByPriceRange(ctx context.Context, min, max float64) ([]Product, error) {
+	return nil, nil
+}
+
+func (r *countingRepository) SearchProducts(ctx context.Context, query string, limit int) ([]Product, error) {
+	return nil, nil
+}
+
+func (r *countingRepository) GetCategoryCounts(ctx context.Context) (map[string]int, error) {
+	return nil, nil
+}
+
+func (r *countingRepository) GetRelatedProducts(ctx context.Context, id, limit int) ([]Product, error) {
+	return nil, nil
+}
+
+func (r *countingRepository) CreateProduct(ctx context.Context, product *Product) error {
+	product.ID = r.nextID
+	r.nextID++
+	r.products[product.ID] = *product
+	return nil
+}
+
+func (r *countingRepository) CreateProducts(ctx context.Context, products []Product) error {
+	for i := range products {
+		if err := r.CreateProduct(ctx, &products[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *countingRepository) DecrementStock(ctx context.Context, id, quantity int) (int, error) {
+	p := r.products[id]
+	p.Stock -= quantity
+	r.products[id] = p
+	return p.Stock, nil
+}
+
+func (r *countingRepository) ReleaseStock(ctx context.Context, id, quantity int) (int, error) {
+	return r.DecrementStock(ctx, id, -quantity)
+}
+
+func (r *countingRepository) DeleteProduct(ctx context.Context, id int) error {
+	delete(r.products, id)
+	return nil
+}
+
+// setupCache wraps a counting repository and returns a clock the test can move forward
+func setupCache(config CacheConfig) (*CachingProductRepository, *countingRepository, *time.Time) {
+	backend := newCountingRepository()
+	cache := NewCachingProductRepository(backend, config).(*CachingProductRepository)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+	return cache, backend, &now
+}
+
+func TestCachingProductRepository(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should serve a second read from the cache", func(t *testing.T) {
+		cache, backend, _ := setupCache(CacheConfig{TTL: time.Minute})
+
+		first, err := cache.GetProductByID(ctx, 1)
+		require.NoError(t, err)
+		second, err := cache.GetProductByID(ctx, 1)
+		require.NoError(t, err)
+
+		assert.Equal(t, first, second)
+		assert.Equal(t, 1, backend.byIDCalls)
+
+		_, err = cache.GetAllProducts(ctx)
+		require.NoError(t, err)
+		products, err := cache.GetAllProducts(ctx)
+		require.NoError(t, err)
+
+		assert.Len(t, products, 2)
+		assert.Equal(t, 1, backend.allCalls)
+	})
+
+	t.Run("should reload after the TTL expires", func(t *testing.T) {
+		cache, backend, now := setupCache(CacheConfig{TTL: time.Minute})
+
+		cache.GetProductByID(ctx, 1)
+		*now = now.Add(59 * time.Second)
+		cache.GetProductByID(ctx, 1)
+		assert.Equal(t, 1, backend.byIDCalls)
+
+		*now = now.Add(time.Second)
+		cache.GetProductByID(ctx, 1)
+		assert.Equal(t, 2, backend.byIDCalls)
+	})
+
+	t.Run("should not cache errors", func(t *testing.T) {
+		cache, backend, _ := setupCache(CacheConfig{TTL: time.Minute})
+
+		_, err := cache.GetProductByID(ctx, 999)
+		assert.ErrorIs(t, err, ErrProductNotFound)
+		_, err = cache.GetProductByID(ctx, 999)
+		assert.ErrorIs(t, err, ErrProductNotFound)
+
+		assert.Equal(t, 2, backend.byIDCalls)
+	})
+
+	t.Run("should invalidate the list when a product is created", func(t *testing.T) {
+		cache, backend, _ := setupCache(CacheConfig{TTL: time.Minute})
+
+		cache.GetAllProducts(ctx)
+		require.NoError(t, cache.CreateProduct(ctx, &Product{Name: "Desk Lamp", Price: 24.00}))
+
+		products, err := cache.GetAllProducts(ctx)
+		require.NoError(t, err)
+		assert.Len(t, products, 3)
+		assert.Equal(t, 2, backend.allCalls)
+	})
+
+	t.Run("should invalidate the product when its stock changes", func(t *testing.T) {
+		cache, backend, _ := setupCache(CacheConfig{TTL: time.Minute})
+
+		cache.GetProductByID(ctx, 1)
+		cache.GetAllProducts(ctx)
+
+		_, err := cache.DecrementStock(ctx, 1, 5)
+		require.NoError(t, err)
+
+		product, err := cache.GetProductByID(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 20, product.Stock)
+		assert.Equal(t, 2, backend.byIDCalls)
+
+		products, err := cache.GetAllProducts(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 20, products[0].Stock)
+		assert.Equal(t, 2, backend.allCalls)
+	})
+
+	t.Run("should invalidate the product when stock is released", func(t *testing.T) {
+		cache, backend, _ := setupCache(CacheConfig{TTL: time.Minute})
+
+		cache.GetProductByID(ctx, 1)
+
+		_, err := cache.ReleaseStock(ctx, 1, 5)
+		require.NoError(t, err)
+
+		product, err := cache.GetProductByID(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 30, product.Stock)
+		assert.Equal(t, 2, backend.byIDCalls)
+	})
+
+	t.Run("should invalidate the product when it is deleted", func(t *testing.T) {
+		cache, _, _ := setupCache(CacheConfig{TTL: time.Minute})
+
+		cache.GetProductByID(ctx, 1)
+		cache.GetAllProducts(ctx)
+
+		require.NoError(t, cache.DeleteProduct(ctx, 1))
+
+		_, err := cache.GetProductByID(ctx, 1)
+		assert.ErrorIs(t, err, ErrProductNotFound)
+		products, err := cache.GetAllProducts(ctx)
+		require.NoError(t, err)
+		assert.Len(t, products, 1)
+	})
+
+	t.Run("should evict the least recently used entry", func(t *testing.T) {
+		cache, backend, _ := setupCache(CacheConfig{TTL: time.Minute, MaxEntries: 2})
+
+		cache.GetProductByID(ctx, 1)
+		cache.GetProductByID(ctx, 2)
+		cache.GetProductByID(ctx, 1) // 2 is now the least recently used
+		cache.GetAllProducts(ctx)    // evicts 2
+
+		cache.GetProductByID(ctx, 1)
+		assert.Equal(t, 2, backend.byIDCalls)
+		cache.GetProductByID(ctx, 2)
+		assert.Equal(t, 3, backend.byIDCalls)
+	})
+
+	t.Run("should not let callers modify cached products", func(t *testing.T) {
+		cache, _, _ := setupCache(CacheConfig{TTL: time.Minute})
+
+		product, _ := cache.GetProductByID(ctx, 1)
+		product.Name = "Changed"
+		products, _ := cache.GetAllProducts(ctx)
+		products[0].Name = "Changed"
+
+		cached, _ := cache.GetProductByID(ctx, 1)
+		assert.Equal(t, "MacBook Pro 16\"", cached.Name)
+		cachedList, _ := cache.GetAllProducts(ctx)
+		assert.Equal(t, "MacBook Pro 16\"", cachedList[0].Name)
+	})
+}
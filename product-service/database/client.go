@@ -3,9 +3,10 @@ package database
 import (
 	"context"
 	"fmt"
-	"math/rand"
 	"time"
 
+	"product-service/internal/retry"
+
 	"github.com/jackc/pgx/v5/pgxpool"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -21,68 +22,53 @@ type Client struct {
 // Config holds database configuration
 type Config struct {
 	DatabaseURL string
-	MaxRetries  int
+	Retry       retry.RetryConfig // Connection retries; left unset, retry.DefaultRetryConfig is used
 	ServiceName string
 }
 
 // NewClient creates a new database client with connection pooling and retry logic
-// It implements exponential backoff similar to cart-service Redis client
+// It retries with the same exponential backoff as the cart-service Redis client
 func NewClient(ctx context.Context, cfg Config) (*Client, error) {
-	if cfg.MaxRetries == 0 {
-		cfg.MaxRetries = 5
+	if cfg.Retry.InitialDelay == 0 && cfg.Retry.MaxRetries == 0 {
+		cfg.Retry = retry.DefaultRetryConfig()
 	}
 
-	var pool *pgxpool.Pool
-	var err error
-
-	// Exponential backoff retry logic
-	for attempt := 1; attempt <= cfg.MaxRetries; attempt++ {
-		// Parse config and configure connection pool
-		config, parseErr := pgxpool.ParseConfig(cfg.DatabaseURL)
-		if parseErr != nil {
-			return nil, fmt.Errorf("failed to parse database URL: %w", parseErr)
-		}
+	// Parse config and configure connection pool once; a bad URL fails before any retry
+	config, err := pgxpool.ParseConfig(cfg.DatabaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse database URL: %w", err)
+	}
+
+	// Connection pool settings
+	config.MaxConns = 25                      // Maximum number of connections
+	config.MinConns = 5                       // Minimum number of idle connections
+	config.MaxConnLifetime = 30 * time.Minute // Connection lifetime
+	config.MaxConnIdleTime = 5 * time.Minute  // Idle connection timeout
 
-		// Connection pool settings
-		config.MaxConns = 25                      // Maximum number of connections
-		config.MinConns = 5                       // Minimum number of idle connections
-		config.MaxConnLifetime = 30 * time.Minute // Connection lifetime
-		config.MaxConnIdleTime = 5 * time.Minute  // Idle connection timeout
+	var pool *pgxpool.Pool
 
+	// Exponential backoff retry logic; only connecting and pinging is retried
+	err = retry.Do(ctx, cfg.Retry, func(ctx context.Context, attempt int) error {
 		// Attempt to create connection pool
-		pool, err = pgxpool.NewWithConfig(ctx, config)
-		if err == nil {
-			// Verify connection with ping
-			pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
-			err = pool.Ping(pingCtx)
-			cancel()
-
-			if err == nil {
-				// Success!
-				break
-			}
+		attemptPool, err := pgxpool.NewWithConfig(ctx, config.Copy())
+		if err != nil {
+			return err
 		}
 
-		// Connection failed, retry with exponential backoff
-		if attempt < cfg.MaxRetries {
-			// Calculate delay: min(100ms * 2^attempt, 2s) with ±10% jitter
-			baseDelay := 100 * time.Millisecond
-			maxDelay := 2 * time.Second
-			delay := time.Duration(float64(baseDelay) * float64(uint(1)<<uint(attempt)))
-			if delay > maxDelay {
-				delay = maxDelay
-			}
-
-			// Add jitter (±10%)
-			jitter := time.Duration(rand.Float64()*0.2-0.1) * delay
-			delay += jitter
-
-			time.Sleep(delay)
+		// Verify connection with ping
+		pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		if err := attemptPool.Ping(pingCtx); err != nil {
+			// Close the failed pool so its connections don't leak into the next attempt
+			attemptPool.Close()
+			return err
 		}
-	}
 
+		pool = attemptPool
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database after %d attempts: %w", cfg.MaxRetries, err)
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
 	// Initialize OpenTelemetry tracer
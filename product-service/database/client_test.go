@@ -0,0 +1,96 @@
+package database
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"product-service/internal/retry"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewClientRetry(t *testing.T) {
+	// recordingRetry retries quickly with fixed jitter and records every backoff
+	recordingRetry := func(delays *[]time.Duration) retry.RetryConfig {
+		return retry.RetryConfig{
+			InitialDelay: time.Millisecond,
+			MaxDelay:     4 * time.Millisecond,
+			MaxRetries:   4,
+			JitterPct:    0.1,
+			Rand:         func() float64 { return 1 },
+			OnRetry: func(attempt int, err error, delay time.Duration) {
+				*delays = append(*delays, delay)
+			},
+		}
+	}
+
+	t.Run("should back off like the shared retry config", func(t *testing.T) {
+		// Nothing listens on a port that was just released
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addr := listener.Addr().String()
+		require.NoError(t, listener.Close())
+
+		var delays []time.Duration
+		config := recordingRetry(&delays)
+		_, err = NewClient(context.Background(), Config{
+			DatabaseURL: "postgres://user:pass@" + addr + "/products?sslmode=disable&connect_timeout=1",
+			Retry:       config,
+		})
+		require.Error(t, err)
+
+		// Same ±JitterPct band and doubling as the Redis client, capped at MaxDelay
+		assert.Equal(t, []time.Duration{
+			1100 * time.Microsecond,
+			2200 * time.Microsecond,
+			4400 * time.Microsecond,
+			4400 * time.Microsecond,
+		}, delays)
+		for attempt, delay := range delays {
+			assert.Equal(t, retry.ComputeBackoff(attempt, config), delay)
+		}
+	})
+
+	t.Run("should stop retrying when the context is cancelled", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		addr := listener.Addr().String()
+		require.NoError(t, listener.Close())
+
+		// Cancel during the first backoff, which would otherwise last an hour
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		config := retry.RetryConfig{InitialDelay: time.Hour, MaxDelay: time.Hour, MaxRetries: 5}
+		config.OnRetry = func(int, error, time.Duration) { cancel() }
+
+		start := time.Now()
+		_, err = NewClient(ctx, Config{
+			DatabaseURL: "postgres://user:pass@" + addr + "/products?sslmode=disable&connect_timeout=1",
+			Retry:       config,
+		})
+
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+
+	t.Run("should fail fast on an invalid database URL", func(t *testing.T) {
+		var delays []time.Duration
+		config := recordingRetry(&delays)
+		// A retry would outlast the context, so a fast parse error proves nothing was retried
+		config.InitialDelay, config.MaxDelay = time.Hour, time.Hour
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		_, err := NewClient(ctx, Config{
+			DatabaseURL: "postgres://%zz",
+			Retry:       config,
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to parse database URL")
+		assert.NotErrorIs(t, err, context.DeadlineExceeded)
+		assert.Empty(t, delays)
+	})
+}
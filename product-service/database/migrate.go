@@ -0,0 +1,103 @@
+package database
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// schemaSQL creates the products table, its indexes and the updated_at trigger
+// Every statement is idempotent, so it is safe to run on every start
+//
+//go:embed schema.sql
+var schemaSQL string
+
+// migrationLockID is the advisory lock key that serializes migrations across replicas
+// Any constant works as long as nothing else in the database uses it
+const migrationLockID = 727_001
+
+// Migrate creates the products schema if it does not exist yet
+// Replicas starting at the same time take turns on an advisory lock instead of racing on DDL
+func Migrate(ctx context.Context, pool *pgxpool.Pool) error {
+	ctx, span := otel.Tracer("product-service").Start(ctx, "database.Migrate")
+	defer span.End()
+
+	err := withMigrationLock(ctx, pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, schemaSQL); err != nil {
+			return fmt.Errorf("failed to apply schema: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+	return nil
+}
+
+// Seed inserts the sample products when the products table is empty
+// The rows come from SampleProducts, the catalog MockProductRepository serves, so both backends
+// start out identical; IDs and timestamps are assigned by PostgreSQL
+// Returns true when rows were inserted; an existing catalog is never touched
+func Seed(ctx context.Context, pool *pgxpool.Pool) (bool, error) {
+	ctx, span := otel.Tracer("product-service").Start(ctx, "database.Seed")
+	defer span.End()
+
+	seeded := false
+	err := withMigrationLock(ctx, pool, func(tx pgx.Tx) error {
+		var hasProducts bool
+		if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM products)`).Scan(&hasProducts); err != nil {
+			return fmt.Errorf("failed to check for existing products: %w", err)
+		}
+		if hasProducts {
+			return nil
+		}
+		for _, p := range SampleProducts() {
+			_, err := tx.Exec(ctx, `
+				INSERT INTO products (name, description, price, stock, category, image_url)
+				VALUES ($1, $2, $3, $4, $5, $6)
+			`, p.Name, p.Description, p.Price, p.Stock, p.Category, p.ImageURL)
+			if err != nil {
+				return fmt.Errorf("failed to seed product %q: %w", p.Name, err)
+			}
+		}
+		seeded = true
+		return nil
+	})
+	if err != nil {
+		span.RecordError(err)
+		return false, err
+	}
+
+	span.SetAttributes(attribute.Bool("db.seeded", seeded))
+	return seeded, nil
+}
+
+// withMigrationLock runs fn in a transaction holding the migration advisory lock
+// The lock is released automatically when the transaction commits or rolls back
+func withMigrationLock(ctx context.Context, pool *pgxpool.Pool, fn func(tx pgx.Tx) error) error {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin migration transaction: %w", err)
+	}
+	// Rollback is a no-op once the transaction has been committed
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, migrationLockID); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit migration: %w", err)
+	}
+	return nil
+}
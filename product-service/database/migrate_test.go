@@ -0,0 +1,60 @@
+package database
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Migrate runs on every start, so each DDL statement in the schema has to tolerate existing objects
+func TestSchemaIsIdempotent(t *testing.T) {
+	assert.NotEmpty(t, schemaSQL)
+
+	statements := regexp.MustCompile(`(?im)^\s*CREATE\s+(OR\s+REPLACE\s+)?(TABLE|INDEX|FUNCTION|TRIGGER)\b[^\n]*`).FindAllString(schemaSQL, -1)
+	assert.NotEmpty(t, statements)
+
+	for _, statement := range statements {
+		statement = strings.TrimSpace(statement)
+		switch {
+		case strings.Contains(statement, "IF NOT EXISTS"), strings.Contains(statement, "OR REPLACE"):
+		case strings.HasPrefix(statement, "CREATE TRIGGER"):
+			// Triggers have no IF NOT EXISTS before PostgreSQL 14, so they must be dropped first
+			name := strings.Fields(statement)[2]
+			assert.Contains(t, schemaSQL, "DROP TRIGGER IF EXISTS "+name, statement)
+		default:
+			t.Errorf("statement is not idempotent: %s", statement)
+		}
+	}
+}
+
+// The name index only covers live products, and existing duplicates are reported before it is built
+func TestSchemaNameIndex(t *testing.T) {
+	index := regexp.MustCompile(`(?m)^CREATE UNIQUE INDEX IF NOT EXISTS idx_products_name_active ON products\(name\) WHERE deleted_at IS NULL;`).FindStringIndex(schemaSQL)
+	require.NotNil(t, index, "the unique name index should be partial")
+
+	check := strings.Index(schemaSQL, "RAISE EXCEPTION 'cannot create unique index on products(name)")
+	require.GreaterOrEqual(t, check, 0, "duplicate names should be reported with an actionable message")
+	assert.Less(t, check, index[0], "duplicates should be checked before the index is created")
+	assert.Contains(t, schemaSQL, "DROP INDEX IF EXISTS idx_products_name_unique;", "the old full index should be replaced")
+}
+
+// Seed inserts SampleProducts, and Docker Compose loads seed.sql, so the two have to list the same catalog
+func TestSeedSQLMatchesSampleProducts(t *testing.T) {
+	seedSQL, err := os.ReadFile("seed.sql")
+	require.NoError(t, err)
+
+	rows := regexp.MustCompile(`(?m)^\('.*\)[,;]\r?$`).FindAllString(string(seedSQL), -1)
+	products := SampleProducts()
+	require.Len(t, rows, len(products))
+
+	for i, p := range products {
+		name := strings.ReplaceAll(p.Name, "'", "''")
+		assert.Contains(t, rows[i], fmt.Sprintf("('%s', ", name))
+		assert.Contains(t, rows[i], fmt.Sprintf(", %d, '%s', ", p.Stock, p.Category), p.Name)
+	}
+}
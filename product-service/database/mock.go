@@ -0,0 +1,417 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// MockProductRepository is an in-memory ProductRepository for tests and running without PostgreSQL
+// It mirrors the PostgreSQL repository's ordering and errors, so handlers behave the same
+// against either; InjectError makes a method fail to exercise error paths
+type MockProductRepository struct {
+	mu       sync.Mutex
+	products map[int]Product
+	nextID   int
+	errors   map[string]error
+	calls    map[string]int
+}
+
+// NewMockProductRepository creates a mock repository holding the given products
+// New products get IDs after the highest preloaded ID
+func NewMockProductRepository(products ...Product) *MockProductRepository {
+	r := &MockProductRepository{
+		products: make(map[int]Product, len(products)),
+		nextID:   1,
+		errors:   make(map[string]error),
+		calls:    make(map[string]int),
+	}
+	for _, p := range products {
+		r.products[p.ID] = p
+		if p.ID >= r.nextID {
+			r.nextID = p.ID + 1
+		}
+	}
+	return r
+}
+
+// NewSeededMockProductRepository creates a mock repository preloaded with the sample catalog
+func NewSeededMockProductRepository() *MockProductRepository {
+	return NewMockProductRepository(SampleProducts()...)
+}
+
+// SampleProducts returns the sample catalog with IDs in insertion order
+// Seed inserts the same products into PostgreSQL; seed.sql repeats them for the Docker Compose init scripts
+func SampleProducts() []Product {
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	products := []Product{
+		{Name: "MacBook Pro 16\"", Description: "Apple M3 Max chip with 16-core CPU and 40-core GPU, 64GB unified memory, 1TB SSD storage", Price: 3499.00, Stock: 25, Category: "Electronics", ImageURL: "https://picsum.photos/seed/laptop1/400/300"},
+		{Name: "Sony WH-1000XM5 Headphones", Description: "Industry-leading noise canceling wireless headphones with 30-hour battery life", Price: 399.99, Stock: 50, Category: "Electronics", ImageURL: "https://picsum.photos/seed/headphones1/400/300"},
+		{Name: "iPhone 15 Pro Max", Description: "A17 Pro chip, titanium design, 6.7-inch Super Retina XDR display, 256GB", Price: 1199.00, Stock: 100, Category: "Electronics", ImageURL: "https://picsum.photos/seed/phone1/400/300"},
+		{Name: "Samsung 65\" QLED TV", Description: "4K Ultra HD Smart TV with Quantum Processor and HDR10+", Price: 1299.99, Stock: 15, Category: "Electronics", ImageURL: "https://picsum.photos/seed/tv1/400/300"},
+		{Name: "Dell UltraSharp Monitor", Description: "27-inch 4K USB-C monitor with 99% sRGB color coverage", Price: 549.00, Stock: 40, Category: "Electronics", ImageURL: "https://picsum.photos/seed/monitor1/400/300"},
+		{Name: "Levi's 501 Original Jeans", Description: "Classic straight fit denim jeans with button fly, available in multiple washes", Price: 69.99, Stock: 200, Category: "Clothing", ImageURL: "https://picsum.photos/seed/jeans1/400/300"},
+		{Name: "Nike Air Max Sneakers", Description: "Comfortable running shoes with visible Air cushioning and breathable mesh", Price: 129.99, Stock: 150, Category: "Clothing", ImageURL: "https://picsum.photos/seed/shoes1/400/300"},
+		{Name: "Patagonia Down Jacket", Description: "Lightweight insulated jacket with 800-fill-power down, water-resistant shell", Price: 229.00, Stock: 75, Category: "Clothing", ImageURL: "https://picsum.photos/seed/jacket1/400/300"},
+		{Name: "Ralph Lauren Oxford Shirt", Description: "Classic fit button-down shirt in 100% cotton, available in multiple colors", Price: 89.50, Stock: 120, Category: "Clothing", ImageURL: "https://picsum.photos/seed/shirt1/400/300"},
+		{Name: "The Pragmatic Programmer", Description: "Your journey to mastery, 20th Anniversary Edition by David Thomas and Andrew Hunt", Price: 49.99, Stock: 80, Category: "Books", ImageURL: "https://picsum.photos/seed/book1/400/300"},
+		{Name: "Atomic Habits", Description: "An easy and proven way to build good habits and break bad ones by James Clear", Price: 27.00, Stock: 150, Category: "Books", ImageURL: "https://picsum.photos/seed/book2/400/300"},
+		{Name: "The Art of War", Description: "Ancient Chinese military treatise by Sun Tzu, deluxe hardcover edition", Price: 19.99, Stock: 200, Category: "Books", ImageURL: "https://picsum.photos/seed/book3/400/300"},
+		{Name: "Ergonomic Office Chair", Description: "Adjustable lumbar support, breathable mesh back, 360-degree swivel, up to 300 lbs", Price: 299.99, Stock: 45, Category: "Home & Garden", ImageURL: "https://picsum.photos/seed/chair1/400/300"},
+		{Name: "Dyson V15 Vacuum", Description: "Cordless stick vacuum with laser detection and LCD screen showing particle count", Price: 649.99, Stock: 30, Category: "Home & Garden", ImageURL: "https://picsum.photos/seed/vacuum1/400/300"},
+		{Name: "KitchenAid Stand Mixer", Description: "5-quart tilt-head stand mixer with 10 speeds and stainless steel bowl", Price: 379.99, Stock: 60, Category: "Home & Garden", ImageURL: "https://picsum.photos/seed/mixer1/400/300"},
+		{Name: "Weber Gas Grill", Description: "3-burner propane gas grill with 529 sq. in. cooking area and side burner", Price: 499.00, Stock: 20, Category: "Home & Garden", ImageURL: "https://picsum.photos/seed/grill1/400/300"},
+	}
+	for i := range products {
+		products[i].ID = i + 1
+		products[i].CreatedAt = created
+		products[i].UpdatedAt = created
+	}
+	return products
+}
+
+// InjectError makes the named method (e.g. "GetProductByID") return err until cleared with a nil err
+func (r *MockProductRepository) InjectError(method string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err == nil {
+		delete(r.errors, method)
+		return
+	}
+	r.errors[method] = err
+}
+
+// Calls returns how many times the named method has been called
+func (r *MockProductRepository) Calls(method string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.calls[method]
+}
+
+// call records a call to method and returns its injected error, if any
+// The caller must hold r.mu
+func (r *MockProductRepository) call(method string) error {
+	r.calls[method]++
+	return r.errors[method]
+}
+
+// GetAllProducts returns every product that has not been deleted, ordered by category, then name
+func (r *MockProductRepository) GetAllProducts(ctx context.Context) ([]Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.call("GetAllProducts"); err != nil {
+		return nil, err
+	}
+
+	products := r.filter(func(Product) bool { return true })
+	sortByCategory(products)
+	return products, nil
+}
+
+// GetAllProductsIncludingDeleted returns every product, deleted ones included, ordered by category, then name
+func (r *MockProductRepository) GetAllProductsIncludingDeleted(ctx context.Context) ([]Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.call("GetAllProductsIncludingDeleted"); err != nil {
+		return nil, err
+	}
+
+	products := []Product{}
+	for id := 1; id < r.nextID; id++ {
+		if p, ok := r.products[id]; ok {
+			products = append(products, p)
+		}
+	}
+	sortByCategory(products)
+	return products, nil
+}
+
+// GetProductByID returns a product, or the same wrapped pgx.ErrNoRows as PostgreSQL when it is missing or deleted
+func (r *MockProductRepository) GetProductByID(ctx context.Context, id int) (*Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.call("GetProductByID"); err != nil {
+		return nil, err
+	}
+
+	p, ok := r.products[id]
+	if !ok || p.DeletedAt != nil {
+		return nil, fmt.Errorf("failed to get product by ID %d: %w", id, pgx.ErrNoRows)
+	}
+	return &p, nil
+}
+
+// GetProductByIDIncludingDeleted returns a product even when it was deleted
+func (r *MockProductRepository) GetProductByIDIncludingDeleted(ctx context.Context, id int) (*Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.call("GetProductByIDIncludingDeleted"); err != nil {
+		return nil, err
+	}
+
+	p, ok := r.products[id]
+	if !ok {
+		return nil, fmt.Errorf("failed to get product by ID %d: %w", id, pgx.ErrNoRows)
+	}
+	return &p, nil
+}
+
+// GetProductsByCategory returns the products in a category ordered by name
+func (r *MockProductRepository) GetProductsByCategory(ctx context.Context, category string) ([]Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.call("GetProductsByCategory"); err != nil {
+		return nil, err
+	}
+
+	products := r.filter(func(p Product) bool { return p.Category == category })
+	sortByName(products)
+	return products, nil
+}
+
+// GetProductsByPriceRange returns the products priced between min and max, inclusive, ordered by price, then name
+func (r *MockProductRepository) GetProductsByPriceRange(ctx context.Context, min, max float64) ([]Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.call("GetProductsByPriceRange"); err != nil {
+		return nil, err
+	}
+
+	products := r.filter(func(p Product) bool { return p.Price >= min && p.Price <= max })
+	sort.SliceStable(products, func(i, j int) bool {
+		if products[i].Price != products[j].Price {
+			return products[i].Price < products[j].Price
+		}
+		return products[i].Name < products[j].Name
+	})
+	return products, nil
+}
+
+// SearchProducts returns up to limit products whose name or description contains query, ordered by name
+func (r *MockProductRepository) SearchProducts(ctx context.Context, query string, limit int) ([]Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.call("SearchProducts"); err != nil {
+		return nil, err
+	}
+
+	query = strings.ToLower(query)
+	products := r.filter(func(p Product) bool {
+		return strings.Contains(strings.ToLower(p.Name), query) ||
+			strings.Contains(strings.ToLower(p.Description), query)
+	})
+	sortByName(products)
+	if len(products) > limit {
+		products = products[:limit]
+	}
+	return products, nil
+}
+
+// GetCategoryCounts returns the number of products in each category
+func (r *MockProductRepository) GetCategoryCounts(ctx context.Context) (map[string]int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.call("GetCategoryCounts"); err != nil {
+		return nil, err
+	}
+
+	counts := map[string]int{}
+	for _, p := range r.products {
+		if p.DeletedAt == nil {
+			counts[p.Category]++
+		}
+	}
+	return counts, nil
+}
+
+// GetRelatedProducts returns up to limit other products in the same category as product id, ordered by name
+// A missing, deleted or uncategorized product has no related products
+func (r *MockProductRepository) GetRelatedProducts(ctx context.Context, id, limit int) ([]Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.call("GetRelatedProducts"); err != nil {
+		return nil, err
+	}
+
+	base, ok := r.products[id]
+	if !ok || base.DeletedAt != nil || base.Category == "" {
+		return []Product{}, nil
+	}
+	products := r.filter(func(p Product) bool { return p.ID != id && p.Category == base.Category })
+	sortByName(products)
+	if len(products) > limit {
+		products = products[:limit]
+	}
+	return products, nil
+}
+
+// CreateProduct stores a product, assigning its ID and timestamps
+// Like the unique index in PostgreSQL, a name that is already taken returns ErrDuplicateProduct
+func (r *MockProductRepository) CreateProduct(ctx context.Context, product *Product) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.call("CreateProduct"); err != nil {
+		return err
+	}
+
+	if r.nameTaken(product.Name, nil) {
+		return fmt.Errorf("failed to create product %q: %w", product.Name, ErrDuplicateProduct)
+	}
+	r.insert(product)
+	return nil
+}
+
+// CreateProducts stores a batch of products; an injected error stores none of them
+func (r *MockProductRepository) CreateProducts(ctx context.Context, products []Product) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.call("CreateProducts"); err != nil {
+		return err
+	}
+
+	batch := make(map[string]bool, len(products))
+	for _, p := range products {
+		if r.nameTaken(p.Name, batch) {
+			return fmt.Errorf("failed to create product %q: %w", p.Name, ErrDuplicateProduct)
+		}
+		batch[p.Name] = true
+	}
+	for i := range products {
+		r.insert(&products[i])
+	}
+	return nil
+}
+
+// DecrementStock takes quantity units out of stock, failing like PostgreSQL when the product is missing or short
+func (r *MockProductRepository) DecrementStock(ctx context.Context, id, quantity int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.call("DecrementStock"); err != nil {
+		return 0, err
+	}
+
+	p, ok := r.products[id]
+	if !ok || p.DeletedAt != nil {
+		return 0, fmt.Errorf("failed to decrement stock for product %d: %w", id, ErrProductNotFound)
+	}
+	if p.Stock < quantity {
+		return 0, fmt.Errorf("failed to decrement stock for product %d: %w", id, ErrInsufficientStock)
+	}
+	p.Stock -= quantity
+	p.UpdatedAt = time.Now()
+	r.products[id] = p
+	return p.Stock, nil
+}
+
+// ReleaseStock returns quantity units to stock
+func (r *MockProductRepository) ReleaseStock(ctx context.Context, id, quantity int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.call("ReleaseStock"); err != nil {
+		return 0, err
+	}
+
+	p, ok := r.products[id]
+	if !ok || p.DeletedAt != nil {
+		return 0, fmt.Errorf("failed to release stock for product %d: %w", id, ErrProductNotFound)
+	}
+	p.Stock += quantity
+	p.UpdatedAt = time.Now()
+	r.products[id] = p
+	return p.Stock, nil
+}
+
+// DeleteProduct soft-deletes a product, failing like PostgreSQL when it is missing or already deleted
+func (r *MockProductRepository) DeleteProduct(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.call("DeleteProduct"); err != nil {
+		return err
+	}
+
+	p, ok := r.products[id]
+	if !ok || p.DeletedAt != nil {
+		return fmt.Errorf("failed to delete product %d: %w", id, ErrProductNotFound)
+	}
+	now := time.Now()
+	p.DeletedAt = &now
+	p.UpdatedAt = now
+	r.products[id] = p
+	return nil
+}
+
+// insert assigns the next ID and timestamps and stores a copy of product
+// The caller must hold r.mu
+func (r *MockProductRepository) insert(product *Product) {
+	now := time.Now()
+	product.ID = r.nextID
+	product.CreatedAt = now
+	product.UpdatedAt = now
+	r.nextID++
+	r.products[product.ID] = *product
+}
+
+// nameTaken reports whether a stored product, or one of the pending names, already uses name
+// Deleted products give their name up, like the partial unique index in PostgreSQL
+// The caller must hold r.mu
+func (r *MockProductRepository) nameTaken(name string, pending map[string]bool) bool {
+	if pending[name] {
+		return true
+	}
+	for _, p := range r.products {
+		if p.Name == name && p.DeletedAt == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// filter returns copies of the products that have not been deleted and match keep, in ID order
+// The caller must hold r.mu
+func (r *MockProductRepository) filter(keep func(Product) bool) []Product {
+	products := []Product{}
+	for id := 1; id < r.nextID; id++ {
+		if p, ok := r.products[id]; ok && p.DeletedAt == nil && keep(p) {
+			products = append(products, p)
+		}
+	}
+	return products
+}
+
+// sortByCategory orders products by category, then name
+func sortByCategory(products []Product) {
+	sort.SliceStable(products, func(i, j int) bool {
+		if products[i].Category != products[j].Category {
+			return products[i].Category < products[j].Category
+		}
+		return products[i].Name < products[j].Name
+	})
+}
+
+// sortByName orders products by name, keeping ID order for equal names
+func sortByName(products []Product) {
+	sort.SliceStable(products, func(i, j int) bool {
+		return products[i].Name < products[j].Name
+	})
+}
@@ -0,0 +1,154 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMockProductRepository(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should preload the sample catalog", func(t *testing.T) {
+		repo := NewSeededMockProductRepository()
+
+		products, err := repo.GetAllProducts(ctx)
+		require.NoError(t, err)
+		require.Len(t, products, 16)
+		// Ordered by category, then name, like the PostgreSQL query
+		assert.Equal(t, "Books", products[0].Category)
+		assert.Equal(t, "Atomic Habits", products[0].Name)
+
+		electronics, err := repo.GetProductsByCategory(ctx, "Electronics")
+		require.NoError(t, err)
+		assert.Len(t, electronics, 5)
+
+		found, err := repo.SearchProducts(ctx, "JACKET", 10)
+		require.NoError(t, err)
+		require.Len(t, found, 1)
+		assert.Equal(t, "Patagonia Down Jacket", found[0].Name)
+
+		counts, err := repo.GetCategoryCounts(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int{"Books": 3, "Clothing": 4, "Electronics": 5, "Home & Garden": 4}, counts)
+
+		related, err := repo.GetRelatedProducts(ctx, 1, 10)
+		require.NoError(t, err)
+		assert.Len(t, related, 4, "Every other Electronics product")
+		for _, p := range related {
+			assert.Equal(t, "Electronics", p.Category)
+			assert.NotEqual(t, 1, p.ID)
+		}
+		related, err = repo.GetRelatedProducts(ctx, 1, 2)
+		require.NoError(t, err)
+		assert.Len(t, related, 2)
+	})
+
+	t.Run("should mirror PostgreSQL errors", func(t *testing.T) {
+		repo := NewSeededMockProductRepository()
+
+		_, err := repo.GetProductByID(ctx, 999)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+		_, err = repo.DecrementStock(ctx, 999, 1)
+		assert.ErrorIs(t, err, ErrProductNotFound)
+
+		_, err = repo.DecrementStock(ctx, 1, 26)
+		assert.ErrorIs(t, err, ErrInsufficientStock)
+
+		remaining, err := repo.DecrementStock(ctx, 1, 5)
+		require.NoError(t, err)
+		assert.Equal(t, 20, remaining)
+
+		remaining, err = repo.ReleaseStock(ctx, 1, 5)
+		require.NoError(t, err)
+		assert.Equal(t, 25, remaining)
+	})
+
+	t.Run("should assign IDs and hand out copies", func(t *testing.T) {
+		repo := NewSeededMockProductRepository()
+
+		product := Product{Name: "Desk Lamp", Price: 24.99, Category: "Home & Garden"}
+		require.NoError(t, repo.CreateProduct(ctx, &product))
+		assert.Equal(t, 17, product.ID)
+		assert.False(t, product.CreatedAt.IsZero())
+
+		stored, err := repo.GetProductByID(ctx, 17)
+		require.NoError(t, err)
+		stored.Name = "Changed"
+
+		again, err := repo.GetProductByID(ctx, 17)
+		require.NoError(t, err)
+		assert.Equal(t, "Desk Lamp", again.Name)
+	})
+
+	t.Run("should reject duplicate names like the unique index", func(t *testing.T) {
+		repo := NewSeededMockProductRepository()
+
+		err := repo.CreateProduct(ctx, &Product{Name: "Atomic Habits", Price: 27.00})
+		assert.ErrorIs(t, err, ErrDuplicateProduct)
+
+		err = repo.CreateProducts(ctx, []Product{{Name: "Desk Lamp", Price: 24.99}, {Name: "Desk Lamp", Price: 19.99}})
+		assert.ErrorIs(t, err, ErrDuplicateProduct)
+
+		products, err := repo.GetAllProducts(ctx)
+		require.NoError(t, err)
+		assert.Len(t, products, 16, "A rejected product should store nothing")
+	})
+
+	t.Run("should hide soft-deleted products from every read but the admin ones", func(t *testing.T) {
+		repo := NewSeededMockProductRepository()
+
+		require.NoError(t, repo.DeleteProduct(ctx, 1))
+		assert.ErrorIs(t, repo.DeleteProduct(ctx, 1), ErrProductNotFound, "Deleting twice should fail like PostgreSQL")
+
+		_, err := repo.GetProductByID(ctx, 1)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+		_, err = repo.DecrementStock(ctx, 1, 1)
+		assert.ErrorIs(t, err, ErrProductNotFound)
+
+		products, err := repo.GetAllProducts(ctx)
+		require.NoError(t, err)
+		assert.Len(t, products, 15)
+		electronics, err := repo.GetProductsByCategory(ctx, "Electronics")
+		require.NoError(t, err)
+		assert.Len(t, electronics, 4)
+		counts, err := repo.GetCategoryCounts(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 4, counts["Electronics"])
+		related, err := repo.GetRelatedProducts(ctx, 2, 10)
+		require.NoError(t, err)
+		assert.Len(t, related, 3, "A deleted product is nobody's related product")
+
+		deleted, err := repo.GetProductByIDIncludingDeleted(ctx, 1)
+		require.NoError(t, err)
+		require.NotNil(t, deleted.DeletedAt)
+		all, err := repo.GetAllProductsIncludingDeleted(ctx)
+		require.NoError(t, err)
+		assert.Len(t, all, 16)
+
+		err = repo.CreateProduct(ctx, &Product{Name: deleted.Name, Price: 1})
+		assert.NoError(t, err, "A deleted product should give its name up")
+	})
+
+	t.Run("should return injected errors until cleared", func(t *testing.T) {
+		repo := NewSeededMockProductRepository()
+		injected := errors.New("connection refused")
+
+		repo.InjectError("CreateProducts", injected)
+		err := repo.CreateProducts(ctx, []Product{{Name: "Desk Lamp", Price: 24.99}})
+		assert.ErrorIs(t, err, injected)
+
+		products, err := repo.GetAllProducts(ctx)
+		require.NoError(t, err)
+		assert.Len(t, products, 16, "A failed batch should store nothing")
+
+		repo.InjectError("CreateProducts", nil)
+		require.NoError(t, repo.CreateProducts(ctx, []Product{{Name: "Desk Lamp", Price: 24.99}}))
+		assert.Equal(t, 2, repo.Calls("CreateProducts"))
+	})
+}
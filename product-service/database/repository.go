@@ -2,9 +2,14 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -22,15 +27,47 @@ type Product struct {
 	ImageURL    string    `json:"image_url"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+	// DeletedAt is set once the product is soft-deleted; only the *IncludingDeleted reads return such products
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// ErrProductNotFound is returned when no product exists with the requested ID
+var ErrProductNotFound = errors.New("product not found")
+
+// ErrInsufficientStock is returned when a reservation asks for more units than are in stock
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+// ErrDuplicateProduct is returned when a product with the same name already exists
+var ErrDuplicateProduct = errors.New("product already exists")
+
+// uniqueViolation is the PostgreSQL error code for a unique constraint violation
+const uniqueViolation = "23505"
+
+// isUniqueViolation reports whether err is PostgreSQL rejecting a duplicate key
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation
 }
 
 // ProductRepository defines the interface for product data operations
 // This interface enables easy mocking for testing
+// Deleted products are soft-deleted: every read leaves them out except the *IncludingDeleted
+// ones, which exist for admin and audit use
 type ProductRepository interface {
 	GetAllProducts(ctx context.Context) ([]Product, error)
+	GetAllProductsIncludingDeleted(ctx context.Context) ([]Product, error)
 	GetProductByID(ctx context.Context, id int) (*Product, error)
+	GetProductByIDIncludingDeleted(ctx context.Context, id int) (*Product, error)
 	GetProductsByCategory(ctx context.Context, category string) ([]Product, error)
+	GetProductsByPriceRange(ctx context.Context, min, max float64) ([]Product, error)
+	SearchProducts(ctx context.Context, query string, limit int) ([]Product, error)
+	GetCategoryCounts(ctx context.Context) (map[string]int, error)
+	GetRelatedProducts(ctx context.Context, id, limit int) ([]Product, error)
 	CreateProduct(ctx context.Context, product *Product) error
+	CreateProducts(ctx context.Context, products []Product) error
+	DecrementStock(ctx context.Context, id, quantity int) (int, error)
+	ReleaseStock(ctx context.Context, id, quantity int) (int, error)
+	DeleteProduct(ctx context.Context, id int) error
 }
 
 // PostgresProductRepository implements ProductRepository using PostgreSQL
@@ -47,14 +84,25 @@ func NewProductRepository(client *Client) ProductRepository {
 	}
 }
 
-// GetAllProducts retrieves all products from the database
+// GetAllProducts retrieves all products from the database that have not been deleted
 func (r *PostgresProductRepository) GetAllProducts(ctx context.Context) ([]Product, error) {
-	ctx, span := r.tracer.Start(ctx, "repository.GetAllProducts")
+	return r.getAllProducts(ctx, "repository.GetAllProducts", false)
+}
+
+// GetAllProductsIncludingDeleted retrieves all products, soft-deleted ones included with DeletedAt set
+func (r *PostgresProductRepository) GetAllProductsIncludingDeleted(ctx context.Context) ([]Product, error) {
+	return r.getAllProducts(ctx, "repository.GetAllProductsIncludingDeleted", true)
+}
+
+// getAllProducts lists the catalog ordered by category, then name, under a span named spanName
+func (r *PostgresProductRepository) getAllProducts(ctx context.Context, spanName string, includeDeleted bool) ([]Product, error) {
+	ctx, span := r.tracer.Start(ctx, spanName)
 	defer span.End()
 
 	query := `
-		SELECT id, name, description, price::float8, stock, category, image_url, created_at, updated_at
+		SELECT id, name, description, price::float8, stock, category, image_url, created_at, updated_at, deleted_at
 		FROM products
+		WHERE $1 OR deleted_at IS NULL
 		ORDER BY category, name
 	`
 
@@ -65,7 +113,7 @@ func (r *PostgresProductRepository) GetAllProducts(ctx context.Context) ([]Produ
 	)
 
 	startTime := time.Now()
-	rows, err := r.pool.Query(ctx, query)
+	rows, err := r.pool.Query(ctx, query, includeDeleted)
 	if err != nil {
 		span.RecordError(err)
 		return nil, fmt.Errorf("failed to query products: %w", err)
@@ -85,6 +133,7 @@ func (r *PostgresProductRepository) GetAllProducts(ctx context.Context) ([]Produ
 			&p.ImageURL,
 			&p.CreatedAt,
 			&p.UpdatedAt,
+			&p.DeletedAt,
 		)
 		if err != nil {
 			span.RecordError(err)
@@ -108,14 +157,25 @@ func (r *PostgresProductRepository) GetAllProducts(ctx context.Context) ([]Produ
 }
 
 // GetProductByID retrieves a single product by its ID
+// A soft-deleted product is reported like a missing one, with pgx.ErrNoRows
 func (r *PostgresProductRepository) GetProductByID(ctx context.Context, id int) (*Product, error) {
-	ctx, span := r.tracer.Start(ctx, "repository.GetProductByID")
+	return r.getProductByID(ctx, "repository.GetProductByID", id, false)
+}
+
+// GetProductByIDIncludingDeleted retrieves a single product by its ID even when it was soft-deleted
+func (r *PostgresProductRepository) GetProductByIDIncludingDeleted(ctx context.Context, id int) (*Product, error) {
+	return r.getProductByID(ctx, "repository.GetProductByIDIncludingDeleted", id, true)
+}
+
+// getProductByID looks up one product under a span named spanName
+func (r *PostgresProductRepository) getProductByID(ctx context.Context, spanName string, id int, includeDeleted bool) (*Product, error) {
+	ctx, span := r.tracer.Start(ctx, spanName)
 	defer span.End()
 
 	query := `
-		SELECT id, name, description, price::float8, stock, category, image_url, created_at, updated_at
+		SELECT id, name, description, price::float8, stock, category, image_url, created_at, updated_at, deleted_at
 		FROM products
-		WHERE id = $1
+		WHERE id = $1 AND ($2 OR deleted_at IS NULL)
 	`
 
 	span.SetAttributes(
@@ -127,7 +187,7 @@ func (r *PostgresProductRepository) GetProductByID(ctx context.Context, id int)
 
 	startTime := time.Now()
 	var p Product
-	err := r.pool.QueryRow(ctx, query, id).Scan(
+	err := r.pool.QueryRow(ctx, query, id, includeDeleted).Scan(
 		&p.ID,
 		&p.Name,
 		&p.Description,
@@ -137,6 +197,7 @@ func (r *PostgresProductRepository) GetProductByID(ctx context.Context, id int)
 		&p.ImageURL,
 		&p.CreatedAt,
 		&p.UpdatedAt,
+		&p.DeletedAt,
 	)
 
 	duration := time.Since(startTime)
@@ -160,7 +221,7 @@ func (r *PostgresProductRepository) GetProductsByCategory(ctx context.Context, c
 	query := `
 		SELECT id, name, description, price::float8, stock, category, image_url, created_at, updated_at
 		FROM products
-		WHERE category = $1
+		WHERE category = $1 AND deleted_at IS NULL
 		ORDER BY name
 	`
 
@@ -214,7 +275,272 @@ func (r *PostgresProductRepository) GetProductsByCategory(ctx context.Context, c
 	return products, nil
 }
 
+// GetProductsByPriceRange retrieves all products priced between min and max, inclusive
+func (r *PostgresProductRepository) GetProductsByPriceRange(ctx context.Context, min, max float64) ([]Product, error) {
+	ctx, span := r.tracer.Start(ctx, "repository.GetProductsByPriceRange")
+	defer span.End()
+
+	query := `
+		SELECT id, name, description, price::float8, stock, category, image_url, created_at, updated_at
+		FROM products
+		WHERE price BETWEEN $1 AND $2 AND deleted_at IS NULL
+		ORDER BY price, name
+	`
+
+	span.SetAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.operation", "SELECT"),
+		attribute.String("db.table", "products"),
+		attribute.Float64("product.price.min", min),
+		attribute.Float64("product.price.max", max),
+	)
+
+	startTime := time.Now()
+	rows, err := r.pool.Query(ctx, query, min, max)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to query products by price range: %w", err)
+	}
+	defer rows.Close()
+
+	products := []Product{}
+	for rows.Next() {
+		var p Product
+		err := rows.Scan(
+			&p.ID,
+			&p.Name,
+			&p.Description,
+			&p.Price,
+			&p.Stock,
+			&p.Category,
+			&p.ImageURL,
+			&p.CreatedAt,
+			&p.UpdatedAt,
+		)
+		if err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("failed to scan product: %w", err)
+		}
+		products = append(products, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("error iterating products: %w", err)
+	}
+
+	duration := time.Since(startTime)
+	span.SetAttributes(
+		attribute.Int("db.result.count", len(products)),
+		attribute.Int64("db.query.duration_ms", duration.Milliseconds()),
+	)
+
+	return products, nil
+}
+
+// maxSearchAttributeLength caps how much of a search term is recorded on spans
+const maxSearchAttributeLength = 64
+
+// likeEscaper makes LIKE wildcards in user input match literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// SearchProducts returns up to limit products whose name or description contains query
+// Matching is case-insensitive; % and _ in the query are matched literally
+func (r *PostgresProductRepository) SearchProducts(ctx context.Context, query string, limit int) ([]Product, error) {
+	ctx, span := r.tracer.Start(ctx, "repository.SearchProducts")
+	defer span.End()
+
+	sql := `
+		SELECT id, name, description, price::float8, stock, category, image_url, created_at, updated_at
+		FROM products
+		WHERE (name ILIKE '%' || $1 || '%' OR description ILIKE '%' || $1 || '%') AND deleted_at IS NULL
+		ORDER BY name
+		LIMIT $2
+	`
+
+	span.SetAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.operation", "SELECT"),
+		attribute.String("db.table", "products"),
+		attribute.String("search.query", truncate(query, maxSearchAttributeLength)),
+		attribute.Int("search.limit", limit),
+	)
+
+	startTime := time.Now()
+	rows, err := r.pool.Query(ctx, sql, likeEscaper.Replace(query), limit)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to search products: %w", err)
+	}
+	defer rows.Close()
+
+	products := []Product{}
+	for rows.Next() {
+		var p Product
+		err := rows.Scan(
+			&p.ID,
+			&p.Name,
+			&p.Description,
+			&p.Price,
+			&p.Stock,
+			&p.Category,
+			&p.ImageURL,
+			&p.CreatedAt,
+			&p.UpdatedAt,
+		)
+		if err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("failed to scan product: %w", err)
+		}
+		products = append(products, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("error iterating products: %w", err)
+	}
+
+	duration := time.Since(startTime)
+	span.SetAttributes(
+		attribute.Int("db.result.count", len(products)),
+		attribute.Int64("db.query.duration_ms", duration.Milliseconds()),
+	)
+
+	return products, nil
+}
+
+// GetCategoryCounts returns the number of products in each category, keyed by category
+// Counting is done by PostgreSQL, so the catalog is never loaded to build it
+// Products without a category are counted under ""
+func (r *PostgresProductRepository) GetCategoryCounts(ctx context.Context) (map[string]int, error) {
+	ctx, span := r.tracer.Start(ctx, "repository.GetCategoryCounts")
+	defer span.End()
+
+	query := `
+		SELECT COALESCE(category, ''), COUNT(*)
+		FROM products
+		WHERE deleted_at IS NULL
+		GROUP BY 1
+	`
+
+	span.SetAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.operation", "SELECT"),
+		attribute.String("db.table", "products"),
+	)
+
+	startTime := time.Now()
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to count products by category: %w", err)
+	}
+	defer rows.Close()
+
+	counts := map[string]int{}
+	for rows.Next() {
+		var category string
+		var count int
+		if err := rows.Scan(&category, &count); err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("failed to scan category count: %w", err)
+		}
+		counts[category] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("error iterating category counts: %w", err)
+	}
+
+	duration := time.Since(startTime)
+	span.SetAttributes(
+		attribute.Int("db.result.count", len(counts)),
+		attribute.Int64("db.query.duration_ms", duration.Milliseconds()),
+	)
+
+	return counts, nil
+}
+
+// GetRelatedProducts returns up to limit other products in the same category as product id,
+// ordered by name
+// The category is looked up in the same query, so a missing, deleted or uncategorized product
+// simply has no related products; callers that need a 404 look the product up first
+func (r *PostgresProductRepository) GetRelatedProducts(ctx context.Context, id, limit int) ([]Product, error) {
+	ctx, span := r.tracer.Start(ctx, "repository.GetRelatedProducts")
+	defer span.End()
+
+	query := `
+		SELECT p.id, p.name, p.description, p.price::float8, p.stock, p.category, p.image_url, p.created_at, p.updated_at
+		FROM products p
+		JOIN products base ON base.category = p.category
+		WHERE base.id = $1 AND base.deleted_at IS NULL AND p.id <> $1 AND p.deleted_at IS NULL
+		ORDER BY p.name
+		LIMIT $2
+	`
+
+	span.SetAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.operation", "SELECT"),
+		attribute.String("db.table", "products"),
+		attribute.Int("product.id", id),
+		attribute.Int("related.limit", limit),
+	)
+
+	startTime := time.Now()
+	rows, err := r.pool.Query(ctx, query, id, limit)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to query related products: %w", err)
+	}
+	defer rows.Close()
+
+	products := []Product{}
+	for rows.Next() {
+		var p Product
+		err := rows.Scan(
+			&p.ID,
+			&p.Name,
+			&p.Description,
+			&p.Price,
+			&p.Stock,
+			&p.Category,
+			&p.ImageURL,
+			&p.CreatedAt,
+			&p.UpdatedAt,
+		)
+		if err != nil {
+			span.RecordError(err)
+			return nil, fmt.Errorf("failed to scan product: %w", err)
+		}
+		products = append(products, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("error iterating related products: %w", err)
+	}
+
+	duration := time.Since(startTime)
+	span.SetAttributes(
+		attribute.Int("db.result.count", len(products)),
+		attribute.Int64("db.query.duration_ms", duration.Milliseconds()),
+	)
+
+	return products, nil
+}
+
+// truncate shortens s to at most n runes
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
+
 // CreateProduct inserts a new product into the database
+// The insert runs in its own transaction so any follow-up writes added later roll back with it
+// Returns ErrDuplicateProduct when a product with the same name already exists
 func (r *PostgresProductRepository) CreateProduct(ctx context.Context, product *Product) error {
 	ctx, span := r.tracer.Start(ctx, "repository.CreateProduct")
 	defer span.End()
@@ -234,7 +560,16 @@ func (r *PostgresProductRepository) CreateProduct(ctx context.Context, product *
 	)
 
 	startTime := time.Now()
-	err := r.pool.QueryRow(
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	// Rollback is a no-op once the transaction has been committed
+	defer tx.Rollback(ctx)
+
+	var created Product
+	err = tx.QueryRow(
 		ctx,
 		query,
 		product.Name,
@@ -243,18 +578,226 @@ func (r *PostgresProductRepository) CreateProduct(ctx context.Context, product *
 		product.Stock,
 		product.Category,
 		product.ImageURL,
-	).Scan(&product.ID, &product.CreatedAt, &product.UpdatedAt)
+	).Scan(&created.ID, &created.CreatedAt, &created.UpdatedAt)
+	if err == nil {
+		err = tx.Commit(ctx)
+	}
 
 	duration := time.Since(startTime)
 	span.SetAttributes(
 		attribute.Int64("db.query.duration_ms", duration.Milliseconds()),
 	)
 
+	if isUniqueViolation(err) {
+		return fmt.Errorf("failed to create product %q: %w", product.Name, ErrDuplicateProduct)
+	}
 	if err != nil {
 		span.RecordError(err)
 		return fmt.Errorf("failed to create product: %w", err)
 	}
 
+	// Only fill in the generated fields once the row is actually committed
+	product.ID = created.ID
+	product.CreatedAt = created.CreatedAt
+	product.UpdatedAt = created.UpdatedAt
+
 	span.SetAttributes(attribute.Int("product.id", product.ID))
 	return nil
 }
+
+// CreateProducts inserts a batch of products in a single transaction
+// Either every product is inserted or none are; IDs and timestamps are filled in on success
+// Returns ErrDuplicateProduct when a name is already taken or repeated within the batch
+func (r *PostgresProductRepository) CreateProducts(ctx context.Context, products []Product) error {
+	ctx, span := r.tracer.Start(ctx, "repository.CreateProducts")
+	defer span.End()
+
+	query := `
+		INSERT INTO products (name, description, price, stock, category, image_url)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at
+	`
+
+	span.SetAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.operation", "INSERT"),
+		attribute.String("db.table", "products"),
+		attribute.Int("db.batch.size", len(products)),
+	)
+
+	startTime := time.Now()
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	// Rollback is a no-op once the transaction has been committed
+	defer tx.Rollback(ctx)
+
+	for i := range products {
+		p := &products[i]
+		err := tx.QueryRow(
+			ctx,
+			query,
+			p.Name,
+			p.Description,
+			p.Price,
+			p.Stock,
+			p.Category,
+			p.ImageURL,
+		).Scan(&p.ID, &p.CreatedAt, &p.UpdatedAt)
+		if isUniqueViolation(err) {
+			return fmt.Errorf("failed to create product %q: %w", p.Name, ErrDuplicateProduct)
+		}
+		if err != nil {
+			span.RecordError(err)
+			return fmt.Errorf("failed to create product %q: %w", p.Name, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to commit products: %w", err)
+	}
+
+	duration := time.Since(startTime)
+	span.SetAttributes(
+		attribute.Int64("db.query.duration_ms", duration.Milliseconds()),
+	)
+
+	return nil
+}
+
+// DecrementStock decrements a product's stock by quantity and returns the remaining stock
+// The check and decrement happen in a single UPDATE so concurrent reservations can't oversell
+// Returns ErrProductNotFound (also for deleted products) or ErrInsufficientStock when nothing was reserved
+func (r *PostgresProductRepository) DecrementStock(ctx context.Context, id, quantity int) (int, error) {
+	ctx, span := r.tracer.Start(ctx, "repository.DecrementStock")
+	defer span.End()
+
+	query := `
+		UPDATE products
+		SET stock = stock - $2, updated_at = NOW()
+		WHERE id = $1 AND stock >= $2 AND deleted_at IS NULL
+		RETURNING stock
+	`
+
+	span.SetAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.operation", "UPDATE"),
+		attribute.String("db.table", "products"),
+		attribute.Int("product.id", id),
+		attribute.Int("stock.quantity", quantity),
+	)
+
+	startTime := time.Now()
+	var stock int
+	err := r.pool.QueryRow(ctx, query, id, quantity).Scan(&stock)
+
+	duration := time.Since(startTime)
+	span.SetAttributes(
+		attribute.Int64("db.query.duration_ms", duration.Milliseconds()),
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Nothing was updated: tell a missing product apart from a short one
+		var exists bool
+		if err := r.pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM products WHERE id = $1 AND deleted_at IS NULL)`, id).Scan(&exists); err != nil {
+			span.RecordError(err)
+			return 0, fmt.Errorf("failed to decrement stock for product %d: %w", id, err)
+		}
+		if !exists {
+			return 0, fmt.Errorf("failed to decrement stock for product %d: %w", id, ErrProductNotFound)
+		}
+		return 0, fmt.Errorf("failed to decrement stock for product %d: %w", id, ErrInsufficientStock)
+	}
+	if err != nil {
+		span.RecordError(err)
+		return 0, fmt.Errorf("failed to decrement stock for product %d: %w", id, err)
+	}
+
+	span.SetAttributes(attribute.Int("stock.remaining", stock))
+	return stock, nil
+}
+
+// ReleaseStock returns previously reserved units to a product's stock and returns the new stock
+// Returns ErrProductNotFound when the product does not exist or was deleted
+func (r *PostgresProductRepository) ReleaseStock(ctx context.Context, id, quantity int) (int, error) {
+	ctx, span := r.tracer.Start(ctx, "repository.ReleaseStock")
+	defer span.End()
+
+	query := `
+		UPDATE products
+		SET stock = stock + $2, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING stock
+	`
+
+	span.SetAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.operation", "UPDATE"),
+		attribute.String("db.table", "products"),
+		attribute.Int("product.id", id),
+		attribute.Int("stock.quantity", quantity),
+	)
+
+	startTime := time.Now()
+	var stock int
+	err := r.pool.QueryRow(ctx, query, id, quantity).Scan(&stock)
+
+	duration := time.Since(startTime)
+	span.SetAttributes(
+		attribute.Int64("db.query.duration_ms", duration.Milliseconds()),
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, fmt.Errorf("failed to release stock for product %d: %w", id, ErrProductNotFound)
+	}
+	if err != nil {
+		span.RecordError(err)
+		return 0, fmt.Errorf("failed to release stock for product %d: %w", id, err)
+	}
+
+	span.SetAttributes(attribute.Int("stock.remaining", stock))
+	return stock, nil
+}
+
+// DeleteProduct soft-deletes a product by setting deleted_at, keeping the row so historical
+// orders that reference it still resolve; its name stays taken
+// Returns ErrProductNotFound when the product does not exist or was already deleted
+func (r *PostgresProductRepository) DeleteProduct(ctx context.Context, id int) error {
+	ctx, span := r.tracer.Start(ctx, "repository.DeleteProduct")
+	defer span.End()
+
+	query := `
+		UPDATE products
+		SET deleted_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING id
+	`
+
+	span.SetAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.operation", "UPDATE"),
+		attribute.String("db.table", "products"),
+		attribute.Int("product.id", id),
+	)
+
+	startTime := time.Now()
+	var deletedID int
+	err := r.pool.QueryRow(ctx, query, id).Scan(&deletedID)
+
+	duration := time.Since(startTime)
+	span.SetAttributes(
+		attribute.Int64("db.query.duration_ms", duration.Milliseconds()),
+	)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("failed to delete product %d: %w", id, ErrProductNotFound)
+	}
+	if err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to delete product %d: %w", id, err)
+	}
+	return nil
+}
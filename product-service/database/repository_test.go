@@ -0,0 +1,21 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLikeEscaper(t *testing.T) {
+	assert.Equal(t, `50\% off`, likeEscaper.Replace("50% off"))
+	assert.Equal(t, `image\_url`, likeEscaper.Replace("image_url"))
+	assert.Equal(t, `C:\\temp`, likeEscaper.Replace(`C:\temp`))
+	assert.Equal(t, "macbook", likeEscaper.Replace("macbook"))
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "short", truncate("short", 64))
+	assert.Equal(t, "abc", truncate("abcdef", 3))
+	// Multi-byte characters are never split
+	assert.Equal(t, "héé", truncate("héééé", 3))
+}
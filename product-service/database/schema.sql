@@ -10,15 +10,47 @@ CREATE TABLE IF NOT EXISTS products (
     category VARCHAR(100),
     image_url TEXT,
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
 );
 
+-- Soft delete: deleted products keep their row so historical orders still resolve
+-- Added separately so databases created before the column existed get it too
+ALTER TABLE products ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
 -- Indexes for common queries
 CREATE INDEX IF NOT EXISTS idx_products_category ON products(category);
-CREATE INDEX IF NOT EXISTS idx_products_name ON products(name);
+-- Product names are unique among live products; the unique index also serves name lookups
+-- A deleted product gives its name up, so it can be recreated under the same name
+-- Duplicates left over from before the index existed are reported instead of failing with a bare
+-- unique violation, so whoever is deploying knows which rows to rename or delete
+DO $$
+DECLARE
+    duplicates TEXT;
+BEGIN
+    SELECT string_agg(format('%L (ids %s)', name, ids), ', ')
+    INTO duplicates
+    FROM (
+        SELECT name, string_agg(id::TEXT, ', ' ORDER BY id) AS ids
+        FROM products
+        WHERE deleted_at IS NULL
+        GROUP BY name
+        HAVING COUNT(*) > 1
+    ) dup;
+
+    IF duplicates IS NOT NULL THEN
+        RAISE EXCEPTION 'cannot create unique index on products(name): duplicate product names %', duplicates
+            USING HINT = 'Rename or soft-delete (SET deleted_at = now()) all but one product per name, then restart';
+    END IF;
+END
+$$;
+DROP INDEX IF EXISTS idx_products_name;
+DROP INDEX IF EXISTS idx_products_name_unique;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_name_active ON products(name) WHERE deleted_at IS NULL;
 CREATE INDEX IF NOT EXISTS idx_products_price ON products(price);
 
 -- Trigger to automatically update updated_at timestamp
+-- Dropped and recreated so the whole file can be re-run safely
 CREATE OR REPLACE FUNCTION update_updated_at_column()
 RETURNS TRIGGER AS $$
 BEGIN
@@ -27,7 +59,9 @@ BEGIN
 END;
 $$ language 'plpgsql';
 
-CREATE TRIGGER update_products_updated_at 
+DROP TRIGGER IF EXISTS update_products_updated_at ON products;
+
+CREATE TRIGGER update_products_updated_at
     BEFORE UPDATE ON products
     FOR EACH ROW
     EXECUTE FUNCTION update_updated_at_column();
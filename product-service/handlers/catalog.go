@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"product-service/database"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Catalog reads shared by the REST handlers and the gRPC server
+// Each transport parses its own request format and maps the errors to its own status codes,
+// so both return the same products and reject the same input
+
+// queryError is a client error found while validating a catalog query
+// REST answers it with 400 and code, gRPC with InvalidArgument
+type queryError struct {
+	code    string
+	message string
+}
+
+func (e *queryError) Error() string {
+	return e.message
+}
+
+// priceRange validates optional price bounds; a missing bound defaults to 0 or maxProductPrice
+// byPrice is false when neither bound is given
+func priceRange(minPrice, maxPrice *float64) (min, max float64, byPrice bool, err error) {
+	check := func(name string, value *float64, fallback float64) (float64, error) {
+		if value == nil {
+			return fallback, nil
+		}
+		byPrice = true
+		if math.IsNaN(*value) || math.IsInf(*value, 0) {
+			return 0, &queryError{CodeInvalidPriceRange, fmt.Sprintf("%s is not a number", name)}
+		}
+		if *value < 0 {
+			return 0, &queryError{CodeInvalidPriceRange, fmt.Sprintf("%s must not be negative", name)}
+		}
+		return *value, nil
+	}
+
+	if min, err = check("min_price", minPrice, 0); err != nil {
+		return 0, 0, false, err
+	}
+	if max, err = check("max_price", maxPrice, maxProductPrice); err != nil {
+		return 0, 0, false, err
+	}
+	if min > max {
+		return 0, 0, false, &queryError{CodeInvalidPriceRange, "min_price must not be greater than max_price"}
+	}
+	return min, max, byPrice, nil
+}
+
+// listProducts returns the catalog, narrowed to category when it is set and to the price range when byPrice is set
+func (h *ProductHandler) listProducts(ctx context.Context, category string, min, max float64, byPrice bool) ([]database.Product, error) {
+	switch {
+	case byPrice:
+		// The price query does the narrowing; a category on top is applied to its result
+		products, err := h.repository.GetProductsByPriceRange(ctx, min, max)
+		if err == nil && category != "" {
+			h.setCategoryAttribute(ctx, category)
+			products = filterByCategory(products, category)
+		}
+		return products, err
+	case category != "":
+		h.setCategoryAttribute(ctx, category)
+		return h.repository.GetProductsByCategory(ctx, category)
+	default:
+		return h.repository.GetAllProducts(ctx)
+	}
+}
+
+// searchProducts validates the search term and limit, then searches names and descriptions
+// A limit of 0 uses defaultSearchLimit; the result is never nil so "no matches" is an empty list
+func (h *ProductHandler) searchProducts(ctx context.Context, query string, limit int) ([]database.Product, error) {
+	query = strings.TrimSpace(query)
+	if utf8.RuneCountInString(query) < minSearchQueryLength {
+		return nil, &queryError{CodeInvalidSearch, fmt.Sprintf("q must be at least %d characters", minSearchQueryLength)}
+	}
+	if limit == 0 {
+		limit = defaultSearchLimit
+	}
+	if limit < 1 || limit > maxSearchLimit {
+		return nil, &queryError{CodeInvalidSearch, fmt.Sprintf("limit must be between 1 and %d", maxSearchLimit)}
+	}
+
+	products, err := h.repository.SearchProducts(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	if products == nil {
+		products = []database.Product{}
+	}
+	return products, nil
+}
+
+// getProduct returns the product with id, or database.ErrProductNotFound when there is none
+func (h *ProductHandler) getProduct(ctx context.Context, id int) (*database.Product, error) {
+	product, err := h.repository.GetProductByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("product %d: %w", id, database.ErrProductNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	h.setCategoryAttribute(ctx, product.Category)
+	return product, nil
+}
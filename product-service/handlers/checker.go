@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DependencyCheck is a named probe of one dependency used by the health endpoints
+type DependencyCheck struct {
+	Name string
+	// Critical checks fail the whole health response with 503; a failing non-critical check
+	// only degrades it
+	Critical bool
+	Check    func(ctx context.Context) error
+	// MaxLatency marks a check that succeeded slower than this as degraded (0 = no threshold)
+	// A degraded dependency is reported with a warning but does not fail the response
+	MaxLatency time.Duration
+	// Timeout bounds this check on its own (0 = only the checker's timeout); it cannot
+	// extend the checker's timeout
+	Timeout time.Duration
+}
+
+// CheckResult is the outcome of one DependencyCheck as reported in health responses
+type CheckResult struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	Critical  bool    `json:"critical"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+	Warning   string  `json:"warning,omitempty"`
+}
+
+// HealthChecker is a registry of dependency checks that runs them concurrently under a shared timeout
+type HealthChecker struct {
+	mu      sync.RWMutex
+	checks  []DependencyCheck
+	timeout time.Duration
+}
+
+// NewHealthChecker creates a checker that gives all checks together at most timeout to finish
+func NewHealthChecker(timeout time.Duration, checks ...DependencyCheck) *HealthChecker {
+	return &HealthChecker{
+		checks:  checks,
+		timeout: timeout,
+	}
+}
+
+// Register adds a check, run after the ones already registered
+// Each dependency registers its own check as it is set up, so the health endpoints don't
+// need to know about every client
+func (hc *HealthChecker) Register(check DependencyCheck) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	hc.checks = append(hc.checks, check)
+}
+
+// Run executes every check and returns their results in registration order
+// healthy is false when any critical check failed or did not finish within the timeout;
+// degraded checks and failing non-critical checks still count as healthy
+func (hc *HealthChecker) Run(ctx context.Context) (results []CheckResult, healthy bool) {
+	hc.mu.RLock()
+	checks := hc.checks
+	hc.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, hc.timeout)
+	defer cancel()
+
+	results = make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check DependencyCheck) {
+			defer wg.Done()
+			results[i] = runCheck(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	healthy = true
+	for _, result := range results {
+		if result.Critical && result.Status == "unhealthy" {
+			healthy = false
+		}
+	}
+	return results, healthy
+}
+
+// OverallStatus aggregates check results into "healthy", "degraded" or "unhealthy"
+// A failing critical check is unhealthy; a failing non-critical check or a slow check is degraded
+func OverallStatus(results []CheckResult) string {
+	status := "healthy"
+	for _, result := range results {
+		switch {
+		case result.Status == "unhealthy" && result.Critical:
+			return "unhealthy"
+		case result.Status != "healthy":
+			status = "degraded"
+		}
+	}
+	return status
+}
+
+// runCheck times a single check, giving up when ctx or the check's own Timeout expires even
+// if the check ignores it
+func runCheck(ctx context.Context, check DependencyCheck) CheckResult {
+	if check.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, check.Timeout)
+		defer cancel()
+	}
+	start := time.Now()
+
+	// Buffered so a check that outlives the timeout doesn't leak its goroutine forever
+	done := make(chan error, 1)
+	go func() {
+		done <- check.Check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	latency := time.Since(start)
+
+	result := CheckResult{
+		Name:      check.Name,
+		Status:    "healthy",
+		Critical:  check.Critical,
+		LatencyMs: float64(latency.Microseconds()) / 1000,
+	}
+	switch {
+	case err != nil:
+		result.Status = "unhealthy"
+		result.Error = err.Error()
+	case check.MaxLatency > 0 && latency > check.MaxLatency:
+		result.Status = "degraded"
+		result.Warning = fmt.Sprintf("responded in %s, above the %s threshold", latency.Round(time.Millisecond), check.MaxLatency)
+	}
+	return result
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"product-service/internal/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// weakETag returns a weak entity tag for a response body
+// The tag is weak because it identifies the JSON content, not the exact bytes sent (compression may differ)
+func weakETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches reports whether an If-None-Match header lists etag, using the weak comparison
+// that RFC 9110 requires for If-None-Match; "*" matches any current representation
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// respondConditionalJSON writes v as JSON with a weak ETag computed from the body
+// A request whose If-None-Match lists that ETag gets 304 Not Modified without a body, so
+// polling clients only download the payload when it has changed
+func respondConditionalJSON(c *gin.Context, v any) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		apierror.RespondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Failed to encode response")
+		return
+	}
+
+	etag := weakETag(body)
+	c.Header("ETag", etag)
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+// notModifiedSince reports whether a resource last changed at lastModified is unchanged since the
+// If-Modified-Since header value; an empty or unparseable header never matches
+// HTTP dates only have second precision, so lastModified is truncated to the second before comparing
+func notModifiedSince(ifModifiedSince string, lastModified time.Time) bool {
+	if ifModifiedSince == "" {
+		return false
+	}
+	since, err := http.ParseTime(ifModifiedSince)
+	if err != nil {
+		return false
+	}
+	return !lastModified.UTC().Truncate(time.Second).After(since)
+}
+
+// respondLastModifiedJSON writes v as JSON with a Last-Modified header from lastModified
+// A request whose If-Modified-Since is not older than lastModified gets 304 Not Modified without a body
+// A zero lastModified (e.g. a product not read from PostgreSQL) sends v without the header
+func respondLastModifiedJSON(c *gin.Context, lastModified time.Time, v any) {
+	if !lastModified.IsZero() {
+		lastModified = lastModified.UTC().Truncate(time.Second)
+		c.Header("Last-Modified", lastModified.Format(http.TimeFormat))
+		if notModifiedSince(c.GetHeader("If-Modified-Since"), lastModified) {
+			c.Status(http.StatusNotModified)
+			return
+		}
+	}
+	c.JSON(http.StatusOK, v)
+}
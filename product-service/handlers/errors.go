@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"product-service/internal/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Error codes returned by the product handlers in apierror.APIError.Code
+// Clients branch on these, so an existing code must never be renamed
+const (
+	CodeInvalidProductID    = "PRODUCT_INVALID_ID"
+	CodeProductNotFound     = "PRODUCT_NOT_FOUND"
+	CodeProductExists       = "PRODUCT_ALREADY_EXISTS"
+	CodeInsufficientStock   = "PRODUCT_INSUFFICIENT_STOCK"
+	CodeInvalidPriceRange   = "PRODUCT_INVALID_PRICE_RANGE"
+	CodeInvalidSearch       = "PRODUCT_INVALID_SEARCH"
+	CodeInvalidQuery        = "PRODUCT_INVALID_QUERY"
+	CodeImportInvalidFile   = "IMPORT_INVALID_FILE"
+	CodeImportTooLarge      = "IMPORT_TOO_LARGE"
+	CodeDatabaseUnavailable = "DATABASE_UNAVAILABLE"
+)
+
+// respondRepositoryError writes the error response for a failed repository call
+// A request that ran past its deadline (see middleware.TimeoutMiddleware) gets 504 instead of 500
+// The underlying error is recorded on the repository span rather than returned to the client
+func respondRepositoryError(c *gin.Context, err error, message string) {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(c.Request.Context().Err(), context.DeadlineExceeded) {
+		apierror.RespondError(c, http.StatusGatewayTimeout, apierror.CodeTimeout, message)
+		return
+	}
+	apierror.RespondError(c, http.StatusInternalServerError, CodeDatabaseUnavailable, message)
+}
+
+// respondQueryError answers a queryError from the shared catalog logic with 400 and its code,
+// and any other error like respondRepositoryError
+func respondQueryError(c *gin.Context, err error, message string) {
+	var queryErr *queryError
+	if errors.As(err, &queryErr) {
+		apierror.RespondError(c, http.StatusBadRequest, queryErr.code, queryErr.message)
+		return
+	}
+	respondRepositoryError(c, err, message)
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+
+	"product-service/database"
+	"product-service/proto/productpb"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ProductGRPCServer implements productpb.ProductService on top of a ProductHandler
+// It runs the same catalog logic as the REST endpoints, so both transports return the same products
+type ProductGRPCServer struct {
+	productpb.UnimplementedProductServiceServer
+	handler *ProductHandler
+}
+
+// NewProductGRPCServer creates a gRPC product service backed by handler
+func NewProductGRPCServer(handler *ProductHandler) *ProductGRPCServer {
+	return &ProductGRPCServer{handler: handler}
+}
+
+// GetProduct returns one product, or NOT_FOUND when no product has the ID
+func (s *ProductGRPCServer) GetProduct(ctx context.Context, req *productpb.GetProductRequest) (*productpb.Product, error) {
+	if req.GetId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "Invalid product ID")
+	}
+
+	product, err := s.handler.getProduct(ctx, int(req.GetId()))
+	if err != nil {
+		if errors.Is(err, database.ErrProductNotFound) {
+			return nil, status.Error(codes.NotFound, "Product not found")
+		}
+		return nil, grpcError(err, "Failed to retrieve product")
+	}
+	return toProtoProduct(*product), nil
+}
+
+// ListProducts returns the catalog, optionally narrowed by category and price
+func (s *ProductGRPCServer) ListProducts(ctx context.Context, req *productpb.ListProductsRequest) (*productpb.ListProductsResponse, error) {
+	min, max, byPrice, err := priceRange(req.MinPrice, req.MaxPrice)
+	if err != nil {
+		return nil, grpcError(err, "Invalid price range")
+	}
+
+	products, err := s.handler.listProducts(ctx, req.GetCategory(), min, max, byPrice)
+	if err != nil {
+		return nil, grpcError(err, "Failed to retrieve products")
+	}
+	return &productpb.ListProductsResponse{Products: toProtoProducts(products)}, nil
+}
+
+// SearchProducts returns products whose name or description contains the query
+func (s *ProductGRPCServer) SearchProducts(ctx context.Context, req *productpb.SearchProductsRequest) (*productpb.SearchProductsResponse, error) {
+	products, err := s.handler.searchProducts(ctx, req.GetQuery(), int(req.GetLimit()))
+	if err != nil {
+		return nil, grpcError(err, "Failed to search products")
+	}
+	return &productpb.SearchProductsResponse{Products: toProtoProducts(products)}, nil
+}
+
+// grpcError maps a catalog error to a gRPC status, mirroring respondQueryError
+// Validation errors become INVALID_ARGUMENT and deadlines DEADLINE_EXCEEDED; anything else is
+// reported as UNAVAILABLE with message, keeping the database error out of the response
+func grpcError(err error, message string) error {
+	var queryErr *queryError
+	switch {
+	case errors.As(err, &queryErr):
+		return status.Error(codes.InvalidArgument, queryErr.message)
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, message)
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, message)
+	default:
+		return status.Error(codes.Unavailable, message)
+	}
+}
+
+// toProtoProducts converts repository products to their protobuf form
+func toProtoProducts(products []database.Product) []*productpb.Product {
+	converted := make([]*productpb.Product, 0, len(products))
+	for _, p := range products {
+		converted = append(converted, toProtoProduct(p))
+	}
+	return converted
+}
+
+// toProtoProduct converts a repository product to its protobuf form
+// Zero timestamps (products not read from PostgreSQL) are left unset
+func toProtoProduct(p database.Product) *productpb.Product {
+	product := &productpb.Product{
+		Id:          int64(p.ID),
+		Name:        p.Name,
+		Description: p.Description,
+		Price:       p.Price,
+		Stock:       int32(p.Stock),
+		Category:    p.Category,
+		ImageUrl:    p.ImageURL,
+	}
+	if !p.CreatedAt.IsZero() {
+		product.CreatedAt = timestamppb.New(p.CreatedAt)
+	}
+	if !p.UpdatedAt.IsZero() {
+		product.UpdatedAt = timestamppb.New(p.UpdatedAt)
+	}
+	return product
+}
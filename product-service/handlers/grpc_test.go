@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"product-service/proto/productpb"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+// newTestGRPCClient serves handler's gRPC product service over an in-memory bufconn listener
+func newTestGRPCClient(t *testing.T, handler *ProductHandler) productpb.ProductServiceClient {
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	productpb.RegisterProductServiceServer(server, NewProductGRPCServer(handler))
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return productpb.NewProductServiceClient(conn)
+}
+
+func TestGRPCServer(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("GetProduct returns the product", func(t *testing.T) {
+		client := newTestGRPCClient(t, NewProductHandler(newTestProductRepository(), ProductHandlerConfig{}))
+
+		product, err := client.GetProduct(ctx, &productpb.GetProductRequest{Id: 2})
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(2), product.GetId())
+		assert.Equal(t, "Cotton T-Shirt", product.GetName())
+		assert.Equal(t, 19.99, product.GetPrice())
+		assert.Equal(t, int32(200), product.GetStock())
+		assert.Equal(t, "https://example.com/tshirt.jpg", product.GetImageUrl())
+	})
+
+	t.Run("GetProduct maps missing and invalid IDs", func(t *testing.T) {
+		client := newTestGRPCClient(t, NewProductHandler(newTestProductRepository(), ProductHandlerConfig{}))
+
+		_, err := client.GetProduct(ctx, &productpb.GetProductRequest{Id: 999})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+
+		_, err = client.GetProduct(ctx, &productpb.GetProductRequest{Id: 0})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("ListProducts filters like GET /products", func(t *testing.T) {
+		client := newTestGRPCClient(t, NewProductHandler(newTestProductRepository(), ProductHandlerConfig{}))
+
+		all, err := client.ListProducts(ctx, &productpb.ListProductsRequest{})
+		require.NoError(t, err)
+		assert.Len(t, all.GetProducts(), 3)
+
+		books, err := client.ListProducts(ctx, &productpb.ListProductsRequest{Category: "Books"})
+		require.NoError(t, err)
+		require.Len(t, books.GetProducts(), 1)
+		assert.Equal(t, "Books", books.GetProducts()[0].GetCategory())
+
+		cheap, err := client.ListProducts(ctx, &productpb.ListProductsRequest{MaxPrice: proto.Float64(40)})
+		require.NoError(t, err)
+		assert.Len(t, cheap.GetProducts(), 2)
+
+		_, err = client.ListProducts(ctx, &productpb.ListProductsRequest{MinPrice: proto.Float64(50), MaxPrice: proto.Float64(10)})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("SearchProducts validates the query like GET /products/search", func(t *testing.T) {
+		client := newTestGRPCClient(t, NewProductHandler(newTestProductRepository(), ProductHandlerConfig{}))
+
+		found, err := client.SearchProducts(ctx, &productpb.SearchProductsRequest{Query: "tee"})
+		require.NoError(t, err)
+		require.Len(t, found.GetProducts(), 1)
+		assert.Equal(t, int64(2), found.GetProducts()[0].GetId())
+
+		_, err = client.SearchProducts(ctx, &productpb.SearchProductsRequest{Query: "a"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+		_, err = client.SearchProducts(ctx, &productpb.SearchProductsRequest{Query: "tee", Limit: maxSearchLimit + 1})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("repository errors become UNAVAILABLE without the error text", func(t *testing.T) {
+		repo := newTestProductRepository()
+		repo.InjectError("GetAllProducts", errors.New("connection refused"))
+		client := newTestGRPCClient(t, NewProductHandler(repo, ProductHandlerConfig{}))
+
+		_, err := client.ListProducts(ctx, &productpb.ListProductsRequest{})
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+		assert.NotContains(t, err.Error(), "connection refused")
+	})
+}
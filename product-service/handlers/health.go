@@ -3,8 +3,7 @@ package handlers
 import (
 	"net/http"
 	"os"
-
-	"product-service/database"
+	"sync/atomic"
 
 	"github.com/gin-gonic/gin"
 )
@@ -15,55 +14,89 @@ type HealthResponse struct {
 	Service string `json:"service"`
 }
 
-// Healthz is a simple health check endpoint
-// Returns 200 OK if the service is healthy
-// In production, this should check:
-// - Application status
-// - Database connectivity
-// - External service connectivity
-func Healthz(dbClient *database.Client) gin.HandlerFunc {
+// Healthz is the dependency health check endpoint
+// Runs the checker's dependency checks concurrently and reports each one with its latency
+// Returns 503 Service Unavailable if any critical check fails, 200 OK otherwise; a failing
+// non-critical check or a slow one reports "degraded" with 200
+// A nil checker (e.g. the in-memory repository) reports healthy with no checks
+func Healthz(checker *HealthChecker) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Check database health
 		dbStatus := "healthy"
+		status := "healthy"
 		statusCode := http.StatusOK
 
-		if dbClient != nil {
-			if err := dbClient.Ping(c.Request.Context()); err != nil {
-				dbStatus = "unhealthy"
+		checks := []CheckResult{}
+		if checker != nil {
+			var healthy bool
+			checks, healthy = checker.Run(c.Request.Context())
+			status = OverallStatus(checks)
+			if !healthy {
 				statusCode = http.StatusServiceUnavailable
 			}
+			for _, check := range checks {
+				if check.Name == "postgres" {
+					dbStatus = check.Status
+				}
+			}
 		}
 
 		response := gin.H{
-			"status":    "healthy",
+			"status":    status,
 			"service":   "product-service",
 			"pod_name":  os.Getenv("POD_NAME"),
 			"node_name": os.Getenv("NODE_NAME"),
 			"database":  dbStatus,
-		}
-
-		if statusCode != http.StatusOK {
-			response["status"] = "unhealthy"
+			"checks":    checks,
 		}
 
 		c.JSON(statusCode, response)
 	}
 }
 
+// DrainState records whether the service has started shutting down
+// The zero value is not draining; a nil *DrainState never drains
+type DrainState struct {
+	draining atomic.Bool
+}
+
+// Start marks the service as draining
+// From then on /ready answers 503 so load balancers stop routing new traffic to the pod,
+// while /live keeps answering 200 so Kubernetes doesn't kill it mid-drain
+func (d *DrainState) Start() {
+	d.draining.Store(true)
+}
+
+// Draining reports whether Start has been called
+func (d *DrainState) Draining() bool {
+	return d != nil && d.draining.Load()
+}
+
 // Ready handles the /ready endpoint
 // This is the Kubernetes readiness probe
 // Indicates whether the service is ready to accept traffic
+// Returns 503 Service Unavailable once drain has started, 200 OK otherwise
 // In a real application, this would check:
 // - Database connectivity
 // - Required service dependencies
 // - Cache availability
-func Ready(c *gin.Context) {
-	// For this demo, we're always ready
-	// In production, add actual readiness checks here
-	c.JSON(http.StatusOK, HealthResponse{
-		Status:  "ready",
-		Service: "product-service",
-	})
+func Ready(drain *DrainState) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if drain.Draining() {
+			c.JSON(http.StatusServiceUnavailable, HealthResponse{
+				Status:  "draining",
+				Service: "product-service",
+			})
+			return
+		}
+
+		// For this demo, we're otherwise always ready
+		// In production, add actual readiness checks here
+		c.JSON(http.StatusOK, HealthResponse{
+			Status:  "ready",
+			Service: "product-service",
+		})
+	}
 }
 
 // Live handles the /live endpoint
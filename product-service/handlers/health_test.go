@@ -1,10 +1,13 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -16,7 +19,7 @@ func TestHealthz(t *testing.T) {
 
 	t.Run("should return 200 OK", func(t *testing.T) {
 		router := gin.New()
-		router.GET("/healthz", Healthz)
+		router.GET("/healthz", Healthz(nil))
 		w := httptest.NewRecorder()
 		req, _ := http.NewRequest("GET", "/healthz", nil)
 
@@ -27,7 +30,7 @@ func TestHealthz(t *testing.T) {
 
 	t.Run("should return valid JSON", func(t *testing.T) {
 		router := gin.New()
-		router.GET("/healthz", Healthz)
+		router.GET("/healthz", Healthz(nil))
 		w := httptest.NewRecorder()
 		req, _ := http.NewRequest("GET", "/healthz", nil)
 
@@ -37,13 +40,13 @@ func TestHealthz(t *testing.T) {
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		require.NoError(t, err)
 		
-		assert.Equal(t, "ok", response.Status)
+		assert.Equal(t, "healthy", response.Status)
 		assert.Equal(t, "product-service", response.Service)
 	})
 
 	t.Run("should have correct content type", func(t *testing.T) {
 		router := gin.New()
-		router.GET("/healthz", Healthz)
+		router.GET("/healthz", Healthz(nil))
 		w := httptest.NewRecorder()
 		req, _ := http.NewRequest("GET", "/healthz", nil)
 
@@ -51,6 +54,68 @@ func TestHealthz(t *testing.T) {
 
 		assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
 	})
+
+	t.Run("should report each dependency and fail on a critical one", func(t *testing.T) {
+		checker := NewHealthChecker(time.Second,
+			DependencyCheck{Name: "postgres", Critical: true, Check: func(ctx context.Context) error {
+				return errors.New("connection refused")
+			}},
+		)
+
+		router := gin.New()
+		router.GET("/healthz", Healthz(checker))
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/healthz", nil)
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+		var response struct {
+			Status   string        `json:"status"`
+			Database string        `json:"database"`
+			Checks   []CheckResult `json:"checks"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "unhealthy", response.Status)
+		assert.Equal(t, "unhealthy", response.Database)
+		require.Len(t, response.Checks, 1)
+		assert.Equal(t, "postgres", response.Checks[0].Name)
+		assert.Equal(t, "connection refused", response.Checks[0].Error)
+	})
+
+	t.Run("should degrade without failing when only a non-critical check fails", func(t *testing.T) {
+		checker := NewHealthChecker(time.Second,
+			DependencyCheck{Name: "postgres", Critical: true, Check: func(ctx context.Context) error { return nil }},
+		)
+		checker.Register(DependencyCheck{Name: "cache", Timeout: 20 * time.Millisecond, Check: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}})
+
+		router := gin.New()
+		router.GET("/healthz", Healthz(checker))
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/healthz", nil)
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			Status   string        `json:"status"`
+			Database string        `json:"database"`
+			Checks   []CheckResult `json:"checks"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "degraded", response.Status)
+		assert.Equal(t, "healthy", response.Database)
+		require.Len(t, response.Checks, 2)
+		assert.Equal(t, CheckResult{Name: "postgres", Status: "healthy", Critical: true, LatencyMs: response.Checks[0].LatencyMs}, response.Checks[0])
+		assert.Equal(t, "cache", response.Checks[1].Name)
+		assert.False(t, response.Checks[1].Critical)
+		assert.Equal(t, context.DeadlineExceeded.Error(), response.Checks[1].Error)
+	})
 }
 
 func TestReady(t *testing.T) {
@@ -58,7 +123,7 @@ func TestReady(t *testing.T) {
 
 	t.Run("should return 200 OK", func(t *testing.T) {
 		router := gin.New()
-		router.GET("/ready", Ready)
+		router.GET("/ready", Ready(nil))
 		w := httptest.NewRecorder()
 		req, _ := http.NewRequest("GET", "/ready", nil)
 
@@ -69,7 +134,7 @@ func TestReady(t *testing.T) {
 
 	t.Run("should return valid JSON with ready status", func(t *testing.T) {
 		router := gin.New()
-		router.GET("/ready", Ready)
+		router.GET("/ready", Ready(nil))
 		w := httptest.NewRecorder()
 		req, _ := http.NewRequest("GET", "/ready", nil)
 
@@ -85,7 +150,7 @@ func TestReady(t *testing.T) {
 
 	t.Run("should be compatible with Kubernetes readiness probe", func(t *testing.T) {
 		router := gin.New()
-		router.GET("/ready", Ready)
+		router.GET("/ready", Ready(nil))
 		w := httptest.NewRecorder()
 		req, _ := http.NewRequest("GET", "/ready", nil)
 
@@ -96,6 +161,36 @@ func TestReady(t *testing.T) {
 		// Should return JSON
 		assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
 	})
+
+	t.Run("should return 503 once draining while liveness stays 200", func(t *testing.T) {
+		drain := &DrainState{}
+		router := gin.New()
+		router.GET("/ready", Ready(drain))
+		router.GET("/live", Live)
+
+		probe := func(path string) (int, HealthResponse) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", path, nil)
+			router.ServeHTTP(w, req)
+
+			var response HealthResponse
+			json.Unmarshal(w.Body.Bytes(), &response)
+			return w.Code, response
+		}
+
+		code, _ := probe("/ready")
+		require.Equal(t, http.StatusOK, code)
+
+		drain.Start()
+
+		code, response := probe("/ready")
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, "draining", response.Status)
+
+		code, response = probe("/live")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "alive", response.Status)
+	})
 }
 
 func TestLive(t *testing.T) {
@@ -148,15 +243,15 @@ func TestAllHealthEndpoints(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	
 	router := gin.New()
-	router.GET("/healthz", Healthz)
-	router.GET("/ready", Ready)
+	router.GET("/healthz", Healthz(nil))
+	router.GET("/ready", Ready(nil))
 	router.GET("/live", Live)
 
 	endpoints := []struct {
 		path           string
 		expectedStatus string
 	}{
-		{"/healthz", "ok"},
+		{"/healthz", "healthy"},
 		{"/ready", "ready"},
 		{"/live", "alive"},
 	}
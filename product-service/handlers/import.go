@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"product-service/database"
+	"product-service/internal/apierror"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// defaultImportMaxBytes caps CSV uploads when ImportMaxBytes is not configured
+	defaultImportMaxBytes = 5 << 20
+
+	// defaultImportMaxRows caps CSV data rows when ImportMaxRows is not configured
+	defaultImportMaxRows = 1000
+
+	// importFormField is the multipart field holding the CSV file
+	importFormField = "file"
+)
+
+// importColumns maps accepted CSV header names to product fields
+// Headers are matched case-insensitively, so "Name" and "PRICE" work as exported by spreadsheets
+var importColumns = map[string]string{
+	"name":        "name",
+	"description": "description",
+	"price":       "price",
+	"stock":       "stock",
+	"category":    "category",
+	"image_url":   "image_url",
+	"imageurl":    "image_url",
+	"image":       "image_url",
+}
+
+// requiredImportColumns must be present in the header row
+var requiredImportColumns = []string{"name", "price"}
+
+// ImportRowError reports why a CSV row was rejected
+// Row is the 1-based line number in the file, counting the header as line 1
+type ImportRowError struct {
+	Row    int      `json:"row"`
+	Errors []string `json:"errors"`
+}
+
+// ImportResponse is the per-row report returned by POST /products/import
+type ImportResponse struct {
+	Imported int               `json:"imported"`
+	Rejected int               `json:"rejected"`
+	Products []ProductResponse `json:"products"`
+	Errors   []ImportRowError  `json:"errors"`
+}
+
+// ImportProducts handles the POST /products/import endpoint
+// It accepts a multipart/form-data CSV upload in the "file" field, validates every row,
+// inserts the valid rows in a single transaction and reports the invalid ones by line number
+func (h *ProductHandler) ImportProducts(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// Cap the whole body so an oversized upload is rejected before it is buffered
+	maxBytes := h.importMaxBytes()
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+
+	fileHeader, err := c.FormFile(importFormField)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			apierror.RespondErrorDetails(c, http.StatusRequestEntityTooLarge, CodeImportTooLarge, "File too large", gin.H{
+				"max_bytes": maxBytes,
+			})
+			return
+		}
+		apierror.RespondError(c, http.StatusBadRequest, CodeImportInvalidFile, "Invalid upload: expected a multipart/form-data CSV file in the \""+importFormField+"\" field")
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		apierror.RespondError(c, http.StatusBadRequest, CodeImportInvalidFile, "Invalid upload: "+err.Error())
+		return
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.TrimLeadingSpace = true
+	// Rows may have fewer trailing columns than the header; missing cells are treated as empty
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		apierror.RespondError(c, http.StatusBadRequest, CodeImportInvalidFile, "Malformed CSV: failed to read header row: "+err.Error())
+		return
+	}
+
+	columns, err := mapImportHeader(header)
+	if err != nil {
+		apierror.RespondError(c, http.StatusBadRequest, CodeImportInvalidFile, "Invalid CSV header: "+err.Error())
+		return
+	}
+
+	maxRows := h.importMaxRows()
+	var valid []database.Product
+	rowErrors := []ImportRowError{}
+	rows := 0
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// A structural error (e.g. an unterminated quote) makes the rest of the file unreliable
+			apierror.RespondError(c, http.StatusBadRequest, CodeImportInvalidFile, "Malformed CSV: "+err.Error())
+			return
+		}
+
+		rows++
+		if rows > maxRows {
+			apierror.RespondErrorDetails(c, http.StatusRequestEntityTooLarge, CodeImportTooLarge, "Too many rows", gin.H{
+				"max_rows": maxRows,
+			})
+			return
+		}
+
+		// The header is line 1, so the first data row is line 2
+		line := rows + 1
+		product, problems := parseImportRow(columns, record)
+		if len(problems) > 0 {
+			rowErrors = append(rowErrors, ImportRowError{Row: line, Errors: problems})
+			continue
+		}
+		valid = append(valid, product)
+	}
+
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(
+		attribute.Int("import.rows", rows),
+		attribute.Int("import.valid", len(valid)),
+		attribute.Int("import.rejected", len(rowErrors)),
+	)
+
+	if rows == 0 {
+		apierror.RespondError(c, http.StatusBadRequest, CodeImportInvalidFile, "CSV contains no data rows")
+		return
+	}
+
+	if len(valid) > 0 {
+		if err := h.repository.CreateProducts(ctx, valid); err != nil {
+			if errors.Is(err, database.ErrDuplicateProduct) {
+				// The batch is all-or-nothing, so one duplicate name rejects the whole file
+				apierror.RespondError(c, http.StatusConflict, CodeProductExists, "Product already exists: "+err.Error())
+				return
+			}
+			respondRepositoryError(c, err, "Failed to import products")
+			return
+		}
+	}
+
+	status := http.StatusOK
+	if len(valid) == 0 {
+		// Nothing was imported: every row failed validation
+		status = http.StatusUnprocessableEntity
+	}
+
+	c.JSON(status, ImportResponse{
+		Imported: len(valid),
+		Rejected: len(rowErrors),
+		Products: h.productResponses(valid),
+		Errors:   rowErrors,
+	})
+}
+
+// importMaxBytes returns the configured upload cap, falling back to the default
+func (h *ProductHandler) importMaxBytes() int64 {
+	if h.config.ImportMaxBytes > 0 {
+		return h.config.ImportMaxBytes
+	}
+	return defaultImportMaxBytes
+}
+
+// importMaxRows returns the configured row cap, falling back to the default
+func (h *ProductHandler) importMaxRows() int {
+	if h.config.ImportMaxRows > 0 {
+		return h.config.ImportMaxRows
+	}
+	return defaultImportMaxRows
+}
+
+// mapImportHeader resolves each product field to its column index in the header row
+// Unknown columns are ignored; duplicate or missing required columns are an error
+func mapImportHeader(header []string) (map[string]int, error) {
+	columns := make(map[string]int)
+	for i, name := range header {
+		// Spreadsheets often prefix the first cell with a UTF-8 byte order mark
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		field, ok := importColumns[name]
+		if !ok {
+			continue
+		}
+		if _, seen := columns[field]; seen {
+			return nil, fmt.Errorf("duplicate column for %q", field)
+		}
+		columns[field] = i
+	}
+
+	for _, field := range requiredImportColumns {
+		if _, ok := columns[field]; !ok {
+			return nil, fmt.Errorf("missing required column %q", field)
+		}
+	}
+	return columns, nil
+}
+
+// parseImportRow builds a product from a CSV record and collects every validation problem
+// Limits mirror the products table: name up to 255 characters, category up to 100,
+// a price that fits DECIMAL(10, 2) and non-negative integer stock
+func parseImportRow(columns map[string]int, record []string) (database.Product, []string) {
+	cell := func(field string) string {
+		i, ok := columns[field]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var problems []string
+	product := database.Product{
+		Name:        cell("name"),
+		Description: cell("description"),
+		Category:    cell("category"),
+		ImageURL:    cell("image_url"),
+	}
+
+	if product.Name == "" {
+		problems = append(problems, "name is required")
+	} else if len(product.Name) > 255 {
+		problems = append(problems, "name must be at most 255 characters")
+	}
+
+	if len(product.Category) > 100 {
+		problems = append(problems, "category must be at most 100 characters")
+	}
+
+	if raw := cell("price"); raw == "" {
+		problems = append(problems, "price is required")
+	} else if price, err := strconv.ParseFloat(raw, 64); err != nil {
+		problems = append(problems, fmt.Sprintf("price %q is not a number", raw))
+	} else if price < 0 || price >= 1e8 {
+		problems = append(problems, "price must be between 0 and 99999999.99")
+	} else {
+		product.Price = price
+	}
+
+	if raw := cell("stock"); raw != "" {
+		if stock, err := strconv.Atoi(raw); err != nil {
+			problems = append(problems, fmt.Sprintf("stock %q is not an integer", raw))
+		} else if stock < 0 {
+			problems = append(problems, "stock must not be negative")
+		} else {
+			product.Stock = stock
+		}
+	}
+
+	return product, problems
+}
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"product-service/database"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newImportRequest builds a multipart/form-data upload carrying csvBody in the "file" field
+func newImportRequest(t *testing.T, csvBody string) *http.Request {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", "products.csv")
+	require.NoError(t, err)
+	_, err = part.Write([]byte(csvBody))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	req, _ := http.NewRequest("POST", "/products/import", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+// allProducts returns everything currently stored in repo
+func allProducts(t *testing.T, repo *database.MockProductRepository) []database.Product {
+	products, err := repo.GetAllProducts(context.Background())
+	require.NoError(t, err)
+	return products
+}
+
+func TestImportProducts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("should import every row of a valid CSV", func(t *testing.T) {
+		repo := newTestProductRepository()
+		handler := NewProductHandler(repo, ProductHandlerConfig{})
+
+		router := gin.New()
+		router.POST("/products/import", handler.ImportProducts)
+
+		// Columns in spreadsheet order with mixed-case headers
+		csvBody := "Category,Name,Price,Stock,Description,Image_URL\n" +
+			"Books,Go in Action,29.99,40,\"Kennedy, Ketelsen\",https://example.com/gia.jpg\n" +
+			"Clothing,Wool Socks,8.50,,,\n"
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, newImportRequest(t, csvBody))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response ImportResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 2, response.Imported)
+		assert.Equal(t, 0, response.Rejected)
+		assert.Empty(t, response.Errors)
+
+		require.Len(t, response.Products, 2)
+		assert.Equal(t, 4, response.Products[0].ID)
+		assert.Equal(t, "Go in Action", response.Products[0].Name)
+		assert.Equal(t, "Kennedy, Ketelsen", response.Products[0].Description)
+		assert.Equal(t, 29.99, response.Products[0].Price)
+		assert.Equal(t, 40, response.Products[0].Stock)
+		assert.Equal(t, 0, response.Products[1].Stock)
+
+		assert.Len(t, allProducts(t, repo), 5)
+	})
+
+	t.Run("should report bad rows and import the rest", func(t *testing.T) {
+		repo := newTestProductRepository()
+		handler := NewProductHandler(repo, ProductHandlerConfig{})
+
+		router := gin.New()
+		router.POST("/products/import", handler.ImportProducts)
+
+		csvBody := "name,price,stock\n" +
+			"Desk Lamp,24.00,10\n" +
+			",abc,-1\n" +
+			"Bookshelf,89.90,3\n"
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, newImportRequest(t, csvBody))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response ImportResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 2, response.Imported)
+		assert.Equal(t, 1, response.Rejected)
+
+		require.Len(t, response.Errors, 1)
+		assert.Equal(t, 3, response.Errors[0].Row)
+		assert.ElementsMatch(t, []string{
+			"name is required",
+			`price "abc" is not a number`,
+			"stock must not be negative",
+		}, response.Errors[0].Errors)
+
+		assert.Len(t, allProducts(t, repo), 5)
+	})
+
+	t.Run("should return 422 when every row is invalid", func(t *testing.T) {
+		repo := newTestProductRepository()
+		handler := NewProductHandler(repo, ProductHandlerConfig{})
+
+		router := gin.New()
+		router.POST("/products/import", handler.ImportProducts)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, newImportRequest(t, "name,price\nFree Lunch,-5\n"))
+
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Len(t, allProducts(t, repo), 3)
+	})
+
+	t.Run("should reject a malformed file", func(t *testing.T) {
+		repo := newTestProductRepository()
+		handler := NewProductHandler(repo, ProductHandlerConfig{})
+
+		router := gin.New()
+		router.POST("/products/import", handler.ImportProducts)
+
+		// Unterminated quote on the second data row
+		csvBody := "name,price\nDesk Lamp,24.00\n\"Broken,9.99\n"
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, newImportRequest(t, csvBody))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "Malformed CSV")
+		assert.Len(t, allProducts(t, repo), 3, "Nothing should be imported from a malformed file")
+	})
+
+	t.Run("should reject a header without required columns", func(t *testing.T) {
+		handler := NewProductHandler(newTestProductRepository(), ProductHandlerConfig{})
+
+		router := gin.New()
+		router.POST("/products/import", handler.ImportProducts)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, newImportRequest(t, "name,stock\nDesk Lamp,10\n"))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "price")
+	})
+
+	t.Run("should reject a request without a file", func(t *testing.T) {
+		handler := NewProductHandler(newTestProductRepository(), ProductHandlerConfig{})
+
+		router := gin.New()
+		router.POST("/products/import", handler.ImportProducts)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/products/import", strings.NewReader("name,price\n"))
+		req.Header.Set("Content-Type", "text/csv")
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("should enforce the row cap", func(t *testing.T) {
+		repo := newTestProductRepository()
+		handler := NewProductHandler(repo, ProductHandlerConfig{ImportMaxRows: 2})
+
+		router := gin.New()
+		router.POST("/products/import", handler.ImportProducts)
+
+		csvBody := "name,price\nA,1\nB,2\nC,3\n"
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, newImportRequest(t, csvBody))
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		assert.Len(t, allProducts(t, repo), 3)
+	})
+
+	t.Run("should enforce the size cap", func(t *testing.T) {
+		handler := NewProductHandler(newTestProductRepository(), ProductHandlerConfig{ImportMaxBytes: 1024})
+
+		router := gin.New()
+		router.POST("/products/import", handler.ImportProducts)
+
+		csvBody := "name,price\n" + strings.Repeat("Desk Lamp,24.00\n", 200)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, newImportRequest(t, csvBody))
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+
+	"product-service/internal/apierror"
+	"product-service/internal/openapi"
+
+	"github.com/gin-gonic/gin"
+)
+
+// openAPIRoutes documents the bodies of the product-service routes, keyed by method and gin path
+// Routes are listed in the spec as soon as they are registered; an entry here adds their types
+var openAPIRoutes = map[string]openapi.Route{
+	"GET /products":              {Summary: "List products", Response: []ProductResponse{}, Query: []string{"category", "min_price", "max_price", "include_deleted"}},
+	"GET /products/search":       {Summary: "Search products by name", Response: []ProductResponse{}, Query: []string{"q", "limit"}},
+	"GET /products/categories":   {Summary: "Count products per category", Response: []CategoryCount{}},
+	"GET /products/:id":          {Summary: "Get a product", Response: ProductResponse{}, Query: []string{"include_deleted"}},
+	"GET /products/:id/related":  {Summary: "List other products in the same category", Response: []ProductResponse{}, Query: []string{"limit"}},
+	"DELETE /products/:id":       {Summary: "Soft-delete a product", Status: http.StatusNoContent},
+	"POST /products":             {Summary: "Create a product", Request: CreateProductRequest{}, Response: ProductResponse{}, Status: http.StatusCreated},
+	"POST /products/import":      {Summary: "Import products from a multipart CSV upload (field \"file\")", Response: ImportResponse{}},
+	"POST /products/:id/reserve": {Summary: "Reserve stock", Request: StockRequest{}, Response: StockResponse{}},
+	"POST /products/:id/release": {Summary: "Release reserved stock", Request: StockRequest{}, Response: StockResponse{}},
+	"GET /stress":                {Summary: "Generate artificial load", Response: StressResponse{}, Query: []string{"n", "memory_mb", "workers", "duration", "cpu_load", "deterministic"}},
+	"GET /healthz":               {Summary: "Dependency health check"},
+	"GET /ready":                 {Summary: "Readiness probe", Response: HealthResponse{}},
+	"GET /live":                  {Summary: "Liveness probe", Response: HealthResponse{}},
+	"GET /startup":               {Summary: "Startup probe"},
+	"GET /openapi.json":          {Summary: "This OpenAPI document"},
+}
+
+// OpenAPI handles GET /openapi.json
+// The document is built on the first request from the routes registered on router by then,
+// so it always matches the running server, and is cached afterwards
+// pprof routes are left out since they are not part of the API
+func OpenAPI(router *gin.Engine, version string) gin.HandlerFunc {
+	var (
+		once sync.Once
+		body []byte
+	)
+	return func(c *gin.Context) {
+		once.Do(func() {
+			var routes gin.RoutesInfo
+			for _, route := range router.Routes() {
+				if !strings.HasPrefix(route.Path, pprofPath) {
+					routes = append(routes, route)
+				}
+			}
+			doc := openapi.Build(openapi.Info{Title: "product-service", Version: version}, routes, openAPIRoutes, apierror.APIError{})
+			// Only plain structs, maps and slices; marshalling cannot fail
+			body, _ = json.Marshal(doc)
+		})
+		c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+	}
+}
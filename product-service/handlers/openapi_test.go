@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewProductHandler(newTestProductRepository(), ProductHandlerConfig{})
+	router := gin.New()
+	router.GET("/products", handler.GetProducts)
+	router.GET("/products/:id", handler.GetProductByID)
+	router.POST("/products", handler.CreateProduct)
+	router.GET("/openapi.json", OpenAPI(router, "1.2.3"))
+	// Registered after the OpenAPI route; the document is built on first request, so it is included
+	router.GET("/live", Live)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/openapi.json", nil)
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.True(t, json.Valid(w.Body.Bytes()), "The document should be valid JSON")
+
+	var doc struct {
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+
+	assert.Contains(t, doc.Paths["/products"], "get")
+	assert.Contains(t, doc.Paths["/products"], "post")
+	assert.Contains(t, doc.Paths, "/products/{id}")
+	assert.Contains(t, doc.Paths, "/live")
+	for _, schema := range []string{"Product", "CreateProductRequest", "HealthResponse", "APIError"} {
+		assert.Contains(t, doc.Components.Schemas, schema)
+	}
+}
@@ -1,28 +1,117 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 
 	"product-service/database"
+	"product-service/internal/apierror"
+	"product-service/internal/money"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// productCategoryAttribute is the business dimension attached to request spans
+const productCategoryAttribute = attribute.Key("product.category")
+
+// ProductHandlerConfig holds optional behaviour toggles for the product handlers
+type ProductHandlerConfig struct {
+	// BusinessSpanAttributes adds product.category to request spans so traces can be sliced by category
+	BusinessSpanAttributes bool
+
+	// ImportMaxBytes caps the size of a CSV upload to POST /products/import (0 uses the default)
+	ImportMaxBytes int64
+
+	// ImportMaxRows caps the number of data rows in a CSV upload (0 uses the default)
+	ImportMaxRows int
+
+	// Currency is the ISO 4217 code prices are in, returned with every product ("" uses defaultCurrency)
+	Currency string
+}
+
+// defaultCurrency is the pricing currency when ProductHandlerConfig.Currency is not set
+const defaultCurrency = "USD"
+
+// ProductResponse is a product as returned by the REST API
+// price stays the plain number for programmatic use; currency and price_formatted tell clients
+// which currency it is in and how to display it, so they don't have to assume USD
+type ProductResponse struct {
+	database.Product
+	Currency       string `json:"currency"`
+	PriceFormatted string `json:"price_formatted"`
+}
+
 // ProductHandler handles product-related HTTP requests
 type ProductHandler struct {
 	repository database.ProductRepository
+	config     ProductHandlerConfig
 }
 
 // NewProductHandler creates a new product handler with a repository
-func NewProductHandler(repository database.ProductRepository) *ProductHandler {
+func NewProductHandler(repository database.ProductRepository, config ProductHandlerConfig) *ProductHandler {
 	return &ProductHandler{
 		repository: repository,
+		config:     config,
+	}
+}
+
+// currency returns the configured pricing currency, falling back to the default
+func (h *ProductHandler) currency() string {
+	if h.config.Currency != "" {
+		return h.config.Currency
 	}
+	return defaultCurrency
 }
 
+// productResponse adds the currency and formatted price to a product
+func (h *ProductHandler) productResponse(p database.Product) ProductResponse {
+	currency := h.currency()
+	return ProductResponse{
+		Product:        p,
+		Currency:       currency,
+		PriceFormatted: money.Format(p.Price, currency),
+	}
+}
+
+// productResponses converts products with productResponse; the result is never nil
+func (h *ProductHandler) productResponses(products []database.Product) []ProductResponse {
+	responses := make([]ProductResponse, 0, len(products))
+	for _, p := range products {
+		responses = append(responses, h.productResponse(p))
+	}
+	return responses
+}
+
+// setCategoryAttribute records the product category on the request span when enabled
+// The span is the one started by the tracing middleware, so no extra span is created
+func (h *ProductHandler) setCategoryAttribute(ctx context.Context, category string) {
+	if !h.config.BusinessSpanAttributes || category == "" {
+		return
+	}
+	trace.SpanFromContext(ctx).SetAttributes(productCategoryAttribute.String(category))
+}
+
+// maxProductPrice is the largest price the DECIMAL(10, 2) price column can hold
+// It is the upper bound when only min_price is given
+const maxProductPrice = 99999999.99
+
 // GetProducts handles the GET /products endpoint
-// It retrieves products from PostgreSQL with optional category filtering
+// It retrieves products from PostgreSQL with optional category and price filtering
+// Responses carry a weak ETag and honour If-None-Match with 304 Not Modified
+// Query parameters:
+// - category: Only products in this category
+// - min_price, max_price: Only products priced within these inclusive bounds (either may be omitted)
+// - include_deleted: Admin flag; true also lists soft-deleted products, with deleted_at set
+//   The route gates it behind ADMIN_API_KEY (see middleware.QueryGate)
 func (h *ProductHandler) GetProducts(c *gin.Context) {
 	// Get the current context from Gin (which already has trace context from middleware)
 	ctx := c.Request.Context()
@@ -30,31 +119,237 @@ func (h *ProductHandler) GetProducts(c *gin.Context) {
 	// Check for optional category query parameter
 	category := c.Query("category")
 
+	minPrice, maxPrice, byPrice, err := parsePriceRange(c)
+	if err != nil {
+		apierror.RespondError(c, http.StatusBadRequest, CodeInvalidPriceRange, err.Error())
+		return
+	}
+
+	includeDeleted, err := parseIncludeDeleted(c)
+	if err != nil {
+		apierror.RespondError(c, http.StatusBadRequest, CodeInvalidQuery, err.Error())
+		return
+	}
+
+	// Filtering is shared with the gRPC ListProducts, which never lists deleted products
 	var products []database.Product
-	var err error
+	if includeDeleted {
+		products, err = h.listProductsIncludingDeleted(ctx, category, minPrice, maxPrice, byPrice)
+	} else {
+		products, err = h.listProducts(ctx, category, minPrice, maxPrice, byPrice)
+	}
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to retrieve products")
+		return
+	}
+
+	// Return the products as JSON, or 304 when the client already has this list
+	respondConditionalJSON(c, h.productResponses(products))
+}
 
+// parsePriceRange reads the optional min_price and max_price query parameters
+// byPrice is false when neither is set; a missing bound defaults to 0 or maxProductPrice
+func parsePriceRange(c *gin.Context) (min, max float64, byPrice bool, err error) {
+	parse := func(name string) (*float64, error) {
+		raw := c.Query(name)
+		if raw == "" {
+			return nil, nil
+		}
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+			return nil, fmt.Errorf("%s %q is not a number", name, raw)
+		}
+		return &value, nil
+	}
+
+	minPrice, err := parse("min_price")
+	if err != nil {
+		return 0, 0, false, err
+	}
+	maxPrice, err := parse("max_price")
+	if err != nil {
+		return 0, 0, false, err
+	}
+	return priceRange(minPrice, maxPrice)
+}
+
+// parseIncludeDeleted reads the optional include_deleted admin flag; a missing flag is false
+func parseIncludeDeleted(c *gin.Context) (bool, error) {
+	raw := c.Query("include_deleted")
+	if raw == "" {
+		return false, nil
+	}
+	includeDeleted, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, fmt.Errorf("include_deleted %q is not a boolean", raw)
+	}
+	return includeDeleted, nil
+}
+
+// listProductsIncludingDeleted is listProducts for the admin listing with soft-deleted products
+// Only the full list includes them, so the category and price filters are applied to it in
+// memory and the result keeps its category, then name order
+func (h *ProductHandler) listProductsIncludingDeleted(ctx context.Context, category string, min, max float64, byPrice bool) ([]database.Product, error) {
+	products, err := h.repository.GetAllProductsIncludingDeleted(ctx)
+	if err != nil {
+		return nil, err
+	}
 	if category != "" {
-		// Filter by category
-		products, err = h.repository.GetProductsByCategory(ctx, category)
-	} else {
-		// Get all products
-		products, err = h.repository.GetAllProducts(ctx)
+		h.setCategoryAttribute(ctx, category)
+		products = filterByCategory(products, category)
+	}
+	if byPrice {
+		products = filterByPrice(products, min, max)
+	}
+	return products, nil
+}
+
+// filterByPrice returns the products priced between min and max, inclusive, keeping their order
+func filterByPrice(products []database.Product, min, max float64) []database.Product {
+	filtered := []database.Product{}
+	for _, p := range products {
+		if p.Price >= min && p.Price <= max {
+			filtered = append(filtered, p)
+		}
+	}
+	return filtered
+}
+
+// filterByCategory returns the products in category, keeping their order
+func filterByCategory(products []database.Product, category string) []database.Product {
+	filtered := []database.Product{}
+	for _, p := range products {
+		if p.Category == category {
+			filtered = append(filtered, p)
+		}
+	}
+	return filtered
+}
+
+const (
+	// minSearchQueryLength is the shortest accepted search term, in characters
+	minSearchQueryLength = 2
+	// defaultSearchLimit and maxSearchLimit bound how many search results are returned
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// SearchProducts handles the GET /products/search endpoint
+// It returns products whose name or description contains the search term, case-insensitively
+// Query parameters:
+// - q: Search term (required, at least 2 characters)
+// - limit: Maximum results (default: 20, max: 100)
+func (h *ProductHandler) SearchProducts(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// An explicit limit=0 is rejected here, since searchProducts treats 0 as "use the default"
+	limit := 0
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			apierror.RespondError(c, http.StatusBadRequest, CodeInvalidSearch, fmt.Sprintf("limit must be between 1 and %d", maxSearchLimit))
+			return
+		}
+		limit = parsed
+	}
+
+	// Validation and the search itself are shared with the gRPC SearchProducts
+	products, err := h.searchProducts(ctx, c.Query("q"), limit)
+	if err != nil {
+		respondQueryError(c, err, "Failed to search products")
+		return
 	}
 
+	// Always an array, so clients don't have to handle null for "no matches"
+	c.JSON(http.StatusOK, h.productResponses(products))
+}
+
+// CategoryCount is one entry of the GET /products/categories response
+type CategoryCount struct {
+	Category string `json:"category"`
+	Count    int    `json:"count"`
+}
+
+// GetCategoryCounts handles the GET /products/categories endpoint
+// It returns the number of products in each category, ordered by category name so a
+// storefront sidebar renders in a stable order without fetching and counting the catalog
+// The number of categories is recorded on the request span as product.category.count
+func (h *ProductHandler) GetCategoryCounts(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	counts, err := h.repository.GetCategoryCounts(ctx)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to retrieve products",
-			"message": err.Error(),
-		})
+		respondRepositoryError(c, err, "Failed to count products by category")
 		return
 	}
 
-	// Return the products as JSON
-	c.JSON(http.StatusOK, products)
+	categories := make([]CategoryCount, 0, len(counts))
+	for category, count := range counts {
+		categories = append(categories, CategoryCount{Category: category, Count: count})
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		return categories[i].Category < categories[j].Category
+	})
+
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Int("product.category.count", len(categories)))
+	c.JSON(http.StatusOK, categories)
+}
+
+const (
+	// defaultRelatedLimit and maxRelatedLimit bound how many related products are returned
+	defaultRelatedLimit = 5
+	maxRelatedLimit     = 50
+)
+
+// GetRelatedProducts handles the GET /products/:id/related endpoint
+// It returns other products in the same category as the product, ordered by name, so a
+// product page can show "related products"; 404 when the product doesn't exist, and an empty
+// array when it is alone in its category
+// Query parameters:
+// - limit: Maximum results (default: 5, max: 50)
+func (h *ProductHandler) GetRelatedProducts(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var id int
+	if _, err := fmt.Sscanf(c.Param("id"), "%d", &id); err != nil {
+		apierror.RespondError(c, http.StatusBadRequest, CodeInvalidProductID, "Invalid product ID")
+		return
+	}
+
+	limit := defaultRelatedLimit
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxRelatedLimit {
+			apierror.RespondError(c, http.StatusBadRequest, CodeInvalidQuery, fmt.Sprintf("limit must be between 1 and %d", maxRelatedLimit))
+			return
+		}
+		limit = parsed
+	}
+
+	// Fetched first so an unknown product is a 404 rather than an empty list
+	if _, err := h.getProduct(ctx, id); err != nil {
+		if errors.Is(err, database.ErrProductNotFound) {
+			apierror.RespondError(c, http.StatusNotFound, CodeProductNotFound, "Product not found")
+			return
+		}
+		respondRepositoryError(c, err, "Failed to retrieve product")
+		return
+	}
+
+	related, err := h.repository.GetRelatedProducts(ctx, id, limit)
+	if err != nil {
+		respondRepositoryError(c, err, "Failed to retrieve related products")
+		return
+	}
+
+	// Always an array, so clients don't have to handle null for "no related products"
+	c.JSON(http.StatusOK, h.productResponses(related))
 }
 
 // GetProductByID handles the GET /products/:id endpoint
-// It retrieves a single product by ID
+// It retrieves a single product by ID, with a Last-Modified header from its updated_at
+// so clients can revalidate with If-Modified-Since and get 304 when it has not changed
+// Soft-deleted products are not found unless the include_deleted admin flag is true
 func (h *ProductHandler) GetProductByID(c *gin.Context) {
 	ctx := c.Request.Context()
 	idStr := c.Param("id")
@@ -62,42 +357,179 @@ func (h *ProductHandler) GetProductByID(c *gin.Context) {
 	// Parse ID string to int
 	var id int
 	if _, err := fmt.Sscanf(idStr, "%d", &id); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid product ID",
-		})
+		apierror.RespondError(c, http.StatusBadRequest, CodeInvalidProductID, "Invalid product ID")
 		return
 	}
 
-	product, err := h.repository.GetProductByID(ctx, id)
+	includeDeleted, err := parseIncludeDeleted(c)
 	if err != nil {
-		// Check if product not found (this depends on repository error behavior)
-		// For now, we assume any error is 500 except specific "no rows" if exposed
-		// In a real app, we'd check for sql.ErrNoRows wrapped error
-		if err.Error() == "failed to get product by ID "+idStr+": no rows in result set" { // specific check might be brittle
-			c.JSON(http.StatusNotFound, gin.H{
-				"error": "Product not found",
-			})
+		apierror.RespondError(c, http.StatusBadRequest, CodeInvalidQuery, err.Error())
+		return
+	}
+
+	var product *database.Product
+	if includeDeleted {
+		product, err = h.getProductIncludingDeleted(ctx, id)
+	} else {
+		product, err = h.getProduct(ctx, id)
+	}
+	if err != nil {
+		if errors.Is(err, database.ErrProductNotFound) {
+			apierror.RespondError(c, http.StatusNotFound, CodeProductNotFound, "Product not found")
 			return
 		}
+		respondRepositoryError(c, err, "Failed to retrieve product")
+		return
+	}
 
-		// Improve error handling: check if error message contains "no rows"
-		if contains(err.Error(), "no rows") {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error": "Product not found",
-			})
+	respondLastModifiedJSON(c, product.UpdatedAt, h.productResponse(*product))
+}
+
+// getProductIncludingDeleted is getProduct for the admin path, also returning soft-deleted products
+func (h *ProductHandler) getProductIncludingDeleted(ctx context.Context, id int) (*database.Product, error) {
+	product, err := h.repository.GetProductByIDIncludingDeleted(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("product %d: %w", id, database.ErrProductNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	h.setCategoryAttribute(ctx, product.Category)
+	return product, nil
+}
+
+// DeleteProduct handles the DELETE /products/:id endpoint
+// The product is soft-deleted: it disappears from listings, lookups and stock changes, but its
+// row is kept and stays readable with include_deleted=true, so orders that reference it still resolve
+// Returns 204 No Content, or 404 when the product does not exist or was already deleted
+func (h *ProductHandler) DeleteProduct(c *gin.Context) {
+	ctx := c.Request.Context()
+	idStr := c.Param("id")
+
+	var id int
+	if _, err := fmt.Sscanf(idStr, "%d", &id); err != nil {
+		apierror.RespondError(c, http.StatusBadRequest, CodeInvalidProductID, "Invalid product ID")
+		return
+	}
+
+	if err := h.repository.DeleteProduct(ctx, id); err != nil {
+		if errors.Is(err, database.ErrProductNotFound) {
+			apierror.RespondError(c, http.StatusNotFound, CodeProductNotFound, "Product not found")
 			return
 		}
+		respondRepositoryError(c, err, "Failed to delete product")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// CreateProductRequest is the body for POST /products
+// Limits mirror the products table, like the CSV import
+type CreateProductRequest struct {
+	Name        string  `json:"name" binding:"required,max=255"`
+	Description string  `json:"description"`
+	Price       float64 `json:"price" binding:"min=0,lt=100000000"`
+	Stock       int     `json:"stock" binding:"min=0"`
+	Category    string  `json:"category" binding:"max=100"`
+	ImageURL    string  `json:"image_url"`
+}
+
+// CreateProduct handles the POST /products endpoint
+// It inserts a single product and returns it with its ID, or 409 if the name is already taken
+func (h *ProductHandler) CreateProduct(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req CreateProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondError(c, http.StatusBadRequest, apierror.CodeInvalidRequest, err.Error())
+		return
+	}
 
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to retrieve product",
-			"message": err.Error(),
-		})
+	product := database.Product{
+		Name:        strings.TrimSpace(req.Name),
+		Description: req.Description,
+		Price:       req.Price,
+		Stock:       req.Stock,
+		Category:    req.Category,
+		ImageURL:    req.ImageURL,
+	}
+	if product.Name == "" {
+		apierror.RespondError(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "name is required")
 		return
 	}
 
-	c.JSON(http.StatusOK, product)
+	if err := h.repository.CreateProduct(ctx, &product); err != nil {
+		if errors.Is(err, database.ErrDuplicateProduct) {
+			apierror.RespondErrorDetails(c, http.StatusConflict, CodeProductExists, "Product already exists", gin.H{
+				"name": product.Name,
+			})
+			return
+		}
+		respondRepositoryError(c, err, "Failed to create product")
+		return
+	}
+
+	h.setCategoryAttribute(ctx, product.Category)
+	c.JSON(http.StatusCreated, h.productResponse(product))
+}
+
+// StockRequest is the body for POST /products/:id/reserve and /products/:id/release
+type StockRequest struct {
+	Quantity int `json:"quantity" binding:"required,min=1"`
 }
 
-func contains(s, substr string) bool {
-	return len(s) >= len(substr) && s[0:len(substr)] == substr // simplistic, use strings.Contains
+// StockResponse reports a product's stock after a reservation or release
+type StockResponse struct {
+	ProductID int `json:"product_id"`
+	Stock     int `json:"stock"`
+}
+
+// ReserveStock handles the POST /products/:id/reserve endpoint
+// It takes quantity units out of stock, or fails with 409 if not enough are available
+func (h *ProductHandler) ReserveStock(c *gin.Context) {
+	h.adjustStock(c, h.repository.DecrementStock)
+}
+
+// ReleaseStock handles the POST /products/:id/release endpoint
+// It puts previously reserved units back into stock
+func (h *ProductHandler) ReleaseStock(c *gin.Context) {
+	h.adjustStock(c, h.repository.ReleaseStock)
+}
+
+// adjustStock parses the product ID and quantity, applies the stock change and writes the response
+func (h *ProductHandler) adjustStock(c *gin.Context, adjust func(ctx context.Context, id, quantity int) (int, error)) {
+	ctx := c.Request.Context()
+	idStr := c.Param("id")
+
+	var id int
+	if _, err := fmt.Sscanf(idStr, "%d", &id); err != nil {
+		apierror.RespondError(c, http.StatusBadRequest, CodeInvalidProductID, "Invalid product ID")
+		return
+	}
+
+	var req StockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondError(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "quantity must be a positive integer")
+		return
+	}
+
+	stock, err := adjust(ctx, id, req.Quantity)
+	if err != nil {
+		switch {
+		case errors.Is(err, database.ErrProductNotFound):
+			apierror.RespondError(c, http.StatusNotFound, CodeProductNotFound, "Product not found")
+		case errors.Is(err, database.ErrInsufficientStock):
+			apierror.RespondError(c, http.StatusConflict, CodeInsufficientStock, "Insufficient stock")
+		default:
+			respondRepositoryError(c, err, "Failed to update stock")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, StockResponse{
+		ProductID: id,
+		Stock:     stock,
+	})
 }
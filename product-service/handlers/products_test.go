@@ -1,146 +1,925 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"product-service/database"
+	"product-service/internal/apierror"
+	"product-service/middleware"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
+// slowProductRepository blocks GetAllProducts until the request context is done, like a hung query
+type slowProductRepository struct {
+	*database.MockProductRepository
+}
+
+func (r slowProductRepository) GetAllProducts(ctx context.Context) ([]database.Product, error) {
+	<-ctx.Done()
+	return nil, fmt.Errorf("failed to query products: %w", ctx.Err())
+}
+
+// newTestProductRepository returns an in-memory repository seeded with a small catalog
+func newTestProductRepository() *database.MockProductRepository {
+	return database.NewMockProductRepository(
+		database.Product{ID: 1, Name: "MacBook Pro 16\"", Description: "Apple M3 Max laptop", Price: 3499.00, Stock: 25, Category: "Electronics", ImageURL: "https://example.com/macbook.jpg"},
+		database.Product{ID: 2, Name: "Cotton T-Shirt", Description: "Plain white tee", Price: 19.99, Stock: 200, Category: "Clothing", ImageURL: "https://example.com/tshirt.jpg"},
+		database.Product{ID: 3, Name: "The Go Programming Language", Description: "Donovan & Kernighan", Price: 39.99, Stock: 50, Category: "Books", ImageURL: "https://example.com/gopl.jpg"},
+	)
+}
+
 func TestGetProducts(t *testing.T) {
 	// Set Gin to test mode
 	gin.SetMode(gin.TestMode)
 
-	t.Run("should return 200 OK", func(t *testing.T) {
+	t.Run("should return all products", func(t *testing.T) {
+		handler := NewProductHandler(newTestProductRepository(), ProductHandlerConfig{})
+
 		// Create test router and recorder
 		router := gin.New()
-		router.GET("/products", GetProducts)
+		router.GET("/products", handler.GetProducts)
 		w := httptest.NewRecorder()
 		req, _ := http.NewRequest("GET", "/products", nil)
 
 		// Execute request
 		router.ServeHTTP(w, req)
 
-		// Assert status code
+		// Assert status code and valid JSON array
 		assert.Equal(t, http.StatusOK, w.Code)
+
+		var products []database.Product
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &products), "Response should be valid JSON")
+		assert.Len(t, products, 3)
+	})
+
+	t.Run("should filter by category", func(t *testing.T) {
+		handler := NewProductHandler(newTestProductRepository(), ProductHandlerConfig{})
+
+		router := gin.New()
+		router.GET("/products", handler.GetProducts)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/products?category=Books", nil)
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var products []database.Product
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &products))
+		require.Len(t, products, 1)
+		assert.Equal(t, "Books", products[0].Category)
 	})
 
-	t.Run("should return valid JSON array", func(t *testing.T) {
+	t.Run("should filter by price range", func(t *testing.T) {
+		tests := []struct {
+			query    string
+			expected []string
+		}{
+			{"min_price=20&max_price=100", []string{"The Go Programming Language"}},
+			{"min_price=19.99&max_price=39.99", []string{"Cotton T-Shirt", "The Go Programming Language"}},
+			{"min_price=100", []string{"MacBook Pro 16\""}},
+			{"max_price=50", []string{"Cotton T-Shirt", "The Go Programming Language"}},
+			{"max_price=50&category=Books", []string{"The Go Programming Language"}},
+			{"min_price=1000&category=Books", []string{}},
+		}
+
+		for _, tt := range tests {
+			handler := NewProductHandler(newTestProductRepository(), ProductHandlerConfig{})
+
+			router := gin.New()
+			router.GET("/products", handler.GetProducts)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/products?"+tt.query, nil)
+
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusOK, w.Code, tt.query)
+			var products []database.Product
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &products))
+			names := []string{}
+			for _, p := range products {
+				names = append(names, p.Name)
+			}
+			assert.Equal(t, tt.expected, names, tt.query)
+		}
+	})
+
+	t.Run("should reject invalid price ranges", func(t *testing.T) {
+		for _, query := range []string{"min_price=abc", "max_price=NaN", "min_price=-1", "max_price=-5", "min_price=50&max_price=10"} {
+			handler := NewProductHandler(newTestProductRepository(), ProductHandlerConfig{})
+
+			router := gin.New()
+			router.GET("/products", handler.GetProducts)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/products?"+query, nil)
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		}
+	})
+
+	t.Run("should return 504 once the handler deadline passes", func(t *testing.T) {
+		handler := NewProductHandler(slowProductRepository{newTestProductRepository()}, ProductHandlerConfig{})
+
 		router := gin.New()
-		router.GET("/products", GetProducts)
+		router.Use(middleware.TimeoutMiddleware(middleware.TimeoutConfig{Timeout: 50 * time.Millisecond}))
+		router.GET("/products", handler.GetProducts)
 		w := httptest.NewRecorder()
 		req, _ := http.NewRequest("GET", "/products", nil)
 
+		start := time.Now()
 		router.ServeHTTP(w, req)
 
-		// Assert valid JSON array
-		var products []Product
-		err := json.Unmarshal(w.Body.Bytes(), &products)
-		require.NoError(t, err, "Response should be valid JSON")
+		assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+		assert.Contains(t, w.Body.String(), `"code":"`+apierror.CodeTimeout+`"`)
+		assert.Less(t, time.Since(start), 5*time.Second)
 	})
 
-	t.Run("should return at least 10 products", func(t *testing.T) {
+	t.Run("should return 500 when the repository fails", func(t *testing.T) {
+		repo := newTestProductRepository()
+		repo.InjectError("GetAllProducts", fmt.Errorf("connection refused"))
+		handler := NewProductHandler(repo, ProductHandlerConfig{})
+
 		router := gin.New()
-		router.GET("/products", GetProducts)
+		router.GET("/products", handler.GetProducts)
 		w := httptest.NewRecorder()
 		req, _ := http.NewRequest("GET", "/products", nil)
 
 		router.ServeHTTP(w, req)
 
-		var products []Product
-		json.Unmarshal(w.Body.Bytes(), &products)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+func TestGetProductsETag(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo := newTestProductRepository()
+	handler := NewProductHandler(repo, ProductHandlerConfig{})
+	router := gin.New()
+	router.GET("/products", handler.GetProducts)
+
+	get := func(path, ifNoneMatch string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	first := get("/products", "")
+	require.Equal(t, http.StatusOK, first.Code)
+	etag := first.Header().Get("ETag")
+	require.True(t, strings.HasPrefix(etag, `W/"`), "ETag should be weak, got %q", etag)
+
+	t.Run("should return 304 without a body for a matching If-None-Match", func(t *testing.T) {
+		w := get("/products", etag)
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Body.String())
+		assert.Equal(t, etag, w.Header().Get("ETag"))
+	})
+
+	t.Run("should match within a list and with the strong form of the tag", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotModified, get("/products", `"other", `+etag).Code)
+		assert.Equal(t, http.StatusNotModified, get("/products", strings.TrimPrefix(etag, "W/")).Code)
+		assert.Equal(t, http.StatusNotModified, get("/products", "*").Code)
+	})
+
+	t.Run("should tag each filtered list separately", func(t *testing.T) {
+		w := get("/products?category=Books", etag)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotEqual(t, etag, w.Header().Get("ETag"))
+	})
+
+	t.Run("should return the new list once the catalog changes", func(t *testing.T) {
+		require.NoError(t, repo.CreateProduct(context.Background(), &database.Product{Name: "Desk Lamp", Price: 25, Stock: 10, Category: "Home"}))
 
-		// Assert at least 10 products
-		assert.GreaterOrEqual(t, len(products), 10, "Should return at least 10 products")
+		w := get("/products", etag)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotEqual(t, etag, w.Header().Get("ETag"))
+		var products []database.Product
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &products))
+		assert.Len(t, products, 4)
 	})
+}
+
+func TestGetProductByID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedCode   string
+	}{
+		{"existing product", "/products/2", http.StatusOK, ""},
+		{"unknown product", "/products/999", http.StatusNotFound, CodeProductNotFound},
+		{"non-numeric ID", "/products/abc", http.StatusBadRequest, CodeInvalidProductID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewProductHandler(newTestProductRepository(), ProductHandlerConfig{})
+
+			router := gin.New()
+			router.GET("/products/:id", handler.GetProductByID)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", tt.path, nil)
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedCode != "" {
+				assert.Contains(t, w.Body.String(), `"code":"`+tt.expectedCode+`"`)
+			}
+		})
+	}
+}
+
+func TestProductCurrency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name              string
+		currency          string
+		path              string
+		expectedCurrency  string
+		expectedFormatted string
+	}{
+		{"defaults to USD", "", "/products/1", "USD", "$3,499.00"},
+		{"configured EUR", "EUR", "/products/2", "EUR", "€19.99"},
+		{"zero-decimal JPY", "JPY", "/products/1", "JPY", "¥3,499"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewProductHandler(newTestProductRepository(), ProductHandlerConfig{Currency: tt.currency})
+
+			router := gin.New()
+			router.GET("/products/:id", handler.GetProductByID)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", tt.path, nil)
+
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusOK, w.Code)
+			var product ProductResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &product))
+			assert.Equal(t, tt.expectedCurrency, product.Currency)
+			assert.Equal(t, tt.expectedFormatted, product.PriceFormatted)
+			// The numeric price is kept as it was
+			assert.NotZero(t, product.Price)
+		})
+	}
+
+	t.Run("list responses carry the currency too", func(t *testing.T) {
+		handler := NewProductHandler(newTestProductRepository(), ProductHandlerConfig{Currency: "GBP"})
 
-	t.Run("each product should have required fields", func(t *testing.T) {
 		router := gin.New()
-		router.GET("/products", GetProducts)
+		router.GET("/products", handler.GetProducts)
 		w := httptest.NewRecorder()
-		req, _ := http.NewRequest("GET", "/products", nil)
+		req, _ := http.NewRequest("GET", "/products?category=Books", nil)
 
 		router.ServeHTTP(w, req)
 
-		var products []Product
-		json.Unmarshal(w.Body.Bytes(), &products)
+		require.Equal(t, http.StatusOK, w.Code)
+		var products []ProductResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &products))
+		require.Len(t, products, 1)
+		assert.Equal(t, 39.99, products[0].Price)
+		assert.Equal(t, "GBP", products[0].Currency)
+		assert.Equal(t, "£39.99", products[0].PriceFormatted)
+	})
+}
+
+func TestGetProductByIDLastModified(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Stored in a non-UTC zone with sub-second precision, like a timestamptz read back by pgx
+	updatedAt := time.Date(2026, 3, 10, 14, 30, 15, 750_000_000, time.FixedZone("CET", 3600))
+	repo := database.NewMockProductRepository(
+		database.Product{ID: 1, Name: "Desk Lamp", Price: 25, Stock: 10, Category: "Home", UpdatedAt: updatedAt},
+	)
+	handler := NewProductHandler(repo, ProductHandlerConfig{})
+	router := gin.New()
+	router.GET("/products/:id", handler.GetProductByID)
+
+	get := func(ifModifiedSince string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/products/1", nil)
+		if ifModifiedSince != "" {
+			req.Header.Set("If-Modified-Since", ifModifiedSince)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	first := get("")
+	require.Equal(t, http.StatusOK, first.Code)
+	lastModified := first.Header().Get("Last-Modified")
+	assert.Equal(t, "Tue, 10 Mar 2026 13:30:15 GMT", lastModified)
+
+	t.Run("should return 304 without a body when unchanged since the given time", func(t *testing.T) {
+		w := get(lastModified)
+
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Body.String())
+		assert.Equal(t, lastModified, w.Header().Get("Last-Modified"))
 
-		// Check first product has all required fields
-		require.NotEmpty(t, products, "Products array should not be empty")
-		
-		product := products[0]
-		assert.NotZero(t, product.ID, "Product ID should not be zero")
-		assert.NotEmpty(t, product.Name, "Product name should not be empty")
-		assert.NotEmpty(t, product.Description, "Product description should not be empty")
-		assert.Greater(t, product.Price, 0.0, "Product price should be positive")
-		assert.NotEmpty(t, product.ImageURL, "Product image URL should not be empty")
+		assert.Equal(t, http.StatusNotModified, get("Tue, 10 Mar 2026 13:31:00 GMT").Code)
 	})
 
-	t.Run("all products should have unique IDs", func(t *testing.T) {
+	t.Run("should return the product when it changed after the given time", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, get("Tue, 10 Mar 2026 13:30:14 GMT").Code)
+	})
+
+	t.Run("should ignore an unparseable If-Modified-Since", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, get("yesterday").Code)
+	})
+
+	t.Run("should return newer data after an update", func(t *testing.T) {
+		_, err := repo.DecrementStock(context.Background(), 1, 2)
+		require.NoError(t, err)
+
+		w := get(lastModified)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotEqual(t, lastModified, w.Header().Get("Last-Modified"))
+		var product database.Product
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &product))
+		assert.Equal(t, 8, product.Stock)
+	})
+
+	t.Run("should omit Last-Modified when the product has no updated_at", func(t *testing.T) {
 		router := gin.New()
-		router.GET("/products", GetProducts)
+		router.GET("/products/:id", NewProductHandler(newTestProductRepository(), ProductHandlerConfig{}).GetProductByID)
 		w := httptest.NewRecorder()
-		req, _ := http.NewRequest("GET", "/products", nil)
+		req, _ := http.NewRequest("GET", "/products/2", nil)
+		req.Header.Set("If-Modified-Since", "Tue, 10 Mar 2026 13:30:15 GMT")
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Last-Modified"))
+	})
+}
+
+func TestSearchProducts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	search := func(t *testing.T, repo *database.MockProductRepository, rawQuery string) *httptest.ResponseRecorder {
+		handler := NewProductHandler(repo, ProductHandlerConfig{})
+
+		router := gin.New()
+		router.GET("/products/search", handler.SearchProducts)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/products/search?"+rawQuery, nil)
 
 		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should match name and description case-insensitively", func(t *testing.T) {
+		w := search(t, newTestProductRepository(), "q=MACBOOK")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var products []database.Product
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &products))
+		require.Len(t, products, 1)
+		assert.Equal(t, 1, products[0].ID)
+
+		w = search(t, newTestProductRepository(), "q=kernighan")
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &products))
+		require.Len(t, products, 1)
+		assert.Equal(t, 3, products[0].ID)
+	})
 
-		var products []Product
-		json.Unmarshal(w.Body.Bytes(), &products)
+	t.Run("should return an empty array when nothing matches", func(t *testing.T) {
+		w := search(t, newTestProductRepository(), "q=zeppelin")
 
-		// Create map to track IDs
-		idMap := make(map[int]bool)
-		for _, product := range products {
-			assert.False(t, idMap[product.ID], "Product ID %d should be unique", product.ID)
-			idMap[product.ID] = true
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, "[]", w.Body.String())
+	})
+
+	t.Run("should apply the limit", func(t *testing.T) {
+		w := search(t, newTestProductRepository(), "q=th&limit=1")
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var products []database.Product
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &products))
+		assert.Len(t, products, 1)
+	})
+
+	t.Run("should reject short or missing queries and bad limits", func(t *testing.T) {
+		for _, rawQuery := range []string{"", "q=", "q=a", "q=%20a%20", "q=mac&limit=0", "q=mac&limit=101", "q=mac&limit=ten"} {
+			w := search(t, newTestProductRepository(), rawQuery)
+			assert.Equal(t, http.StatusBadRequest, w.Code, "query %q", rawQuery)
 		}
 	})
 
-	t.Run("should have valid price range", func(t *testing.T) {
+	t.Run("should return 500 when the repository fails", func(t *testing.T) {
+		repo := newTestProductRepository()
+		repo.InjectError("SearchProducts", fmt.Errorf("connection refused"))
+
+		w := search(t, repo, "q=mac")
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+func TestGetCategoryCounts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	countCategories := func(t *testing.T, repo *database.MockProductRepository) *httptest.ResponseRecorder {
+		handler := NewProductHandler(repo, ProductHandlerConfig{})
+
 		router := gin.New()
-		router.GET("/products", GetProducts)
+		router.GET("/products/categories", handler.GetCategoryCounts)
 		w := httptest.NewRecorder()
-		req, _ := http.NewRequest("GET", "/products", nil)
+		req, _ := http.NewRequest("GET", "/products/categories", nil)
+
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should count products per category ordered by category", func(t *testing.T) {
+		repo := database.NewSeededMockProductRepository()
+
+		w := countCategories(t, repo)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `[
+			{"category":"Books","count":3},
+			{"category":"Clothing","count":4},
+			{"category":"Electronics","count":5},
+			{"category":"Home & Garden","count":4}
+		]`, w.Body.String())
+	})
+
+	t.Run("should return an empty array for an empty catalog", func(t *testing.T) {
+		w := countCategories(t, database.NewMockProductRepository())
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, "[]", w.Body.String())
+	})
+
+	t.Run("should record the number of categories on the request span", func(t *testing.T) {
+		recorder := tracetest.NewSpanRecorder()
+		provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+		handler := NewProductHandler(newTestProductRepository(), ProductHandlerConfig{})
+
+		router := gin.New()
+		router.Use(func(c *gin.Context) {
+			ctx, span := provider.Tracer("test").Start(c.Request.Context(), "request")
+			defer span.End()
+			c.Request = c.Request.WithContext(ctx)
+			c.Next()
+		})
+		router.GET("/products/categories", handler.GetCategoryCounts)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/products/categories", nil)
+
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		spans := recorder.Ended()
+		require.Len(t, spans, 1)
+		assert.Contains(t, spans[0].Attributes(), attribute.Int("product.category.count", 3))
+	})
+
+	t.Run("should return 500 when the repository fails", func(t *testing.T) {
+		repo := newTestProductRepository()
+		repo.InjectError("GetCategoryCounts", fmt.Errorf("connection refused"))
+
+		w := countCategories(t, repo)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+func TestGetRelatedProducts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRepo := func() *database.MockProductRepository {
+		return database.NewMockProductRepository(
+			database.Product{ID: 1, Name: "Laptop", Price: 999, Category: "Electronics"},
+			database.Product{ID: 2, Name: "Mouse", Price: 25, Category: "Electronics"},
+			database.Product{ID: 3, Name: "Keyboard", Price: 75, Category: "Electronics"},
+			database.Product{ID: 4, Name: "Headphones", Price: 150, Category: "Electronics"},
+			database.Product{ID: 5, Name: "Cookbook", Price: 30, Category: "Books"},
+		)
+	}
+	related := func(t *testing.T, repo *database.MockProductRepository, path string) *httptest.ResponseRecorder {
+		handler := NewProductHandler(repo, ProductHandlerConfig{})
+
+		router := gin.New()
+		router.GET("/products/:id/related", handler.GetRelatedProducts)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+
+		router.ServeHTTP(w, req)
+		return w
+	}
+	names := func(t *testing.T, w *httptest.ResponseRecorder) []string {
+		var products []ProductResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &products))
+		names := make([]string, 0, len(products))
+		for _, p := range products {
+			names = append(names, p.Name)
+		}
+		return names
+	}
+
+	t.Run("should return other products in the category ordered by name", func(t *testing.T) {
+		w := related(t, newRepo(), "/products/1/related")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, []string{"Headphones", "Keyboard", "Mouse"}, names(t, w))
+	})
+
+	t.Run("should apply the limit", func(t *testing.T) {
+		w := related(t, newRepo(), "/products/1/related?limit=2")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, []string{"Headphones", "Keyboard"}, names(t, w))
+	})
+
+	t.Run("should return an empty array for a product alone in its category", func(t *testing.T) {
+		w := related(t, newRepo(), "/products/5/related")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `[]`, w.Body.String())
+	})
+
+	t.Run("should return 404 for an unknown or deleted product", func(t *testing.T) {
+		repo := newRepo()
+		require.NoError(t, repo.DeleteProduct(context.Background(), 2))
+
+		for _, path := range []string{"/products/999/related", "/products/2/related"} {
+			w := related(t, repo, path)
+			assert.Equal(t, http.StatusNotFound, w.Code, path)
+			assert.Contains(t, w.Body.String(), `"code":"`+CodeProductNotFound+`"`, path)
+		}
+
+		w := related(t, repo, "/products/1/related")
+		assert.Equal(t, []string{"Headphones", "Keyboard"}, names(t, w), "Deleted products are not related")
+	})
+
+	t.Run("should reject invalid IDs and limits", func(t *testing.T) {
+		tests := []struct {
+			path string
+			code string
+		}{
+			{"/products/abc/related", CodeInvalidProductID},
+			{"/products/1/related?limit=0", CodeInvalidQuery},
+			{"/products/1/related?limit=51", CodeInvalidQuery},
+			{"/products/1/related?limit=many", CodeInvalidQuery},
+		}
+
+		for _, tt := range tests {
+			w := related(t, newRepo(), tt.path)
+			assert.Equal(t, http.StatusBadRequest, w.Code, tt.path)
+			assert.Contains(t, w.Body.String(), `"code":"`+tt.code+`"`, tt.path)
+		}
+	})
+
+	t.Run("should return 500 when the repository fails", func(t *testing.T) {
+		repo := newRepo()
+		repo.InjectError("GetRelatedProducts", fmt.Errorf("connection refused"))
+
+		w := related(t, repo, "/products/1/related")
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+func TestDeleteProduct(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(repo database.ProductRepository) *gin.Engine {
+		handler := NewProductHandler(repo, ProductHandlerConfig{})
+		router := gin.New()
+		router.GET("/products", handler.GetProducts)
+		router.GET("/products/:id", handler.GetProductByID)
+		router.DELETE("/products/:id", handler.DeleteProduct)
+		return router
+	}
+	do := func(router *gin.Engine, method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+	productIDs := func(t *testing.T, w *httptest.ResponseRecorder) []int {
+		require.Equal(t, http.StatusOK, w.Code)
+		var products []database.Product
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &products))
+		ids := []int{}
+		for _, p := range products {
+			ids = append(ids, p.ID)
+		}
+		return ids
+	}
+
+	t.Run("should hide a deleted product from normal listings and lookups", func(t *testing.T) {
+		router := newRouter(newTestProductRepository())
+
+		w := do(router, "DELETE", "/products/1")
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Empty(t, w.Body.String())
+
+		assert.ElementsMatch(t, []int{2, 3}, productIDs(t, do(router, "GET", "/products")))
+		assert.Empty(t, productIDs(t, do(router, "GET", "/products?category=Electronics")))
+		assert.Equal(t, http.StatusNotFound, do(router, "GET", "/products/1").Code)
+	})
+
+	t.Run("should keep a deleted product fetchable on the admin path", func(t *testing.T) {
+		router := newRouter(newTestProductRepository())
+		require.Equal(t, http.StatusNoContent, do(router, "DELETE", "/products/1").Code)
+
+		w := do(router, "GET", "/products/1?include_deleted=true")
+		require.Equal(t, http.StatusOK, w.Code)
+		var product database.Product
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &product))
+		assert.Equal(t, "MacBook Pro 16\"", product.Name)
+		require.NotNil(t, product.DeletedAt)
+
+		assert.ElementsMatch(t, []int{1, 2, 3}, productIDs(t, do(router, "GET", "/products?include_deleted=true")))
+		assert.Equal(t, []int{1}, productIDs(t, do(router, "GET", "/products?include_deleted=true&category=Electronics")))
+		assert.Equal(t, []int{2}, productIDs(t, do(router, "GET", "/products?include_deleted=true&max_price=20")))
+	})
+
+	t.Run("should leave deleted_at out for live products", func(t *testing.T) {
+		router := newRouter(newTestProductRepository())
+
+		w := do(router, "GET", "/products/2?include_deleted=true")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), "deleted_at")
+	})
+
+	t.Run("should return 404 for missing or already deleted products", func(t *testing.T) {
+		router := newRouter(newTestProductRepository())
+
+		assert.Equal(t, http.StatusNotFound, do(router, "DELETE", "/products/999").Code)
+		require.Equal(t, http.StatusNoContent, do(router, "DELETE", "/products/1").Code)
+		assert.Equal(t, http.StatusNotFound, do(router, "DELETE", "/products/1").Code)
+		assert.Equal(t, http.StatusNotFound, do(router, "GET", "/products/999?include_deleted=true").Code)
+	})
+
+	t.Run("should reject bad IDs and flags", func(t *testing.T) {
+		router := newRouter(newTestProductRepository())
+
+		assert.Equal(t, http.StatusBadRequest, do(router, "DELETE", "/products/abc").Code)
+
+		w := do(router, "GET", "/products?include_deleted=maybe")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		var apiErr apierror.APIError
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &apiErr))
+		assert.Equal(t, CodeInvalidQuery, apiErr.Code)
+	})
+
+	t.Run("should return 500 when the repository fails", func(t *testing.T) {
+		repo := newTestProductRepository()
+		repo.InjectError("DeleteProduct", fmt.Errorf("connection refused"))
+
+		assert.Equal(t, http.StatusInternalServerError, do(newRouter(repo), "DELETE", "/products/1").Code)
+	})
+}
+
+func TestReserveStock(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	reserve := func(t *testing.T, repo *database.MockProductRepository, path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		handler := NewProductHandler(repo, ProductHandlerConfig{})
+
+		router := gin.New()
+		router.POST("/products/:id/reserve", handler.ReserveStock)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should decrement stock", func(t *testing.T) {
+		w := reserve(t, newTestProductRepository(), "/products/1/reserve", `{"quantity": 5}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response StockResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, StockResponse{ProductID: 1, Stock: 20}, response)
+	})
+
+	t.Run("should return 409 and leave stock alone when it is insufficient", func(t *testing.T) {
+		repo := newTestProductRepository()
+
+		w := reserve(t, repo, "/products/1/reserve", `{"quantity": 26}`)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), `"code":"`+CodeInsufficientStock+`"`)
+
+		product, err := repo.GetProductByID(context.Background(), 1)
+		require.NoError(t, err)
+		assert.Equal(t, 25, product.Stock)
+	})
+
+	t.Run("should not oversell under concurrent reservations", func(t *testing.T) {
+		repo := newTestProductRepository()
+
+		// 25 in stock: exactly five of ten concurrent reservations of 5 can succeed
+		var wg sync.WaitGroup
+		codes := make(chan int, 10)
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				codes <- reserve(t, repo, "/products/1/reserve", `{"quantity": 5}`).Code
+			}()
+		}
+		wg.Wait()
+		close(codes)
+
+		counts := map[int]int{}
+		for code := range codes {
+			counts[code]++
+		}
+		assert.Equal(t, map[int]int{http.StatusOK: 5, http.StatusConflict: 5}, counts)
+
+		product, err := repo.GetProductByID(context.Background(), 1)
+		require.NoError(t, err)
+		assert.Equal(t, 0, product.Stock)
+	})
+
+	t.Run("should reject bad requests", func(t *testing.T) {
+		tests := []struct {
+			path           string
+			body           string
+			expectedStatus int
+		}{
+			{"/products/abc/reserve", `{"quantity": 1}`, http.StatusBadRequest},
+			{"/products/1/reserve", `{"quantity": 0}`, http.StatusBadRequest},
+			{"/products/1/reserve", `{}`, http.StatusBadRequest},
+			{"/products/999/reserve", `{"quantity": 1}`, http.StatusNotFound},
+		}
+
+		for _, tt := range tests {
+			w := reserve(t, newTestProductRepository(), tt.path, tt.body)
+			assert.Equal(t, tt.expectedStatus, w.Code, "%s %s", tt.path, tt.body)
+		}
+	})
+}
+
+func TestReleaseStock(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(repo *database.MockProductRepository) *gin.Engine {
+		handler := NewProductHandler(repo, ProductHandlerConfig{})
+
+		router := gin.New()
+		router.POST("/products/:id/reserve", handler.ReserveStock)
+		router.POST("/products/:id/release", handler.ReleaseStock)
+		return router
+	}
+
+	post := func(router *gin.Engine, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
 
 		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should return released units to stock", func(t *testing.T) {
+		router := newRouter(newTestProductRepository())
 
-		var products []Product
-		json.Unmarshal(w.Body.Bytes(), &products)
+		require.Equal(t, http.StatusOK, post(router, "/products/1/reserve", `{"quantity": 5}`).Code)
+		w := post(router, "/products/1/release", `{"quantity": 5}`)
 
-		// All prices should be positive and reasonable
-		for _, product := range products {
-			assert.Greater(t, product.Price, 0.0, "Price should be positive")
-			assert.Less(t, product.Price, 10000.0, "Price should be reasonable")
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response StockResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, StockResponse{ProductID: 1, Stock: 25}, response)
+	})
+
+	t.Run("should reject bad requests", func(t *testing.T) {
+		tests := []struct {
+			path           string
+			body           string
+			expectedStatus int
+		}{
+			{"/products/abc/release", `{"quantity": 1}`, http.StatusBadRequest},
+			{"/products/1/release", `{"quantity": 0}`, http.StatusBadRequest},
+			{"/products/1/release", `{}`, http.StatusBadRequest},
+			{"/products/999/release", `{"quantity": 1}`, http.StatusNotFound},
+		}
+
+		for _, tt := range tests {
+			w := post(newRouter(newTestProductRepository()), tt.path, tt.body)
+			assert.Equal(t, tt.expectedStatus, w.Code, "%s %s", tt.path, tt.body)
 		}
 	})
+
+	t.Run("should return 500 when the repository fails", func(t *testing.T) {
+		repo := newTestProductRepository()
+		repo.InjectError("ReleaseStock", fmt.Errorf("connection refused"))
+
+		assert.Equal(t, http.StatusInternalServerError, post(newRouter(repo), "/products/1/release", `{"quantity": 1}`).Code)
+	})
 }
 
-func TestMockProducts(t *testing.T) {
-	t.Run("should return consistent data", func(t *testing.T) {
-		// Call mockProducts multiple times
-		products1 := mockProducts()
-		products2 := mockProducts()
+func TestCreateProduct(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	create := func(t *testing.T, repo *database.MockProductRepository, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		handler := NewProductHandler(repo, ProductHandlerConfig{})
+
+		router := gin.New()
+		router.POST("/products", handler.CreateProduct)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/products", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should create a product", func(t *testing.T) {
+		repo := newTestProductRepository()
 
-		// Should return the same data each time
-		assert.Equal(t, len(products1), len(products2))
-		assert.Equal(t, products1[0].ID, products2[0].ID)
-		assert.Equal(t, products1[0].Name, products2[0].Name)
+		w := create(t, repo, `{"name": "Desk Lamp", "price": 24.99, "stock": 10, "category": "Home & Garden"}`)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var product database.Product
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &product))
+		assert.Equal(t, 4, product.ID)
+		assert.Equal(t, "Desk Lamp", product.Name)
 	})
 
-	t.Run("should have at least 10 products", func(t *testing.T) {
-		products := mockProducts()
-		assert.GreaterOrEqual(t, len(products), 10)
+	t.Run("should return 409 for a duplicate name", func(t *testing.T) {
+		repo := newTestProductRepository()
+
+		w := create(t, repo, `{"name": "Cotton T-Shirt", "price": 9.99}`)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		var apiErr apierror.APIError
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &apiErr))
+		assert.Equal(t, CodeProductExists, apiErr.Code)
+		assert.Equal(t, "Product already exists", apiErr.Message)
+
+		products, err := repo.GetAllProducts(context.Background())
+		require.NoError(t, err)
+		assert.Len(t, products, 3, "The duplicate should not be stored")
+	})
+
+	t.Run("should reject invalid bodies", func(t *testing.T) {
+		for _, body := range []string{`{}`, `{"name": "  ", "price": 1}`, `{"name": "Lamp", "price": -1}`, `{"name": "Lamp", "stock": -1}`, `not json`} {
+			w := create(t, newTestProductRepository(), body)
+			assert.Equal(t, http.StatusBadRequest, w.Code, "body %s", body)
+		}
+	})
+
+	t.Run("should return 500 when the repository fails", func(t *testing.T) {
+		repo := newTestProductRepository()
+		repo.InjectError("CreateProduct", fmt.Errorf("connection refused"))
+
+		w := create(t, repo, `{"name": "Desk Lamp", "price": 24.99}`)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
 	})
 }
 
 // Benchmark test to measure performance
 func BenchmarkGetProducts(b *testing.B) {
 	gin.SetMode(gin.TestMode)
+	handler := NewProductHandler(newTestProductRepository(), ProductHandlerConfig{})
 	router := gin.New()
-	router.GET("/products", GetProducts)
+	router.GET("/products", handler.GetProducts)
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
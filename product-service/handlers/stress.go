@@ -1,14 +1,21 @@
 package handlers
 
 import (
+	"context"
+	"fmt"
+	"math"
 	"net/http"
 	"strconv"
 	"time"
 
+	"product-service/internal/apierror"
+	"product-service/internal/stress"
+
 	"github.com/gin-gonic/gin"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // StressResponse represents the response from the stress test endpoint
@@ -17,24 +24,138 @@ type StressResponse struct {
 	Result          uint64 `json:"result"`
 	ComputationTime string `json:"computation_time"`
 	Message         string `json:"message"`
+	MemoryMB        int    `json:"memory_mb"`
+	Workers         int    `json:"workers"`
+	CPULoad         bool   `json:"cpu_load"`
+	// Set only for ?duration= requests
+	RequestedDuration string `json:"requested_duration,omitempty"`
+	// Set for ?duration= and ?deterministic=true requests
+	Iterations    int  `json:"iterations,omitempty"`
+	Deterministic bool `json:"deterministic,omitempty"`
 }
 
-// fibonacci calculates the nth Fibonacci number recursively
-// This is intentionally inefficient for CPU stress testing
-// Time complexity: O(2^n) - exponential growth
+// maxStressDuration caps ?duration= on a full core so a single request can't pin a core indefinitely
+const maxStressDuration = 60 * time.Second
+
+// stressWorkUnit is the Fibonacci input recomputed on each ?duration= iteration
+// Small enough (~1ms) that the loop overshoots the deadline by very little
+const stressWorkUnit = 25
+
+// deterministicIterations is how many times each worker recomputes slowFibonacci(stressWorkUnit)
+// with ?deterministic=true, roughly 100ms of CPU per worker
+const deterministicIterations = 100
+
+// maxFibonacciInput is the largest n whose Fibonacci number fits in a uint64
+const maxFibonacciInput = 93
+
+// maxCPULoadInput caps n for ?cpu_load=true, where the work grows exponentially
+// Fibonacci(50) takes several minutes on a single CPU core
+const maxCPULoadInput = 50
+
+// StressLimits caps what a single /stress request may ask for
+type StressLimits struct {
+	// MaxDuration caps duration
+	MaxDuration time.Duration
+	// MaxCPULoadInput caps n with cpu_load; n without it stays capped at maxFibonacciInput
+	MaxCPULoadInput int
+	// MaxWorkers caps workers, including the GOMAXPROCS default
+	MaxWorkers int
+}
+
+// DefaultStressLimits scales the caps to cpus, the CPUs the pod may use (see stress.AvailableCPUs)
+// Below one core a capped request should take about as long as it does on a full core, instead of
+// starving the probes: duration shrinks in proportion, and n drops by one for every factor of
+// the golden ratio, which is how much each step of the exponential recursion costs
+// workers is capped at cpus rounded up, since extra goroutines only queue for the same quota
+func DefaultStressLimits(cpus float64) StressLimits {
+	limits := StressLimits{
+		MaxDuration:     maxStressDuration,
+		MaxCPULoadInput: maxCPULoadInput,
+		MaxWorkers:      min(stress.MaxWorkers, max(1, int(math.Ceil(cpus)))),
+	}
+	if cpus < 1 {
+		limits.MaxDuration = max(time.Second, time.Duration(float64(maxStressDuration)*cpus).Truncate(time.Second))
+		steps := int(math.Ceil(math.Log(1/cpus) / math.Log(math.Phi)))
+		limits.MaxCPULoadInput = max(stressWorkUnit, maxCPULoadInput-steps)
+	}
+	return limits
+}
+
+// WithOverrides replaces each cap with the matching positive field of overrides
+// n never goes above maxFibonacciInput and workers never above stress.MaxWorkers
+func (l StressLimits) WithOverrides(overrides StressLimits) StressLimits {
+	if overrides.MaxDuration > 0 {
+		l.MaxDuration = overrides.MaxDuration
+	}
+	if overrides.MaxCPULoadInput > 0 {
+		l.MaxCPULoadInput = min(overrides.MaxCPULoadInput, maxFibonacciInput)
+	}
+	if overrides.MaxWorkers > 0 {
+		l.MaxWorkers = min(overrides.MaxWorkers, stress.MaxWorkers)
+	}
+	return l
+}
+
+// fibonacci calculates the nth Fibonacci number iteratively in O(n)
+// Correct for n <= maxFibonacciInput; larger inputs overflow uint64
 func fibonacci(n int) uint64 {
-	if n <= 1 {
-		return uint64(n)
+	var a, b uint64 = 0, 1
+	for i := 0; i < n; i++ {
+		a, b = b, a+b
 	}
-	// Recursive calls create exponential work
-	// This will heavily utilize CPU for larger values of n
-	return fibonacci(n-1) + fibonacci(n-2)
+	return a
 }
 
-// StressTest handles the GET /stress endpoint
+// statusClientClosedRequest is nginx's non-standard 499, recorded when the client went away
+// before the stress computation finished; the client never sees it
+const statusClientClosedRequest = 499
+
+// fibonacciCheckEvery is how many steps slowFibonacci takes between context checks
+const fibonacciCheckEvery = 1 << 16
+
+// slowFibonacci calculates the nth Fibonacci number with the naive O(2^n) recursion, to burn CPU
+// The recursion is unrolled onto an explicit stack so it can stop part-way: it returns
+// ctx.Err() once ctx is done
+func slowFibonacci(ctx context.Context, n int) (uint64, error) {
+	var result uint64
+	stack := []int{n}
+	for steps := 1; len(stack) > 0; steps++ {
+		if steps%fibonacciCheckEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				return 0, err
+			}
+		}
+
+		k := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if k <= 1 {
+			result += uint64(k)
+			continue
+		}
+		stack = append(stack, k-1, k-2)
+	}
+	return result, nil
+}
+
+// StressTest returns the handler for the GET /stress endpoint, enforcing limits
 // This endpoint is designed for Horizontal Pod Autoscaler (HPA) testing
 // by performing CPU-intensive recursive calculations
-func StressTest(c *gin.Context) {
+// Query parameters:
+// - duration: Busy-loop for this long (Go duration, max MaxDuration); takes precedence over n
+// - n: Fibonacci input (default 42, max 93; max MaxCPULoadInput with cpu_load)
+// - cpu_load: Compute n with the exponential algorithm to burn CPU (default false); how long
+//   it takes depends on the CPU, so prefer duration for reproducible load
+// - memory_mb: Memory to allocate and touch after the CPU work, in MB (default 0, max 1000)
+// - workers: Goroutines that each run the CPU work in parallel (default GOMAXPROCS, max MaxWorkers)
+// - deterministic: Run a fixed workload for regression benchmarks (default false); see deterministicStress
+func StressTest(limits StressLimits) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stressTest(c, limits)
+	}
+}
+
+// stressTest serves one /stress request within limits
+func stressTest(c *gin.Context, limits StressLimits) {
 	// Get the current context from Gin
 	ctx := c.Request.Context()
 
@@ -43,6 +164,111 @@ func StressTest(c *gin.Context) {
 	ctx, span := tracer.Start(ctx, "stress_test_computation")
 	defer span.End()
 
+	// Parse memory_mb, default 0 so existing CPU-only callers are unaffected
+	memoryMB, err := strconv.Atoi(c.DefaultQuery("memory_mb", "0"))
+	if err != nil || memoryMB < 0 || memoryMB > stress.MaxMemoryMB {
+		span.SetStatus(codes.Error, "Invalid memory_mb")
+		span.SetAttributes(attribute.String("error", "invalid_parameter"))
+		apierror.RespondError(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "memory_mb must be between 0 and 1000")
+		return
+	}
+	span.SetAttributes(attribute.Int("memory_mb", memoryMB))
+
+	deterministic, err := strconv.ParseBool(c.DefaultQuery("deterministic", "false"))
+	if err != nil {
+		span.SetStatus(codes.Error, "Invalid deterministic parameter")
+		span.SetAttributes(attribute.String("error", "invalid_parameter"))
+		apierror.RespondError(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "Parameter 'deterministic' must be true or false")
+		return
+	}
+
+	// Each worker runs the whole computation, so the load spreads over that many cores
+	// A deterministic run uses one worker unless asked, so GOMAXPROCS doesn't change the workload
+	workers := min(stress.DefaultWorkers(), limits.MaxWorkers)
+	if deterministic {
+		workers = 1
+	}
+	if workersStr, ok := c.GetQuery("workers"); ok {
+		workers, err = strconv.Atoi(workersStr)
+		if err != nil || workers < 1 || workers > limits.MaxWorkers {
+			span.SetStatus(codes.Error, "Invalid workers")
+			span.SetAttributes(attribute.String("error", "invalid_parameter"))
+			apierror.RespondError(c, http.StatusBadRequest, apierror.CodeInvalidRequest,
+				fmt.Sprintf("workers must be between 1 and %d", limits.MaxWorkers))
+			return
+		}
+	}
+	span.SetAttributes(attribute.Int("stress.workers", workers))
+
+	if deterministic {
+		for _, param := range []string{"duration", "n", "cpu_load"} {
+			if _, ok := c.GetQuery(param); ok {
+				span.SetStatus(codes.Error, "Invalid deterministic parameter")
+				span.SetAttributes(attribute.String("error", "invalid_parameter"))
+				apierror.RespondError(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "Parameter 'deterministic' cannot be combined with duration, n or cpu_load")
+				return
+			}
+		}
+		deterministicStress(ctx, c, span, workers, memoryMB)
+		return
+	}
+
+	// A fixed duration gives the same CPU pressure on any hardware, so it wins over n
+	if durationStr, ok := c.GetQuery("duration"); ok {
+		requested, err := time.ParseDuration(durationStr)
+		if err != nil || requested <= 0 || requested > limits.MaxDuration {
+			span.SetStatus(codes.Error, "Invalid duration parameter")
+			span.SetAttributes(attribute.String("error", "invalid_parameter"))
+			apierror.RespondError(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "Parameter 'duration' must be a positive duration such as 5s, at most " + limits.MaxDuration.String())
+			return
+		}
+
+		span.SetAttributes(attribute.Int64("stress.requested_duration_ms", requested.Milliseconds()))
+
+		startTime := time.Now()
+		deadline := startTime.Add(requested)
+		counts := make([]int, workers)
+		var result uint64
+		stress.Parallel(ctx, workers, func(ctx context.Context, worker int) {
+			var last uint64
+			counts[worker], last = busyLoop(ctx, deadline)
+			if worker == 0 {
+				result = last
+			}
+		})
+		iterations := 0
+		for _, count := range counts {
+			iterations += count
+		}
+		if ctx.Err() != nil {
+			abortStress(c, span, time.Since(startTime))
+			return
+		}
+		if memoryMB > 0 {
+			stress.AllocateMemory(memoryMB)
+		}
+		elapsed := time.Since(startTime)
+
+		span.SetAttributes(
+			attribute.Int64("stress.elapsed_ms", elapsed.Milliseconds()),
+			attribute.Int("stress.iterations", iterations),
+			attribute.Int64("computation.duration_ms", elapsed.Milliseconds()),
+		)
+		span.SetStatus(codes.Ok, "Stress computation completed")
+
+		c.JSON(http.StatusOK, StressResponse{
+			Input:             stressWorkUnit,
+			Result:            result,
+			ComputationTime:   elapsed.String(),
+			Message:           "CPU stress test completed successfully",
+			MemoryMB:          memoryMB,
+			Workers:           workers,
+			RequestedDuration: requested.String(),
+			Iterations:        iterations,
+		})
+		return
+	}
+
 	// Parse the 'n' query parameter, default to 42 if not provided
 	// Example: /stress?n=40
 	nStr := c.DefaultQuery("n", "42")
@@ -50,36 +276,67 @@ func StressTest(c *gin.Context) {
 	if err != nil || n < 0 {
 		span.SetStatus(codes.Error, "Invalid input parameter")
 		span.SetAttributes(attribute.String("error", "invalid_parameter"))
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid parameter 'n'",
-			"message": "Parameter 'n' must be a non-negative integer",
-		})
+		apierror.RespondError(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "Parameter 'n' must be a non-negative integer")
+		return
+	}
+
+	// cpu_load=true opts into the exponential computation that generates the CPU load
+	cpuLoad, err := strconv.ParseBool(c.DefaultQuery("cpu_load", "false"))
+	if err != nil {
+		span.SetStatus(codes.Error, "Invalid cpu_load parameter")
+		span.SetAttributes(attribute.String("error", "invalid_parameter"))
+		apierror.RespondError(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "Parameter 'cpu_load' must be true or false")
 		return
 	}
 
-	// Limit the input to prevent excessive computation
-	// Fibonacci(50) takes several minutes on a single CPU core
-	if n > 50 {
+	// Limit the input: larger results overflow uint64, and with cpu_load the runtime explodes
+	maxInput := maxFibonacciInput
+	if cpuLoad {
+		maxInput = limits.MaxCPULoadInput
+	}
+	if n > maxInput {
 		span.SetStatus(codes.Error, "Input too large")
 		span.SetAttributes(attribute.Int("input.value", n))
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Input too large",
-			"message": "Maximum allowed value is 50",
-		})
+		apierror.RespondError(c, http.StatusBadRequest, apierror.CodeInvalidRequest, "Maximum allowed value is " + strconv.Itoa(maxInput))
 		return
 	}
 
 	// Add span attribute for the input value
-	span.SetAttributes(attribute.Int("fibonacci.input", n))
+	span.SetAttributes(
+		attribute.Int("fibonacci.input", n),
+		attribute.Bool("stress.cpu_load", cpuLoad),
+	)
 
 	// Record the start time
 	startTime := time.Now()
 
-	// Perform the CPU-intensive Fibonacci calculation
-	// For n=42, this typically takes 2-5 seconds on a modern CPU
-	// For n=45, this can take 10-30 seconds
-	// This creates measurable CPU load for HPA testing
-	result := fibonacci(n)
+	var result uint64
+	if cpuLoad {
+		// Perform the CPU-intensive Fibonacci calculation
+		// For n=42, this typically takes 2-5 seconds on a modern CPU
+		// For n=45, this can take 10-30 seconds
+		// This creates measurable CPU load for HPA testing, on as many cores as there are workers
+		// Workers give up part-way if the client disconnects
+		stress.Parallel(ctx, workers, func(ctx context.Context, worker int) {
+			value, err := slowFibonacci(ctx, n)
+			if err == nil && worker == 0 {
+				result = value
+			}
+		})
+		if ctx.Err() != nil {
+			abortStress(c, span, time.Since(startTime))
+			return
+		}
+	} else {
+		// Without cpu_load the answer is computed directly and no workers are used
+		result = fibonacci(n)
+		workers = 1
+	}
+
+	// Memory Stress: Allocate and populate byte slices
+	if memoryMB > 0 {
+		stress.AllocateMemory(memoryMB)
+	}
 
 	// Calculate the computation time
 	duration := time.Since(startTime)
@@ -99,7 +356,83 @@ func StressTest(c *gin.Context) {
 		Result:          result,
 		ComputationTime: duration.String(),
 		Message:         "CPU stress test completed successfully",
+		MemoryMB:        memoryMB,
+		Workers:         workers,
+		CPULoad:         cpuLoad,
 	}
 
 	c.JSON(http.StatusOK, response)
 }
+
+// deterministicStress serves ?deterministic=true: every worker recomputes
+// slowFibonacci(stressWorkUnit) exactly deterministicIterations times, then memory_mb is
+// allocated with stress.AllocateMemoryFixed
+// Unlike duration or cpu_load, the work done depends only on workers and memory_mb, never on
+// the clock, GOMAXPROCS or the allocator's fill, so benchmark runs stay comparable
+func deterministicStress(ctx context.Context, c *gin.Context, span trace.Span, workers, memoryMB int) {
+	span.SetAttributes(attribute.Bool("stress.deterministic", true))
+
+	startTime := time.Now()
+	var result uint64
+	stress.Parallel(ctx, workers, func(ctx context.Context, worker int) {
+		for i := 0; i < deterministicIterations; i++ {
+			value, err := slowFibonacci(ctx, stressWorkUnit)
+			if err != nil {
+				return
+			}
+			if worker == 0 {
+				result = value
+			}
+		}
+	})
+	if ctx.Err() != nil {
+		abortStress(c, span, time.Since(startTime))
+		return
+	}
+	if memoryMB > 0 {
+		stress.AllocateMemoryFixed(memoryMB)
+	}
+	elapsed := time.Since(startTime)
+	iterations := workers * deterministicIterations
+
+	span.SetAttributes(
+		attribute.Int("stress.iterations", iterations),
+		attribute.Int64("computation.duration_ms", elapsed.Milliseconds()),
+	)
+	span.SetStatus(codes.Ok, "Stress computation completed")
+
+	c.JSON(http.StatusOK, StressResponse{
+		Input:           stressWorkUnit,
+		Result:          result,
+		ComputationTime: elapsed.String(),
+		Message:         "CPU stress test completed successfully",
+		MemoryMB:        memoryMB,
+		Workers:         workers,
+		Iterations:      iterations,
+		Deterministic:   true,
+	})
+}
+
+// abortStress records a stress run abandoned because the request context ended
+// The 499 only reaches the access log and trace, since the client is already gone
+func abortStress(c *gin.Context, span trace.Span, elapsed time.Duration) {
+	span.SetAttributes(attribute.Int64("computation.duration_ms", elapsed.Milliseconds()))
+	span.SetStatus(codes.Error, "Client disconnected")
+	c.AbortWithStatus(statusClientClosedRequest)
+}
+
+// busyLoop recomputes slowFibonacci(stressWorkUnit) until deadline, or until ctx is cancelled
+// because the client went away, and returns how many iterations ran and the last result
+func busyLoop(ctx context.Context, deadline time.Time) (iterations int, result uint64) {
+	for time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			return iterations, result
+		default:
+		}
+		// Cancellation is checked above, so the error can be ignored
+		result, _ = slowFibonacci(ctx, stressWorkUnit)
+		iterations++
+	}
+	return iterations, result
+}
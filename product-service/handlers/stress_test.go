@@ -1,11 +1,13 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -26,22 +28,42 @@ func TestFibonacci(t *testing.T) {
 		{6, 8},
 		{10, 55},
 		{20, 6765},
+		{90, 2880067194370816120},
+		{93, 12200160415121876738},
 	}
 
 	for _, tt := range tests {
 		t.Run(fmt.Sprintf("fibonacci(%d)", tt.input), func(t *testing.T) {
 			result := fibonacci(tt.input)
 			assert.Equal(t, tt.expected, result)
+
+			// The exponential variant is only practical for small inputs
+			if tt.input <= 20 {
+				result, err := slowFibonacci(context.Background(), tt.input)
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, result)
+			}
 		})
 	}
+
+	t.Run("slowFibonacci stops once the context is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := slowFibonacci(ctx, 50)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
 }
 
+// fullCoreStressLimits are the caps on a pod with a full core per worker, as before they scaled
+var fullCoreStressLimits = StressLimits{MaxDuration: maxStressDuration, MaxCPULoadInput: maxCPULoadInput, MaxWorkers: 64}
+
 func TestStressTest(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	t.Run("should return 200 OK with default parameter", func(t *testing.T) {
 		router := gin.New()
-		router.GET("/stress", StressTest)
+		router.GET("/stress", StressTest(fullCoreStressLimits))
 		w := httptest.NewRecorder()
 		req, _ := http.NewRequest("GET", "/stress", nil)
 
@@ -52,7 +74,7 @@ func TestStressTest(t *testing.T) {
 
 	t.Run("should return valid JSON response", func(t *testing.T) {
 		router := gin.New()
-		router.GET("/stress", StressTest)
+		router.GET("/stress", StressTest(fullCoreStressLimits))
 		w := httptest.NewRecorder()
 		req, _ := http.NewRequest("GET", "/stress?n=10", nil)
 
@@ -70,7 +92,7 @@ func TestStressTest(t *testing.T) {
 
 	t.Run("should handle query parameter n=20", func(t *testing.T) {
 		router := gin.New()
-		router.GET("/stress", StressTest)
+		router.GET("/stress", StressTest(fullCoreStressLimits))
 		w := httptest.NewRecorder()
 		req, _ := http.NewRequest("GET", "/stress?n=20", nil)
 
@@ -87,7 +109,7 @@ func TestStressTest(t *testing.T) {
 
 	t.Run("should reject negative input", func(t *testing.T) {
 		router := gin.New()
-		router.GET("/stress", StressTest)
+		router.GET("/stress", StressTest(fullCoreStressLimits))
 		w := httptest.NewRecorder()
 		req, _ := http.NewRequest("GET", "/stress?n=-5", nil)
 
@@ -97,12 +119,12 @@ func TestStressTest(t *testing.T) {
 		
 		var errorResponse map[string]interface{}
 		json.Unmarshal(w.Body.Bytes(), &errorResponse)
-		assert.Contains(t, errorResponse, "error")
+		assert.Equal(t, "INVALID_REQUEST", errorResponse["code"])
 	})
 
 	t.Run("should reject invalid input", func(t *testing.T) {
 		router := gin.New()
-		router.GET("/stress", StressTest)
+		router.GET("/stress", StressTest(fullCoreStressLimits))
 		w := httptest.NewRecorder()
 		req, _ := http.NewRequest("GET", "/stress?n=invalid", nil)
 
@@ -111,11 +133,11 @@ func TestStressTest(t *testing.T) {
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 	})
 
-	t.Run("should reject input greater than 50", func(t *testing.T) {
+	t.Run("should reject input greater than 50 with cpu_load", func(t *testing.T) {
 		router := gin.New()
-		router.GET("/stress", StressTest)
+		router.GET("/stress", StressTest(fullCoreStressLimits))
 		w := httptest.NewRecorder()
-		req, _ := http.NewRequest("GET", "/stress?n=51", nil)
+		req, _ := http.NewRequest("GET", "/stress?n=51&cpu_load=true", nil)
 
 		router.ServeHTTP(w, req)
 
@@ -123,23 +145,63 @@ func TestStressTest(t *testing.T) {
 		
 		var errorResponse map[string]interface{}
 		json.Unmarshal(w.Body.Bytes(), &errorResponse)
-		assert.Contains(t, errorResponse["error"], "too large")
+		assert.Contains(t, errorResponse["message"], "Maximum allowed value is 50")
+	})
+
+	t.Run("should reject input that overflows uint64", func(t *testing.T) {
+		router := gin.New()
+		router.GET("/stress", StressTest(fullCoreStressLimits))
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/stress?n=94", nil)
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
 	})
 
-	t.Run("should accept maximum allowed value of 50", func(t *testing.T) {
+	t.Run("should return large results instantly without cpu_load", func(t *testing.T) {
 		router := gin.New()
-		router.GET("/stress", StressTest)
+		router.GET("/stress", StressTest(fullCoreStressLimits))
 		w := httptest.NewRecorder()
-		req, _ := http.NewRequest("GET", "/stress?n=50", nil)
+		req, _ := http.NewRequest("GET", "/stress?n=90", nil)
 
+		start := time.Now()
 		router.ServeHTTP(w, req)
 
 		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Less(t, time.Since(start), 100*time.Millisecond)
+
+		var response StressResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, uint64(2880067194370816120), response.Result)
+		assert.False(t, response.CPULoad)
+	})
+
+	t.Run("should burn CPU with cpu_load", func(t *testing.T) {
+		router := gin.New()
+		router.GET("/stress", StressTest(fullCoreStressLimits))
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/stress?n=20&cpu_load=true&workers=2", nil)
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response StressResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, uint64(6765), response.Result)
+		assert.True(t, response.CPULoad)
+		assert.Equal(t, 2, response.Workers)
+
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/stress?n=20&cpu_load=maybe", nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
 	})
 
 	t.Run("should include computation time in response", func(t *testing.T) {
 		router := gin.New()
-		router.GET("/stress", StressTest)
+		router.GET("/stress", StressTest(fullCoreStressLimits))
 		w := httptest.NewRecorder()
 		req, _ := http.NewRequest("GET", "/stress?n=30", nil)
 
@@ -153,6 +215,208 @@ func TestStressTest(t *testing.T) {
 		// Should contain time units (ms, µs, or s)
 		assert.Contains(t, response.ComputationTime, "s")
 	})
+
+	t.Run("should busy-loop for the requested duration ignoring n", func(t *testing.T) {
+		router := gin.New()
+		router.GET("/stress", StressTest(fullCoreStressLimits))
+		w := httptest.NewRecorder()
+		// n=50 with cpu_load alone would take minutes; duration must take precedence
+		req, _ := http.NewRequest("GET", "/stress?duration=200ms&n=50&cpu_load=true", nil)
+
+		start := time.Now()
+		router.ServeHTTP(w, req)
+		elapsed := time.Since(start)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.GreaterOrEqual(t, elapsed, 200*time.Millisecond)
+		assert.Less(t, elapsed, 2*time.Second)
+
+		var response StressResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "200ms", response.RequestedDuration)
+		assert.Positive(t, response.Iterations)
+		assert.Equal(t, fibonacci(stressWorkUnit), response.Result)
+	})
+
+	t.Run("should allocate the requested memory", func(t *testing.T) {
+		router := gin.New()
+		router.GET("/stress", StressTest(fullCoreStressLimits))
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/stress?n=10&memory_mb=5", nil)
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response StressResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 5, response.MemoryMB)
+	})
+
+	t.Run("should reject memory_mb outside 0-1000", func(t *testing.T) {
+		for _, memoryMB := range []string{"-1", "1001", "lots"} {
+			router := gin.New()
+			router.GET("/stress", StressTest(fullCoreStressLimits))
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/stress?n=10&memory_mb="+memoryMB, nil)
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, "memory_mb %q", memoryMB)
+		}
+	})
+
+	t.Run("should run the requested number of workers", func(t *testing.T) {
+		router := gin.New()
+		router.GET("/stress", StressTest(fullCoreStressLimits))
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/stress?duration=50ms&workers=3", nil)
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response StressResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 3, response.Workers)
+		assert.GreaterOrEqual(t, response.Iterations, 3, "Every worker should have iterated")
+	})
+
+	t.Run("should reject invalid workers", func(t *testing.T) {
+		for _, workers := range []string{"0", "65", "many"} {
+			router := gin.New()
+			router.GET("/stress", StressTest(fullCoreStressLimits))
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/stress?n=10&workers="+workers, nil)
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, "workers %q", workers)
+		}
+	})
+
+	t.Run("should abort promptly when the client disconnects mid-request", func(t *testing.T) {
+		for _, rawQuery := range []string{"n=50&cpu_load=true", "duration=30s"} {
+			router := gin.New()
+			router.GET("/stress", StressTest(fullCoreStressLimits))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(100*time.Millisecond, cancel)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequestWithContext(ctx, "GET", "/stress?workers=2&"+rawQuery, nil)
+
+			start := time.Now()
+			router.ServeHTTP(w, req)
+
+			assert.Less(t, time.Since(start), 2*time.Second, rawQuery)
+			assert.Equal(t, statusClientClosedRequest, w.Code, rawQuery)
+		}
+	})
+
+	t.Run("should run the same fixed workload with deterministic=true", func(t *testing.T) {
+		router := gin.New()
+		router.GET("/stress", StressTest(fullCoreStressLimits))
+
+		run := func() StressResponse {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/stress?deterministic=true&memory_mb=2", nil)
+			router.ServeHTTP(w, req)
+			require.Equal(t, http.StatusOK, w.Code)
+
+			var response StressResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			return response
+		}
+
+		first := run()
+		assert.True(t, first.Deterministic)
+		assert.Equal(t, 1, first.Workers, "GOMAXPROCS must not change the workload")
+		assert.Equal(t, stressWorkUnit, first.Input)
+		assert.Equal(t, fibonacci(stressWorkUnit), first.Result)
+		assert.Equal(t, deterministicIterations, first.Iterations)
+		assert.Equal(t, 2, first.MemoryMB)
+
+		second := run()
+		first.ComputationTime, second.ComputationTime = "", ""
+		assert.Equal(t, first, second)
+	})
+
+	t.Run("should reject deterministic with time- or n-based parameters", func(t *testing.T) {
+		for _, query := range []string{"deterministic=maybe", "deterministic=true&duration=1s", "deterministic=true&n=20", "deterministic=true&cpu_load=true"} {
+			router := gin.New()
+			router.GET("/stress", StressTest(fullCoreStressLimits))
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/stress?"+query, nil)
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		}
+	})
+
+	t.Run("should reject invalid or excessive durations", func(t *testing.T) {
+		for _, duration := range []string{"abc", "0s", "-1s", "61s", "5"} {
+			router := gin.New()
+			router.GET("/stress", StressTest(fullCoreStressLimits))
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/stress?duration="+duration, nil)
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, "duration %q", duration)
+		}
+	})
+}
+
+func TestStressLimits(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("should scale the caps to the available CPUs", func(t *testing.T) {
+		assert.Equal(t, fullCoreStressLimits, DefaultStressLimits(128))
+		assert.Equal(t, StressLimits{MaxDuration: 60 * time.Second, MaxCPULoadInput: 50, MaxWorkers: 2}, DefaultStressLimits(1.5))
+		// 0.25 cores is 4x slower, and phi^3 > 4
+		assert.Equal(t, StressLimits{MaxDuration: 15 * time.Second, MaxCPULoadInput: 47, MaxWorkers: 1}, DefaultStressLimits(0.25))
+		assert.Equal(t, time.Second, DefaultStressLimits(0.001).MaxDuration)
+	})
+
+	t.Run("should apply positive overrides only", func(t *testing.T) {
+		defaults := DefaultStressLimits(0.25)
+
+		assert.Equal(t, defaults, defaults.WithOverrides(StressLimits{}))
+		assert.Equal(t, StressLimits{MaxDuration: 2 * time.Minute, MaxCPULoadInput: 47, MaxWorkers: 1},
+			defaults.WithOverrides(StressLimits{MaxDuration: 2 * time.Minute, MaxCPULoadInput: -1}))
+		assert.Equal(t, StressLimits{MaxDuration: 15 * time.Second, MaxCPULoadInput: 93, MaxWorkers: 64},
+			defaults.WithOverrides(StressLimits{MaxCPULoadInput: 1000, MaxWorkers: 1000}))
+	})
+
+	t.Run("should enforce the effective caps and report them", func(t *testing.T) {
+		limits := DefaultStressLimits(0.25).WithOverrides(StressLimits{MaxCPULoadInput: 30, MaxWorkers: 2})
+		router := gin.New()
+		router.GET("/stress", StressTest(limits))
+
+		get := func(query string) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/stress?"+query, nil)
+			router.ServeHTTP(w, req)
+			return w
+		}
+
+		assert.Equal(t, http.StatusOK, get("n=20&cpu_load=true&workers=2").Code)
+		assert.Equal(t, http.StatusOK, get("n=60").Code, "n without cpu_load is only capped by overflow")
+
+		w := get("n=31&cpu_load=true")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "Maximum allowed value is 30")
+
+		w = get("n=10&workers=3")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "workers must be between 1 and 2")
+
+		w = get("duration=16s")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "at most 15s")
+	})
 }
 
 // Benchmark the Fibonacci function
@@ -168,16 +432,31 @@ func BenchmarkFibonacci(b *testing.B) {
 	}
 }
 
-// Benchmark the stress endpoint
+// Benchmark the stress endpoint with the deterministic workload, so CI can compare runs
+// Every call must do the same work, checked by comparing its response with the first one
 func BenchmarkStressTest(b *testing.B) {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
-	router.GET("/stress", StressTest)
+	router.GET("/stress", StressTest(fullCoreStressLimits))
 
+	var first *StressResponse
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		w := httptest.NewRecorder()
-		req, _ := http.NewRequest("GET", "/stress?n=20", nil)
+		req, _ := http.NewRequest("GET", "/stress?deterministic=true&memory_mb=1", nil)
 		router.ServeHTTP(w, req)
+
+		b.StopTimer()
+		var response StressResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || w.Code != http.StatusOK {
+			b.Fatalf("stress request failed: %d %s", w.Code, w.Body.String())
+		}
+		response.ComputationTime = ""
+		if first == nil {
+			first = &response
+		} else if response != *first {
+			b.Fatalf("deterministic run %d differs: %+v, first was %+v", i, response, *first)
+		}
+		b.StartTimer()
 	}
 }
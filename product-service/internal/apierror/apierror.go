@@ -0,0 +1,44 @@
+// Package apierror defines the JSON body of every error response
+// cart-service and product-service keep identical copies so clients can handle both the same way
+package apierror
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// Codes shared by both services; service-specific codes live next to their handlers
+const (
+	// CodeInvalidRequest is a request that failed validation; Details lists the offending fields when known
+	CodeInvalidRequest = "INVALID_REQUEST"
+	// CodeRateLimited is a request rejected by the per-client rate limiter
+	CodeRateLimited = "RATE_LIMITED"
+	// CodeOverloaded is a request shed because the service is at its concurrent request limit
+	CodeOverloaded = "OVERLOADED"
+	// CodeTimeout is a request that ran past its handler deadline
+	CodeTimeout = "REQUEST_TIMEOUT"
+	// CodeInternal is an unexpected failure, such as a recovered panic
+	CodeInternal = "INTERNAL_ERROR"
+)
+
+// APIError is the body of an error response
+// Code is stable and meant for clients to branch on; Message is for humans and may change
+type APIError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Details any    `json:"details,omitempty"`
+}
+
+// RespondError writes an error response and aborts the remaining handlers
+func RespondError(c *gin.Context, status int, code, message string) {
+	Respond(c, status, APIError{Code: code, Message: message})
+}
+
+// RespondErrorDetails is RespondError with machine-readable context, e.g. the invalid fields
+func RespondErrorDetails(c *gin.Context, status int, code, message string, details any) {
+	Respond(c, status, APIError{Code: code, Message: message, Details: details})
+}
+
+// Respond writes err as the response body and aborts the remaining handlers
+func Respond(c *gin.Context, status int, err APIError) {
+	c.AbortWithStatusJSON(status, err)
+}
@@ -0,0 +1,73 @@
+// Package money formats prices for display in product responses
+package money
+
+import (
+	"strconv"
+	"strings"
+)
+
+// currency describes how amounts in one ISO 4217 currency are written
+type currency struct {
+	symbol   string // Written before the amount
+	decimals int    // Minor unit digits; 0 for currencies without a minor unit (e.g. JPY)
+}
+
+// currencies lists the currencies with a known symbol
+// Others are written with their code and two decimals (e.g. "CHF 12.50")
+var currencies = map[string]currency{
+	"USD": {symbol: "$", decimals: 2},
+	"EUR": {symbol: "€", decimals: 2},
+	"GBP": {symbol: "£", decimals: 2},
+	"JPY": {symbol: "¥", decimals: 0},
+	"KRW": {symbol: "₩", decimals: 0},
+	"INR": {symbol: "₹", decimals: 2},
+}
+
+// Known reports whether code is a currency with a symbol and minor unit in this package
+func Known(code string) bool {
+	_, ok := currencies[code]
+	return ok
+}
+
+// Format writes amount in the currency with ISO 4217 code, e.g. "$1,299.99" for USD or "¥1,300"
+// for JPY: rounded to the currency's minor unit, with comma thousands separators and a point
+// before the decimals. Unknown codes are written before the amount with two decimals
+func Format(amount float64, code string) string {
+	c, ok := currencies[code]
+	if !ok {
+		c = currency{symbol: code + " ", decimals: 2}
+	}
+
+	digits := strconv.FormatFloat(amount, 'f', c.decimals, 64)
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+
+	whole, fraction := digits, ""
+	if dot := strings.IndexByte(digits, '.'); dot >= 0 {
+		whole, fraction = digits[:dot], digits[dot:]
+	}
+
+	return sign + c.symbol + groupThousands(whole) + fraction
+}
+
+// groupThousands inserts a comma between every three digits of whole, counting from the right
+func groupThousands(whole string) string {
+	if len(whole) <= 3 {
+		return whole
+	}
+
+	var b strings.Builder
+	head := len(whole) % 3
+	if head > 0 {
+		b.WriteString(whole[:head])
+	}
+	for i := head; i < len(whole); i += 3 {
+		if b.Len() > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(whole[i : i+3])
+	}
+	return b.String()
+}
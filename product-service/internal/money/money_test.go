@@ -0,0 +1,42 @@
+package money
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   float64
+		code     string
+		expected string
+	}{
+		{"USD with cents", 199.99, "USD", "$199.99"},
+		{"USD thousands", 3499, "USD", "$3,499.00"},
+		{"USD millions", 1234567.5, "USD", "$1,234,567.50"},
+		{"EUR", 69.99, "EUR", "€69.99"},
+		{"GBP", 0.5, "GBP", "£0.50"},
+		{"zero", 0, "USD", "$0.00"},
+		{"zero-decimal currency", 1299.99, "JPY", "¥1,300"},
+		{"zero-decimal currency below one unit", 0.4, "JPY", "¥0"},
+		{"zero in a zero-decimal currency", 0, "KRW", "₩0"},
+		{"rounds to the minor unit", 19.995, "USD", "$20.00"},
+		{"unknown code", 12.5, "CHF", "CHF 12.50"},
+		{"negative", -1000, "USD", "-$1,000.00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Format(tt.amount, tt.code))
+		})
+	}
+}
+
+func TestKnown(t *testing.T) {
+	assert.True(t, Known("USD"))
+	assert.True(t, Known("JPY"))
+	assert.False(t, Known("CHF"))
+	assert.False(t, Known("usd"), "Codes are upper case, like ISO 4217")
+}
@@ -0,0 +1,110 @@
+// Package retry implements the exponential backoff used to connect to Redis and Postgres
+// cart-service and product-service keep identical copies so both back off the same way
+package retry
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// RetryConfig holds configuration for exponential backoff retry logic
+type RetryConfig struct {
+	InitialDelay time.Duration // Starting delay (e.g., 100ms)
+	MaxDelay     time.Duration // Maximum delay (e.g., 2s)
+	MaxRetries   int           // Maximum number of retries after the first attempt (e.g., 5)
+	JitterPct    float64       // Jitter percentage (e.g., 0.1 for ±10%); 0 disables jitter
+	// Rand returns the jitter source in [0, 1); nil uses math/rand
+	// Tests inject a fixed value to assert exact delays
+	Rand func() float64
+	// OnRetry, when set, is called after each failed attempt that will be retried, before waiting
+	// attempt is zero-based; callers use it to log retries or add span events
+	OnRetry func(attempt int, err error, delay time.Duration)
+}
+
+// DefaultRetryConfig returns the default retry configuration
+// Initial delay: 100ms, Max delay: 2s, Max retries: 5, Jitter: ±10%
+func DefaultRetryConfig() RetryConfig {
+	return RetryConfig{
+		InitialDelay: 100 * time.Millisecond,
+		MaxDelay:     2 * time.Second,
+		MaxRetries:   5,
+		JitterPct:    0.1,
+	}
+}
+
+// permanentError marks an error that retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so Do returns it immediately instead of retrying
+// Used for failures such as an unparsable connection string
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// ComputeBackoff returns the delay before retrying after the given zero-based attempt
+// Formula: min(initialDelay * 2^attempt, maxDelay) * (1 ± jitterPct)
+// Deterministic when JitterPct is 0 or Rand is fixed, so tests can assert exact delays
+func ComputeBackoff(attempt int, config RetryConfig) time.Duration {
+	delay := time.Duration(float64(config.InitialDelay) * math.Pow(2, float64(attempt)))
+	if delay > config.MaxDelay {
+		delay = config.MaxDelay
+	}
+
+	if config.JitterPct == 0 {
+		return delay
+	}
+
+	// Add jitter to prevent thundering herd
+	// Jitter range: delay * (1 ± jitterPct)
+	random := rand.Float64
+	if config.Rand != nil {
+		random = config.Rand
+	}
+	jitter := 1.0 + (random()*2-1)*config.JitterPct
+	return time.Duration(float64(delay) * jitter)
+}
+
+// Do calls fn until it succeeds, returns a Permanent error, or MaxRetries retries have failed
+// fn receives the zero-based attempt number. Between attempts Do waits ComputeBackoff, and
+// returns ctx.Err() if ctx is done first; otherwise the last error from fn is returned
+func Do(ctx context.Context, config RetryConfig, fn func(ctx context.Context, attempt int) error) error {
+	for attempt := 0; ; attempt++ {
+		err := fn(ctx, attempt)
+		if err == nil {
+			return nil
+		}
+
+		var permanent *permanentError
+		if errors.As(err, &permanent) {
+			return permanent.err
+		}
+
+		// If this was the last attempt, don't wait
+		if attempt >= config.MaxRetries {
+			return err
+		}
+
+		delay := ComputeBackoff(attempt, config)
+		if config.OnRetry != nil {
+			config.OnRetry(attempt, err, delay)
+		}
+
+		// Wait before retrying
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+			// Continue to next attempt
+		}
+	}
+}
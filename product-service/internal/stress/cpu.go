@@ -0,0 +1,71 @@
+package stress
+
+import (
+	"math"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// cgroup files holding the CPU quota, for cgroup v2 and v1
+// Kubernetes CPU limits end up here; a container without a limit has no quota
+const (
+	cgroupV2CPUMax    = "/sys/fs/cgroup/cpu.max"
+	cgroupV1CFSQuota  = "/sys/fs/cgroup/cpu/cpu.cfs_quota_us"
+	cgroupV1CFSPeriod = "/sys/fs/cgroup/cpu/cpu.cfs_period_us"
+)
+
+// AvailableCPUs returns how many CPUs this process may keep busy: the cgroup CPU quota when
+// one is set and is below GOMAXPROCS, otherwise GOMAXPROCS
+// The quota can be fractional (a 250m limit is 0.25), which GOMAXPROCS cannot express
+func AvailableCPUs() float64 {
+	procs := float64(runtime.GOMAXPROCS(0))
+	if quota, ok := cgroupCPUQuota(); ok && quota < procs {
+		return quota
+	}
+	return procs
+}
+
+// cgroupCPUQuota reads the CPU quota in CPUs, trying cgroup v2 before v1
+func cgroupCPUQuota() (float64, bool) {
+	if content, err := os.ReadFile(cgroupV2CPUMax); err == nil {
+		return parseCPUMax(string(content))
+	}
+	quota, err := os.ReadFile(cgroupV1CFSQuota)
+	if err != nil {
+		return 0, false
+	}
+	period, err := os.ReadFile(cgroupV1CFSPeriod)
+	if err != nil {
+		return 0, false
+	}
+	return parseCFSQuota(string(quota), string(period))
+}
+
+// parseCPUMax parses a cgroup v2 cpu.max ("<quota> <period>", quota "max" when unlimited)
+func parseCPUMax(content string) (float64, bool) {
+	fields := strings.Fields(content)
+	if len(fields) != 2 || fields[0] == "max" {
+		return 0, false
+	}
+	return quotaRatio(fields[0], fields[1])
+}
+
+// parseCFSQuota parses the cgroup v1 cpu.cfs_quota_us and cpu.cfs_period_us (quota -1 when unlimited)
+func parseCFSQuota(quota, period string) (float64, bool) {
+	return quotaRatio(strings.TrimSpace(quota), strings.TrimSpace(period))
+}
+
+// quotaRatio divides a quota by its period, rejecting unlimited or malformed values
+func quotaRatio(quota, period string) (float64, bool) {
+	q, err := strconv.ParseFloat(quota, 64)
+	if err != nil || q <= 0 || math.IsInf(q, 0) {
+		return 0, false
+	}
+	p, err := strconv.ParseFloat(period, 64)
+	if err != nil || p <= 0 || math.IsInf(p, 0) {
+		return 0, false
+	}
+	return q / p, true
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryGate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(allowedKeys []string) *gin.Engine {
+		router := gin.New()
+		router.GET("/products", QueryGate("include_deleted", APIKeyMiddleware(allowedKeys)), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+		return router
+	}
+
+	tests := []struct {
+		name           string
+		allowedKeys    []string
+		path           string
+		apiKey         string
+		expectedStatus int
+		expectedCode   string
+	}{
+		{"should leave requests without the flag open", []string{"admin"}, "/products", "", http.StatusOK, ""},
+		{"should accept the flag with a valid key", []string{"admin"}, "/products?include_deleted=true", "admin", http.StatusOK, ""},
+		{"should return 401 for the flag without a key", []string{"admin"}, "/products?include_deleted=true", "", http.StatusUnauthorized, CodeAPIKeyMissing},
+		{"should return 403 for the flag with an unknown key", []string{"admin"}, "/products?include_deleted=true", "other", http.StatusForbidden, CodeAPIKeyInvalid},
+		{"should gate the flag whatever its value", []string{"admin"}, "/products?include_deleted=false", "", http.StatusUnauthorized, CodeAPIKeyMissing},
+		{"should refuse the flag when no keys are configured", nil, "/products?include_deleted=true", "anything", http.StatusForbidden, CodeAPIKeyInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", tt.path, nil)
+			if tt.apiKey != "" {
+				req.Header.Set(APIKeyHeader, tt.apiKey)
+			}
+
+			newRouter(tt.allowedKeys).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedCode != "" {
+				assert.Contains(t, w.Body.String(), `"code":"`+tt.expectedCode+`"`)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CORSConfig controls CORSMiddleware
+type CORSConfig struct {
+	// AllowedOrigins lists origins (e.g. https://shop.example.com) allowed to call the API; "*" allows any
+	AllowedOrigins []string
+	// AllowedMethods is returned to preflight requests in Access-Control-Allow-Methods
+	AllowedMethods []string
+	// AllowedHeaders is returned to preflight requests in Access-Control-Allow-Headers
+	AllowedHeaders []string
+	// ExposedHeaders lists response headers browser code may read (Access-Control-Expose-Headers)
+	ExposedHeaders []string
+	// MaxAge is how long browsers may cache a preflight response (0 omits the header)
+	MaxAge time.Duration
+}
+
+// CORSMiddleware returns a Gin middleware that adds CORS headers for allowed origins
+// Preflight (OPTIONS) requests are answered directly with 204, so this middleware should be
+// added before the tracing middleware to keep preflights from creating spans
+func CORSMiddleware(config CORSConfig) gin.HandlerFunc {
+	allowAny := false
+	allowed := make(map[string]bool, len(config.AllowedOrigins))
+	for _, origin := range config.AllowedOrigins {
+		if origin == "*" {
+			allowAny = true
+		}
+		allowed[origin] = true
+	}
+
+	allowMethods := strings.Join(config.AllowedMethods, ", ")
+	allowHeaders := strings.Join(config.AllowedHeaders, ", ")
+	exposeHeaders := strings.Join(config.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(config.MaxAge.Seconds()))
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+
+		if origin == "" {
+			// Not a cross-origin browser request
+			c.Next()
+			return
+		}
+
+		// The response depends on Origin, so shared caches must not serve it to other origins
+		c.Writer.Header().Add("Vary", "Origin")
+
+		if !allowAny && !allowed[origin] {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			// Without CORS headers the browser blocks the response; non-browser clients are unaffected
+			c.Next()
+			return
+		}
+
+		if allowAny {
+			c.Header("Access-Control-Allow-Origin", "*")
+		} else {
+			c.Header("Access-Control-Allow-Origin", origin)
+		}
+
+		if preflight {
+			c.Header("Access-Control-Allow-Methods", allowMethods)
+			c.Header("Access-Control-Allow-Headers", allowHeaders)
+			if config.MaxAge > 0 {
+				c.Header("Access-Control-Max-Age", maxAge)
+			}
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		if exposeHeaders != "" {
+			c.Header("Access-Control-Expose-Headers", exposeHeaders)
+		}
+		c.Next()
+	}
+}
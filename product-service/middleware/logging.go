@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// AccessLogConfig controls which requests ZapMiddleware writes access logs for
+type AccessLogConfig struct {
+	// QuietPaths lists routes (e.g. Kubernetes probes) whose successful requests are sampled
+	// instead of always logged; failed requests to these paths are always logged
+	QuietPaths []string
+	// QuietSampleEvery logs one in every N successful requests per quiet path
+	// 0 suppresses them entirely and 1 logs every request
+	QuietSampleEvery uint64
+}
+
+// ZapMiddleware returns a Gin middleware that logs HTTP requests using Zap
+// Logs include trace_id and span_id for correlation with distributed traces
+// route holds the matched route template (e.g. /v1/cart/:user_id), which unlike the raw path
+// stays low-cardinality and is what dashboards should aggregate on; it is empty for unmatched routes
+// This middleware should be added after the tracing middleware to capture trace IDs
+func ZapMiddleware(logger *zap.Logger, config AccessLogConfig) gin.HandlerFunc {
+	// One counter per quiet path; the map is never written after this point,
+	// so concurrent requests only touch the atomic counters
+	quietCounters := make(map[string]*atomic.Uint64, len(config.QuietPaths))
+	for _, path := range config.QuietPaths {
+		quietCounters[path] = &atomic.Uint64{}
+	}
+
+	return func(c *gin.Context) {
+		start := time.Now()
+		path := c.Request.URL.Path
+		method := c.Request.Method
+
+		// Process request
+		c.Next()
+
+		// Calculate request duration
+		duration := time.Since(start)
+		status := c.Writer.Status()
+
+		// Size is -1 until the body is written, e.g. for HEAD requests or empty 204s
+		responseSize := c.Writer.Size()
+		if responseSize < 0 {
+			responseSize = 0
+		}
+
+		// Sample successful requests to quiet paths so probes don't flood the logs
+		if counter, ok := quietCounters[path]; ok && status < 400 {
+			if config.QuietSampleEvery == 0 || (counter.Add(1)-1)%config.QuietSampleEvery != 0 {
+				return
+			}
+		}
+
+		// Determine log level based on status code
+		fields := []zap.Field{
+			zap.String("method", method),
+			zap.String("path", path),
+			zap.String("route", c.FullPath()),
+			zap.String("proto", c.Request.Proto),
+			zap.Int("status", status),
+			zap.Int("response_size", responseSize),
+			zap.Duration("duration", duration),
+			zap.String("client_ip", c.ClientIP()),
+			zap.String("user_agent", c.Request.UserAgent()),
+		}
+
+		// Add trace_id and span_id if available, so the log can be matched to its exact span
+		// This allows correlating logs with traces in observability systems
+		if spanContext := trace.SpanContextFromContext(c.Request.Context()); spanContext.IsValid() {
+			fields = append(fields,
+				zap.String("trace_id", spanContext.TraceID().String()),
+				zap.String("span_id", spanContext.SpanID().String()),
+			)
+		}
+
+		// Add request_id (set by RequestIDMiddleware) for pasting into tickets
+		if requestID := RequestIDFromContext(c.Request.Context()); requestID != "" {
+			fields = append(fields, zap.String("request_id", requestID))
+		}
+
+		// Add error if present
+		if len(c.Errors) > 0 {
+			fields = append(fields, zap.String("error", c.Errors.String()))
+		}
+
+		// Log based on status code
+		if status >= 500 {
+			logger.Error("HTTP request failed", fields...)
+		} else if status >= 400 {
+			logger.Warn("HTTP request client error", fields...)
+		} else {
+			logger.Info("HTTP request completed", fields...)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package middleware
+
+import (
+	"container/list"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"product-service/internal/apierror"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+)
+
+// RateLimitConfig controls RateLimitMiddleware
+type RateLimitConfig struct {
+	// RPS is the sustained requests per second allowed per client IP; 0 disables rate limiting
+	RPS float64
+	// Burst is how many requests a client may make at once before being throttled to RPS
+	Burst int
+	// ExemptPaths lists routes (e.g. Kubernetes probes) that are never limited
+	ExemptPaths []string
+	// IdleTTL drops a client's bucket after this long without requests
+	// An idle bucket has refilled anyway, so forgetting it changes nothing for the client
+	IdleTTL time.Duration
+	// MaxClients bounds how many buckets are kept; the least recently seen client is dropped first
+	MaxClients int
+}
+
+// clientBucket is one client IP's token bucket
+type clientBucket struct {
+	ip       string
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// clientBuckets keeps per-IP buckets in least-recently-seen order so expiry and eviction are cheap
+type clientBuckets struct {
+	config RateLimitConfig
+	now    func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*list.Element
+	order   *list.List // Front is most recently seen
+}
+
+// RateLimitMiddleware returns a Gin middleware that rate limits requests per client IP
+// using token buckets; over-limit requests get 429 with a Retry-After header
+// Memory is bounded: idle buckets expire after IdleTTL and at most MaxClients are kept
+func RateLimitMiddleware(config RateLimitConfig) gin.HandlerFunc {
+	if config.RPS <= 0 {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+	if config.Burst < 1 {
+		config.Burst = 1
+	}
+
+	exempt := make(map[string]bool, len(config.ExemptPaths))
+	for _, path := range config.ExemptPaths {
+		exempt[path] = true
+	}
+
+	buckets := newClientBuckets(config, time.Now)
+
+	return func(c *gin.Context) {
+		if exempt[c.Request.URL.Path] {
+			c.Next()
+			return
+		}
+
+		if wait := buckets.reserve(c.ClientIP()); wait > 0 {
+			// Retry-After is whole seconds; round up so a client honouring it isn't rejected again
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			apierror.RespondError(c, http.StatusTooManyRequests, apierror.CodeRateLimited, "Too many requests")
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// newClientBuckets creates an empty bucket store
+func newClientBuckets(config RateLimitConfig, now func() time.Time) *clientBuckets {
+	return &clientBuckets{
+		config:  config,
+		now:     now,
+		buckets: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// reserve takes a token from ip's bucket
+// Returns 0 when the request may proceed, otherwise how long until a token is available
+func (b *clientBuckets) reserve(ip string) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	b.expire(now)
+
+	var bucket *clientBucket
+	if element, ok := b.buckets[ip]; ok {
+		bucket = element.Value.(*clientBucket)
+		b.order.MoveToFront(element)
+	} else {
+		bucket = &clientBucket{
+			ip:      ip,
+			limiter: rate.NewLimiter(rate.Limit(b.config.RPS), b.config.Burst),
+		}
+		b.buckets[ip] = b.order.PushFront(bucket)
+		b.evict()
+	}
+	bucket.lastSeen = now
+
+	reservation := bucket.limiter.ReserveN(now, 1)
+	if wait := reservation.DelayFrom(now); wait > 0 {
+		// Rejected requests must not consume tokens, or a client retrying early is locked out longer
+		reservation.CancelAt(now)
+		return wait
+	}
+	return 0
+}
+
+// expire drops buckets idle for longer than IdleTTL, oldest first
+func (b *clientBuckets) expire(now time.Time) {
+	if b.config.IdleTTL <= 0 {
+		return
+	}
+	for element := b.order.Back(); element != nil; element = b.order.Back() {
+		bucket := element.Value.(*clientBucket)
+		if now.Sub(bucket.lastSeen) < b.config.IdleTTL {
+			return
+		}
+		b.order.Remove(element)
+		delete(b.buckets, bucket.ip)
+	}
+}
+
+// evict drops the least recently seen buckets beyond MaxClients
+func (b *clientBuckets) evict() {
+	for b.config.MaxClients > 0 && b.order.Len() > b.config.MaxClients {
+		element := b.order.Back()
+		b.order.Remove(element)
+		delete(b.buckets, element.Value.(*clientBucket).ip)
+	}
+}
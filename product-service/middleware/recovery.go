@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"strings"
+
+	"product-service/internal/apierror"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RecoveryMiddleware returns a Gin middleware that turns a panic into a 500 APIError
+// Unlike gin.Recovery it logs the panic through Zap as one JSON entry with the stack, route,
+// trace_id and request_id, and marks the request span as failed
+// It must run after TracingMiddleware: otelgin ends its span while the panic unwinds, so only
+// a recovery inside it can still record the error on that span
+func RecoveryMiddleware(logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+
+			err := fmt.Errorf("panic: %v", recovered)
+			ctx := c.Request.Context()
+
+			span := trace.SpanFromContext(ctx)
+			span.RecordError(err, trace.WithStackTrace(true))
+			span.SetStatus(codes.Error, "Panic recovered")
+
+			fields := []zap.Field{
+				zap.Any("panic", recovered),
+				zap.String("method", c.Request.Method),
+				zap.String("path", c.Request.URL.Path),
+				zap.String("route", c.FullPath()),
+				zap.String("stack", string(debug.Stack())),
+			}
+			if spanContext := span.SpanContext(); spanContext.IsValid() {
+				fields = append(fields, zap.String("trace_id", spanContext.TraceID().String()))
+			}
+			if requestID := RequestIDFromContext(ctx); requestID != "" {
+				fields = append(fields, zap.String("request_id", requestID))
+			}
+
+			// The client is gone, so there is nobody to send the 500 to
+			if isBrokenPipe(recovered) {
+				logger.Warn("Panic recovered: client connection closed", fields...)
+				c.Abort()
+				return
+			}
+
+			logger.Error("Panic recovered", fields...)
+			apierror.RespondError(c, http.StatusInternalServerError, apierror.CodeInternal, "Internal server error")
+		}()
+
+		c.Next()
+	}
+}
+
+// RecoveryInterceptor is RecoveryMiddleware for the gRPC server: a panicking call is logged through Zap
+// with its method, stack and trace_id, recorded on the call span and answered with INTERNAL
+// The otelgrpc stats handler starts the call span before any interceptor runs, so the panic lands on it
+func RecoveryInterceptor(logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+
+			span := trace.SpanFromContext(ctx)
+			span.RecordError(fmt.Errorf("panic: %v", recovered), trace.WithStackTrace(true))
+			span.SetStatus(codes.Error, "Panic recovered")
+
+			fields := []zap.Field{
+				zap.Any("panic", recovered),
+				zap.String("grpc_method", info.FullMethod),
+				zap.String("stack", string(debug.Stack())),
+			}
+			if spanContext := span.SpanContext(); spanContext.IsValid() {
+				fields = append(fields, zap.String("trace_id", spanContext.TraceID().String()))
+			}
+
+			logger.Error("Panic recovered", fields...)
+			err = status.Error(grpccodes.Internal, "Internal server error")
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+// isBrokenPipe reports whether the panic came from writing to a connection the client closed
+func isBrokenPipe(recovered any) bool {
+	err, ok := recovered.(error)
+	if !ok {
+		return false
+	}
+
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) {
+		return false
+	}
+	var syscallErr *os.SyscallError
+	if !errors.As(opErr, &syscallErr) {
+		return false
+	}
+
+	message := strings.ToLower(syscallErr.Error())
+	return strings.Contains(message, "broken pipe") || strings.Contains(message, "connection reset by peer")
+}
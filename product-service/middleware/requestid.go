@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader carries the request ID in both directions
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds client-supplied IDs so they can't bloat every log line
+const maxRequestIDLength = 128
+
+// requestIDContextKey stores the request ID in the request context
+type requestIDContextKey struct{}
+
+// RequestIDMiddleware returns a Gin middleware that assigns every request an ID
+// An incoming X-Request-ID is reused (so IDs from a gateway carry through); otherwise a UUID is generated
+// The ID is echoed in the X-Request-ID response header, stored in the request context for
+// RequestIDFromContext and recorded on the request span, so it should run after the tracing middleware
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), requestIDContextKey{}, requestID))
+		c.Header(RequestIDHeader, requestID)
+		trace.SpanFromContext(c.Request.Context()).SetAttributes(attribute.String("request.id", requestID))
+
+		c.Next()
+	}
+}
+
+// RequestIDFromContext returns the ID assigned by RequestIDMiddleware, or "" if there is none
+// Accepts either the request context or the *gin.Context itself
+func RequestIDFromContext(ctx context.Context) string {
+	if c, ok := ctx.(*gin.Context); ok {
+		ctx = c.Request.Context()
+	}
+	requestID, _ := ctx.Value(requestIDContextKey{}).(string)
+	return requestID
+}
+
+// validRequestID accepts non-empty IDs of printable ASCII without spaces
+// Anything else is replaced so a client can't inject control characters into the logs
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] < '!' || requestID[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TimeoutConfig controls TimeoutMiddleware
+type TimeoutConfig struct {
+	// Timeout is how long a handler may run before its request context is cancelled; 0 disables the deadline
+	Timeout time.Duration
+	// ExemptPaths lists routes (e.g. /stress) that are intentionally slow and run without a deadline
+	ExemptPaths []string
+}
+
+// TimeoutMiddleware returns a Gin middleware that puts a deadline on each request's context
+// Repository calls made with that context give up with context.DeadlineExceeded once it passes,
+// so a slow query can't hold a connection indefinitely; handlers answer those with 504
+func TimeoutMiddleware(config TimeoutConfig) gin.HandlerFunc {
+	if config.Timeout <= 0 {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+
+	exempt := make(map[string]bool, len(config.ExemptPaths))
+	for _, path := range config.ExemptPaths {
+		exempt[path] = true
+	}
+
+	return func(c *gin.Context) {
+		if exempt[c.Request.URL.Path] {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), config.Timeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
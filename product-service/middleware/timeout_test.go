@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupTimeoutTest creates a router whose routes report the deadline on their request context
+func setupTimeoutTest(config TimeoutConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	reportDeadline := func(c *gin.Context) {
+		deadline, ok := c.Request.Context().Deadline()
+		c.JSON(http.StatusOK, gin.H{"has_deadline": ok, "remaining_ms": time.Until(deadline).Milliseconds()})
+	}
+
+	router := gin.New()
+	router.Use(TimeoutMiddleware(config))
+	router.GET("/products", reportDeadline)
+	router.GET("/stress", reportDeadline)
+
+	return router
+}
+
+func TestTimeoutMiddleware(t *testing.T) {
+	serve := func(router *gin.Engine, path string) string {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		router.ServeHTTP(w, req)
+		return w.Body.String()
+	}
+
+	t.Run("should put a deadline on the request context", func(t *testing.T) {
+		router := setupTimeoutTest(TimeoutConfig{Timeout: time.Minute, ExemptPaths: []string{"/stress"}})
+
+		assert.Contains(t, serve(router, "/products"), `"has_deadline":true`)
+	})
+
+	t.Run("should leave exempt paths without a deadline", func(t *testing.T) {
+		router := setupTimeoutTest(TimeoutConfig{Timeout: time.Minute, ExemptPaths: []string{"/stress"}})
+
+		assert.Contains(t, serve(router, "/stress"), `"has_deadline":false`)
+	})
+
+	t.Run("should add no deadline when disabled", func(t *testing.T) {
+		router := setupTimeoutTest(TimeoutConfig{})
+
+		assert.Contains(t, serve(router, "/products"), `"has_deadline":false`)
+	})
+}
@@ -105,7 +105,7 @@ function testStressEndpoint() {
     const fibonacciValues = [35, 38, 40, 42];
     const n = fibonacciValues[Math.floor(Math.random() * fibonacciValues.length)];
 
-    const response = http.get(`${BASE_URL}/stress?n=${n}`);
+    const response = http.get(`${BASE_URL}/stress?n=${n}&cpu_load=true`);
 
     const result = check(response, {
         'stress: status is 200': (r) => r.status === 200,